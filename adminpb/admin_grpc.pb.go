@@ -0,0 +1,540 @@
+// gRPC admin API of SH Server.
+//
+// The service is served on the HTTP port at /shserver.admin.v1.Admin/ and
+// takes the same credentials as the REST admin API, sent as metadata:
+// "authorization: Bearer <token>" or "x-admin-token: <token>". Changes go
+// through the same validation, hooks, versioning, audit log and webhooks.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: admin.proto
+
+package adminpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Admin_ListScripts_FullMethodName  = "/shserver.admin.v1.Admin/ListScripts"
+	Admin_GetScript_FullMethodName    = "/shserver.admin.v1.Admin/GetScript"
+	Admin_CreateScript_FullMethodName = "/shserver.admin.v1.Admin/CreateScript"
+	Admin_UpdateScript_FullMethodName = "/shserver.admin.v1.Admin/UpdateScript"
+	Admin_DeleteScript_FullMethodName = "/shserver.admin.v1.Admin/DeleteScript"
+	Admin_ListFolders_FullMethodName  = "/shserver.admin.v1.Admin/ListFolders"
+	Admin_CreateFolder_FullMethodName = "/shserver.admin.v1.Admin/CreateFolder"
+	Admin_DeleteFolder_FullMethodName = "/shserver.admin.v1.Admin/DeleteFolder"
+	Admin_ListVersions_FullMethodName = "/shserver.admin.v1.Admin/ListVersions"
+	Admin_GetVersion_FullMethodName   = "/shserver.admin.v1.Admin/GetVersion"
+	Admin_Watch_FullMethodName        = "/shserver.admin.v1.Admin/Watch"
+)
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminClient interface {
+	// ListScripts streams every script, optionally under a folder
+	ListScripts(ctx context.Context, in *ListScriptsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Script], error)
+	// GetScript returns a script by id or path
+	GetScript(ctx context.Context, in *GetScriptRequest, opts ...grpc.CallOption) (*Script, error)
+	CreateScript(ctx context.Context, in *CreateScriptRequest, opts ...grpc.CallOption) (*Script, error)
+	// UpdateScript changes the fields in update_mask, or replaces the script
+	// like the REST API's PUT when the mask is empty
+	UpdateScript(ctx context.Context, in *UpdateScriptRequest, opts ...grpc.CallOption) (*Script, error)
+	DeleteScript(ctx context.Context, in *DeleteScriptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListFolders(ctx context.Context, in *ListFoldersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Folder], error)
+	CreateFolder(ctx context.Context, in *CreateFolderRequest, opts ...grpc.CallOption) (*Folder, error)
+	// DeleteFolder deletes an empty folder
+	DeleteFolder(ctx context.Context, in *DeleteFolderRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ListVersions streams a script's versions, newest first, without content
+	ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Version], error)
+	// GetVersion returns one version with its content
+	GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*Version, error)
+	// Watch streams script changes and other events (the webhook events) as
+	// they happen, until the client cancels
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) ListScripts(ctx context.Context, in *ListScriptsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Script], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[0], Admin_ListScripts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListScriptsRequest, Script]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_ListScriptsClient = grpc.ServerStreamingClient[Script]
+
+func (c *adminClient) GetScript(ctx context.Context, in *GetScriptRequest, opts ...grpc.CallOption) (*Script, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Script)
+	err := c.cc.Invoke(ctx, Admin_GetScript_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) CreateScript(ctx context.Context, in *CreateScriptRequest, opts ...grpc.CallOption) (*Script, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Script)
+	err := c.cc.Invoke(ctx, Admin_CreateScript_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) UpdateScript(ctx context.Context, in *UpdateScriptRequest, opts ...grpc.CallOption) (*Script, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Script)
+	err := c.cc.Invoke(ctx, Admin_UpdateScript_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) DeleteScript(ctx context.Context, in *DeleteScriptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Admin_DeleteScript_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListFolders(ctx context.Context, in *ListFoldersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Folder], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[1], Admin_ListFolders_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListFoldersRequest, Folder]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_ListFoldersClient = grpc.ServerStreamingClient[Folder]
+
+func (c *adminClient) CreateFolder(ctx context.Context, in *CreateFolderRequest, opts ...grpc.CallOption) (*Folder, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Folder)
+	err := c.cc.Invoke(ctx, Admin_CreateFolder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) DeleteFolder(ctx context.Context, in *DeleteFolderRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Admin_DeleteFolder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Version], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[2], Admin_ListVersions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListVersionsRequest, Version]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_ListVersionsClient = grpc.ServerStreamingClient[Version]
+
+func (c *adminClient) GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*Version, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Version)
+	err := c.cc.Invoke(ctx, Admin_GetVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[3], Admin_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_WatchClient = grpc.ServerStreamingClient[Event]
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility.
+type AdminServer interface {
+	// ListScripts streams every script, optionally under a folder
+	ListScripts(*ListScriptsRequest, grpc.ServerStreamingServer[Script]) error
+	// GetScript returns a script by id or path
+	GetScript(context.Context, *GetScriptRequest) (*Script, error)
+	CreateScript(context.Context, *CreateScriptRequest) (*Script, error)
+	// UpdateScript changes the fields in update_mask, or replaces the script
+	// like the REST API's PUT when the mask is empty
+	UpdateScript(context.Context, *UpdateScriptRequest) (*Script, error)
+	DeleteScript(context.Context, *DeleteScriptRequest) (*emptypb.Empty, error)
+	ListFolders(*ListFoldersRequest, grpc.ServerStreamingServer[Folder]) error
+	CreateFolder(context.Context, *CreateFolderRequest) (*Folder, error)
+	// DeleteFolder deletes an empty folder
+	DeleteFolder(context.Context, *DeleteFolderRequest) (*emptypb.Empty, error)
+	// ListVersions streams a script's versions, newest first, without content
+	ListVersions(*ListVersionsRequest, grpc.ServerStreamingServer[Version]) error
+	// GetVersion returns one version with its content
+	GetVersion(context.Context, *GetVersionRequest) (*Version, error)
+	// Watch streams script changes and other events (the webhook events) as
+	// they happen, until the client cancels
+	Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServer struct{}
+
+func (UnimplementedAdminServer) ListScripts(*ListScriptsRequest, grpc.ServerStreamingServer[Script]) error {
+	return status.Errorf(codes.Unimplemented, "method ListScripts not implemented")
+}
+func (UnimplementedAdminServer) GetScript(context.Context, *GetScriptRequest) (*Script, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetScript not implemented")
+}
+func (UnimplementedAdminServer) CreateScript(context.Context, *CreateScriptRequest) (*Script, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateScript not implemented")
+}
+func (UnimplementedAdminServer) UpdateScript(context.Context, *UpdateScriptRequest) (*Script, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateScript not implemented")
+}
+func (UnimplementedAdminServer) DeleteScript(context.Context, *DeleteScriptRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteScript not implemented")
+}
+func (UnimplementedAdminServer) ListFolders(*ListFoldersRequest, grpc.ServerStreamingServer[Folder]) error {
+	return status.Errorf(codes.Unimplemented, "method ListFolders not implemented")
+}
+func (UnimplementedAdminServer) CreateFolder(context.Context, *CreateFolderRequest) (*Folder, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateFolder not implemented")
+}
+func (UnimplementedAdminServer) DeleteFolder(context.Context, *DeleteFolderRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFolder not implemented")
+}
+func (UnimplementedAdminServer) ListVersions(*ListVersionsRequest, grpc.ServerStreamingServer[Version]) error {
+	return status.Errorf(codes.Unimplemented, "method ListVersions not implemented")
+}
+func (UnimplementedAdminServer) GetVersion(context.Context, *GetVersionRequest) (*Version, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVersion not implemented")
+}
+func (UnimplementedAdminServer) Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+func (UnimplementedAdminServer) testEmbeddedByValue()               {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_ListScripts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListScriptsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).ListScripts(m, &grpc.GenericServerStream[ListScriptsRequest, Script]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_ListScriptsServer = grpc.ServerStreamingServer[Script]
+
+func _Admin_GetScript_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetScriptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetScript(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetScript_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetScript(ctx, req.(*GetScriptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_CreateScript_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateScriptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).CreateScript(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_CreateScript_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).CreateScript(ctx, req.(*CreateScriptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_UpdateScript_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateScriptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).UpdateScript(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_UpdateScript_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).UpdateScript(ctx, req.(*UpdateScriptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_DeleteScript_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteScriptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).DeleteScript(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_DeleteScript_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).DeleteScript(ctx, req.(*DeleteScriptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListFolders_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListFoldersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).ListFolders(m, &grpc.GenericServerStream[ListFoldersRequest, Folder]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_ListFoldersServer = grpc.ServerStreamingServer[Folder]
+
+func _Admin_CreateFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateFolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).CreateFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_CreateFolder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).CreateFolder(ctx, req.(*CreateFolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_DeleteFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).DeleteFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_DeleteFolder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).DeleteFolder(ctx, req.(*DeleteFolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListVersions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListVersionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).ListVersions(m, &grpc.GenericServerStream[ListVersionsRequest, Version]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_ListVersionsServer = grpc.ServerStreamingServer[Version]
+
+func _Admin_GetVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetVersion(ctx, req.(*GetVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).Watch(m, &grpc.GenericServerStream[WatchRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_WatchServer = grpc.ServerStreamingServer[Event]
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "shserver.admin.v1.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetScript",
+			Handler:    _Admin_GetScript_Handler,
+		},
+		{
+			MethodName: "CreateScript",
+			Handler:    _Admin_CreateScript_Handler,
+		},
+		{
+			MethodName: "UpdateScript",
+			Handler:    _Admin_UpdateScript_Handler,
+		},
+		{
+			MethodName: "DeleteScript",
+			Handler:    _Admin_DeleteScript_Handler,
+		},
+		{
+			MethodName: "CreateFolder",
+			Handler:    _Admin_CreateFolder_Handler,
+		},
+		{
+			MethodName: "DeleteFolder",
+			Handler:    _Admin_DeleteFolder_Handler,
+		},
+		{
+			MethodName: "GetVersion",
+			Handler:    _Admin_GetVersion_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListScripts",
+			Handler:       _Admin_ListScripts_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListFolders",
+			Handler:       _Admin_ListFolders_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListVersions",
+			Handler:       _Admin_ListVersions_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Admin_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "admin.proto",
+}
@@ -0,0 +1,4 @@
+// Package adminpb holds the gRPC admin API generated from admin.proto
+package adminpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative admin.proto
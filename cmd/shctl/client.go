@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// client calls the admin API of an SH Server
+type client struct {
+	server string // e.g. https://sh.example.com
+	token  string
+	http   *http.Client
+}
+
+func newClient(cfg config) *client {
+	return &client{server: cfg.Server, token: cfg.Token, http: &http.Client{Timeout: time.Minute}}
+}
+
+// do sends a request and returns the response body. Error responses are
+// returned as errors carrying the server's message.
+func (c *client) do(method, path string, body io.Reader, header http.Header) ([]byte, error) {
+	req, err := http.NewRequest(method, c.server+path, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("User-Agent", "shctl")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, responseError(resp.StatusCode, data)
+	}
+	return data, nil
+}
+
+// responseError reads the message of an /api/v1 error response, or takes
+// a plain-text one as it is
+func responseError(status int, body []byte) error {
+	var resp struct {
+		Error struct {
+			Message string `json:"message"`
+			Fields  []struct {
+				Field   string `json:"field"`
+				Message string `json:"message"`
+			} `json:"fields"`
+		} `json:"error"`
+	}
+	msg := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &resp) == nil && resp.Error.Message != "" {
+		msg = resp.Error.Message
+		for _, f := range resp.Error.Fields {
+			msg += "\n  " + f.Field + ": " + f.Message
+		}
+	}
+	if text := http.StatusText(status); msg != text && msg != "" {
+		return fmt.Errorf("%s: %s", text, msg)
+	}
+	return errors.New(http.StatusText(status))
+}
+
+// script is what shctl reads of a script. raw holds every field the server
+// sent, so an update can send back those it doesn't change.
+type script struct {
+	ID          string `json:"id"`
+	Path        string `json:"path"`
+	Content     string `json:"content"`
+	Description string `json:"description"`
+	Locked      bool   `json:"locked"`
+	Private     bool   `json:"private"`
+	Unlisted    bool   `json:"unlisted"`
+	Disabled    bool   `json:"disabled"`
+	Deprecated  bool   `json:"deprecated"`
+	Archived    bool   `json:"archived"`
+
+	raw map[string]any
+}
+
+// flags names the states of the script worth showing in a listing
+func (sc script) flags() []string {
+	var flags []string
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{
+		{sc.Locked, "locked"},
+		{sc.Private, "private"},
+		{sc.Unlisted, "unlisted"},
+		{sc.Disabled, "disabled"},
+		{sc.Deprecated, "deprecated"},
+		{sc.Archived, "archived"},
+	} {
+		if f.set {
+			flags = append(flags, f.name)
+		}
+	}
+	return flags
+}
+
+// scripts lists every script
+func (c *client) scripts() ([]script, error) {
+	data, err := c.do(http.MethodGet, "/api/v1/scripts", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, err
+	}
+	scripts := make([]script, len(raws))
+	for i, raw := range raws {
+		if err := json.Unmarshal(raw, &scripts[i]); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &scripts[i].raw); err != nil {
+			return nil, err
+		}
+	}
+	return scripts, nil
+}
+
+// script finds the script at path
+func (c *client) script(path string) (script, error) {
+	scripts, err := c.scripts()
+	if err != nil {
+		return script{}, err
+	}
+	for _, sc := range scripts {
+		if sc.Path == path {
+			return sc, nil
+		}
+	}
+	return script{}, fmt.Errorf("%s: no such script", path)
+}
+
+// update sends a script back with changes made to its fields
+func (c *client) update(sc script, changes map[string]any) error {
+	fields := make(map[string]any, len(sc.raw)+len(changes))
+	for k, v := range sc.raw {
+		fields[k] = v
+	}
+	for k, v := range changes {
+		fields[k] = v
+	}
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(http.MethodPut, "/api/v1/scripts/"+url.PathEscape(sc.ID), bytes.NewReader(body),
+		http.Header{"Content-Type": {"application/json"}})
+	return err
+}
+
+// upload creates the script at path or replaces its content, keeping its
+// other fields, and returns the server's plain-text report
+func (c *client) upload(path string, content []byte, message string) ([]byte, error) {
+	header := http.Header{"Content-Type": {"text/plain; charset=utf-8"}}
+	if message != "" {
+		header.Set("X-Script-Message", message)
+	}
+	return c.do(http.MethodPut, path, bytes.NewReader(content), header)
+}
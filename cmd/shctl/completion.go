@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// completeCommand is the hidden command the completion scripts call
+const completeCommand = "__complete"
+
+// The completion scripts pass the words of the command line after "shctl",
+// up to the one being completed, to shctl __complete. Its first line of
+// output tells them what to offer: "words" for the lines that follow,
+// "files" for local paths, or "none".
+
+const bashCompletion = `# bash completion for shctl
+# Load with: source <(shctl completion bash)
+_shctl() {
+    local cur=${COMP_WORDS[COMP_CWORD]} out
+    out=$(shctl __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null) || return
+    case ${out%%$'\n'*} in
+    files)
+        compopt -o filenames
+        COMPREPLY=($(compgen -f -- "$cur"))
+        ;;
+    words)
+        mapfile -t COMPREPLY < <(printf '%s\n' "$out" | sed 1d)
+        # Keep going into a folder
+        if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]]; then
+            compopt -o nospace
+        fi
+        ;;
+    esac
+}
+complete -F _shctl shctl
+`
+
+const zshCompletion = `#compdef shctl
+# zsh completion for shctl
+# Load with: source <(shctl completion zsh)
+_shctl() {
+    local -a lines
+    lines=("${(@f)$(shctl __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    case $lines[1] in
+    files) _files ;;
+    words)
+        # Folders take no space after them, so the path can go on
+        compadd -S '' -- ${(M)lines[2,-1]:#*/}
+        compadd -- ${lines[2,-1]:#*/}
+        ;;
+    esac
+}
+compdef _shctl shctl
+`
+
+const fishCompletion = `# fish completion for shctl
+# Load with: shctl completion fish | source
+function __shctl_complete
+    set -l words (commandline -opc)
+    set -e words[1]
+    set -l out (shctl __complete $words (commandline -ct) 2>/dev/null)
+    switch "$out[1]"
+        case files
+            __fish_complete_path (commandline -ct)
+        case words
+            printf '%s\n' $out[2..-1]
+    end
+end
+complete -c shctl -f -a '(__shctl_complete)'
+`
+
+func runCompletion(args []string) error {
+	scripts := map[string]string{"bash": bashCompletion, "zsh": zshCompletion, "fish": fishCompletion}
+	if len(args) != 1 || scripts[args[0]] == "" {
+		return fmt.Errorf("completion: expected bash, zsh or fish")
+	}
+	fmt.Print(scripts[args[0]])
+	return nil
+}
+
+// What an argument of a command is, by position
+const (
+	argNone    = iota
+	argFiles   // local paths
+	argScripts // remote scripts and folders
+	argFolders // remote folders only
+	argShells
+)
+
+var commandArgs = map[string][]int{
+	"ls":         {argFolders},
+	"get":        {argScripts},
+	"push":       {argFiles, argScripts},
+	"edit":       {argScripts},
+	"lock":       {argScripts},
+	"unlock":     {argScripts},
+	"completion": {argShells},
+}
+
+// Flags that take a value, and what the value is
+var valueFlags = map[string]int{"-o": argFiles, "-m": argNone}
+
+// runComplete prints the completions of the last of words. It doesn't
+// fail: the shell is offered nothing instead.
+func runComplete(words []string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+	if len(words) == 1 {
+		var names []string
+		for name := range commands {
+			if strings.HasPrefix(name, cur) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		printCompletions(names)
+		return
+	}
+
+	kind, pos := argNone, 0
+	for i, w := range words[1:] {
+		last := i == len(words)-2
+		if prev := words[i]; i > 0 && strings.HasPrefix(prev, "-") {
+			if k, ok := valueFlags[prev]; ok {
+				if last {
+					kind = k
+				}
+				continue
+			}
+		}
+		if strings.HasPrefix(w, "-") {
+			continue
+		}
+		if last {
+			if args := commandArgs[words[0]]; pos < len(args) {
+				kind = args[pos]
+			}
+		}
+		pos++
+	}
+
+	switch kind {
+	case argFiles:
+		fmt.Println("files")
+	case argShells:
+		printCompletions(slices.DeleteFunc([]string{"bash", "fish", "zsh"}, func(s string) bool { return !strings.HasPrefix(s, cur) }))
+	case argScripts, argFolders:
+		paths, err := catalogPaths()
+		if err != nil {
+			fmt.Println("none")
+			return
+		}
+		printCompletions(remoteCompletions(paths, cur, kind == argFolders))
+	default:
+		fmt.Println("none")
+	}
+}
+
+func printCompletions(words []string) {
+	fmt.Println("words")
+	for _, w := range words {
+		fmt.Println(w)
+	}
+}
+
+// catalogPaths lists the scripts in the server's catalog. Private and
+// unlisted scripts aren't in it, so they aren't completed.
+func catalogPaths() ([]string, error) {
+	c, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	// A slow server mustn't hang the shell
+	c.http.Timeout = 5 * time.Second
+	data, err := c.do(http.MethodGet, "/_catalog.json", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.Path
+	}
+	return paths, nil
+}
+
+// remoteCompletions completes cur one folder at a time, the way a shell
+// completes local paths: the scripts in cur's folder and the folders in it,
+// with a trailing slash. cur may leave out the leading slash; completions
+// of an empty word have it.
+func remoteCompletions(paths []string, cur string, foldersOnly bool) []string {
+	rooted := cur == "" || strings.HasPrefix(cur, "/")
+	full := scriptPath(cur)
+	dir := full[:strings.LastIndex(full, "/")+1]
+
+	seen := map[string]bool{}
+	var out []string
+	for _, p := range paths {
+		if !strings.HasPrefix(p, full) {
+			continue
+		}
+		candidate := p
+		if i := strings.Index(p[len(dir):], "/"); i >= 0 {
+			candidate = p[:len(dir)+i+1]
+		} else if foldersOnly {
+			continue
+		}
+		if !rooted {
+			candidate = candidate[1:]
+		}
+		if !seen[candidate] {
+			seen[candidate] = true
+			out = append(out, candidate)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config is the server shctl talks to and the admin token it sends
+type config struct {
+	Server string `yaml:"server"`
+	Token  string `yaml:"token"`
+}
+
+// configPath is ~/.config/shctl/config.yaml or the platform's equivalent
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "shctl", "config.yaml"), nil
+}
+
+// loadConfig reads the config file; SHCTL_SERVER and SHCTL_TOKEN take
+// precedence over it
+func loadConfig() (config, error) {
+	var cfg config
+	path, err := configPath()
+	if err != nil {
+		return cfg, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if v := os.Getenv("SHCTL_SERVER"); v != "" {
+		cfg.Server = v
+	}
+	if v := os.Getenv("SHCTL_TOKEN"); v != "" {
+		cfg.Token = v
+	}
+	if cfg.Server == "" {
+		return cfg, errors.New("no server configured; run shctl login <server> or set SHCTL_SERVER")
+	}
+	cfg.Server = serverURL(cfg.Server)
+	return cfg, nil
+}
+
+// saveConfig writes the config file. It holds the token, so only the user
+// may read it.
+func saveConfig(cfg config) (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0o600)
+}
+
+// serverURL turns sh.example.com into https://sh.example.com
+func serverURL(server string) string {
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	return strings.TrimRight(server, "/")
+}
@@ -0,0 +1,383 @@
+// Command shctl manages the scripts of an SH Server through its admin API
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const usage = `shctl manages the scripts of an SH Server through its admin API.
+
+Usage:
+  shctl login <server>                      save the server and an admin token
+  shctl ls [folder]                         list scripts
+  shctl get [-o file] <path>                print a script
+  shctl push [-m message] <file|dir> <path|folder/>
+                                            upload scripts
+  shctl edit [-m message] <path>            edit a script in $EDITOR and push it
+  shctl lock <path>                         lock a script with a password
+  shctl unlock <path>                       remove the lock of a script
+  shctl completion bash|zsh|fish            print a shell completion script
+
+The server and token are read from ~/.config/shctl/config.yaml;
+SHCTL_SERVER and SHCTL_TOKEN take precedence.
+`
+
+var commands = map[string]func([]string) error{
+	"login":      runLogin,
+	"ls":         runLs,
+	"get":        runGet,
+	"push":       runPush,
+	"edit":       runEdit,
+	"lock":       runLock,
+	"unlock":     runUnlock,
+	"completion": runCompletion,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	name, args := os.Args[1], os.Args[2:]
+	switch name {
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	case completeCommand:
+		runComplete(args)
+		return
+	}
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "shctl: unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
+	if err := run(args); err != nil {
+		fmt.Fprintln(os.Stderr, "shctl:", err)
+		os.Exit(1)
+	}
+}
+
+// parseArgs parses the flags of a command and checks it got between min
+// and max arguments
+func parseArgs(flags *flag.FlagSet, args []string, min, max int) error {
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if n := flags.NArg(); n < min || n > max {
+		return fmt.Errorf("%s: wrong number of arguments; see shctl help", flags.Name())
+	}
+	return nil
+}
+
+func connect() (*client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newClient(cfg), nil
+}
+
+// scriptPath makes a path given on the command line absolute
+func scriptPath(p string) string {
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
+
+// prompt asks for a line on stdin
+func prompt(label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// promptSecret asks for a line on stdin without echoing it, if stdin is a
+// terminal
+func promptSecret(label string) (string, error) {
+	stty := func(arg string) error {
+		cmd := exec.Command("stty", arg)
+		cmd.Stdin = os.Stdin
+		return cmd.Run()
+	}
+	if stty("-echo") == nil {
+		defer func() {
+			stty("echo")
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+	return prompt(label)
+}
+
+func runLogin(args []string) error {
+	flags := flag.NewFlagSet("login", flag.ExitOnError)
+	if err := parseArgs(flags, args, 1, 1); err != nil {
+		return err
+	}
+	cfg := config{Server: serverURL(flags.Arg(0))}
+	var err error
+	if cfg.Token, err = promptSecret("Admin token (empty if the server has none): "); err != nil {
+		return err
+	}
+	if _, err := newClient(cfg).do(http.MethodGet, "/api/v1/version", nil, nil); err != nil {
+		return fmt.Errorf("checking the token: %w", err)
+	}
+	path, err := saveConfig(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Logged in to %s; saved to %s\n", cfg.Server, path)
+	return nil
+}
+
+func runLs(args []string) error {
+	flags := flag.NewFlagSet("ls", flag.ExitOnError)
+	if err := parseArgs(flags, args, 0, 1); err != nil {
+		return err
+	}
+	folder := "/"
+	if flags.NArg() == 1 {
+		folder = strings.TrimSuffix(scriptPath(flags.Arg(0)), "/") + "/"
+	}
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+	scripts, err := c.scripts()
+	if err != nil {
+		return err
+	}
+	sort.Slice(scripts, func(i, j int) bool { return scripts[i].Path < scripts[j].Path })
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, sc := range scripts {
+		if strings.HasPrefix(sc.Path, folder) {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", sc.Path, strings.Join(sc.flags(), ","), sc.Description)
+		}
+	}
+	return tw.Flush()
+}
+
+func runGet(args []string) error {
+	flags := flag.NewFlagSet("get", flag.ExitOnError)
+	out := flags.String("o", "", "write the script to this file instead of stdout")
+	if err := parseArgs(flags, args, 1, 1); err != nil {
+		return err
+	}
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+	sc, err := c.script(scriptPath(flags.Arg(0)))
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.WriteString(sc.Content)
+		return err
+	}
+	return os.WriteFile(*out, []byte(sc.Content), 0o755)
+}
+
+func runPush(args []string) error {
+	flags := flag.NewFlagSet("push", flag.ExitOnError)
+	message := flags.String("m", "", "describe the change in the version history")
+	if err := parseArgs(flags, args, 2, 2); err != nil {
+		return err
+	}
+	src, dest := flags.Arg(0), scriptPath(flags.Arg(1))
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	// Local file to remote path
+	uploads := [][2]string{}
+	switch {
+	case info.IsDir():
+		folder := strings.TrimSuffix(dest, "/") + "/"
+		err = filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && p != src && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if d.IsDir() || !strings.HasSuffix(p, ".sh") {
+				return nil
+			}
+			rel, err := filepath.Rel(src, p)
+			if err != nil {
+				return err
+			}
+			uploads = append(uploads, [2]string{p, folder + filepath.ToSlash(rel)})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(uploads) == 0 {
+			return fmt.Errorf("no .sh files in %s", src)
+		}
+	case strings.HasSuffix(dest, "/"):
+		uploads = append(uploads, [2]string{src, dest + filepath.Base(src)})
+	default:
+		uploads = append(uploads, [2]string{src, dest})
+	}
+
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+	for _, u := range uploads {
+		content, err := os.ReadFile(u[0])
+		if err != nil {
+			return err
+		}
+		report, err := c.upload(u[1], content, *message)
+		if err != nil {
+			return fmt.Errorf("%s: %w", u[1], err)
+		}
+		os.Stdout.Write(report)
+	}
+	return nil
+}
+
+func runEdit(args []string) error {
+	flags := flag.NewFlagSet("edit", flag.ExitOnError)
+	message := flags.String("m", "", "describe the change in the version history")
+	if err := parseArgs(flags, args, 1, 1); err != nil {
+		return err
+	}
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+	sc, err := c.script(scriptPath(flags.Arg(0)))
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "shctl-")
+	if err != nil {
+		return err
+	}
+	orig, edited := filepath.Join(dir, "orig"), filepath.Join(dir, path.Base(sc.Path))
+	for _, f := range []string{orig, edited} {
+		if err := os.WriteFile(f, []byte(sc.Content), 0o600); err != nil {
+			return err
+		}
+	}
+	// The editor may come with arguments, e.g. "code --wait"
+	editor := cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi")
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", edited)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("%s: %w", editor, err)
+	}
+	content, err := os.ReadFile(edited)
+	if err != nil {
+		return err
+	}
+	if string(content) == sc.Content {
+		os.RemoveAll(dir)
+		fmt.Println("No changes")
+		return nil
+	}
+
+	// diff exits 1 when the files differ
+	diff := exec.Command("diff", "-u", "-L", sc.Path+" (server)", "-L", sc.Path+" (edited)", orig, edited)
+	diff.Stdout, diff.Stderr = os.Stdout, os.Stderr
+	diff.Run()
+	answer, _ := prompt("Push " + sc.Path + "? [y/N] ")
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		fmt.Printf("Not pushed; the edit is kept in %s\n", edited)
+		return nil
+	}
+	// Someone may have pushed while the editor was open; the upload would
+	// silently undo their change
+	current, err := c.script(sc.Path)
+	if err != nil {
+		return fmt.Errorf("%w; the edit is kept in %s", err, edited)
+	}
+	if current.Content != sc.Content {
+		return fmt.Errorf("%s changed on the server during the edit; not pushed, the edit is kept in %s", sc.Path, edited)
+	}
+	report, err := c.upload(sc.Path, content, *message)
+	if err != nil {
+		return fmt.Errorf("%w; the edit is kept in %s", err, edited)
+	}
+	os.RemoveAll(dir)
+	os.Stdout.Write(report)
+	return nil
+}
+
+func runLock(args []string) error {
+	flags := flag.NewFlagSet("lock", flag.ExitOnError)
+	if err := parseArgs(flags, args, 1, 1); err != nil {
+		return err
+	}
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+	sc, err := c.script(scriptPath(flags.Arg(0)))
+	if err != nil {
+		return err
+	}
+	password, err := promptSecret("Password: ")
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return errors.New("a locked script needs a password")
+	}
+	if err := c.update(sc, map[string]any{"locked": true, "password": password}); err != nil {
+		return err
+	}
+	fmt.Printf("Locked %s\n", sc.Path)
+	return nil
+}
+
+func runUnlock(args []string) error {
+	flags := flag.NewFlagSet("unlock", flag.ExitOnError)
+	if err := parseArgs(flags, args, 1, 1); err != nil {
+		return err
+	}
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+	sc, err := c.script(scriptPath(flags.Arg(0)))
+	if err != nil {
+		return err
+	}
+	if !sc.Locked {
+		fmt.Printf("%s is not locked\n", sc.Path)
+		return nil
+	}
+	if err := c.update(sc, map[string]any{"locked": false}); err != nil {
+		return err
+	}
+	fmt.Printf("Unlocked %s\n", sc.Path)
+	return nil
+}
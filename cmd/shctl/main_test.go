@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer serves one script at /tools/foo.sh through the parts of the
+// admin API shctl uses. Each listing hands out the next of contents, so a
+// test can change the script while shctl works on it.
+type fakeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	contents []string
+	uploads  []*http.Request
+	uploaded []string
+	updates  []map[string]any
+}
+
+func newFakeServer(t *testing.T, contents ...string) *fakeServer {
+	t.Helper()
+	f := &fakeServer{contents: contents}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/scripts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		content := f.contents[0]
+		if len(f.contents) > 1 {
+			f.contents = f.contents[1:]
+		}
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode([]map[string]any{
+			{"id": "s1", "path": "/tools/foo.sh", "content": content, "description": "Foo", "tags": []string{"ops"}, "locked": false},
+		})
+	})
+	mux.HandleFunc("PUT /api/v1/scripts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var fields map[string]any
+		json.NewDecoder(r.Body).Decode(&fields)
+		f.mu.Lock()
+		f.updates = append(f.updates, fields)
+		f.mu.Unlock()
+	})
+	mux.HandleFunc("PUT /{path...}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.uploads = append(f.uploads, r)
+		f.uploaded = append(f.uploaded, string(body))
+		f.mu.Unlock()
+		io.WriteString(w, "Updated "+r.URL.Path+"\n")
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SHCTL_SERVER", f.URL)
+	t.Setenv("SHCTL_TOKEN", "secret")
+	return f
+}
+
+// withStdio runs fn with input on stdin and returns what it wrote to stdout
+func withStdio(t *testing.T, input string, fn func()) string {
+	t.Helper()
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "stdin"), filepath.Join(dir, "stdout")
+	os.WriteFile(in, []byte(input), 0o600)
+	stdin, err := os.Open(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	stdout, err := os.Create(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+
+	oldIn, oldOut, oldErr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = stdin, stdout, stdout
+	defer func() { os.Stdin, os.Stdout, os.Stderr = oldIn, oldOut, oldErr }()
+	fn()
+
+	data, _ := os.ReadFile(out)
+	return string(data)
+}
+
+func TestClient(t *testing.T) {
+	t.Run("serverURL and scriptPath", func(t *testing.T) {
+		for in, want := range map[string]string{
+			"sh.example.com":          "https://sh.example.com",
+			"http://localhost:8000/":  "http://localhost:8000",
+			"https://sh.example.com/": "https://sh.example.com",
+		} {
+			if got := serverURL(in); got != want {
+				t.Errorf("serverURL(%q) = %q, want %q", in, got, want)
+			}
+		}
+		if scriptPath("tools/foo.sh") != "/tools/foo.sh" || scriptPath("/foo.sh") != "/foo.sh" {
+			t.Error("scriptPath should make paths absolute")
+		}
+	})
+
+	t.Run("responseError", func(t *testing.T) {
+		tests := []struct {
+			status int
+			body   string
+			want   string
+		}{
+			{http.StatusNotFound, "Script not found\n", "Not Found: Script not found"},
+			{http.StatusUnauthorized, "Unauthorized\n", "Unauthorized"},
+			{http.StatusBadGateway, "", "Bad Gateway"},
+			{http.StatusBadRequest, `{"error":{"message":"Validation failed","fields":[{"field":"path","message":"must end in .sh"}]}}`,
+				"Bad Request: Validation failed\n  path: must end in .sh"},
+		}
+		for _, tt := range tests {
+			if got := responseError(tt.status, []byte(tt.body)).Error(); got != tt.want {
+				t.Errorf("responseError(%d, %q) = %q, want %q", tt.status, tt.body, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("scripts and updates", func(t *testing.T) {
+		f := newFakeServer(t, "#!/bin/sh\n")
+		c, err := connect()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.script("/tools/missing.sh"); err == nil || !strings.Contains(err.Error(), "no such script") {
+			t.Errorf("expected a missing script to be reported, got %v", err)
+		}
+		sc, err := c.script("/tools/foo.sh")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.update(sc, map[string]any{"locked": true, "password": "pw"}); err != nil {
+			t.Fatal(err)
+		}
+		// Fields shctl doesn't know about are sent back unchanged
+		got := f.updates[0]
+		if got["locked"] != true || got["password"] != "pw" || got["description"] != "Foo" || len(got["tags"].([]any)) != 1 {
+			t.Errorf("update sent %v", got)
+		}
+
+		c.token = "wrong"
+		if _, err := c.scripts(); err == nil || err.Error() != "Unauthorized" {
+			t.Errorf("expected the server's error, got %v", err)
+		}
+	})
+
+	t.Run("upload", func(t *testing.T) {
+		f := newFakeServer(t, "#!/bin/sh\n")
+		c, _ := connect()
+		report, err := c.upload("/tools/foo.sh", []byte("#!/bin/sh\necho new\n"), "fix retry")
+		if err != nil || string(report) != "Updated /tools/foo.sh\n" {
+			t.Fatalf("upload: %q %v", report, err)
+		}
+		req := f.uploads[0]
+		if req.Header.Get("X-Script-Message") != "fix retry" || req.Header.Get("Authorization") != "Bearer secret" || f.uploaded[0] != "#!/bin/sh\necho new\n" {
+			t.Errorf("upload sent %v %q", req.Header, f.uploaded[0])
+		}
+	})
+}
+
+func TestRunEdit(t *testing.T) {
+	const orig = "#!/bin/sh\necho old\n"
+	t.Setenv("EDITOR", "")
+	t.Setenv("VISUAL", `printf 'echo edited\n' >>`)
+
+	t.Run("pushes the edit", func(t *testing.T) {
+		f := newFakeServer(t, orig)
+		var err error
+		out := withStdio(t, "y\n", func() { err = runEdit([]string{"-m", "tweak", "tools/foo.sh"}) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(f.uploaded) != 1 || f.uploaded[0] != orig+"echo edited\n" || f.uploads[0].Header.Get("X-Script-Message") != "tweak" {
+			t.Errorf("expected the edit to be uploaded, got %q", f.uploaded)
+		}
+		if !strings.Contains(out, "+echo edited") || !strings.Contains(out, "Updated /tools/foo.sh") {
+			t.Errorf("expected the diff and the report, got %q", out)
+		}
+	})
+
+	t.Run("keeps an unconfirmed edit", func(t *testing.T) {
+		f := newFakeServer(t, orig)
+		var err error
+		out := withStdio(t, "n\n", func() { err = runEdit([]string{"/tools/foo.sh"}) })
+		_, kept, ok := strings.Cut(strings.TrimSpace(out), "Not pushed; the edit is kept in ")
+		if err != nil || len(f.uploaded) != 0 || !ok {
+			t.Fatalf("expected nothing to be pushed: %v %q", err, out)
+		}
+		os.RemoveAll(filepath.Dir(kept))
+	})
+
+	t.Run("refuses to overwrite a change made on the server", func(t *testing.T) {
+		f := newFakeServer(t, orig, "#!/bin/sh\necho theirs\n")
+		var err error
+		withStdio(t, "y\n", func() { err = runEdit([]string{"/tools/foo.sh"}) })
+		if err == nil || !strings.Contains(err.Error(), "changed on the server") {
+			t.Fatalf("expected a conflict, got %v", err)
+		}
+		if len(f.uploaded) != 0 {
+			t.Errorf("expected nothing to be pushed, got %q", f.uploaded)
+		}
+		kept := err.Error()[strings.LastIndex(err.Error(), " ")+1:]
+		if data, _ := os.ReadFile(kept); string(data) != orig+"echo edited\n" {
+			t.Errorf("expected the edit to be kept in %s, got %q", kept, data)
+		}
+		os.RemoveAll(filepath.Dir(kept))
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		t.Setenv("VISUAL", "true")
+		f := newFakeServer(t, orig)
+		var err error
+		out := withStdio(t, "", func() { err = runEdit([]string{"/tools/foo.sh"}) })
+		if err != nil || len(f.uploaded) != 0 || !strings.Contains(out, "No changes") {
+			t.Errorf("expected nothing to be pushed: %v %q", err, out)
+		}
+	})
+}
+
+func TestCompletion(t *testing.T) {
+	t.Run("scripts", func(t *testing.T) {
+		// How each shell checks a script without running it
+		checks := map[string][]string{
+			"bash": {"bash", "-n"},
+			"zsh":  {"zsh", "-n"},
+			"fish": {"fish", "--no-execute"},
+		}
+		for shell, check := range checks {
+			var err error
+			out := withStdio(t, "", func() { err = runCompletion([]string{shell}) })
+			if err != nil || !strings.Contains(out, "shctl __complete") {
+				t.Fatalf("%s: %v %q", shell, err, out)
+			}
+			if _, err := exec.LookPath(check[0]); err != nil {
+				t.Logf("%s is not installed; skipping its syntax check", check[0])
+				continue
+			}
+			script := filepath.Join(t.TempDir(), "shctl."+shell)
+			os.WriteFile(script, []byte(out), 0o600)
+			if out, err := exec.Command(check[0], append(check[1:], script)...).CombinedOutput(); err != nil {
+				t.Errorf("%s rejected its completion script: %v %s", shell, err, out)
+			}
+		}
+		if err := runCompletion([]string{"tcsh"}); err == nil {
+			t.Error("expected an unknown shell to be refused")
+		}
+	})
+
+	t.Run("runComplete", func(t *testing.T) {
+		tests := []struct {
+			words []string
+			want  string
+		}{
+			{[]string{""}, "words\ncompletion\nedit\nget\nlock\nlogin\nls\npush\nunlock\n"},
+			{[]string{"l"}, "words\nlock\nlogin\nls\n"},
+			{[]string{"completion", "f"}, "words\nfish\n"},
+			{[]string{"push", ""}, "files\n"},
+			{[]string{"get", "-o", ""}, "files\n"},
+			{[]string{"push", "-m", ""}, "none\n"},
+			{[]string{"login", ""}, "none\n"},
+		}
+		for _, tt := range tests {
+			out := withStdio(t, "", func() { runComplete(tt.words) })
+			if out != tt.want {
+				t.Errorf("runComplete(%q) = %q, want %q", tt.words, out, tt.want)
+			}
+		}
+	})
+
+	t.Run("remoteCompletions", func(t *testing.T) {
+		paths := []string{"/setup.sh", "/tools/foo.sh", "/tools/db/backup.sh", "/tools/db/restore.sh", "/team/x.sh"}
+		tests := []struct {
+			cur         string
+			foldersOnly bool
+			want        []string
+		}{
+			{"", false, []string{"/setup.sh", "/team/", "/tools/"}},
+			{"/t", false, []string{"/team/", "/tools/"}},
+			{"tools/", false, []string{"tools/db/", "tools/foo.sh"}},
+			{"/tools/db/r", false, []string{"/tools/db/restore.sh"}},
+			{"/tools/", true, []string{"/tools/db/"}},
+			{"/nope", false, nil},
+		}
+		for _, tt := range tests {
+			if got := remoteCompletions(paths, tt.cur, tt.foldersOnly); !slices.Equal(got, tt.want) {
+				t.Errorf("remoteCompletions(%q, %v) = %q, want %q", tt.cur, tt.foldersOnly, got, tt.want)
+			}
+		}
+	})
+}
@@ -1,43 +1,670 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"net/url"
 	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/hunydev/sh-server/db"
 	"github.com/hunydev/sh-server/srv"
+	"github.com/hunydev/sh-server/storage"
 )
 
 func main() {
+	configPath := flag.String("config", "", "YAML file of settings; environment variables take precedence")
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	flag.Parse()
+	if *showVersion {
+		v := srv.BuildVersion()
+		fmt.Printf("sh-server %s\n", v)
+		if v.BuildDate != "" {
+			fmt.Printf("built %s\n", v.BuildDate)
+		}
+		fmt.Println(v.GoVersion)
+		return
+	}
+	if err := loadConfigFile(*configPath); err != nil {
+		log.Fatalf("Failed to read config file: %v", err)
+	}
+
+	logHandler, err := srv.NewLogHandler(os.Stderr, getEnv("LOG_FORMAT", "text"), getEnv("LOG_LEVEL", "info"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(slog.New(logHandler))
+
 	dbPath := getEnv("DB_PATH", "./sh.db")
+	databaseURL := getEnv("DATABASE_URL", "")
+	var sqliteOpts db.Options
+	if v := getEnv("DB_BUSY_TIMEOUT", ""); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid DB_BUSY_TIMEOUT: %q", v)
+		}
+		sqliteOpts.BusyTimeout = d
+	}
+	if v := getEnv("DB_MAX_OPEN_CONNS", ""); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			log.Fatalf("Invalid DB_MAX_OPEN_CONNS: %q", v)
+		}
+		sqliteOpts.MaxOpenConns = n
+	}
 	hostname := getEnv("HOSTNAME", "localhost:8000")
-	adminToken := getEnv("ADMIN_TOKEN", "")
+	reloadable, err := reloadableSettings()
+	if err != nil {
+		log.Fatal(err)
+	}
+	adminToken := reloadable.AdminToken
 	addr := ":" + getEnv("PORT", "8000")
+	archiveExpired, _ := strconv.ParseBool(getEnv("AUTO_ARCHIVE_EXPIRED", "false"))
+	basicAuthChallenge, _ := strconv.ParseBool(getEnv("BASIC_AUTH_CHALLENGE", "false"))
+	accessLog, _ := strconv.ParseBool(getEnv("ACCESS_LOG", "false"))
+	oidc := srv.OIDCConfig{
+		Issuer:       getEnv("OIDC_ISSUER", ""),
+		ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+		ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:  getEnv("OIDC_REDIRECT_URL", ""),
+		GroupsClaim:  getEnv("OIDC_GROUPS_CLAIM", ""),
+		AdminGroups:  splitList(getEnv("OIDC_ADMIN_GROUPS", "")),
+		ViewerGroups: splitList(getEnv("OIDC_VIEWER_GROUPS", "")),
+	}
+
+	trustedHeader := srv.TrustedHeaderConfig{
+		Header:  getEnv("TRUSTED_HEADER", ""),
+		Admins:  splitList(getEnv("TRUSTED_HEADER_ADMINS", "")),
+		Viewers: splitList(getEnv("TRUSTED_HEADER_VIEWERS", "")),
+	}
+
+	requireClientCert, _ := strconv.ParseBool(getEnv("REQUIRE_CLIENT_CERT", "false"))
+	hstsSubdomains, _ := strconv.ParseBool(getEnv("HSTS_INCLUDE_SUBDOMAINS", "false"))
+	tlsCfg := srv.TLSConfig{
+		CertFile:              getEnv("TLS_CERT_FILE", ""),
+		KeyFile:               getEnv("TLS_KEY_FILE", ""),
+		ClientCAFile:          getEnv("CLIENT_CA_FILE", ""),
+		RequireClientCert:     requireClientCert,
+		RedirectAddr:          getEnv("HTTP_REDIRECT_ADDR", ""),
+		HSTSIncludeSubdomains: hstsSubdomains,
+	}
+	if v := getEnv("HSTS_MAX_AGE", ""); v != "" {
+		tlsCfg.HSTSMaxAge, err = time.ParseDuration(v)
+		if err != nil || tlsCfg.HSTSMaxAge < 0 {
+			log.Fatalf("Invalid HSTS_MAX_AGE: %q", v)
+		}
+	}
+
+	argon2Memory, _ := strconv.ParseUint(getEnv("ARGON2_MEMORY", "65536"), 10, 32)
+	argon2Iterations, _ := strconv.ParseUint(getEnv("ARGON2_ITERATIONS", "3"), 10, 32)
+	argon2Parallelism, _ := strconv.ParseUint(getEnv("ARGON2_PARALLELISM", "4"), 10, 8)
+	passwords := srv.PasswordConfig{
+		Algorithm:         getEnv("PASSWORD_HASH", "bcrypt"),
+		Argon2Memory:      uint32(argon2Memory),
+		Argon2Iterations:  uint32(argon2Iterations),
+		Argon2Parallelism: uint8(argon2Parallelism),
+	}
+	if passwords.Algorithm != "bcrypt" && passwords.Algorithm != "argon2id" {
+		log.Fatalf("PASSWORD_HASH must be bcrypt or argon2id, got %q", passwords.Algorithm)
+	}
+
+	unlockTTL, err := time.ParseDuration(getEnv("UNLOCK_TOKEN_TTL", "5m"))
+	if err != nil {
+		log.Fatalf("Invalid UNLOCK_TOKEN_TTL: %v", err)
+	}
+	unlockMaxTTL, err := time.ParseDuration(getEnv("UNLOCK_TOKEN_MAX_TTL", "1h"))
+	if err != nil {
+		log.Fatalf("Invalid UNLOCK_TOKEN_MAX_TTL: %v", err)
+	}
+	var unlockBindIP, unlockBindUA bool
+	for _, b := range splitList(getEnv("UNLOCK_TOKEN_BIND", "")) {
+		switch b {
+		case "ip":
+			unlockBindIP = true
+		case "ua":
+			unlockBindUA = true
+		default:
+			log.Fatalf("UNLOCK_TOKEN_BIND accepts ip and ua, got %q", b)
+		}
+	}
+	signingKey := getEnv("SIGNING_KEY", "")
+	if signingKey != "" && len(signingKey) < 32 {
+		log.Fatal("SIGNING_KEY must be at least 32 characters")
+	}
+	powDifficulty, _ := strconv.Atoi(getEnv("UNLOCK_POW_DIFFICULTY", "0"))
+	if powDifficulty < 0 || powDifficulty > 6 {
+		log.Fatal("UNLOCK_POW_DIFFICULTY must be between 0 and 6")
+	}
+	secretScan := getEnv("SECRET_SCAN", "warn")
+	if secretScan != "warn" && secretScan != "reject" && secretScan != "off" {
+		log.Fatalf("SECRET_SCAN must be warn, reject or off, got %q", secretScan)
+	}
+	var auditRetention srv.AuditRetentionConfig
+	if v := getEnv("AUDIT_RETENTION", ""); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid AUDIT_RETENTION: %q", v)
+		}
+		auditRetention.Retention = d
+	}
+	auditRetention.ArchiveDir = getEnv("AUDIT_ARCHIVE_DIR", "")
+	if auditRetention.ArchiveDir != "" {
+		if err := os.MkdirAll(auditRetention.ArchiveDir, 0o700); err != nil {
+			log.Fatalf("Invalid AUDIT_ARCHIVE_DIR: %v", err)
+		}
+	}
+	validateSyntax, _ := strconv.ParseBool(getEnv("VALIDATE_SYNTAX", "true"))
+	shellCheckPath := getEnv("SHELLCHECK_PATH", "shellcheck")
+	lintBlockErrors, _ := strconv.ParseBool(getEnv("LINT_BLOCK_ERRORS", "false"))
+	if lintBlockErrors {
+		if _, err := exec.LookPath(shellCheckPath); err != nil {
+			log.Fatalf("LINT_BLOCK_ERRORS needs shellcheck: %v", err)
+		}
+	}
+	preSaveHook := getEnv("PRE_SAVE_HOOK", "")
+	postSaveHook := getEnv("POST_SAVE_HOOK", "")
+	for _, hook := range []string{preSaveHook, postSaveHook} {
+		if hook == "" {
+			continue
+		}
+		if _, err := exec.LookPath(hook); err != nil {
+			log.Fatalf("Invalid save hook: %v", err)
+		}
+	}
+	ipAnonymize := srv.IPAnonymizeConfig{
+		Mode: getEnv("IP_ANONYMIZE", "off"),
+		Salt: getEnv("IP_HASH_SALT", ""),
+	}
+	switch ipAnonymize.Mode {
+	case "off":
+		ipAnonymize.Mode = ""
+	case "truncate", "hash":
+	default:
+		log.Fatalf("IP_ANONYMIZE must be off, truncate or hash, got %q", ipAnonymize.Mode)
+	}
+	ipAnonymize.Rotate, err = time.ParseDuration(getEnv("IP_HASH_ROTATE", "24h"))
+	if err != nil || ipAnonymize.Rotate < 0 || (ipAnonymize.Rotate > 0 && ipAnonymize.Rotate < time.Minute) {
+		log.Fatalf("Invalid IP_HASH_ROTATE: %q", getEnv("IP_HASH_ROTATE", "24h"))
+	}
+	digest := srv.DigestConfig{URL: reloadable.DigestURL}
+	switch v := getEnv("DIGEST_INTERVAL", "weekly"); v {
+	case "daily":
+		digest.Interval = 24 * time.Hour
+	case "weekly":
+		digest.Interval = 7 * 24 * time.Hour
+	default:
+		digest.Interval, err = time.ParseDuration(v)
+		if err != nil || digest.Interval < time.Hour {
+			log.Fatalf("DIGEST_INTERVAL must be daily, weekly or a duration of at least 1h, got %q", v)
+		}
+	}
+	githubSync := srv.GitHubSyncConfig{
+		Repo:   getEnv("GITHUB_SYNC_REPO", ""),
+		Ref:    getEnv("GITHUB_SYNC_REF", "main"),
+		Dir:    getEnv("GITHUB_SYNC_DIR", ""),
+		Prefix: getEnv("GITHUB_SYNC_PREFIX", "/"),
+		Token:  getEnv("GITHUB_SYNC_TOKEN", ""),
+		Secret: getEnv("GITHUB_SYNC_SECRET", ""),
+		APIURL: getEnv("GITHUB_API_URL", "https://api.github.com"),
+	}
+	githubSync.Interval, err = time.ParseDuration(getEnv("GITHUB_SYNC_INTERVAL", "0"))
+	if err != nil || (githubSync.Interval > 0 && githubSync.Interval < time.Minute) {
+		log.Fatalf("GITHUB_SYNC_INTERVAL must be 0 or a duration of at least 1m, got %q", getEnv("GITHUB_SYNC_INTERVAL", "0"))
+	}
+	gitMirror := srv.GitMirrorConfig{
+		Dir:    getEnv("GIT_MIRROR_DIR", ""),
+		Remote: getEnv("GIT_MIRROR_REMOTE", ""),
+	}
+	if gitMirror.Remote != "" && gitMirror.Dir == "" {
+		log.Fatal("GIT_MIRROR_REMOTE needs GIT_MIRROR_DIR")
+	}
+	sshCfg := srv.SSHConfig{
+		Addr:               getEnv("SSH_ADDR", ""),
+		HostKeyFile:        getEnv("SSH_HOST_KEY", "ssh_host_ed25519_key"),
+		AuthorizedKeysFile: getEnv("SSH_AUTHORIZED_KEYS", ""),
+	}
+	if sshCfg.Addr != "" && sshCfg.AuthorizedKeysFile == "" {
+		log.Fatal("SSH_ADDR needs SSH_AUTHORIZED_KEYS")
+	}
+	importOnStartup, _ := strconv.ParseBool(getEnv("IMPORT_ON_STARTUP", "false"))
+	fsImport := srv.FSImportConfig{
+		Dir:       getEnv("IMPORT_DIR", ""),
+		Prefix:    getEnv("IMPORT_PREFIX", "/"),
+		OnStartup: importOnStartup,
+	}
+	if fsImport.OnStartup && fsImport.Dir == "" {
+		log.Fatal("IMPORT_ON_STARTUP needs IMPORT_DIR")
+	}
+	fsSync := srv.FSSyncConfig{
+		Dir:    getEnv("FS_SYNC_DIR", ""),
+		Prefix: getEnv("FS_SYNC_PREFIX", "/"),
+	}
+	replica := srv.ReplicaConfig{
+		Upstream: getEnv("REPLICA_OF", ""),
+		Token:    getEnv("REPLICA_TOKEN", ""),
+		Prefix:   getEnv("REPLICA_PREFIX", ""),
+		Secret:   getEnv("REPLICA_SECRET", ""),
+	}
+	replica.Interval, err = time.ParseDuration(getEnv("REPLICA_INTERVAL", "5m"))
+	if err != nil || replica.Interval < 10*time.Second {
+		log.Fatalf("REPLICA_INTERVAL must be a duration of at least 10s, got %q", getEnv("REPLICA_INTERVAL", "5m"))
+	}
+	if replica.Upstream != "" && (githubSync.Repo != "" || fsSync.Dir != "" || fsImport.OnStartup) {
+		log.Fatal("REPLICA_OF cannot be combined with GITHUB_SYNC_REPO, FS_SYNC_DIR or IMPORT_ON_STARTUP")
+	}
+	s3PathStyle, _ := strconv.ParseBool(getEnv("S3_PATH_STYLE", "false"))
+	storageCfg := srv.StorageConfig{
+		Backend:     getEnv("STORAGE", ""),
+		Dir:         getEnv("STORAGE_DIR", ""),
+		Compression: getEnv("STORAGE_COMPRESSION", ""),
+		S3: storage.S3Config{
+			Endpoint:        getEnv("S3_ENDPOINT", ""),
+			Region:          getEnv("S3_REGION", getEnv("AWS_REGION", "")),
+			Bucket:          getEnv("S3_BUCKET", ""),
+			Prefix:          getEnv("S3_PREFIX", ""),
+			AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", getEnv("AWS_ACCESS_KEY_ID", "")),
+			SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", getEnv("AWS_SECRET_ACCESS_KEY", "")),
+			SessionToken:    getEnv("S3_SESSION_TOKEN", getEnv("AWS_SESSION_TOKEN", "")),
+			PathStyle:       s3PathStyle,
+		},
+	}
+	switch {
+	case storageCfg.Backend == "fs" && storageCfg.Dir == "":
+		log.Fatal("STORAGE=fs needs STORAGE_DIR")
+	case storageCfg.Backend == "s3" && storageCfg.S3.Bucket == "":
+		log.Fatal("STORAGE=s3 needs S3_BUCKET")
+	case storageCfg.Compression != "" && storageCfg.Compression != "zstd":
+		log.Fatalf("Invalid STORAGE_COMPRESSION: %q", storageCfg.Compression)
+	}
+	backups := srv.BackupConfig{Dir: getEnv("BACKUP_DIR", "")}
+	if v := getEnv("BACKUP_INTERVAL", ""); v != "" {
+		backups.Interval, err = time.ParseDuration(v)
+		if err != nil || backups.Interval < time.Minute {
+			log.Fatalf("BACKUP_INTERVAL must be a duration of at least 1m, got %q", v)
+		}
+	}
+	backups.Keep, err = strconv.Atoi(getEnv("BACKUP_KEEP", "7"))
+	if err != nil || backups.Keep < 0 {
+		log.Fatalf("Invalid BACKUP_KEEP: %q", getEnv("BACKUP_KEEP", "7"))
+	}
+	if bucket := getEnv("BACKUP_S3_BUCKET", ""); bucket != "" {
+		// Endpoint and credentials are those of the S3 storage backend
+		backups.S3 = storageCfg.S3
+		backups.S3.Bucket = bucket
+		backups.S3.Prefix = getEnv("BACKUP_S3_PREFIX", "")
+	}
+	if backups.Interval > 0 && backups.Dir == "" && backups.S3.Bucket == "" {
+		log.Fatal("BACKUP_INTERVAL needs BACKUP_DIR or BACKUP_S3_BUCKET")
+	}
+	var maintenance srv.MaintenanceConfig
+	if v := getEnv("MAINTENANCE_INTERVAL", ""); v != "" {
+		maintenance.Interval, err = time.ParseDuration(v)
+		if err != nil || maintenance.Interval < time.Hour {
+			log.Fatalf("MAINTENANCE_INTERVAL must be a duration of at least 1h, got %q", v)
+		}
+	}
+	scriptCache := srv.ScriptCacheConfig{}
+	scriptCache.Size, err = strconv.Atoi(getEnv("SCRIPT_CACHE_SIZE", "256"))
+	if err != nil || scriptCache.Size < 0 {
+		log.Fatalf("Invalid SCRIPT_CACHE_SIZE: %q", getEnv("SCRIPT_CACHE_SIZE", "256"))
+	}
+	scriptCache.TTL = reloadable.ScriptCacheTTL
+	shutdownTimeout, err := time.ParseDuration(getEnv("SHUTDOWN_TIMEOUT", "30s"))
+	if err != nil || shutdownTimeout < 0 {
+		log.Fatalf("Invalid SHUTDOWN_TIMEOUT: %q", getEnv("SHUTDOWN_TIMEOUT", "30s"))
+	}
+	httpCfg := srv.HTTPConfig{}
+	for _, t := range []struct {
+		key, fallback string
+		d             *time.Duration
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", "10s", &httpCfg.ReadHeaderTimeout},
+		{"HTTP_READ_TIMEOUT", "1m", &httpCfg.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", "2m", &httpCfg.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", "2m", &httpCfg.IdleTimeout},
+	} {
+		*t.d, err = time.ParseDuration(getEnv(t.key, t.fallback))
+		if err != nil || *t.d <= 0 {
+			log.Fatalf("Invalid %s: %q", t.key, getEnv(t.key, t.fallback))
+		}
+	}
+	httpCfg.MaxHeaderBytes, err = strconv.Atoi(getEnv("HTTP_MAX_HEADER_BYTES", "65536"))
+	if err != nil || httpCfg.MaxHeaderBytes <= 0 {
+		log.Fatalf("Invalid HTTP_MAX_HEADER_BYTES: %q", getEnv("HTTP_MAX_HEADER_BYTES", "65536"))
+	}
+	writeQueue := srv.WriteQueueConfig{}
+	writeQueue.Size, err = strconv.Atoi(getEnv("WRITE_QUEUE_SIZE", "4096"))
+	if err != nil || writeQueue.Size < 0 {
+		log.Fatalf("Invalid WRITE_QUEUE_SIZE: %q", getEnv("WRITE_QUEUE_SIZE", "4096"))
+	}
+	writeQueue.BatchSize, err = strconv.Atoi(getEnv("WRITE_BATCH_SIZE", "256"))
+	if err != nil || writeQueue.BatchSize <= 0 {
+		log.Fatalf("Invalid WRITE_BATCH_SIZE: %q", getEnv("WRITE_BATCH_SIZE", "256"))
+	}
+	writeQueue.FlushInterval, err = time.ParseDuration(getEnv("WRITE_FLUSH_INTERVAL", "250ms"))
+	if err != nil || writeQueue.FlushInterval < 0 {
+		log.Fatalf("Invalid WRITE_FLUSH_INTERVAL: %q", getEnv("WRITE_FLUSH_INTERVAL", "250ms"))
+	}
+	securityHeaders := srv.SecurityHeadersConfig{
+		ContentSecurityPolicy: getEnv("CONTENT_SECURITY_POLICY", ""),
+		ReferrerPolicy:        getEnv("REFERRER_POLICY", ""),
+		FrameOptions:          getEnv("FRAME_OPTIONS", ""),
+	}
+	switch v := getEnv("SECURITY_HEADERS_EXCLUDE", ""); v {
+	case "":
+	case "none":
+		securityHeaders.Exclude = []string{}
+	default:
+		securityHeaders.Exclude = splitList(v)
+	}
+	switch securityHeaders.FrameOptions {
+	case "", "off", "DENY", "SAMEORIGIN":
+	default:
+		log.Fatalf("FRAME_OPTIONS must be DENY, SAMEORIGIN or off, got %q", securityHeaders.FrameOptions)
+	}
+	limits := srv.LimitsConfig{}
+	limits.MaxScriptSize, err = strconv.ParseInt(getEnv("MAX_SCRIPT_SIZE", "1048576"), 10, 64)
+	if err != nil || limits.MaxScriptSize <= 0 {
+		log.Fatalf("Invalid MAX_SCRIPT_SIZE: %q", getEnv("MAX_SCRIPT_SIZE", "1048576"))
+	}
+	limits.MaxRequestBody, err = strconv.ParseInt(getEnv("MAX_REQUEST_BODY", "0"), 10, 64)
+	if err != nil || limits.MaxRequestBody < 0 {
+		log.Fatalf("Invalid MAX_REQUEST_BODY: %q", getEnv("MAX_REQUEST_BODY", "0"))
+	}
+	var instanceLimits srv.InstanceLimitsConfig
+	for _, l := range []struct {
+		env string
+		n   *int64
+	}{
+		{"MAX_SCRIPTS", &instanceLimits.Scripts.Hard},
+		{"MAX_SCRIPTS_SOFT", &instanceLimits.Scripts.Soft},
+		{"MAX_VERSIONS_PER_SCRIPT", &instanceLimits.VersionsPerScript.Hard},
+		{"MAX_VERSIONS_PER_SCRIPT_SOFT", &instanceLimits.VersionsPerScript.Soft},
+		{"MAX_CONTENT_BYTES", &instanceLimits.ContentBytes.Hard},
+		{"MAX_CONTENT_BYTES_SOFT", &instanceLimits.ContentBytes.Soft},
+	} {
+		*l.n, err = strconv.ParseInt(getEnv(l.env, "0"), 10, 64)
+		if err != nil || *l.n < 0 {
+			log.Fatalf("Invalid %s: %q", l.env, getEnv(l.env, "0"))
+		}
+	}
+	geoIP := srv.GeoIPConfig{
+		DBFile: getEnv("GEOIP_DB", ""),
+		Allow:  splitList(getEnv("GEOIP_ALLOW", "")),
+		Deny:   splitList(getEnv("GEOIP_DENY", "")),
+	}
+	if geoIP.DBFile == "" && (len(geoIP.Allow) > 0 || len(geoIP.Deny) > 0) {
+		log.Fatal("GEOIP_ALLOW and GEOIP_DENY need GEOIP_DB")
+	}
 
-	if adminToken == "" {
-		log.Println("WARNING: ADMIN_TOKEN not set, API access will be unrestricted")
+	if (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+		log.Fatal("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if (tlsCfg.ClientCAFile != "" || tlsCfg.RequireClientCert) && tlsCfg.CertFile == "" {
+		log.Fatal("client certificates need TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if tlsCfg.RequireClientCert && tlsCfg.ClientCAFile == "" {
+		log.Fatal("REQUIRE_CLIENT_CERT needs CLIENT_CA_FILE")
+	}
+	if tlsCfg.RedirectAddr != "" && tlsCfg.CertFile == "" {
+		log.Fatal("HTTP_REDIRECT_ADDR needs TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	trustedProxies := getEnv("TRUSTED_PROXY_IPS", "")
+	if trustedHeader.Header != "" && trustedProxies == "" {
+		log.Fatal("TRUSTED_PROXY_IPS is required when TRUSTED_HEADER is set")
+	}
+	if oidc.Issuer != "" && oidc.ClientID == "" {
+		log.Fatal("OIDC_CLIENT_ID is required when OIDC_ISSUER is set")
+	}
+	if adminToken == "" && oidc.Issuer == "" && trustedHeader.Header == "" && tlsCfg.ClientCAFile == "" {
+		slog.Warn("ADMIN_TOKEN not set, API access will be unrestricted")
 	}
 
 	server, err := srv.New(srv.Config{
-		DBPath:     dbPath,
-		Hostname:   hostname,
-		AdminToken: adminToken,
+		DBPath:         dbPath,
+		DatabaseURL:    databaseURL,
+		SQLite:         sqliteOpts,
+		Hostname:       hostname,
+		AdminToken:     adminToken,
+		ArchiveExpired: archiveExpired,
+		OIDC:           oidc,
+		TrustedHeader:  trustedHeader,
+		TrustedProxies: trustedProxies,
+		VirtualHosts:   getEnv("VIRTUAL_HOSTS", ""),
+		TLS:            tlsCfg,
+		Passwords:      passwords,
+		UnlockTTL:      unlockTTL,
+		UnlockMaxTTL:   unlockMaxTTL,
+		UnlockBindIP:   unlockBindIP,
+		UnlockBindUA:   unlockBindUA,
+		SigningKey:     signingKey,
+		GeoIP:          geoIP,
+		UnlockLimit:    reloadable.UnlockLimit,
+		RateLimits:     reloadable.RateLimits,
+
+		ScanTrustedHosts:    splitList(strings.ToLower(getEnv("SCAN_TRUSTED_HOSTS", ""))),
+		SecretScan:          secretScan,
+		AuditRetention:      auditRetention,
+		HoneypotAlertURL:    reloadable.HoneypotAlertURL,
+		ValidateSyntax:      validateSyntax,
+		ShellCheckPath:      shellCheckPath,
+		LintBlockErrors:     lintBlockErrors,
+		UnlockPoWDifficulty: powDifficulty,
+		BasicAuthChallenge:  basicAuthChallenge,
+		AccessLog:           accessLog,
+		AuthFailLog:         getEnv("AUTH_FAIL_LOG", ""),
+		IPAnonymize:         ipAnonymize,
+		Digest:              digest,
+		PreSaveHook:         preSaveHook,
+		PostSaveHook:        postSaveHook,
+		GitHubSync:          githubSync,
+		GitMirror:           gitMirror,
+		SSH:                 sshCfg,
+		GitHTTPDir:          getEnv("GIT_HTTP_DIR", ""),
+		FSImport:            fsImport,
+		FSSync:              fsSync,
+		Replica:             replica,
+		Storage:             storageCfg,
+		Backups:             backups,
+		Maintenance:         maintenance,
+		ScriptCache:         scriptCache,
+		CatalogCacheTTL:     reloadable.CatalogCacheTTL,
+		WriteQueue:          writeQueue,
+		Limits:              limits,
+		InstanceLimits:      instanceLimits,
+		ShutdownTimeout:     shutdownTimeout,
+		HTTP:                httpCfg,
+		SecurityHeaders:     securityHeaders,
+		Reload: func() (srv.ReloadConfig, error) {
+			if err := loadConfigFile(*configPath); err != nil {
+				return srv.ReloadConfig{}, err
+			}
+			defer warnUnusedSettings()
+			return reloadableSettings()
+		},
 	})
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
 
-	log.Printf("Starting SH Server on %s", addr)
-	log.Printf("Database: %s", dbPath)
-	log.Printf("Hostname: %s", hostname)
+	slog.Info("starting SH Server", "version", srv.BuildVersion().String(), "addr", addr, "database", dbPath, "hostname", hostname)
+	if oidc.Issuer != "" {
+		slog.Info("OIDC enabled", "issuer", oidc.Issuer)
+	}
+	if tlsCfg.CertFile != "" {
+		slog.Info("TLS enabled", "client_ca", tlsCfg.ClientCAFile, "redirect", tlsCfg.RedirectAddr, "hsts_max_age", tlsCfg.HSTSMaxAge)
+	}
+	if trustedHeader.Header != "" {
+		slog.Info("trusting identity header", "header", trustedHeader.Header, "proxies", trustedProxies)
+	}
+	if digest.URL != "" {
+		slog.Info("usage digests enabled", "interval", digest.Interval)
+	}
+	if githubSync.Repo != "" {
+		slog.Info("GitHub sync enabled", "repo", githubSync.Repo, "ref", githubSync.Ref, "dir", githubSync.Dir, "interval", githubSync.Interval)
+	}
+	if gitMirror.Dir != "" {
+		slog.Info("git mirror enabled", "dir", gitMirror.Dir, "remote", gitMirror.Remote)
+	}
+	if dir := getEnv("GIT_HTTP_DIR", ""); dir != "" {
+		slog.Info("git repository enabled", "dir", dir)
+	}
+	if fsImport.Dir != "" {
+		slog.Info("filesystem import enabled", "dir", fsImport.Dir, "prefix", fsImport.Prefix, "on_startup", fsImport.OnStartup)
+	}
+	if fsSync.Dir != "" {
+		slog.Info("directory sync enabled", "dir", fsSync.Dir, "prefix", fsSync.Prefix)
+	}
+	if databaseURL != "" {
+		// The URL may hold a password
+		dbURL := "(connection string)"
+		if u, err := url.Parse(databaseURL); err == nil && u.Host != "" {
+			dbURL = u.Redacted()
+		}
+		slog.Info("PostgreSQL enabled", "url", dbURL)
+	}
+	if replica.Upstream != "" {
+		slog.Info("read-only replica enabled", "upstream", replica.Upstream, "prefix", replica.Prefix, "interval", replica.Interval)
+	}
+	if storageCfg.Backend != "" || storageCfg.Compression != "" {
+		slog.Info("storage backend enabled", "backend", storageCfg.Backend, "dir", storageCfg.Dir, "bucket", storageCfg.S3.Bucket, "compression", storageCfg.Compression)
+	}
+	if backups.Interval > 0 {
+		slog.Info("scheduled backups enabled", "interval", backups.Interval, "keep", backups.Keep, "dir", backups.Dir, "bucket", backups.S3.Bucket)
+	}
+	if sshCfg.Addr != "" {
+		slog.Info("SSH enabled", "addr", sshCfg.Addr, "authorized_keys", sshCfg.AuthorizedKeysFile)
+	}
+
+	warnUnusedSettings()
+
+	// On SIGHUP, read the config file again and apply the settings that
+	// can change without a restart
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if _, err := server.Reload(context.Background()); err != nil {
+				slog.Error("failed to reload settings", "error", err)
+			}
+		}
+	}()
 
-	if err := server.Serve(addr); err != nil {
+	// On SIGINT or SIGTERM, finish the requests in flight, then write what
+	// is still queued and close the database
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = server.Serve(ctx, addr)
+	if cerr := server.Close(); cerr != nil {
+		slog.Error("failed to close the database", "error", cerr)
+	}
+	if err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
+	slog.Info("server stopped")
 }
 
+// settings are the ones read from the -config file
+var (
+	settings    map[string]string
+	settingUsed = map[string]bool{}
+)
+
+// loadConfigFile reads the settings from path, if any. On error the
+// settings read before are kept.
+func loadConfigFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	loaded, err := srv.LoadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	settings = loaded
+	return nil
+}
+
+// reloadableSettings reads the settings the server can change while it
+// runs; see srv.ReloadConfig
+func reloadableSettings() (srv.ReloadConfig, error) {
+	cfg := srv.ReloadConfig{
+		AdminToken:       getEnv("ADMIN_TOKEN", ""),
+		HoneypotAlertURL: getEnv("HONEYPOT_ALERT_URL", ""),
+		DigestURL:        getEnv("DIGEST_URL", ""),
+	}
+	cfg.UnlockLimit.MaxFailures, _ = strconv.Atoi(getEnv("UNLOCK_MAX_FAILURES", "5"))
+	cfg.UnlockLimit.ScriptMaxFailures, _ = strconv.Atoi(getEnv("UNLOCK_SCRIPT_MAX_FAILURES", "50"))
+	var err error
+	cfg.UnlockLimit.Lockout, err = time.ParseDuration(getEnv("UNLOCK_LOCKOUT", "1m"))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid UNLOCK_LOCKOUT: %v", err)
+	}
+	for _, l := range []struct {
+		key, rate, burst string
+		limit            *srv.RateLimit
+	}{
+		{"RATE_LIMIT_SCRIPTS", "20", "100", &cfg.RateLimits.Scripts},
+		{"RATE_LIMIT_UNLOCK", "1", "10", &cfg.RateLimits.Unlock},
+		{"RATE_LIMIT_ADMIN", "50", "200", &cfg.RateLimits.Admin},
+	} {
+		l.limit.Rate, err = strconv.ParseFloat(getEnv(l.key, l.rate), 64)
+		if err != nil || l.limit.Rate < 0 {
+			return cfg, fmt.Errorf("invalid %s: %q", l.key, getEnv(l.key, l.rate))
+		}
+		l.limit.Burst, err = strconv.Atoi(getEnv(l.key+"_BURST", l.burst))
+		if err != nil || l.limit.Burst < 0 {
+			return cfg, fmt.Errorf("invalid %s_BURST: %q", l.key, getEnv(l.key+"_BURST", l.burst))
+		}
+	}
+	cfg.ScriptCacheTTL, err = time.ParseDuration(getEnv("SCRIPT_CACHE_TTL", "1m"))
+	if err != nil || cfg.ScriptCacheTTL <= 0 {
+		return cfg, fmt.Errorf("invalid SCRIPT_CACHE_TTL: %q", getEnv("SCRIPT_CACHE_TTL", "1m"))
+	}
+	cfg.CatalogCacheTTL, err = time.ParseDuration(getEnv("CATALOG_CACHE_TTL", "1m"))
+	if err != nil || cfg.CatalogCacheTTL < 0 {
+		return cfg, fmt.Errorf("invalid CATALOG_CACHE_TTL: %q", getEnv("CATALOG_CACHE_TTL", "1m"))
+	}
+	return cfg, nil
+}
+
+// getEnv returns the setting key from the environment, else from the
+// config file, else fallback
 func getEnv(key, fallback string) string {
+	settingUsed[key] = true
 	if v := os.Getenv(key); v != "" {
 		return v
 	}
+	if v := settings[key]; v != "" {
+		return v
+	}
 	return fallback
 }
+
+// warnUnusedSettings points out settings in the config file that nothing
+// read, most likely misspelled
+func warnUnusedSettings() {
+	for key := range settings {
+		if !settingUsed[key] {
+			slog.Warn("config file setting is not used", "setting", key)
+		}
+	}
+}
+
+// splitList splits a comma-separated value, dropping empty items
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"modernc.org/sqlite"
+)
+
+// ErrBackupUnsupported is returned when backing up or restoring a database
+// that isn't SQLite; PostgreSQL has pg_dump and pg_restore for that
+var ErrBackupUnsupported = errors.New("backup and restore are only supported on SQLite")
+
+// backuper is the driver connection of modernc.org/sqlite, which exposes
+// SQLite's online backup API
+type backuper interface {
+	NewBackup(dstUri string) (*sqlite.Backup, error)
+	NewRestore(srcUri string) (*sqlite.Backup, error)
+}
+
+// Backup writes a consistent snapshot of db to a new SQLite file at path
+// while the database stays in use
+func Backup(ctx context.Context, db *sql.DB, path string) error {
+	return withBackuper(ctx, db, func(b backuper) error {
+		backup, err := b.NewBackup(path)
+		if err != nil {
+			return fmt.Errorf("start backup: %w", err)
+		}
+		return copyPages(backup)
+	})
+}
+
+// Restore replaces the content of db with the SQLite file at path. The
+// file should pass CheckSnapshot first.
+func Restore(ctx context.Context, db *sql.DB, path string) error {
+	return withBackuper(ctx, db, func(b backuper) error {
+		restore, err := b.NewRestore(path)
+		if err != nil {
+			return fmt.Errorf("start restore: %w", err)
+		}
+		return copyPages(restore)
+	})
+}
+
+// CheckSnapshot verifies that the file at path is an intact SQLite
+// database of this server
+func CheckSnapshot(ctx context.Context, path string) error {
+	snap, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer snap.Close()
+	var result string
+	if err := snap.QueryRowContext(ctx, "PRAGMA quick_check").Scan(&result); err != nil {
+		return fmt.Errorf("not an SQLite database: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("database is damaged: %s", result)
+	}
+	var migrations int
+	if err := snap.QueryRowContext(ctx, "SELECT COUNT(*) FROM migrations").Scan(&migrations); err != nil || migrations == 0 {
+		return errors.New("not a database of this server")
+	}
+	return nil
+}
+
+func withBackuper(ctx context.Context, db *sql.DB, f func(backuper) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn any) error {
+		b, ok := driverConn.(backuper)
+		if !ok {
+			return ErrBackupUnsupported
+		}
+		return f(b)
+	})
+}
+
+// copyPages runs a backup to completion in one step, so the copy is of a
+// single moment even if the source is written to meanwhile
+func copyPages(b *sqlite.Backup) error {
+	if _, err := b.Step(-1); err != nil {
+		b.Finish()
+		return fmt.Errorf("copy pages: %w", err)
+	}
+	return b.Finish()
+}
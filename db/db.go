@@ -1,14 +1,18 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -18,24 +22,68 @@ import (
 //go:embed migrations/*.sql
 var migrationFS embed.FS
 
+// Options tunes an SQLite database; zero values use the defaults
+type Options struct {
+	// BusyTimeout is how long a statement waits for another connection's
+	// write lock before failing with SQLITE_BUSY
+	BusyTimeout time.Duration
+	// MaxOpenConns caps the connection pool; idle connections are kept
+	// up to the same number so their caches survive
+	MaxOpenConns int
+}
+
+const (
+	defaultBusyTimeout  = 5 * time.Second
+	defaultMaxOpenConns = 8
+)
+
 // Open opens an sqlite database and prepares pragmas suitable for a small web app.
-func Open(path string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite", path)
-	if err != nil {
-		return nil, err
+// The pragmas are part of the DSN so that every connection of the pool
+// gets them, not just the first.
+func Open(path string, opts Options) (*sql.DB, error) {
+	return open(path, opts, false)
+}
+
+// OpenReader opens an sqlite database again as a pool of read-only
+// connections. Open the database with Open first, which sets up WAL.
+func OpenReader(path string, opts Options) (*sql.DB, error) {
+	return open(path, opts, true)
+}
+
+func open(path string, opts Options, readOnly bool) (*sql.DB, error) {
+	if opts.BusyTimeout <= 0 {
+		opts.BusyTimeout = defaultBusyTimeout
 	}
-	// Light pragmas similar
-	if _, err := db.Exec("PRAGMA foreign_keys=ON;"); err != nil {
-		_ = db.Close()
-		return nil, fmt.Errorf("enable foreign keys: %w", err)
+	if opts.MaxOpenConns <= 0 {
+		opts.MaxOpenConns = defaultMaxOpenConns
 	}
-	if _, err := db.Exec("PRAGMA journal_mode=wal;"); err != nil {
-		_ = db.Close()
-		return nil, fmt.Errorf("set WAL: %w", err)
+	params := url.Values{
+		"_pragma": {
+			fmt.Sprintf("busy_timeout(%d)", opts.BusyTimeout.Milliseconds()),
+			"foreign_keys(1)",
+			"journal_mode(wal)",
+			"synchronous(normal)", // durable enough with WAL, and far fewer fsyncs
+		},
+		// Transactions take the write lock up front, so they wait for it
+		// instead of failing when they start writing
+		"_txlock": {"immediate"},
+	}
+	if readOnly {
+		params["_pragma"] = append(params["_pragma"], "query_only(1)")
 	}
-	if _, err := db.Exec("PRAGMA busy_timeout=1000;"); err != nil {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	db, err := sql.Open("sqlite", path+sep+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxOpenConns)
+	if err := db.Ping(); err != nil {
 		_ = db.Close()
-		return nil, fmt.Errorf("set busy_timeout: %w", err)
+		return nil, fmt.Errorf("open %s: %w", path, err)
 	}
 	return db, nil
 }
@@ -43,7 +91,21 @@ func Open(path string) (*sql.DB, error) {
 // RunMigrations executes database migrations in numeric order (NNN-*.sql),
 // similar in spirit to exed's exedb.RunMigrations.
 func RunMigrations(db *sql.DB) error {
-	entries, err := migrationFS.ReadDir("migrations")
+	return runMigrations(context.Background(), db, migrationFS, "migrations",
+		"SELECT name FROM sqlite_master WHERE type='table' AND name='migrations'")
+}
+
+// migrationConn is an *sql.DB or, to hold a lock while migrating, an *sql.Conn
+type migrationConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// runMigrations executes the migrations in dir that the migrations table
+// doesn't list yet. tableQuery finds the migrations table.
+func runMigrations(ctx context.Context, db migrationConn, fsys embed.FS, dir, tableQuery string) error {
+	entries, err := fsys.ReadDir(dir)
 	if err != nil {
 		return fmt.Errorf("read migrations dir: %w", err)
 	}
@@ -62,10 +124,10 @@ func RunMigrations(db *sql.DB) error {
 
 	executed := make(map[int]bool)
 	var tableName string
-	err = db.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name='migrations'").Scan(&tableName)
+	err = db.QueryRowContext(ctx, tableQuery).Scan(&tableName)
 	switch {
 	case err == nil:
-		rows, err := db.Query("SELECT migration_number FROM migrations")
+		rows, err := db.QueryContext(ctx, "SELECT migration_number FROM migrations")
 		if err != nil {
 			return fmt.Errorf("query executed migrations: %w", err)
 		}
@@ -95,7 +157,11 @@ func RunMigrations(db *sql.DB) error {
 		if executed[n] {
 			continue
 		}
-		if err := executeMigration(db, m); err != nil {
+		content, err := fsys.ReadFile(dir + "/" + m)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", m, err)
+		}
+		if _, err := db.ExecContext(ctx, string(content)); err != nil {
 			return fmt.Errorf("execute %s: %w", m, err)
 		}
 		slog.Info("db: applied migration", "file", m, "number", n)
@@ -103,13 +169,14 @@ func RunMigrations(db *sql.DB) error {
 	return nil
 }
 
-func executeMigration(db *sql.DB, filename string) error {
-	content, err := migrationFS.ReadFile("migrations/" + filename)
-	if err != nil {
-		return fmt.Errorf("read %s: %w", filename, err)
-	}
-	if _, err := db.Exec(string(content)); err != nil {
-		return fmt.Errorf("exec %s: %w", filename, err)
+// Size returns the size of the main database file in bytes (the WAL is not
+// included), or of the database on PostgreSQL.
+func Size(ctx context.Context, db *sql.DB, dialect Dialect) (int64, error) {
+	query := "SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()"
+	if dialect == Postgres {
+		query = "SELECT pg_database_size(current_database())"
 	}
-	return nil
+	var size int64
+	err := db.QueryRowContext(ctx, query).Scan(&size)
+	return size, err
 }
@@ -10,9 +10,51 @@ import (
 	"time"
 )
 
+const countAuditActions = `-- name: CountAuditActions :many
+SELECT action, CAST(COUNT(*) AS INTEGER) AS count FROM audit_log
+WHERE created_at >= ? AND created_at < ?
+GROUP BY action
+`
+
+type CountAuditActionsParams struct {
+	CreatedAt   time.Time `json:"created_at"`
+	CreatedAt_2 time.Time `json:"created_at_2"`
+}
+
+type CountAuditActionsRow struct {
+	Action string `json:"action"`
+	Count  int64  `json:"count"`
+}
+
+func (q *Queries) CountAuditActions(ctx context.Context, arg CountAuditActionsParams) ([]CountAuditActionsRow, error) {
+	rows, err := q.query(ctx, q.countAuditActionsStmt, countAuditActions, arg.CreatedAt, arg.CreatedAt_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountAuditActionsRow{}
+	for rows.Next() {
+		var i CountAuditActionsRow
+		if err := rows.Scan(
+			&i.Action,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createAuditLog = `-- name: CreateAuditLog :exec
-INSERT INTO audit_log (action, entity_type, entity_id, entity_path, details, ip_address, user_agent, created_at)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+INSERT INTO audit_log (action, entity_type, entity_id, entity_path, details, ip_address, user_agent, actor, request_id, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateAuditLogParams struct {
@@ -23,11 +65,13 @@ type CreateAuditLogParams struct {
 	Details    *string   `json:"details"`
 	IpAddress  *string   `json:"ip_address"`
 	UserAgent  *string   `json:"user_agent"`
+	Actor      *string   `json:"actor"`
+	RequestID  *string   `json:"request_id"`
 	CreatedAt  time.Time `json:"created_at"`
 }
 
 func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error {
-	_, err := q.db.ExecContext(ctx, createAuditLog,
+	_, err := q.exec(ctx, q.createAuditLogStmt, createAuditLog,
 		arg.Action,
 		arg.EntityType,
 		arg.EntityID,
@@ -35,17 +79,121 @@ func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams)
 		arg.Details,
 		arg.IpAddress,
 		arg.UserAgent,
+		arg.Actor,
+		arg.RequestID,
 		arg.CreatedAt,
 	)
 	return err
 }
 
+const deleteAuditLogsThrough = `-- name: DeleteAuditLogsThrough :execrows
+DELETE FROM audit_log WHERE id <= ? AND created_at < ?
+`
+
+type DeleteAuditLogsThroughParams struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) DeleteAuditLogsThrough(ctx context.Context, arg DeleteAuditLogsThroughParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteAuditLogsThroughStmt, deleteAuditLogsThrough, arg.ID, arg.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listAuditFailures = `-- name: ListAuditFailures :many
+SELECT id, "action", entity_type, entity_id, entity_path, details, ip_address, user_agent, created_at, actor, request_id FROM audit_log
+WHERE action IN ('UNLOCK_FAILED', 'UNLOCK_LOCKOUT', 'UNLOCK_TOKEN_REJECTED', 'LOGIN_FAILED', 'GEO_BLOCKED', 'HONEYPOT_HIT', 'SECRET_DETECTED')
+ORDER BY id DESC LIMIT ?
+`
+
+func (q *Queries) ListAuditFailures(ctx context.Context, limit int64) ([]AuditLog, error) {
+	rows, err := q.query(ctx, q.listAuditFailuresStmt, listAuditFailures, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.EntityType,
+			&i.EntityID,
+			&i.EntityPath,
+			&i.Details,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.Actor,
+			&i.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAuditLogs = `-- name: ListAuditLogs :many
-SELECT id, "action", entity_type, entity_id, entity_path, details, ip_address, user_agent, created_at FROM audit_log ORDER BY created_at DESC LIMIT ?
+SELECT id, "action", entity_type, entity_id, entity_path, details, ip_address, user_agent, created_at, actor, request_id FROM audit_log ORDER BY created_at DESC LIMIT ?
 `
 
 func (q *Queries) ListAuditLogs(ctx context.Context, limit int64) ([]AuditLog, error) {
-	rows, err := q.db.QueryContext(ctx, listAuditLogs, limit)
+	rows, err := q.query(ctx, q.listAuditLogsStmt, listAuditLogs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.EntityType,
+			&i.EntityID,
+			&i.EntityPath,
+			&i.Details,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.Actor,
+			&i.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditLogsBefore = `-- name: ListAuditLogsBefore :many
+SELECT id, "action", entity_type, entity_id, entity_path, details, ip_address, user_agent, created_at, actor, request_id FROM audit_log WHERE created_at < ? ORDER BY id LIMIT ?
+`
+
+type ListAuditLogsBeforeParams struct {
+	CreatedAt time.Time `json:"created_at"`
+	Limit     int64     `json:"limit"`
+}
+
+func (q *Queries) ListAuditLogsBefore(ctx context.Context, arg ListAuditLogsBeforeParams) ([]AuditLog, error) {
+	rows, err := q.query(ctx, q.listAuditLogsBeforeStmt, listAuditLogsBefore, arg.CreatedAt, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +211,8 @@ func (q *Queries) ListAuditLogs(ctx context.Context, limit int64) ([]AuditLog, e
 			&i.IpAddress,
 			&i.UserAgent,
 			&i.CreatedAt,
+			&i.Actor,
+			&i.RequestID,
 		); err != nil {
 			return nil, err
 		}
@@ -78,11 +228,64 @@ func (q *Queries) ListAuditLogs(ctx context.Context, limit int64) ([]AuditLog, e
 }
 
 const listAuditLogsByEntity = `-- name: ListAuditLogsByEntity :many
-SELECT id, "action", entity_type, entity_id, entity_path, details, ip_address, user_agent, created_at FROM audit_log WHERE entity_id = ? ORDER BY created_at DESC
+SELECT id, "action", entity_type, entity_id, entity_path, details, ip_address, user_agent, created_at, actor, request_id FROM audit_log WHERE entity_id = ? ORDER BY created_at DESC
 `
 
 func (q *Queries) ListAuditLogsByEntity(ctx context.Context, entityID *string) ([]AuditLog, error) {
-	rows, err := q.db.QueryContext(ctx, listAuditLogsByEntity, entityID)
+	rows, err := q.query(ctx, q.listAuditLogsByEntityStmt, listAuditLogsByEntity, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.EntityType,
+			&i.EntityID,
+			&i.EntityPath,
+			&i.Details,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.Actor,
+			&i.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditLogsRange = `-- name: ListAuditLogsRange :many
+SELECT id, "action", entity_type, entity_id, entity_path, details, ip_address, user_agent, created_at, actor, request_id FROM audit_log
+WHERE created_at >= ? AND created_at < ? AND id > ?
+ORDER BY id LIMIT ?
+`
+
+type ListAuditLogsRangeParams struct {
+	CreatedAt   time.Time `json:"created_at"`
+	CreatedAt_2 time.Time `json:"created_at_2"`
+	ID          int64     `json:"id"`
+	Limit       int64     `json:"limit"`
+}
+
+func (q *Queries) ListAuditLogsRange(ctx context.Context, arg ListAuditLogsRangeParams) ([]AuditLog, error) {
+	rows, err := q.query(ctx, q.listAuditLogsRangeStmt, listAuditLogsRange,
+		arg.CreatedAt,
+		arg.CreatedAt_2,
+		arg.ID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -100,6 +303,8 @@ func (q *Queries) ListAuditLogsByEntity(ctx context.Context, entityID *string) (
 			&i.IpAddress,
 			&i.UserAgent,
 			&i.CreatedAt,
+			&i.Actor,
+			&i.RequestID,
 		); err != nil {
 			return nil, err
 		}
@@ -10,24 +10,37 @@ import (
 	"time"
 )
 
+const countActiveAuthTokens = `-- name: CountActiveAuthTokens :one
+SELECT COUNT(*) FROM auth_tokens WHERE expires_at > ?
+`
+
+func (q *Queries) CountActiveAuthTokens(ctx context.Context, expiresAt time.Time) (int64, error) {
+	row := q.queryRow(ctx, q.countActiveAuthTokensStmt, countActiveAuthTokens, expiresAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAuthToken = `-- name: CreateAuthToken :exec
-INSERT INTO auth_tokens (token, script_id, expires_at, created_at, ip_address, user_agent)
-VALUES (?, ?, ?, ?, ?, ?)
+INSERT INTO auth_tokens (token, script_id, folder_path, expires_at, created_at, ip_address, user_agent)
+VALUES (?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateAuthTokenParams struct {
-	Token     string    `json:"token"`
-	ScriptID  string    `json:"script_id"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
-	IpAddress *string   `json:"ip_address"`
-	UserAgent *string   `json:"user_agent"`
+	Token      string    `json:"token"`
+	ScriptID   string    `json:"script_id"`
+	FolderPath *string   `json:"folder_path"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	IpAddress  *string   `json:"ip_address"`
+	UserAgent  *string   `json:"user_agent"`
 }
 
 func (q *Queries) CreateAuthToken(ctx context.Context, arg CreateAuthTokenParams) error {
-	_, err := q.db.ExecContext(ctx, createAuthToken,
+	_, err := q.exec(ctx, q.createAuthTokenStmt, createAuthToken,
 		arg.Token,
 		arg.ScriptID,
+		arg.FolderPath,
 		arg.ExpiresAt,
 		arg.CreatedAt,
 		arg.IpAddress,
@@ -36,30 +49,57 @@ func (q *Queries) CreateAuthToken(ctx context.Context, arg CreateAuthTokenParams
 	return err
 }
 
+const deleteAuthToken = `-- name: DeleteAuthToken :execrows
+DELETE FROM auth_tokens WHERE token = ?
+`
+
+func (q *Queries) DeleteAuthToken(ctx context.Context, token string) (int64, error) {
+	result, err := q.exec(ctx, q.deleteAuthTokenStmt, deleteAuthToken, token)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteExpiredTokens = `-- name: DeleteExpiredTokens :exec
 DELETE FROM auth_tokens WHERE expires_at < ?
 `
 
 func (q *Queries) DeleteExpiredTokens(ctx context.Context, expiresAt time.Time) error {
-	_, err := q.db.ExecContext(ctx, deleteExpiredTokens, expiresAt)
+	_, err := q.exec(ctx, q.deleteExpiredTokensStmt, deleteExpiredTokens, expiresAt)
 	return err
 }
 
-const deleteTokensByScript = `-- name: DeleteTokensByScript :exec
+const deleteTokensByFolder = `-- name: DeleteTokensByFolder :execrows
+DELETE FROM auth_tokens WHERE folder_path = ?
+`
+
+func (q *Queries) DeleteTokensByFolder(ctx context.Context, folderPath *string) (int64, error) {
+	result, err := q.exec(ctx, q.deleteTokensByFolderStmt, deleteTokensByFolder, folderPath)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTokensByScript = `-- name: DeleteTokensByScript :execrows
 DELETE FROM auth_tokens WHERE script_id = ?
 `
 
-func (q *Queries) DeleteTokensByScript(ctx context.Context, scriptID string) error {
-	_, err := q.db.ExecContext(ctx, deleteTokensByScript, scriptID)
-	return err
+func (q *Queries) DeleteTokensByScript(ctx context.Context, scriptID string) (int64, error) {
+	result, err := q.exec(ctx, q.deleteTokensByScriptStmt, deleteTokensByScript, scriptID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getAuthToken = `-- name: GetAuthToken :one
-SELECT token, script_id, expires_at, created_at, ip_address, user_agent FROM auth_tokens WHERE token = ?
+SELECT token, script_id, expires_at, created_at, ip_address, user_agent, folder_path FROM auth_tokens WHERE token = ?
 `
 
 func (q *Queries) GetAuthToken(ctx context.Context, token string) (AuthToken, error) {
-	row := q.db.QueryRowContext(ctx, getAuthToken, token)
+	row := q.queryRow(ctx, q.getAuthTokenStmt, getAuthToken, token)
 	var i AuthToken
 	err := row.Scan(
 		&i.Token,
@@ -68,6 +108,58 @@ func (q *Queries) GetAuthToken(ctx context.Context, token string) (AuthToken, er
 		&i.CreatedAt,
 		&i.IpAddress,
 		&i.UserAgent,
+		&i.FolderPath,
 	)
 	return i, err
 }
+
+const listActiveAuthTokens = `-- name: ListActiveAuthTokens :many
+SELECT auth_tokens.token, auth_tokens.script_id, auth_tokens.expires_at, auth_tokens.created_at, auth_tokens.ip_address, auth_tokens.user_agent, auth_tokens.folder_path, scripts.path AS script_path
+FROM auth_tokens
+JOIN scripts ON scripts.id = auth_tokens.script_id
+WHERE auth_tokens.expires_at > ?
+ORDER BY auth_tokens.created_at DESC
+`
+
+type ListActiveAuthTokensRow struct {
+	Token      string    `json:"token"`
+	ScriptID   string    `json:"script_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	IpAddress  *string   `json:"ip_address"`
+	UserAgent  *string   `json:"user_agent"`
+	FolderPath *string   `json:"folder_path"`
+	ScriptPath string    `json:"script_path"`
+}
+
+func (q *Queries) ListActiveAuthTokens(ctx context.Context, expiresAt time.Time) ([]ListActiveAuthTokensRow, error) {
+	rows, err := q.query(ctx, q.listActiveAuthTokensStmt, listActiveAuthTokens, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListActiveAuthTokensRow{}
+	for rows.Next() {
+		var i ListActiveAuthTokensRow
+		if err := rows.Scan(
+			&i.Token,
+			&i.ScriptID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.FolderPath,
+			&i.ScriptPath,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,6 +7,7 @@ package dbgen
 import (
 	"context"
 	"database/sql"
+	"fmt"
 )
 
 type DBTX interface {
@@ -20,12 +21,1818 @@ func New(db DBTX) *Queries {
 	return &Queries{db: db}
 }
 
+func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
+	q := Queries{db: db}
+	var err error
+	if q.claimWebhookRetryStmt, err = db.PrepareContext(ctx, claimWebhookRetry); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimWebhookRetry: %w", err)
+	}
+	if q.consumeShareLinkStmt, err = db.PrepareContext(ctx, consumeShareLink); err != nil {
+		return nil, fmt.Errorf("error preparing query ConsumeShareLink: %w", err)
+	}
+	if q.countActiveAPITokensStmt, err = db.PrepareContext(ctx, countActiveAPITokens); err != nil {
+		return nil, fmt.Errorf("error preparing query CountActiveAPITokens: %w", err)
+	}
+	if q.countActiveAuthTokensStmt, err = db.PrepareContext(ctx, countActiveAuthTokens); err != nil {
+		return nil, fmt.Errorf("error preparing query CountActiveAuthTokens: %w", err)
+	}
+	if q.countActiveSessionsStmt, err = db.PrepareContext(ctx, countActiveSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query CountActiveSessions: %w", err)
+	}
+	if q.countActiveShareLinksStmt, err = db.PrepareContext(ctx, countActiveShareLinks); err != nil {
+		return nil, fmt.Errorf("error preparing query CountActiveShareLinks: %w", err)
+	}
+	if q.countAdminTokensStmt, err = db.PrepareContext(ctx, countAdminTokens); err != nil {
+		return nil, fmt.Errorf("error preparing query CountAdminTokens: %w", err)
+	}
+	if q.countAuditActionsStmt, err = db.PrepareContext(ctx, countAuditActions); err != nil {
+		return nil, fmt.Errorf("error preparing query CountAuditActions: %w", err)
+	}
+	if q.countFoldersStmt, err = db.PrepareContext(ctx, countFolders); err != nil {
+		return nil, fmt.Errorf("error preparing query CountFolders: %w", err)
+	}
+	if q.countScriptContentRefsStmt, err = db.PrepareContext(ctx, countScriptContentRefs); err != nil {
+		return nil, fmt.Errorf("error preparing query CountScriptContentRefs: %w", err)
+	}
+	if q.countScriptRunsSinceStmt, err = db.PrepareContext(ctx, countScriptRunsSince); err != nil {
+		return nil, fmt.Errorf("error preparing query CountScriptRunsSince: %w", err)
+	}
+	if q.countScriptsStmt, err = db.PrepareContext(ctx, countScripts); err != nil {
+		return nil, fmt.Errorf("error preparing query CountScripts: %w", err)
+	}
+	if q.countStoredContentStmt, err = db.PrepareContext(ctx, countStoredContent); err != nil {
+		return nil, fmt.Errorf("error preparing query CountStoredContent: %w", err)
+	}
+	if q.countVersionContentRefsStmt, err = db.PrepareContext(ctx, countVersionContentRefs); err != nil {
+		return nil, fmt.Errorf("error preparing query CountVersionContentRefs: %w", err)
+	}
+	if q.createAPITokenStmt, err = db.PrepareContext(ctx, createAPIToken); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAPIToken: %w", err)
+	}
+	if q.createAccessLogStmt, err = db.PrepareContext(ctx, createAccessLog); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAccessLog: %w", err)
+	}
+	if q.createAdminTokenStmt, err = db.PrepareContext(ctx, createAdminToken); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAdminToken: %w", err)
+	}
+	if q.createAuditLogStmt, err = db.PrepareContext(ctx, createAuditLog); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAuditLog: %w", err)
+	}
+	if q.createAuthTokenStmt, err = db.PrepareContext(ctx, createAuthToken); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAuthToken: %w", err)
+	}
+	if q.createCanaryStmt, err = db.PrepareContext(ctx, createCanary); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateCanary: %w", err)
+	}
+	if q.createFolderStmt, err = db.PrepareContext(ctx, createFolder); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateFolder: %w", err)
+	}
+	if q.createHoneypotStmt, err = db.PrepareContext(ctx, createHoneypot); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateHoneypot: %w", err)
+	}
+	if q.createNoticeStmt, err = db.PrepareContext(ctx, createNotice); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateNotice: %w", err)
+	}
+	if q.createScanRuleStmt, err = db.PrepareContext(ctx, createScanRule); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateScanRule: %w", err)
+	}
+	if q.createScriptStmt, err = db.PrepareContext(ctx, createScript); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateScript: %w", err)
+	}
+	if q.createScriptRunStmt, err = db.PrepareContext(ctx, createScriptRun); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateScriptRun: %w", err)
+	}
+	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
+	}
+	if q.createShareLinkStmt, err = db.PrepareContext(ctx, createShareLink); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateShareLink: %w", err)
+	}
+	if q.createStoredVersionStmt, err = db.PrepareContext(ctx, createStoredVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateStoredVersion: %w", err)
+	}
+	if q.createTemplateStmt, err = db.PrepareContext(ctx, createTemplate); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTemplate: %w", err)
+	}
+	if q.createVariantStmt, err = db.PrepareContext(ctx, createVariant); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateVariant: %w", err)
+	}
+	if q.createVersionStmt, err = db.PrepareContext(ctx, createVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateVersion: %w", err)
+	}
+	if q.createWebhookStmt, err = db.PrepareContext(ctx, createWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhook: %w", err)
+	}
+	if q.createWebhookDeliveryStmt, err = db.PrepareContext(ctx, createWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookDelivery: %w", err)
+	}
+	if q.deleteAdminTokenStmt, err = db.PrepareContext(ctx, deleteAdminToken); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAdminToken: %w", err)
+	}
+	if q.deleteAuditLogsThroughStmt, err = db.PrepareContext(ctx, deleteAuditLogsThrough); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAuditLogsThrough: %w", err)
+	}
+	if q.deleteAuthTokenStmt, err = db.PrepareContext(ctx, deleteAuthToken); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAuthToken: %w", err)
+	}
+	if q.deleteBlobStmt, err = db.PrepareContext(ctx, deleteBlob); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteBlob: %w", err)
+	}
+	if q.deleteCanaryStmt, err = db.PrepareContext(ctx, deleteCanary); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteCanary: %w", err)
+	}
+	if q.deleteExpiredNoticesStmt, err = db.PrepareContext(ctx, deleteExpiredNotices); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredNotices: %w", err)
+	}
+	if q.deleteExpiredSessionsStmt, err = db.PrepareContext(ctx, deleteExpiredSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredSessions: %w", err)
+	}
+	if q.deleteExpiredTokensStmt, err = db.PrepareContext(ctx, deleteExpiredTokens); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredTokens: %w", err)
+	}
+	if q.deleteFetchConsumersBeforeStmt, err = db.PrepareContext(ctx, deleteFetchConsumersBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteFetchConsumersBefore: %w", err)
+	}
+	if q.deleteFolderStmt, err = db.PrepareContext(ctx, deleteFolder); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteFolder: %w", err)
+	}
+	if q.deleteFolderByPathStmt, err = db.PrepareContext(ctx, deleteFolderByPath); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteFolderByPath: %w", err)
+	}
+	if q.deleteGitHubSyncFileStmt, err = db.PrepareContext(ctx, deleteGitHubSyncFile); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGitHubSyncFile: %w", err)
+	}
+	if q.deleteHoneypotStmt, err = db.PrepareContext(ctx, deleteHoneypot); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteHoneypot: %w", err)
+	}
+	if q.deleteNoticeStmt, err = db.PrepareContext(ctx, deleteNotice); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteNotice: %w", err)
+	}
+	if q.deleteScanRuleStmt, err = db.PrepareContext(ctx, deleteScanRule); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteScanRule: %w", err)
+	}
+	if q.deleteScriptStmt, err = db.PrepareContext(ctx, deleteScript); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteScript: %w", err)
+	}
+	if q.deleteSessionStmt, err = db.PrepareContext(ctx, deleteSession); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSession: %w", err)
+	}
+	if q.deleteTemplateStmt, err = db.PrepareContext(ctx, deleteTemplate); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTemplate: %w", err)
+	}
+	if q.deleteTokensByFolderStmt, err = db.PrepareContext(ctx, deleteTokensByFolder); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTokensByFolder: %w", err)
+	}
+	if q.deleteTokensByScriptStmt, err = db.PrepareContext(ctx, deleteTokensByScript); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTokensByScript: %w", err)
+	}
+	if q.deleteVariantStmt, err = db.PrepareContext(ctx, deleteVariant); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteVariant: %w", err)
+	}
+	if q.deleteWebhookStmt, err = db.PrepareContext(ctx, deleteWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhook: %w", err)
+	}
+	if q.getAPITokenStmt, err = db.PrepareContext(ctx, getAPIToken); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAPIToken: %w", err)
+	}
+	if q.getAPITokenByHashStmt, err = db.PrepareContext(ctx, getAPITokenByHash); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAPITokenByHash: %w", err)
+	}
+	if q.getAdminTokenStmt, err = db.PrepareContext(ctx, getAdminToken); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAdminToken: %w", err)
+	}
+	if q.getAdminTokenByHashStmt, err = db.PrepareContext(ctx, getAdminTokenByHash); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAdminTokenByHash: %w", err)
+	}
+	if q.getAuthTokenStmt, err = db.PrepareContext(ctx, getAuthToken); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAuthToken: %w", err)
+	}
+	if q.getBlobStmt, err = db.PrepareContext(ctx, getBlob); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBlob: %w", err)
+	}
+	if q.getCanaryStmt, err = db.PrepareContext(ctx, getCanary); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCanary: %w", err)
+	}
+	if q.getContentUsageStmt, err = db.PrepareContext(ctx, getContentUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query GetContentUsage: %w", err)
+	}
+	if q.getCurrentVersionStmt, err = db.PrepareContext(ctx, getCurrentVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCurrentVersion: %w", err)
+	}
+	if q.getFolderStmt, err = db.PrepareContext(ctx, getFolder); err != nil {
+		return nil, fmt.Errorf("error preparing query GetFolder: %w", err)
+	}
+	if q.getFolderByPathStmt, err = db.PrepareContext(ctx, getFolderByPath); err != nil {
+		return nil, fmt.Errorf("error preparing query GetFolderByPath: %w", err)
+	}
+	if q.getHoneypotStmt, err = db.PrepareContext(ctx, getHoneypot); err != nil {
+		return nil, fmt.Errorf("error preparing query GetHoneypot: %w", err)
+	}
+	if q.getHoneypotByPathStmt, err = db.PrepareContext(ctx, getHoneypotByPath); err != nil {
+		return nil, fmt.Errorf("error preparing query GetHoneypotByPath: %w", err)
+	}
+	if q.getLastWebhookAttemptStmt, err = db.PrepareContext(ctx, getLastWebhookAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLastWebhookAttempt: %w", err)
+	}
+	if q.getLatestVersionStmt, err = db.PrepareContext(ctx, getLatestVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestVersion: %w", err)
+	}
+	if q.getMostVersionedScriptStmt, err = db.PrepareContext(ctx, getMostVersionedScript); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMostVersionedScript: %w", err)
+	}
+	if q.getNoticeStmt, err = db.PrepareContext(ctx, getNotice); err != nil {
+		return nil, fmt.Errorf("error preparing query GetNotice: %w", err)
+	}
+	if q.getScanRuleStmt, err = db.PrepareContext(ctx, getScanRule); err != nil {
+		return nil, fmt.Errorf("error preparing query GetScanRule: %w", err)
+	}
+	if q.getScriptStmt, err = db.PrepareContext(ctx, getScript); err != nil {
+		return nil, fmt.Errorf("error preparing query GetScript: %w", err)
+	}
+	if q.getScriptByPathStmt, err = db.PrepareContext(ctx, getScriptByPath); err != nil {
+		return nil, fmt.Errorf("error preparing query GetScriptByPath: %w", err)
+	}
+	if q.getScriptStatsStmt, err = db.PrepareContext(ctx, getScriptStats); err != nil {
+		return nil, fmt.Errorf("error preparing query GetScriptStats: %w", err)
+	}
+	if q.getSessionStmt, err = db.PrepareContext(ctx, getSession); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSession: %w", err)
+	}
+	if q.getShareLinkStmt, err = db.PrepareContext(ctx, getShareLink); err != nil {
+		return nil, fmt.Errorf("error preparing query GetShareLink: %w", err)
+	}
+	if q.getTemplateStmt, err = db.PrepareContext(ctx, getTemplate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTemplate: %w", err)
+	}
+	if q.getTemplateByNameStmt, err = db.PrepareContext(ctx, getTemplateByName); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTemplateByName: %w", err)
+	}
+	if q.getVariantStmt, err = db.PrepareContext(ctx, getVariant); err != nil {
+		return nil, fmt.Errorf("error preparing query GetVariant: %w", err)
+	}
+	if q.getVersionStmt, err = db.PrepareContext(ctx, getVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query GetVersion: %w", err)
+	}
+	if q.getWebhookStmt, err = db.PrepareContext(ctx, getWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhook: %w", err)
+	}
+	if q.getWebhookDeliveryStmt, err = db.PrepareContext(ctx, getWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookDelivery: %w", err)
+	}
+	if q.incrementVersionServesStmt, err = db.PrepareContext(ctx, incrementVersionServes); err != nil {
+		return nil, fmt.Errorf("error preparing query IncrementVersionServes: %w", err)
+	}
+	if q.listAPITokensStmt, err = db.PrepareContext(ctx, listAPITokens); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAPITokens: %w", err)
+	}
+	if q.listAccessClientsByPathStmt, err = db.PrepareContext(ctx, listAccessClientsByPath); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccessClientsByPath: %w", err)
+	}
+	if q.listAccessLogStmt, err = db.PrepareContext(ctx, listAccessLog); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccessLog: %w", err)
+	}
+	if q.listAccessLogByIPStmt, err = db.PrepareContext(ctx, listAccessLogByIP); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccessLogByIP: %w", err)
+	}
+	if q.listAccessLogByPathStmt, err = db.PrepareContext(ctx, listAccessLogByPath); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccessLogByPath: %w", err)
+	}
+	if q.listActiveAuthTokensStmt, err = db.PrepareContext(ctx, listActiveAuthTokens); err != nil {
+		return nil, fmt.Errorf("error preparing query ListActiveAuthTokens: %w", err)
+	}
+	if q.listAdminTokensStmt, err = db.PrepareContext(ctx, listAdminTokens); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAdminTokens: %w", err)
+	}
+	if q.listAllFetchBreakdownStmt, err = db.PrepareContext(ctx, listAllFetchBreakdown); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAllFetchBreakdown: %w", err)
+	}
+	if q.listAllVersionsStmt, err = db.PrepareContext(ctx, listAllVersions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAllVersions: %w", err)
+	}
+	if q.listAuditFailuresStmt, err = db.PrepareContext(ctx, listAuditFailures); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAuditFailures: %w", err)
+	}
+	if q.listAuditLogsStmt, err = db.PrepareContext(ctx, listAuditLogs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAuditLogs: %w", err)
+	}
+	if q.listAuditLogsBeforeStmt, err = db.PrepareContext(ctx, listAuditLogsBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAuditLogsBefore: %w", err)
+	}
+	if q.listAuditLogsByEntityStmt, err = db.PrepareContext(ctx, listAuditLogsByEntity); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAuditLogsByEntity: %w", err)
+	}
+	if q.listAuditLogsRangeStmt, err = db.PrepareContext(ctx, listAuditLogsRange); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAuditLogsRange: %w", err)
+	}
+	if q.listDueWebhookRetriesStmt, err = db.PrepareContext(ctx, listDueWebhookRetries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDueWebhookRetries: %w", err)
+	}
+	if q.listFavoritesStmt, err = db.PrepareContext(ctx, listFavorites); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFavorites: %w", err)
+	}
+	if q.listFetchBreakdownStmt, err = db.PrepareContext(ctx, listFetchBreakdown); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFetchBreakdown: %w", err)
+	}
+	if q.listFoldersStmt, err = db.PrepareContext(ctx, listFolders); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFolders: %w", err)
+	}
+	if q.listGitHubSyncFilesStmt, err = db.PrepareContext(ctx, listGitHubSyncFiles); err != nil {
+		return nil, fmt.Errorf("error preparing query ListGitHubSyncFiles: %w", err)
+	}
+	if q.listHoneypotsStmt, err = db.PrepareContext(ctx, listHoneypots); err != nil {
+		return nil, fmt.Errorf("error preparing query ListHoneypots: %w", err)
+	}
+	if q.listInlineVersionsStmt, err = db.PrepareContext(ctx, listInlineVersions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListInlineVersions: %w", err)
+	}
+	if q.listLockedFoldersStmt, err = db.PrepareContext(ctx, listLockedFolders); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLockedFolders: %w", err)
+	}
+	if q.listNoticesStmt, err = db.PrepareContext(ctx, listNotices); err != nil {
+		return nil, fmt.Errorf("error preparing query ListNotices: %w", err)
+	}
+	if q.listPlainScriptContentRefsStmt, err = db.PrepareContext(ctx, listPlainScriptContentRefs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPlainScriptContentRefs: %w", err)
+	}
+	if q.listPlainVersionContentRefsStmt, err = db.PrepareContext(ctx, listPlainVersionContentRefs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPlainVersionContentRefs: %w", err)
+	}
+	if q.listRecentlyUpdatedStmt, err = db.PrepareContext(ctx, listRecentlyUpdated); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRecentlyUpdated: %w", err)
+	}
+	if q.listScanRulesStmt, err = db.PrepareContext(ctx, listScanRules); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScanRules: %w", err)
+	}
+	if q.listScriptDailyConsumersStmt, err = db.PrepareContext(ctx, listScriptDailyConsumers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScriptDailyConsumers: %w", err)
+	}
+	if q.listScriptDailyRunsStmt, err = db.PrepareContext(ctx, listScriptDailyRuns); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScriptDailyRuns: %w", err)
+	}
+	if q.listScriptDailyStatsStmt, err = db.PrepareContext(ctx, listScriptDailyStats); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScriptDailyStats: %w", err)
+	}
+	if q.listScriptMetadataStmt, err = db.PrepareContext(ctx, listScriptMetadata); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScriptMetadata: %w", err)
+	}
+	if q.listScriptRunFailuresStmt, err = db.PrepareContext(ctx, listScriptRunFailures); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScriptRunFailures: %w", err)
+	}
+	if q.listScriptStatsStmt, err = db.PrepareContext(ctx, listScriptStats); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScriptStats: %w", err)
+	}
+	if q.listScriptsStmt, err = db.PrepareContext(ctx, listScripts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScripts: %w", err)
+	}
+	if q.listScriptsByFolderStmt, err = db.PrepareContext(ctx, listScriptsByFolder); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScriptsByFolder: %w", err)
+	}
+	if q.listScriptsReferencingStmt, err = db.PrepareContext(ctx, listScriptsReferencing); err != nil {
+		return nil, fmt.Errorf("error preparing query ListScriptsReferencing: %w", err)
+	}
+	if q.listSessionsStmt, err = db.PrepareContext(ctx, listSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSessions: %w", err)
+	}
+	if q.listShareLinksStmt, err = db.PrepareContext(ctx, listShareLinks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListShareLinks: %w", err)
+	}
+	if q.listSubfoldersStmt, err = db.PrepareContext(ctx, listSubfolders); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSubfolders: %w", err)
+	}
+	if q.listTemplatesStmt, err = db.PrepareContext(ctx, listTemplates); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTemplates: %w", err)
+	}
+	if q.listUnsizedScriptIDsStmt, err = db.PrepareContext(ctx, listUnsizedScriptIDs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUnsizedScriptIDs: %w", err)
+	}
+	if q.listVariantStatsStmt, err = db.PrepareContext(ctx, listVariantStats); err != nil {
+		return nil, fmt.Errorf("error preparing query ListVariantStats: %w", err)
+	}
+	if q.listVariantsStmt, err = db.PrepareContext(ctx, listVariants); err != nil {
+		return nil, fmt.Errorf("error preparing query ListVariants: %w", err)
+	}
+	if q.listVersionContentRefsStmt, err = db.PrepareContext(ctx, listVersionContentRefs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListVersionContentRefs: %w", err)
+	}
+	if q.listVersionHistoryStmt, err = db.PrepareContext(ctx, listVersionHistory); err != nil {
+		return nil, fmt.Errorf("error preparing query ListVersionHistory: %w", err)
+	}
+	if q.listVersionsStmt, err = db.PrepareContext(ctx, listVersions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListVersions: %w", err)
+	}
+	if q.listWebhookDeliveriesStmt, err = db.PrepareContext(ctx, listWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookDeliveries: %w", err)
+	}
+	if q.listWebhooksStmt, err = db.PrepareContext(ctx, listWebhooks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhooks: %w", err)
+	}
+	if q.putBlobStmt, err = db.PrepareContext(ctx, putBlob); err != nil {
+		return nil, fmt.Errorf("error preparing query PutBlob: %w", err)
+	}
+	if q.recordFetchBreakdownStmt, err = db.PrepareContext(ctx, recordFetchBreakdown); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordFetchBreakdown: %w", err)
+	}
+	if q.recordFetchConsumerStmt, err = db.PrepareContext(ctx, recordFetchConsumer); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordFetchConsumer: %w", err)
+	}
+	if q.recordScriptDailyFetchStmt, err = db.PrepareContext(ctx, recordScriptDailyFetch); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordScriptDailyFetch: %w", err)
+	}
+	if q.recordScriptFetchStmt, err = db.PrepareContext(ctx, recordScriptFetch); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordScriptFetch: %w", err)
+	}
+	if q.recordVariantServeStmt, err = db.PrepareContext(ctx, recordVariantServe); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordVariantServe: %w", err)
+	}
+	if q.revokeAPITokenStmt, err = db.PrepareContext(ctx, revokeAPIToken); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeAPIToken: %w", err)
+	}
+	if q.revokeShareLinkStmt, err = db.PrepareContext(ctx, revokeShareLink); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeShareLink: %w", err)
+	}
+	if q.searchScriptMetadataStmt, err = db.PrepareContext(ctx, searchScriptMetadata); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchScriptMetadata: %w", err)
+	}
+	if q.setFavoriteStmt, err = db.PrepareContext(ctx, setFavorite); err != nil {
+		return nil, fmt.Errorf("error preparing query SetFavorite: %w", err)
+	}
+	if q.setScriptArchivedStmt, err = db.PrepareContext(ctx, setScriptArchived); err != nil {
+		return nil, fmt.Errorf("error preparing query SetScriptArchived: %w", err)
+	}
+	if q.setScriptContentRefStmt, err = db.PrepareContext(ctx, setScriptContentRef); err != nil {
+		return nil, fmt.Errorf("error preparing query SetScriptContentRef: %w", err)
+	}
+	if q.setScriptContentSizeStmt, err = db.PrepareContext(ctx, setScriptContentSize); err != nil {
+		return nil, fmt.Errorf("error preparing query SetScriptContentSize: %w", err)
+	}
+	if q.setScriptDisabledStmt, err = db.PrepareContext(ctx, setScriptDisabled); err != nil {
+		return nil, fmt.Errorf("error preparing query SetScriptDisabled: %w", err)
+	}
+	if q.setScriptSourceFetchedStmt, err = db.PrepareContext(ctx, setScriptSourceFetched); err != nil {
+		return nil, fmt.Errorf("error preparing query SetScriptSourceFetched: %w", err)
+	}
+	if q.setVersionContentRefStmt, err = db.PrepareContext(ctx, setVersionContentRef); err != nil {
+		return nil, fmt.Errorf("error preparing query SetVersionContentRef: %w", err)
+	}
+	if q.sumDailyConsumersByScriptStmt, err = db.PrepareContext(ctx, sumDailyConsumersByScript); err != nil {
+		return nil, fmt.Errorf("error preparing query SumDailyConsumersByScript: %w", err)
+	}
+	if q.sumDailyFetchesByScriptStmt, err = db.PrepareContext(ctx, sumDailyFetchesByScript); err != nil {
+		return nil, fmt.Errorf("error preparing query SumDailyFetchesByScript: %w", err)
+	}
+	if q.sumFetchesSinceStmt, err = db.PrepareContext(ctx, sumFetchesSince); err != nil {
+		return nil, fmt.Errorf("error preparing query SumFetchesSince: %w", err)
+	}
+	if q.sumRunsByScriptStmt, err = db.PrepareContext(ctx, sumRunsByScript); err != nil {
+		return nil, fmt.Errorf("error preparing query SumRunsByScript: %w", err)
+	}
+	if q.touchAPITokenStmt, err = db.PrepareContext(ctx, touchAPIToken); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchAPIToken: %w", err)
+	}
+	if q.touchAdminTokenStmt, err = db.PrepareContext(ctx, touchAdminToken); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchAdminToken: %w", err)
+	}
+	if q.touchSessionStmt, err = db.PrepareContext(ctx, touchSession); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchSession: %w", err)
+	}
+	if q.updateCanaryPercentStmt, err = db.PrepareContext(ctx, updateCanaryPercent); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateCanaryPercent: %w", err)
+	}
+	if q.updateCanaryStableStmt, err = db.PrepareContext(ctx, updateCanaryStable); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateCanaryStable: %w", err)
+	}
+	if q.updateFolderLockStmt, err = db.PrepareContext(ctx, updateFolderLock); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateFolderLock: %w", err)
+	}
+	if q.updateFolderPasswordHashStmt, err = db.PrepareContext(ctx, updateFolderPasswordHash); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateFolderPasswordHash: %w", err)
+	}
+	if q.updateScriptStmt, err = db.PrepareContext(ctx, updateScript); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScript: %w", err)
+	}
+	if q.updateScriptAvailabilityStmt, err = db.PrepareContext(ctx, updateScriptAvailability); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScriptAvailability: %w", err)
+	}
+	if q.updateScriptContentStmt, err = db.PrepareContext(ctx, updateScriptContent); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScriptContent: %w", err)
+	}
+	if q.updateScriptCountriesStmt, err = db.PrepareContext(ctx, updateScriptCountries); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScriptCountries: %w", err)
+	}
+	if q.updateScriptDeprecationStmt, err = db.PrepareContext(ctx, updateScriptDeprecation); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScriptDeprecation: %w", err)
+	}
+	if q.updateScriptExpirationStmt, err = db.PrepareContext(ctx, updateScriptExpiration); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScriptExpiration: %w", err)
+	}
+	if q.updateScriptLockStmt, err = db.PrepareContext(ctx, updateScriptLock); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScriptLock: %w", err)
+	}
+	if q.updateScriptPasswordHashStmt, err = db.PrepareContext(ctx, updateScriptPasswordHash); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScriptPasswordHash: %w", err)
+	}
+	if q.updateScriptQuotasStmt, err = db.PrepareContext(ctx, updateScriptQuotas); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScriptQuotas: %w", err)
+	}
+	if q.updateScriptSourceStmt, err = db.PrepareContext(ctx, updateScriptSource); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScriptSource: %w", err)
+	}
+	if q.updateScriptUnlockTTLStmt, err = db.PrepareContext(ctx, updateScriptUnlockTTL); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScriptUnlockTTL: %w", err)
+	}
+	if q.updateScriptVisibilityStmt, err = db.PrepareContext(ctx, updateScriptVisibility); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateScriptVisibility: %w", err)
+	}
+	if q.updateTemplateStmt, err = db.PrepareContext(ctx, updateTemplate); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateTemplate: %w", err)
+	}
+	if q.updateVariantStmt, err = db.PrepareContext(ctx, updateVariant); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateVariant: %w", err)
+	}
+	if q.upsertGitHubSyncFileStmt, err = db.PrepareContext(ctx, upsertGitHubSyncFile); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertGitHubSyncFile: %w", err)
+	}
+	return &q, nil
+}
+
+func (q *Queries) Close() error {
+	var err error
+	if q.claimWebhookRetryStmt != nil {
+		if cerr := q.claimWebhookRetryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimWebhookRetryStmt: %w", cerr)
+		}
+	}
+	if q.consumeShareLinkStmt != nil {
+		if cerr := q.consumeShareLinkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing consumeShareLinkStmt: %w", cerr)
+		}
+	}
+	if q.countActiveAPITokensStmt != nil {
+		if cerr := q.countActiveAPITokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countActiveAPITokensStmt: %w", cerr)
+		}
+	}
+	if q.countActiveAuthTokensStmt != nil {
+		if cerr := q.countActiveAuthTokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countActiveAuthTokensStmt: %w", cerr)
+		}
+	}
+	if q.countActiveSessionsStmt != nil {
+		if cerr := q.countActiveSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countActiveSessionsStmt: %w", cerr)
+		}
+	}
+	if q.countActiveShareLinksStmt != nil {
+		if cerr := q.countActiveShareLinksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countActiveShareLinksStmt: %w", cerr)
+		}
+	}
+	if q.countAdminTokensStmt != nil {
+		if cerr := q.countAdminTokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countAdminTokensStmt: %w", cerr)
+		}
+	}
+	if q.countAuditActionsStmt != nil {
+		if cerr := q.countAuditActionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countAuditActionsStmt: %w", cerr)
+		}
+	}
+	if q.countFoldersStmt != nil {
+		if cerr := q.countFoldersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countFoldersStmt: %w", cerr)
+		}
+	}
+	if q.countScriptContentRefsStmt != nil {
+		if cerr := q.countScriptContentRefsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countScriptContentRefsStmt: %w", cerr)
+		}
+	}
+	if q.countScriptRunsSinceStmt != nil {
+		if cerr := q.countScriptRunsSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countScriptRunsSinceStmt: %w", cerr)
+		}
+	}
+	if q.countScriptsStmt != nil {
+		if cerr := q.countScriptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countScriptsStmt: %w", cerr)
+		}
+	}
+	if q.countStoredContentStmt != nil {
+		if cerr := q.countStoredContentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countStoredContentStmt: %w", cerr)
+		}
+	}
+	if q.countVersionContentRefsStmt != nil {
+		if cerr := q.countVersionContentRefsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countVersionContentRefsStmt: %w", cerr)
+		}
+	}
+	if q.createAPITokenStmt != nil {
+		if cerr := q.createAPITokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAPITokenStmt: %w", cerr)
+		}
+	}
+	if q.createAccessLogStmt != nil {
+		if cerr := q.createAccessLogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAccessLogStmt: %w", cerr)
+		}
+	}
+	if q.createAdminTokenStmt != nil {
+		if cerr := q.createAdminTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAdminTokenStmt: %w", cerr)
+		}
+	}
+	if q.createAuditLogStmt != nil {
+		if cerr := q.createAuditLogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAuditLogStmt: %w", cerr)
+		}
+	}
+	if q.createAuthTokenStmt != nil {
+		if cerr := q.createAuthTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAuthTokenStmt: %w", cerr)
+		}
+	}
+	if q.createCanaryStmt != nil {
+		if cerr := q.createCanaryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createCanaryStmt: %w", cerr)
+		}
+	}
+	if q.createFolderStmt != nil {
+		if cerr := q.createFolderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createFolderStmt: %w", cerr)
+		}
+	}
+	if q.createHoneypotStmt != nil {
+		if cerr := q.createHoneypotStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createHoneypotStmt: %w", cerr)
+		}
+	}
+	if q.createNoticeStmt != nil {
+		if cerr := q.createNoticeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createNoticeStmt: %w", cerr)
+		}
+	}
+	if q.createScanRuleStmt != nil {
+		if cerr := q.createScanRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createScanRuleStmt: %w", cerr)
+		}
+	}
+	if q.createScriptStmt != nil {
+		if cerr := q.createScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createScriptStmt: %w", cerr)
+		}
+	}
+	if q.createScriptRunStmt != nil {
+		if cerr := q.createScriptRunStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createScriptRunStmt: %w", cerr)
+		}
+	}
+	if q.createSessionStmt != nil {
+		if cerr := q.createSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
+		}
+	}
+	if q.createShareLinkStmt != nil {
+		if cerr := q.createShareLinkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createShareLinkStmt: %w", cerr)
+		}
+	}
+	if q.createStoredVersionStmt != nil {
+		if cerr := q.createStoredVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createStoredVersionStmt: %w", cerr)
+		}
+	}
+	if q.createTemplateStmt != nil {
+		if cerr := q.createTemplateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTemplateStmt: %w", cerr)
+		}
+	}
+	if q.createVariantStmt != nil {
+		if cerr := q.createVariantStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createVariantStmt: %w", cerr)
+		}
+	}
+	if q.createVersionStmt != nil {
+		if cerr := q.createVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createVersionStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookStmt != nil {
+		if cerr := q.createWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookDeliveryStmt != nil {
+		if cerr := q.createWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.deleteAdminTokenStmt != nil {
+		if cerr := q.deleteAdminTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAdminTokenStmt: %w", cerr)
+		}
+	}
+	if q.deleteAuditLogsThroughStmt != nil {
+		if cerr := q.deleteAuditLogsThroughStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAuditLogsThroughStmt: %w", cerr)
+		}
+	}
+	if q.deleteAuthTokenStmt != nil {
+		if cerr := q.deleteAuthTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAuthTokenStmt: %w", cerr)
+		}
+	}
+	if q.deleteBlobStmt != nil {
+		if cerr := q.deleteBlobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteBlobStmt: %w", cerr)
+		}
+	}
+	if q.deleteCanaryStmt != nil {
+		if cerr := q.deleteCanaryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteCanaryStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredNoticesStmt != nil {
+		if cerr := q.deleteExpiredNoticesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredNoticesStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredSessionsStmt != nil {
+		if cerr := q.deleteExpiredSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredSessionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredTokensStmt != nil {
+		if cerr := q.deleteExpiredTokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredTokensStmt: %w", cerr)
+		}
+	}
+	if q.deleteFetchConsumersBeforeStmt != nil {
+		if cerr := q.deleteFetchConsumersBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteFetchConsumersBeforeStmt: %w", cerr)
+		}
+	}
+	if q.deleteFolderStmt != nil {
+		if cerr := q.deleteFolderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteFolderStmt: %w", cerr)
+		}
+	}
+	if q.deleteFolderByPathStmt != nil {
+		if cerr := q.deleteFolderByPathStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteFolderByPathStmt: %w", cerr)
+		}
+	}
+	if q.deleteGitHubSyncFileStmt != nil {
+		if cerr := q.deleteGitHubSyncFileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGitHubSyncFileStmt: %w", cerr)
+		}
+	}
+	if q.deleteHoneypotStmt != nil {
+		if cerr := q.deleteHoneypotStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteHoneypotStmt: %w", cerr)
+		}
+	}
+	if q.deleteNoticeStmt != nil {
+		if cerr := q.deleteNoticeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteNoticeStmt: %w", cerr)
+		}
+	}
+	if q.deleteScanRuleStmt != nil {
+		if cerr := q.deleteScanRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteScanRuleStmt: %w", cerr)
+		}
+	}
+	if q.deleteScriptStmt != nil {
+		if cerr := q.deleteScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteScriptStmt: %w", cerr)
+		}
+	}
+	if q.deleteSessionStmt != nil {
+		if cerr := q.deleteSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSessionStmt: %w", cerr)
+		}
+	}
+	if q.deleteTemplateStmt != nil {
+		if cerr := q.deleteTemplateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTemplateStmt: %w", cerr)
+		}
+	}
+	if q.deleteTokensByFolderStmt != nil {
+		if cerr := q.deleteTokensByFolderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTokensByFolderStmt: %w", cerr)
+		}
+	}
+	if q.deleteTokensByScriptStmt != nil {
+		if cerr := q.deleteTokensByScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTokensByScriptStmt: %w", cerr)
+		}
+	}
+	if q.deleteVariantStmt != nil {
+		if cerr := q.deleteVariantStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteVariantStmt: %w", cerr)
+		}
+	}
+	if q.deleteWebhookStmt != nil {
+		if cerr := q.deleteWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookStmt: %w", cerr)
+		}
+	}
+	if q.getAPITokenStmt != nil {
+		if cerr := q.getAPITokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAPITokenStmt: %w", cerr)
+		}
+	}
+	if q.getAPITokenByHashStmt != nil {
+		if cerr := q.getAPITokenByHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAPITokenByHashStmt: %w", cerr)
+		}
+	}
+	if q.getAdminTokenStmt != nil {
+		if cerr := q.getAdminTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAdminTokenStmt: %w", cerr)
+		}
+	}
+	if q.getAdminTokenByHashStmt != nil {
+		if cerr := q.getAdminTokenByHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAdminTokenByHashStmt: %w", cerr)
+		}
+	}
+	if q.getAuthTokenStmt != nil {
+		if cerr := q.getAuthTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAuthTokenStmt: %w", cerr)
+		}
+	}
+	if q.getBlobStmt != nil {
+		if cerr := q.getBlobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBlobStmt: %w", cerr)
+		}
+	}
+	if q.getCanaryStmt != nil {
+		if cerr := q.getCanaryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCanaryStmt: %w", cerr)
+		}
+	}
+	if q.getContentUsageStmt != nil {
+		if cerr := q.getContentUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getContentUsageStmt: %w", cerr)
+		}
+	}
+	if q.getCurrentVersionStmt != nil {
+		if cerr := q.getCurrentVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCurrentVersionStmt: %w", cerr)
+		}
+	}
+	if q.getFolderStmt != nil {
+		if cerr := q.getFolderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getFolderStmt: %w", cerr)
+		}
+	}
+	if q.getFolderByPathStmt != nil {
+		if cerr := q.getFolderByPathStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getFolderByPathStmt: %w", cerr)
+		}
+	}
+	if q.getHoneypotStmt != nil {
+		if cerr := q.getHoneypotStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getHoneypotStmt: %w", cerr)
+		}
+	}
+	if q.getHoneypotByPathStmt != nil {
+		if cerr := q.getHoneypotByPathStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getHoneypotByPathStmt: %w", cerr)
+		}
+	}
+	if q.getLastWebhookAttemptStmt != nil {
+		if cerr := q.getLastWebhookAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLastWebhookAttemptStmt: %w", cerr)
+		}
+	}
+	if q.getLatestVersionStmt != nil {
+		if cerr := q.getLatestVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestVersionStmt: %w", cerr)
+		}
+	}
+	if q.getMostVersionedScriptStmt != nil {
+		if cerr := q.getMostVersionedScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMostVersionedScriptStmt: %w", cerr)
+		}
+	}
+	if q.getNoticeStmt != nil {
+		if cerr := q.getNoticeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getNoticeStmt: %w", cerr)
+		}
+	}
+	if q.getScanRuleStmt != nil {
+		if cerr := q.getScanRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getScanRuleStmt: %w", cerr)
+		}
+	}
+	if q.getScriptStmt != nil {
+		if cerr := q.getScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getScriptStmt: %w", cerr)
+		}
+	}
+	if q.getScriptByPathStmt != nil {
+		if cerr := q.getScriptByPathStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getScriptByPathStmt: %w", cerr)
+		}
+	}
+	if q.getScriptStatsStmt != nil {
+		if cerr := q.getScriptStatsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getScriptStatsStmt: %w", cerr)
+		}
+	}
+	if q.getSessionStmt != nil {
+		if cerr := q.getSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSessionStmt: %w", cerr)
+		}
+	}
+	if q.getShareLinkStmt != nil {
+		if cerr := q.getShareLinkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getShareLinkStmt: %w", cerr)
+		}
+	}
+	if q.getTemplateStmt != nil {
+		if cerr := q.getTemplateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTemplateStmt: %w", cerr)
+		}
+	}
+	if q.getTemplateByNameStmt != nil {
+		if cerr := q.getTemplateByNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTemplateByNameStmt: %w", cerr)
+		}
+	}
+	if q.getVariantStmt != nil {
+		if cerr := q.getVariantStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getVariantStmt: %w", cerr)
+		}
+	}
+	if q.getVersionStmt != nil {
+		if cerr := q.getVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getVersionStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookStmt != nil {
+		if cerr := q.getWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookDeliveryStmt != nil {
+		if cerr := q.getWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.incrementVersionServesStmt != nil {
+		if cerr := q.incrementVersionServesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing incrementVersionServesStmt: %w", cerr)
+		}
+	}
+	if q.listAPITokensStmt != nil {
+		if cerr := q.listAPITokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAPITokensStmt: %w", cerr)
+		}
+	}
+	if q.listAccessClientsByPathStmt != nil {
+		if cerr := q.listAccessClientsByPathStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccessClientsByPathStmt: %w", cerr)
+		}
+	}
+	if q.listAccessLogStmt != nil {
+		if cerr := q.listAccessLogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccessLogStmt: %w", cerr)
+		}
+	}
+	if q.listAccessLogByIPStmt != nil {
+		if cerr := q.listAccessLogByIPStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccessLogByIPStmt: %w", cerr)
+		}
+	}
+	if q.listAccessLogByPathStmt != nil {
+		if cerr := q.listAccessLogByPathStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccessLogByPathStmt: %w", cerr)
+		}
+	}
+	if q.listActiveAuthTokensStmt != nil {
+		if cerr := q.listActiveAuthTokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listActiveAuthTokensStmt: %w", cerr)
+		}
+	}
+	if q.listAdminTokensStmt != nil {
+		if cerr := q.listAdminTokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAdminTokensStmt: %w", cerr)
+		}
+	}
+	if q.listAllFetchBreakdownStmt != nil {
+		if cerr := q.listAllFetchBreakdownStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAllFetchBreakdownStmt: %w", cerr)
+		}
+	}
+	if q.listAllVersionsStmt != nil {
+		if cerr := q.listAllVersionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAllVersionsStmt: %w", cerr)
+		}
+	}
+	if q.listAuditFailuresStmt != nil {
+		if cerr := q.listAuditFailuresStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAuditFailuresStmt: %w", cerr)
+		}
+	}
+	if q.listAuditLogsStmt != nil {
+		if cerr := q.listAuditLogsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAuditLogsStmt: %w", cerr)
+		}
+	}
+	if q.listAuditLogsBeforeStmt != nil {
+		if cerr := q.listAuditLogsBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAuditLogsBeforeStmt: %w", cerr)
+		}
+	}
+	if q.listAuditLogsByEntityStmt != nil {
+		if cerr := q.listAuditLogsByEntityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAuditLogsByEntityStmt: %w", cerr)
+		}
+	}
+	if q.listAuditLogsRangeStmt != nil {
+		if cerr := q.listAuditLogsRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAuditLogsRangeStmt: %w", cerr)
+		}
+	}
+	if q.listDueWebhookRetriesStmt != nil {
+		if cerr := q.listDueWebhookRetriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDueWebhookRetriesStmt: %w", cerr)
+		}
+	}
+	if q.listFavoritesStmt != nil {
+		if cerr := q.listFavoritesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFavoritesStmt: %w", cerr)
+		}
+	}
+	if q.listFetchBreakdownStmt != nil {
+		if cerr := q.listFetchBreakdownStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFetchBreakdownStmt: %w", cerr)
+		}
+	}
+	if q.listFoldersStmt != nil {
+		if cerr := q.listFoldersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFoldersStmt: %w", cerr)
+		}
+	}
+	if q.listGitHubSyncFilesStmt != nil {
+		if cerr := q.listGitHubSyncFilesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listGitHubSyncFilesStmt: %w", cerr)
+		}
+	}
+	if q.listHoneypotsStmt != nil {
+		if cerr := q.listHoneypotsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listHoneypotsStmt: %w", cerr)
+		}
+	}
+	if q.listInlineVersionsStmt != nil {
+		if cerr := q.listInlineVersionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listInlineVersionsStmt: %w", cerr)
+		}
+	}
+	if q.listLockedFoldersStmt != nil {
+		if cerr := q.listLockedFoldersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLockedFoldersStmt: %w", cerr)
+		}
+	}
+	if q.listNoticesStmt != nil {
+		if cerr := q.listNoticesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listNoticesStmt: %w", cerr)
+		}
+	}
+	if q.listPlainScriptContentRefsStmt != nil {
+		if cerr := q.listPlainScriptContentRefsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPlainScriptContentRefsStmt: %w", cerr)
+		}
+	}
+	if q.listPlainVersionContentRefsStmt != nil {
+		if cerr := q.listPlainVersionContentRefsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPlainVersionContentRefsStmt: %w", cerr)
+		}
+	}
+	if q.listRecentlyUpdatedStmt != nil {
+		if cerr := q.listRecentlyUpdatedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRecentlyUpdatedStmt: %w", cerr)
+		}
+	}
+	if q.listScanRulesStmt != nil {
+		if cerr := q.listScanRulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScanRulesStmt: %w", cerr)
+		}
+	}
+	if q.listScriptDailyConsumersStmt != nil {
+		if cerr := q.listScriptDailyConsumersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScriptDailyConsumersStmt: %w", cerr)
+		}
+	}
+	if q.listScriptDailyRunsStmt != nil {
+		if cerr := q.listScriptDailyRunsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScriptDailyRunsStmt: %w", cerr)
+		}
+	}
+	if q.listScriptDailyStatsStmt != nil {
+		if cerr := q.listScriptDailyStatsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScriptDailyStatsStmt: %w", cerr)
+		}
+	}
+	if q.listScriptMetadataStmt != nil {
+		if cerr := q.listScriptMetadataStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScriptMetadataStmt: %w", cerr)
+		}
+	}
+	if q.listScriptRunFailuresStmt != nil {
+		if cerr := q.listScriptRunFailuresStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScriptRunFailuresStmt: %w", cerr)
+		}
+	}
+	if q.listScriptStatsStmt != nil {
+		if cerr := q.listScriptStatsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScriptStatsStmt: %w", cerr)
+		}
+	}
+	if q.listScriptsStmt != nil {
+		if cerr := q.listScriptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScriptsStmt: %w", cerr)
+		}
+	}
+	if q.listScriptsByFolderStmt != nil {
+		if cerr := q.listScriptsByFolderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScriptsByFolderStmt: %w", cerr)
+		}
+	}
+	if q.listScriptsReferencingStmt != nil {
+		if cerr := q.listScriptsReferencingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listScriptsReferencingStmt: %w", cerr)
+		}
+	}
+	if q.listSessionsStmt != nil {
+		if cerr := q.listSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSessionsStmt: %w", cerr)
+		}
+	}
+	if q.listShareLinksStmt != nil {
+		if cerr := q.listShareLinksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listShareLinksStmt: %w", cerr)
+		}
+	}
+	if q.listSubfoldersStmt != nil {
+		if cerr := q.listSubfoldersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSubfoldersStmt: %w", cerr)
+		}
+	}
+	if q.listTemplatesStmt != nil {
+		if cerr := q.listTemplatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTemplatesStmt: %w", cerr)
+		}
+	}
+	if q.listUnsizedScriptIDsStmt != nil {
+		if cerr := q.listUnsizedScriptIDsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUnsizedScriptIDsStmt: %w", cerr)
+		}
+	}
+	if q.listVariantStatsStmt != nil {
+		if cerr := q.listVariantStatsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listVariantStatsStmt: %w", cerr)
+		}
+	}
+	if q.listVariantsStmt != nil {
+		if cerr := q.listVariantsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listVariantsStmt: %w", cerr)
+		}
+	}
+	if q.listVersionContentRefsStmt != nil {
+		if cerr := q.listVersionContentRefsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listVersionContentRefsStmt: %w", cerr)
+		}
+	}
+	if q.listVersionHistoryStmt != nil {
+		if cerr := q.listVersionHistoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listVersionHistoryStmt: %w", cerr)
+		}
+	}
+	if q.listVersionsStmt != nil {
+		if cerr := q.listVersionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listVersionsStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookDeliveriesStmt != nil {
+		if cerr := q.listWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.listWebhooksStmt != nil {
+		if cerr := q.listWebhooksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhooksStmt: %w", cerr)
+		}
+	}
+	if q.putBlobStmt != nil {
+		if cerr := q.putBlobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing putBlobStmt: %w", cerr)
+		}
+	}
+	if q.recordFetchBreakdownStmt != nil {
+		if cerr := q.recordFetchBreakdownStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordFetchBreakdownStmt: %w", cerr)
+		}
+	}
+	if q.recordFetchConsumerStmt != nil {
+		if cerr := q.recordFetchConsumerStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordFetchConsumerStmt: %w", cerr)
+		}
+	}
+	if q.recordScriptDailyFetchStmt != nil {
+		if cerr := q.recordScriptDailyFetchStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordScriptDailyFetchStmt: %w", cerr)
+		}
+	}
+	if q.recordScriptFetchStmt != nil {
+		if cerr := q.recordScriptFetchStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordScriptFetchStmt: %w", cerr)
+		}
+	}
+	if q.recordVariantServeStmt != nil {
+		if cerr := q.recordVariantServeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordVariantServeStmt: %w", cerr)
+		}
+	}
+	if q.revokeAPITokenStmt != nil {
+		if cerr := q.revokeAPITokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeAPITokenStmt: %w", cerr)
+		}
+	}
+	if q.revokeShareLinkStmt != nil {
+		if cerr := q.revokeShareLinkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeShareLinkStmt: %w", cerr)
+		}
+	}
+	if q.searchScriptMetadataStmt != nil {
+		if cerr := q.searchScriptMetadataStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchScriptMetadataStmt: %w", cerr)
+		}
+	}
+	if q.setFavoriteStmt != nil {
+		if cerr := q.setFavoriteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setFavoriteStmt: %w", cerr)
+		}
+	}
+	if q.setScriptArchivedStmt != nil {
+		if cerr := q.setScriptArchivedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setScriptArchivedStmt: %w", cerr)
+		}
+	}
+	if q.setScriptContentRefStmt != nil {
+		if cerr := q.setScriptContentRefStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setScriptContentRefStmt: %w", cerr)
+		}
+	}
+	if q.setScriptContentSizeStmt != nil {
+		if cerr := q.setScriptContentSizeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setScriptContentSizeStmt: %w", cerr)
+		}
+	}
+	if q.setScriptDisabledStmt != nil {
+		if cerr := q.setScriptDisabledStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setScriptDisabledStmt: %w", cerr)
+		}
+	}
+	if q.setScriptSourceFetchedStmt != nil {
+		if cerr := q.setScriptSourceFetchedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setScriptSourceFetchedStmt: %w", cerr)
+		}
+	}
+	if q.setVersionContentRefStmt != nil {
+		if cerr := q.setVersionContentRefStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setVersionContentRefStmt: %w", cerr)
+		}
+	}
+	if q.sumDailyConsumersByScriptStmt != nil {
+		if cerr := q.sumDailyConsumersByScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumDailyConsumersByScriptStmt: %w", cerr)
+		}
+	}
+	if q.sumDailyFetchesByScriptStmt != nil {
+		if cerr := q.sumDailyFetchesByScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumDailyFetchesByScriptStmt: %w", cerr)
+		}
+	}
+	if q.sumFetchesSinceStmt != nil {
+		if cerr := q.sumFetchesSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumFetchesSinceStmt: %w", cerr)
+		}
+	}
+	if q.sumRunsByScriptStmt != nil {
+		if cerr := q.sumRunsByScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumRunsByScriptStmt: %w", cerr)
+		}
+	}
+	if q.touchAPITokenStmt != nil {
+		if cerr := q.touchAPITokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchAPITokenStmt: %w", cerr)
+		}
+	}
+	if q.touchAdminTokenStmt != nil {
+		if cerr := q.touchAdminTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchAdminTokenStmt: %w", cerr)
+		}
+	}
+	if q.touchSessionStmt != nil {
+		if cerr := q.touchSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchSessionStmt: %w", cerr)
+		}
+	}
+	if q.updateCanaryPercentStmt != nil {
+		if cerr := q.updateCanaryPercentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateCanaryPercentStmt: %w", cerr)
+		}
+	}
+	if q.updateCanaryStableStmt != nil {
+		if cerr := q.updateCanaryStableStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateCanaryStableStmt: %w", cerr)
+		}
+	}
+	if q.updateFolderLockStmt != nil {
+		if cerr := q.updateFolderLockStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateFolderLockStmt: %w", cerr)
+		}
+	}
+	if q.updateFolderPasswordHashStmt != nil {
+		if cerr := q.updateFolderPasswordHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateFolderPasswordHashStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptStmt != nil {
+		if cerr := q.updateScriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptAvailabilityStmt != nil {
+		if cerr := q.updateScriptAvailabilityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptAvailabilityStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptContentStmt != nil {
+		if cerr := q.updateScriptContentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptContentStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptCountriesStmt != nil {
+		if cerr := q.updateScriptCountriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptCountriesStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptDeprecationStmt != nil {
+		if cerr := q.updateScriptDeprecationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptDeprecationStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptExpirationStmt != nil {
+		if cerr := q.updateScriptExpirationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptExpirationStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptLockStmt != nil {
+		if cerr := q.updateScriptLockStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptLockStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptPasswordHashStmt != nil {
+		if cerr := q.updateScriptPasswordHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptPasswordHashStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptQuotasStmt != nil {
+		if cerr := q.updateScriptQuotasStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptQuotasStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptSourceStmt != nil {
+		if cerr := q.updateScriptSourceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptSourceStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptUnlockTTLStmt != nil {
+		if cerr := q.updateScriptUnlockTTLStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptUnlockTTLStmt: %w", cerr)
+		}
+	}
+	if q.updateScriptVisibilityStmt != nil {
+		if cerr := q.updateScriptVisibilityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateScriptVisibilityStmt: %w", cerr)
+		}
+	}
+	if q.updateTemplateStmt != nil {
+		if cerr := q.updateTemplateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateTemplateStmt: %w", cerr)
+		}
+	}
+	if q.updateVariantStmt != nil {
+		if cerr := q.updateVariantStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateVariantStmt: %w", cerr)
+		}
+	}
+	if q.upsertGitHubSyncFileStmt != nil {
+		if cerr := q.upsertGitHubSyncFileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertGitHubSyncFileStmt: %w", cerr)
+		}
+	}
+	return err
+}
+
+func (q *Queries) exec(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) (sql.Result, error) {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).ExecContext(ctx, args...)
+	case stmt != nil:
+		return stmt.ExecContext(ctx, args...)
+	default:
+		return q.db.ExecContext(ctx, query, args...)
+	}
+}
+
+func (q *Queries) query(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) (*sql.Rows, error) {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).QueryContext(ctx, args...)
+	case stmt != nil:
+		return stmt.QueryContext(ctx, args...)
+	default:
+		return q.db.QueryContext(ctx, query, args...)
+	}
+}
+
+func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) *sql.Row {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).QueryRowContext(ctx, args...)
+	case stmt != nil:
+		return stmt.QueryRowContext(ctx, args...)
+	default:
+		return q.db.QueryRowContext(ctx, query, args...)
+	}
+}
+
 type Queries struct {
-	db DBTX
+	db                              DBTX
+	tx                              *sql.Tx
+	claimWebhookRetryStmt           *sql.Stmt
+	consumeShareLinkStmt            *sql.Stmt
+	countActiveAPITokensStmt        *sql.Stmt
+	countActiveAuthTokensStmt       *sql.Stmt
+	countActiveSessionsStmt         *sql.Stmt
+	countActiveShareLinksStmt       *sql.Stmt
+	countAdminTokensStmt            *sql.Stmt
+	countAuditActionsStmt           *sql.Stmt
+	countFoldersStmt                *sql.Stmt
+	countScriptContentRefsStmt      *sql.Stmt
+	countScriptRunsSinceStmt        *sql.Stmt
+	countScriptsStmt                *sql.Stmt
+	countStoredContentStmt          *sql.Stmt
+	countVersionContentRefsStmt     *sql.Stmt
+	createAPITokenStmt              *sql.Stmt
+	createAccessLogStmt             *sql.Stmt
+	createAdminTokenStmt            *sql.Stmt
+	createAuditLogStmt              *sql.Stmt
+	createAuthTokenStmt             *sql.Stmt
+	createCanaryStmt                *sql.Stmt
+	createFolderStmt                *sql.Stmt
+	createHoneypotStmt              *sql.Stmt
+	createNoticeStmt                *sql.Stmt
+	createScanRuleStmt              *sql.Stmt
+	createScriptStmt                *sql.Stmt
+	createScriptRunStmt             *sql.Stmt
+	createSessionStmt               *sql.Stmt
+	createShareLinkStmt             *sql.Stmt
+	createStoredVersionStmt         *sql.Stmt
+	createTemplateStmt              *sql.Stmt
+	createVariantStmt               *sql.Stmt
+	createVersionStmt               *sql.Stmt
+	createWebhookStmt               *sql.Stmt
+	createWebhookDeliveryStmt       *sql.Stmt
+	deleteAdminTokenStmt            *sql.Stmt
+	deleteAuditLogsThroughStmt      *sql.Stmt
+	deleteAuthTokenStmt             *sql.Stmt
+	deleteBlobStmt                  *sql.Stmt
+	deleteCanaryStmt                *sql.Stmt
+	deleteExpiredNoticesStmt        *sql.Stmt
+	deleteExpiredSessionsStmt       *sql.Stmt
+	deleteExpiredTokensStmt         *sql.Stmt
+	deleteFetchConsumersBeforeStmt  *sql.Stmt
+	deleteFolderStmt                *sql.Stmt
+	deleteFolderByPathStmt          *sql.Stmt
+	deleteGitHubSyncFileStmt        *sql.Stmt
+	deleteHoneypotStmt              *sql.Stmt
+	deleteNoticeStmt                *sql.Stmt
+	deleteScanRuleStmt              *sql.Stmt
+	deleteScriptStmt                *sql.Stmt
+	deleteSessionStmt               *sql.Stmt
+	deleteTemplateStmt              *sql.Stmt
+	deleteTokensByFolderStmt        *sql.Stmt
+	deleteTokensByScriptStmt        *sql.Stmt
+	deleteVariantStmt               *sql.Stmt
+	deleteWebhookStmt               *sql.Stmt
+	getAPITokenStmt                 *sql.Stmt
+	getAPITokenByHashStmt           *sql.Stmt
+	getAdminTokenStmt               *sql.Stmt
+	getAdminTokenByHashStmt         *sql.Stmt
+	getAuthTokenStmt                *sql.Stmt
+	getBlobStmt                     *sql.Stmt
+	getCanaryStmt                   *sql.Stmt
+	getContentUsageStmt             *sql.Stmt
+	getCurrentVersionStmt           *sql.Stmt
+	getFolderStmt                   *sql.Stmt
+	getFolderByPathStmt             *sql.Stmt
+	getHoneypotStmt                 *sql.Stmt
+	getHoneypotByPathStmt           *sql.Stmt
+	getLastWebhookAttemptStmt       *sql.Stmt
+	getLatestVersionStmt            *sql.Stmt
+	getMostVersionedScriptStmt      *sql.Stmt
+	getNoticeStmt                   *sql.Stmt
+	getScanRuleStmt                 *sql.Stmt
+	getScriptStmt                   *sql.Stmt
+	getScriptByPathStmt             *sql.Stmt
+	getScriptStatsStmt              *sql.Stmt
+	getSessionStmt                  *sql.Stmt
+	getShareLinkStmt                *sql.Stmt
+	getTemplateStmt                 *sql.Stmt
+	getTemplateByNameStmt           *sql.Stmt
+	getVariantStmt                  *sql.Stmt
+	getVersionStmt                  *sql.Stmt
+	getWebhookStmt                  *sql.Stmt
+	getWebhookDeliveryStmt          *sql.Stmt
+	incrementVersionServesStmt      *sql.Stmt
+	listAPITokensStmt               *sql.Stmt
+	listAccessClientsByPathStmt     *sql.Stmt
+	listAccessLogStmt               *sql.Stmt
+	listAccessLogByIPStmt           *sql.Stmt
+	listAccessLogByPathStmt         *sql.Stmt
+	listActiveAuthTokensStmt        *sql.Stmt
+	listAdminTokensStmt             *sql.Stmt
+	listAllFetchBreakdownStmt       *sql.Stmt
+	listAllVersionsStmt             *sql.Stmt
+	listAuditFailuresStmt           *sql.Stmt
+	listAuditLogsStmt               *sql.Stmt
+	listAuditLogsBeforeStmt         *sql.Stmt
+	listAuditLogsByEntityStmt       *sql.Stmt
+	listAuditLogsRangeStmt          *sql.Stmt
+	listDueWebhookRetriesStmt       *sql.Stmt
+	listFavoritesStmt               *sql.Stmt
+	listFetchBreakdownStmt          *sql.Stmt
+	listFoldersStmt                 *sql.Stmt
+	listGitHubSyncFilesStmt         *sql.Stmt
+	listHoneypotsStmt               *sql.Stmt
+	listInlineVersionsStmt          *sql.Stmt
+	listLockedFoldersStmt           *sql.Stmt
+	listNoticesStmt                 *sql.Stmt
+	listPlainScriptContentRefsStmt  *sql.Stmt
+	listPlainVersionContentRefsStmt *sql.Stmt
+	listRecentlyUpdatedStmt         *sql.Stmt
+	listScanRulesStmt               *sql.Stmt
+	listScriptDailyConsumersStmt    *sql.Stmt
+	listScriptDailyRunsStmt         *sql.Stmt
+	listScriptDailyStatsStmt        *sql.Stmt
+	listScriptMetadataStmt          *sql.Stmt
+	listScriptRunFailuresStmt       *sql.Stmt
+	listScriptStatsStmt             *sql.Stmt
+	listScriptsStmt                 *sql.Stmt
+	listScriptsByFolderStmt         *sql.Stmt
+	listScriptsReferencingStmt      *sql.Stmt
+	listSessionsStmt                *sql.Stmt
+	listShareLinksStmt              *sql.Stmt
+	listSubfoldersStmt              *sql.Stmt
+	listTemplatesStmt               *sql.Stmt
+	listUnsizedScriptIDsStmt        *sql.Stmt
+	listVariantStatsStmt            *sql.Stmt
+	listVariantsStmt                *sql.Stmt
+	listVersionContentRefsStmt      *sql.Stmt
+	listVersionHistoryStmt          *sql.Stmt
+	listVersionsStmt                *sql.Stmt
+	listWebhookDeliveriesStmt       *sql.Stmt
+	listWebhooksStmt                *sql.Stmt
+	putBlobStmt                     *sql.Stmt
+	recordFetchBreakdownStmt        *sql.Stmt
+	recordFetchConsumerStmt         *sql.Stmt
+	recordScriptDailyFetchStmt      *sql.Stmt
+	recordScriptFetchStmt           *sql.Stmt
+	recordVariantServeStmt          *sql.Stmt
+	revokeAPITokenStmt              *sql.Stmt
+	revokeShareLinkStmt             *sql.Stmt
+	searchScriptMetadataStmt        *sql.Stmt
+	setFavoriteStmt                 *sql.Stmt
+	setScriptArchivedStmt           *sql.Stmt
+	setScriptContentRefStmt         *sql.Stmt
+	setScriptContentSizeStmt        *sql.Stmt
+	setScriptDisabledStmt           *sql.Stmt
+	setScriptSourceFetchedStmt      *sql.Stmt
+	setVersionContentRefStmt        *sql.Stmt
+	sumDailyConsumersByScriptStmt   *sql.Stmt
+	sumDailyFetchesByScriptStmt     *sql.Stmt
+	sumFetchesSinceStmt             *sql.Stmt
+	sumRunsByScriptStmt             *sql.Stmt
+	touchAPITokenStmt               *sql.Stmt
+	touchAdminTokenStmt             *sql.Stmt
+	touchSessionStmt                *sql.Stmt
+	updateCanaryPercentStmt         *sql.Stmt
+	updateCanaryStableStmt          *sql.Stmt
+	updateFolderLockStmt            *sql.Stmt
+	updateFolderPasswordHashStmt    *sql.Stmt
+	updateScriptStmt                *sql.Stmt
+	updateScriptAvailabilityStmt    *sql.Stmt
+	updateScriptContentStmt         *sql.Stmt
+	updateScriptCountriesStmt       *sql.Stmt
+	updateScriptDeprecationStmt     *sql.Stmt
+	updateScriptExpirationStmt      *sql.Stmt
+	updateScriptLockStmt            *sql.Stmt
+	updateScriptPasswordHashStmt    *sql.Stmt
+	updateScriptQuotasStmt          *sql.Stmt
+	updateScriptSourceStmt          *sql.Stmt
+	updateScriptUnlockTTLStmt       *sql.Stmt
+	updateScriptVisibilityStmt      *sql.Stmt
+	updateTemplateStmt              *sql.Stmt
+	updateVariantStmt               *sql.Stmt
+	upsertGitHubSyncFileStmt        *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db: tx,
+		db:                              tx,
+		tx:                              tx,
+		claimWebhookRetryStmt:           q.claimWebhookRetryStmt,
+		consumeShareLinkStmt:            q.consumeShareLinkStmt,
+		countActiveAPITokensStmt:        q.countActiveAPITokensStmt,
+		countActiveAuthTokensStmt:       q.countActiveAuthTokensStmt,
+		countActiveSessionsStmt:         q.countActiveSessionsStmt,
+		countActiveShareLinksStmt:       q.countActiveShareLinksStmt,
+		countAdminTokensStmt:            q.countAdminTokensStmt,
+		countAuditActionsStmt:           q.countAuditActionsStmt,
+		countFoldersStmt:                q.countFoldersStmt,
+		countScriptContentRefsStmt:      q.countScriptContentRefsStmt,
+		countScriptRunsSinceStmt:        q.countScriptRunsSinceStmt,
+		countScriptsStmt:                q.countScriptsStmt,
+		countStoredContentStmt:          q.countStoredContentStmt,
+		countVersionContentRefsStmt:     q.countVersionContentRefsStmt,
+		createAPITokenStmt:              q.createAPITokenStmt,
+		createAccessLogStmt:             q.createAccessLogStmt,
+		createAdminTokenStmt:            q.createAdminTokenStmt,
+		createAuditLogStmt:              q.createAuditLogStmt,
+		createAuthTokenStmt:             q.createAuthTokenStmt,
+		createCanaryStmt:                q.createCanaryStmt,
+		createFolderStmt:                q.createFolderStmt,
+		createHoneypotStmt:              q.createHoneypotStmt,
+		createNoticeStmt:                q.createNoticeStmt,
+		createScanRuleStmt:              q.createScanRuleStmt,
+		createScriptStmt:                q.createScriptStmt,
+		createScriptRunStmt:             q.createScriptRunStmt,
+		createSessionStmt:               q.createSessionStmt,
+		createShareLinkStmt:             q.createShareLinkStmt,
+		createStoredVersionStmt:         q.createStoredVersionStmt,
+		createTemplateStmt:              q.createTemplateStmt,
+		createVariantStmt:               q.createVariantStmt,
+		createVersionStmt:               q.createVersionStmt,
+		createWebhookStmt:               q.createWebhookStmt,
+		createWebhookDeliveryStmt:       q.createWebhookDeliveryStmt,
+		deleteAdminTokenStmt:            q.deleteAdminTokenStmt,
+		deleteAuditLogsThroughStmt:      q.deleteAuditLogsThroughStmt,
+		deleteAuthTokenStmt:             q.deleteAuthTokenStmt,
+		deleteBlobStmt:                  q.deleteBlobStmt,
+		deleteCanaryStmt:                q.deleteCanaryStmt,
+		deleteExpiredNoticesStmt:        q.deleteExpiredNoticesStmt,
+		deleteExpiredSessionsStmt:       q.deleteExpiredSessionsStmt,
+		deleteExpiredTokensStmt:         q.deleteExpiredTokensStmt,
+		deleteFetchConsumersBeforeStmt:  q.deleteFetchConsumersBeforeStmt,
+		deleteFolderStmt:                q.deleteFolderStmt,
+		deleteFolderByPathStmt:          q.deleteFolderByPathStmt,
+		deleteGitHubSyncFileStmt:        q.deleteGitHubSyncFileStmt,
+		deleteHoneypotStmt:              q.deleteHoneypotStmt,
+		deleteNoticeStmt:                q.deleteNoticeStmt,
+		deleteScanRuleStmt:              q.deleteScanRuleStmt,
+		deleteScriptStmt:                q.deleteScriptStmt,
+		deleteSessionStmt:               q.deleteSessionStmt,
+		deleteTemplateStmt:              q.deleteTemplateStmt,
+		deleteTokensByFolderStmt:        q.deleteTokensByFolderStmt,
+		deleteTokensByScriptStmt:        q.deleteTokensByScriptStmt,
+		deleteVariantStmt:               q.deleteVariantStmt,
+		deleteWebhookStmt:               q.deleteWebhookStmt,
+		getAPITokenStmt:                 q.getAPITokenStmt,
+		getAPITokenByHashStmt:           q.getAPITokenByHashStmt,
+		getAdminTokenStmt:               q.getAdminTokenStmt,
+		getAdminTokenByHashStmt:         q.getAdminTokenByHashStmt,
+		getAuthTokenStmt:                q.getAuthTokenStmt,
+		getBlobStmt:                     q.getBlobStmt,
+		getCanaryStmt:                   q.getCanaryStmt,
+		getContentUsageStmt:             q.getContentUsageStmt,
+		getCurrentVersionStmt:           q.getCurrentVersionStmt,
+		getFolderStmt:                   q.getFolderStmt,
+		getFolderByPathStmt:             q.getFolderByPathStmt,
+		getHoneypotStmt:                 q.getHoneypotStmt,
+		getHoneypotByPathStmt:           q.getHoneypotByPathStmt,
+		getLastWebhookAttemptStmt:       q.getLastWebhookAttemptStmt,
+		getLatestVersionStmt:            q.getLatestVersionStmt,
+		getMostVersionedScriptStmt:      q.getMostVersionedScriptStmt,
+		getNoticeStmt:                   q.getNoticeStmt,
+		getScanRuleStmt:                 q.getScanRuleStmt,
+		getScriptStmt:                   q.getScriptStmt,
+		getScriptByPathStmt:             q.getScriptByPathStmt,
+		getScriptStatsStmt:              q.getScriptStatsStmt,
+		getSessionStmt:                  q.getSessionStmt,
+		getShareLinkStmt:                q.getShareLinkStmt,
+		getTemplateStmt:                 q.getTemplateStmt,
+		getTemplateByNameStmt:           q.getTemplateByNameStmt,
+		getVariantStmt:                  q.getVariantStmt,
+		getVersionStmt:                  q.getVersionStmt,
+		getWebhookStmt:                  q.getWebhookStmt,
+		getWebhookDeliveryStmt:          q.getWebhookDeliveryStmt,
+		incrementVersionServesStmt:      q.incrementVersionServesStmt,
+		listAPITokensStmt:               q.listAPITokensStmt,
+		listAccessClientsByPathStmt:     q.listAccessClientsByPathStmt,
+		listAccessLogStmt:               q.listAccessLogStmt,
+		listAccessLogByIPStmt:           q.listAccessLogByIPStmt,
+		listAccessLogByPathStmt:         q.listAccessLogByPathStmt,
+		listActiveAuthTokensStmt:        q.listActiveAuthTokensStmt,
+		listAdminTokensStmt:             q.listAdminTokensStmt,
+		listAllFetchBreakdownStmt:       q.listAllFetchBreakdownStmt,
+		listAllVersionsStmt:             q.listAllVersionsStmt,
+		listAuditFailuresStmt:           q.listAuditFailuresStmt,
+		listAuditLogsStmt:               q.listAuditLogsStmt,
+		listAuditLogsBeforeStmt:         q.listAuditLogsBeforeStmt,
+		listAuditLogsByEntityStmt:       q.listAuditLogsByEntityStmt,
+		listAuditLogsRangeStmt:          q.listAuditLogsRangeStmt,
+		listDueWebhookRetriesStmt:       q.listDueWebhookRetriesStmt,
+		listFavoritesStmt:               q.listFavoritesStmt,
+		listFetchBreakdownStmt:          q.listFetchBreakdownStmt,
+		listFoldersStmt:                 q.listFoldersStmt,
+		listGitHubSyncFilesStmt:         q.listGitHubSyncFilesStmt,
+		listHoneypotsStmt:               q.listHoneypotsStmt,
+		listInlineVersionsStmt:          q.listInlineVersionsStmt,
+		listLockedFoldersStmt:           q.listLockedFoldersStmt,
+		listNoticesStmt:                 q.listNoticesStmt,
+		listPlainScriptContentRefsStmt:  q.listPlainScriptContentRefsStmt,
+		listPlainVersionContentRefsStmt: q.listPlainVersionContentRefsStmt,
+		listRecentlyUpdatedStmt:         q.listRecentlyUpdatedStmt,
+		listScanRulesStmt:               q.listScanRulesStmt,
+		listScriptDailyConsumersStmt:    q.listScriptDailyConsumersStmt,
+		listScriptDailyRunsStmt:         q.listScriptDailyRunsStmt,
+		listScriptDailyStatsStmt:        q.listScriptDailyStatsStmt,
+		listScriptMetadataStmt:          q.listScriptMetadataStmt,
+		listScriptRunFailuresStmt:       q.listScriptRunFailuresStmt,
+		listScriptStatsStmt:             q.listScriptStatsStmt,
+		listScriptsStmt:                 q.listScriptsStmt,
+		listScriptsByFolderStmt:         q.listScriptsByFolderStmt,
+		listScriptsReferencingStmt:      q.listScriptsReferencingStmt,
+		listSessionsStmt:                q.listSessionsStmt,
+		listShareLinksStmt:              q.listShareLinksStmt,
+		listSubfoldersStmt:              q.listSubfoldersStmt,
+		listTemplatesStmt:               q.listTemplatesStmt,
+		listUnsizedScriptIDsStmt:        q.listUnsizedScriptIDsStmt,
+		listVariantStatsStmt:            q.listVariantStatsStmt,
+		listVariantsStmt:                q.listVariantsStmt,
+		listVersionContentRefsStmt:      q.listVersionContentRefsStmt,
+		listVersionHistoryStmt:          q.listVersionHistoryStmt,
+		listVersionsStmt:                q.listVersionsStmt,
+		listWebhookDeliveriesStmt:       q.listWebhookDeliveriesStmt,
+		listWebhooksStmt:                q.listWebhooksStmt,
+		putBlobStmt:                     q.putBlobStmt,
+		recordFetchBreakdownStmt:        q.recordFetchBreakdownStmt,
+		recordFetchConsumerStmt:         q.recordFetchConsumerStmt,
+		recordScriptDailyFetchStmt:      q.recordScriptDailyFetchStmt,
+		recordScriptFetchStmt:           q.recordScriptFetchStmt,
+		recordVariantServeStmt:          q.recordVariantServeStmt,
+		revokeAPITokenStmt:              q.revokeAPITokenStmt,
+		revokeShareLinkStmt:             q.revokeShareLinkStmt,
+		searchScriptMetadataStmt:        q.searchScriptMetadataStmt,
+		setFavoriteStmt:                 q.setFavoriteStmt,
+		setScriptArchivedStmt:           q.setScriptArchivedStmt,
+		setScriptContentRefStmt:         q.setScriptContentRefStmt,
+		setScriptContentSizeStmt:        q.setScriptContentSizeStmt,
+		setScriptDisabledStmt:           q.setScriptDisabledStmt,
+		setScriptSourceFetchedStmt:      q.setScriptSourceFetchedStmt,
+		setVersionContentRefStmt:        q.setVersionContentRefStmt,
+		sumDailyConsumersByScriptStmt:   q.sumDailyConsumersByScriptStmt,
+		sumDailyFetchesByScriptStmt:     q.sumDailyFetchesByScriptStmt,
+		sumFetchesSinceStmt:             q.sumFetchesSinceStmt,
+		sumRunsByScriptStmt:             q.sumRunsByScriptStmt,
+		touchAPITokenStmt:               q.touchAPITokenStmt,
+		touchAdminTokenStmt:             q.touchAdminTokenStmt,
+		touchSessionStmt:                q.touchSessionStmt,
+		updateCanaryPercentStmt:         q.updateCanaryPercentStmt,
+		updateCanaryStableStmt:          q.updateCanaryStableStmt,
+		updateFolderLockStmt:            q.updateFolderLockStmt,
+		updateFolderPasswordHashStmt:    q.updateFolderPasswordHashStmt,
+		updateScriptStmt:                q.updateScriptStmt,
+		updateScriptAvailabilityStmt:    q.updateScriptAvailabilityStmt,
+		updateScriptContentStmt:         q.updateScriptContentStmt,
+		updateScriptCountriesStmt:       q.updateScriptCountriesStmt,
+		updateScriptDeprecationStmt:     q.updateScriptDeprecationStmt,
+		updateScriptExpirationStmt:      q.updateScriptExpirationStmt,
+		updateScriptLockStmt:            q.updateScriptLockStmt,
+		updateScriptPasswordHashStmt:    q.updateScriptPasswordHashStmt,
+		updateScriptQuotasStmt:          q.updateScriptQuotasStmt,
+		updateScriptSourceStmt:          q.updateScriptSourceStmt,
+		updateScriptUnlockTTLStmt:       q.updateScriptUnlockTTLStmt,
+		updateScriptVisibilityStmt:      q.updateScriptVisibilityStmt,
+		updateTemplateStmt:              q.updateTemplateStmt,
+		updateVariantStmt:               q.updateVariantStmt,
+		upsertGitHubSyncFileStmt:        q.upsertGitHubSyncFileStmt,
 	}
 }
@@ -10,6 +10,17 @@ import (
 	"time"
 )
 
+const countFolders = `-- name: CountFolders :one
+SELECT COUNT(*) FROM folders
+`
+
+func (q *Queries) CountFolders(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.countFoldersStmt, countFolders)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFolder = `-- name: CreateFolder :exec
 INSERT INTO folders (id, path, name, created_at) VALUES (?, ?, ?, ?)
 `
@@ -22,7 +33,7 @@ type CreateFolderParams struct {
 }
 
 func (q *Queries) CreateFolder(ctx context.Context, arg CreateFolderParams) error {
-	_, err := q.db.ExecContext(ctx, createFolder,
+	_, err := q.exec(ctx, q.createFolderStmt, createFolder,
 		arg.ID,
 		arg.Path,
 		arg.Name,
@@ -36,7 +47,7 @@ DELETE FROM folders WHERE id = ?
 `
 
 func (q *Queries) DeleteFolder(ctx context.Context, id string) error {
-	_, err := q.db.ExecContext(ctx, deleteFolder, id)
+	_, err := q.exec(ctx, q.deleteFolderStmt, deleteFolder, id)
 	return err
 }
 
@@ -50,48 +61,86 @@ type DeleteFolderByPathParams struct {
 }
 
 func (q *Queries) DeleteFolderByPath(ctx context.Context, arg DeleteFolderByPathParams) error {
-	_, err := q.db.ExecContext(ctx, deleteFolderByPath, arg.Path, arg.Column2)
+	_, err := q.exec(ctx, q.deleteFolderByPathStmt, deleteFolderByPath, arg.Path, arg.Column2)
 	return err
 }
 
 const getFolder = `-- name: GetFolder :one
-SELECT id, path, name, created_at FROM folders WHERE id = ?
+SELECT id, path, name, created_at, locked, password_hash FROM folders WHERE id = ?
 `
 
 func (q *Queries) GetFolder(ctx context.Context, id string) (Folder, error) {
-	row := q.db.QueryRowContext(ctx, getFolder, id)
+	row := q.queryRow(ctx, q.getFolderStmt, getFolder, id)
 	var i Folder
 	err := row.Scan(
 		&i.ID,
 		&i.Path,
 		&i.Name,
 		&i.CreatedAt,
+		&i.Locked,
+		&i.PasswordHash,
 	)
 	return i, err
 }
 
 const getFolderByPath = `-- name: GetFolderByPath :one
-SELECT id, path, name, created_at FROM folders WHERE path = ?
+SELECT id, path, name, created_at, locked, password_hash FROM folders WHERE path = ?
 `
 
 func (q *Queries) GetFolderByPath(ctx context.Context, path string) (Folder, error) {
-	row := q.db.QueryRowContext(ctx, getFolderByPath, path)
+	row := q.queryRow(ctx, q.getFolderByPathStmt, getFolderByPath, path)
 	var i Folder
 	err := row.Scan(
 		&i.ID,
 		&i.Path,
 		&i.Name,
 		&i.CreatedAt,
+		&i.Locked,
+		&i.PasswordHash,
 	)
 	return i, err
 }
 
 const listFolders = `-- name: ListFolders :many
-SELECT id, path, name, created_at FROM folders ORDER BY path
+SELECT id, path, name, created_at, locked, password_hash FROM folders ORDER BY path
 `
 
 func (q *Queries) ListFolders(ctx context.Context) ([]Folder, error) {
-	rows, err := q.db.QueryContext(ctx, listFolders)
+	rows, err := q.query(ctx, q.listFoldersStmt, listFolders)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Folder{}
+	for rows.Next() {
+		var i Folder
+		if err := rows.Scan(
+			&i.ID,
+			&i.Path,
+			&i.Name,
+			&i.CreatedAt,
+			&i.Locked,
+			&i.PasswordHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLockedFolders = `-- name: ListLockedFolders :many
+SELECT id, path, name, created_at, locked, password_hash FROM folders WHERE locked = 1 ORDER BY path
+`
+
+func (q *Queries) ListLockedFolders(ctx context.Context) ([]Folder, error) {
+	rows, err := q.query(ctx, q.listLockedFoldersStmt, listLockedFolders)
 	if err != nil {
 		return nil, err
 	}
@@ -104,6 +153,8 @@ func (q *Queries) ListFolders(ctx context.Context) ([]Folder, error) {
 			&i.Path,
 			&i.Name,
 			&i.CreatedAt,
+			&i.Locked,
+			&i.PasswordHash,
 		); err != nil {
 			return nil, err
 		}
@@ -119,7 +170,7 @@ func (q *Queries) ListFolders(ctx context.Context) ([]Folder, error) {
 }
 
 const listSubfolders = `-- name: ListSubfolders :many
-SELECT id, path, name, created_at FROM folders WHERE path LIKE ? || '/%' AND path NOT LIKE ? || '/%/%' ORDER BY name
+SELECT id, path, name, created_at, locked, password_hash FROM folders WHERE path LIKE ? || '/%' AND path NOT LIKE ? || '/%/%' ORDER BY name
 `
 
 type ListSubfoldersParams struct {
@@ -128,7 +179,7 @@ type ListSubfoldersParams struct {
 }
 
 func (q *Queries) ListSubfolders(ctx context.Context, arg ListSubfoldersParams) ([]Folder, error) {
-	rows, err := q.db.QueryContext(ctx, listSubfolders, arg.Column1, arg.Column2)
+	rows, err := q.query(ctx, q.listSubfoldersStmt, listSubfolders, arg.Column1, arg.Column2)
 	if err != nil {
 		return nil, err
 	}
@@ -141,6 +192,8 @@ func (q *Queries) ListSubfolders(ctx context.Context, arg ListSubfoldersParams)
 			&i.Path,
 			&i.Name,
 			&i.CreatedAt,
+			&i.Locked,
+			&i.PasswordHash,
 		); err != nil {
 			return nil, err
 		}
@@ -154,3 +207,32 @@ func (q *Queries) ListSubfolders(ctx context.Context, arg ListSubfoldersParams)
 	}
 	return items, nil
 }
+
+const updateFolderLock = `-- name: UpdateFolderLock :exec
+UPDATE folders SET locked = ?, password_hash = ? WHERE id = ?
+`
+
+type UpdateFolderLockParams struct {
+	Locked       int64   `json:"locked"`
+	PasswordHash *string `json:"password_hash"`
+	ID           string  `json:"id"`
+}
+
+func (q *Queries) UpdateFolderLock(ctx context.Context, arg UpdateFolderLockParams) error {
+	_, err := q.exec(ctx, q.updateFolderLockStmt, updateFolderLock, arg.Locked, arg.PasswordHash, arg.ID)
+	return err
+}
+
+const updateFolderPasswordHash = `-- name: UpdateFolderPasswordHash :exec
+UPDATE folders SET password_hash = ? WHERE id = ?
+`
+
+type UpdateFolderPasswordHashParams struct {
+	PasswordHash *string `json:"password_hash"`
+	ID           string  `json:"id"`
+}
+
+func (q *Queries) UpdateFolderPasswordHash(ctx context.Context, arg UpdateFolderPasswordHashParams) error {
+	_, err := q.exec(ctx, q.updateFolderPasswordHashStmt, updateFolderPasswordHash, arg.PasswordHash, arg.ID)
+	return err
+}
@@ -8,6 +8,38 @@ import (
 	"time"
 )
 
+type AccessLog struct {
+	ID         int64     `json:"id"`
+	Path       string    `json:"path"`
+	Status     int64     `json:"status"`
+	IpAddress  *string   `json:"ip_address"`
+	UserAgent  *string   `json:"user_agent"`
+	Bytes      int64     `json:"bytes"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type AdminToken struct {
+	ID         string     `json:"id"`
+	Label      string     `json:"label"`
+	TokenHash  string     `json:"token_hash"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ApiToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"token_hash"`
+	ReadOnly   int64      `json:"read_only"`
+	Paths      *string    `json:"paths"`
+	Endpoints  *string    `json:"endpoints"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIp *string    `json:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
 type AuditLog struct {
 	ID         int64     `json:"id"`
 	Action     string    `json:"action"`
@@ -18,21 +50,67 @@ type AuditLog struct {
 	IpAddress  *string   `json:"ip_address"`
 	UserAgent  *string   `json:"user_agent"`
 	CreatedAt  time.Time `json:"created_at"`
+	Actor      *string   `json:"actor"`
+	RequestID  *string   `json:"request_id"`
 }
 
 type AuthToken struct {
-	Token     string    `json:"token"`
-	ScriptID  string    `json:"script_id"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
-	IpAddress *string   `json:"ip_address"`
-	UserAgent *string   `json:"user_agent"`
+	Token      string    `json:"token"`
+	ScriptID   string    `json:"script_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	IpAddress  *string   `json:"ip_address"`
+	UserAgent  *string   `json:"user_agent"`
+	FolderPath *string   `json:"folder_path"`
+}
+
+type Blob struct {
+	Key  string `json:"key"`
+	Data []byte `json:"data"`
+}
+
+type Canary struct {
+	ScriptID      string    `json:"script_id"`
+	StableVersion int64     `json:"stable_version"`
+	CanaryVersion int64     `json:"canary_version"`
+	Percent       int64     `json:"percent"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type FetchBreakdown struct {
+	ScriptID string `json:"script_id"`
+	Day      string `json:"day"`
+	Kind     string `json:"kind"`
+	Value    string `json:"value"`
+	Fetches  int64  `json:"fetches"`
+}
+
+type FetchConsumer struct {
+	ScriptID string `json:"script_id"`
+	Day      string `json:"day"`
+	Consumer string `json:"consumer"`
 }
 
 type Folder struct {
+	ID           string    `json:"id"`
+	Path         string    `json:"path"`
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"created_at"`
+	Locked       int64     `json:"locked"`
+	PasswordHash *string   `json:"password_hash"`
+}
+
+type GithubSyncFile struct {
+	Path      string    `json:"path"`
+	BlobSha   string    `json:"blob_sha"`
+	CommitSha string    `json:"commit_sha"`
+	SyncedAt  time.Time `json:"synced_at"`
+}
+
+type Honeypot struct {
 	ID        string    `json:"id"`
 	Path      string    `json:"path"`
-	Name      string    `json:"name"`
+	Note      *string   `json:"note"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -42,27 +120,170 @@ type Migration struct {
 	ExecutedAt      time.Time `json:"executed_at"`
 }
 
+type Notice struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ScanRule struct {
+	ID        string    `json:"id"`
+	Pattern   string    `json:"pattern"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Script struct {
-	ID           string    `json:"id"`
-	Path         string    `json:"path"`
-	Name         string    `json:"name"`
-	Content      string    `json:"content"`
-	Description  *string   `json:"description"`
-	Tags         *string   `json:"tags"`
-	Locked       int64     `json:"locked"`
-	PasswordHash *string   `json:"password_hash"`
-	DangerLevel  *int64    `json:"danger_level"`
-	Requires     *string   `json:"requires"`
-	Examples     *string   `json:"examples"`
-	Favorite     int64     `json:"favorite"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                  string     `json:"id"`
+	Path                string     `json:"path"`
+	Name                string     `json:"name"`
+	Content             string     `json:"content"`
+	Description         *string    `json:"description"`
+	Tags                *string    `json:"tags"`
+	Locked              int64      `json:"locked"`
+	PasswordHash        *string    `json:"password_hash"`
+	DangerLevel         *int64     `json:"danger_level"`
+	Requires            *string    `json:"requires"`
+	Examples            *string    `json:"examples"`
+	Favorite            int64      `json:"favorite"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	Deprecated          int64      `json:"deprecated"`
+	ReplacementPath     *string    `json:"replacement_path"`
+	SunsetAt            *time.Time `json:"sunset_at"`
+	Disabled            int64      `json:"disabled"`
+	DisabledReason      *string    `json:"disabled_reason"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	AvailableFrom       *time.Time `json:"available_from"`
+	AvailableUntil      *time.Time `json:"available_until"`
+	ExpiresAt           *time.Time `json:"expires_at"`
+	Archived            int64      `json:"archived"`
+	Unlisted            int64      `json:"unlisted"`
+	Private             int64      `json:"private"`
+	UnlockTtl           *int64     `json:"unlock_ttl"`
+	AllowCountries      *string    `json:"allow_countries"`
+	DenyCountries       *string    `json:"deny_countries"`
+	SourceUrl           *string    `json:"source_url"`
+	SourceTtl           *int64     `json:"source_ttl"`
+	SourceSha256        *string    `json:"source_sha256"`
+	SourceFetchedAt     *time.Time `json:"source_fetched_at"`
+	ContentRef          *string    `json:"content_ref"`
+	MaxDownloadsPerHour *int64     `json:"max_downloads_per_hour"`
+	MaxDownloadsPerDay  *int64     `json:"max_downloads_per_day"`
+	ContentSize         *int64     `json:"content_size"`
 }
 
-type ScriptVersion struct {
-	ID        int64     `json:"id"`
+type ScriptDailyStat struct {
+	ScriptID string `json:"script_id"`
+	Day      string `json:"day"`
+	Fetches  int64  `json:"fetches"`
+}
+
+type ScriptRun struct {
+	ID         int64     `json:"id"`
+	ScriptID   string    `json:"script_id"`
+	ExitCode   int64     `json:"exit_code"`
+	DurationMs *int64    `json:"duration_ms"`
+	Version    *int64    `json:"version"`
+	Message    *string   `json:"message"`
+	IpAddress  *string   `json:"ip_address"`
+	UserAgent  *string   `json:"user_agent"`
+	Day        string    `json:"day"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ScriptStat struct {
+	ScriptID      string     `json:"script_id"`
+	Fetches       int64      `json:"fetches"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+}
+
+type ScriptTemplate struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description"`
+	Content     string    `json:"content"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ScriptVariant struct {
+	ID        string    `json:"id"`
 	ScriptID  string    `json:"script_id"`
+	Name      string    `json:"name"`
 	Content   string    `json:"content"`
-	Version   int64     `json:"version"`
+	MatchCidr *string   `json:"match_cidr"`
+	Percent   int64     `json:"percent"`
+	Priority  int64     `json:"priority"`
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ScriptVersion struct {
+	ID         int64     `json:"id"`
+	ScriptID   string    `json:"script_id"`
+	Content    string    `json:"content"`
+	Version    int64     `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	Serves     int64     `json:"serves"`
+	Message    *string   `json:"message"`
+	ContentRef *string   `json:"content_ref"`
+}
+
+type Session struct {
+	ID         string    `json:"id"`
+	CsrfToken  string    `json:"csrf_token"`
+	IpAddress  *string   `json:"ip_address"`
+	UserAgent  *string   `json:"user_agent"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	Role       string    `json:"role"`
+	Subject    *string   `json:"subject"`
+}
+
+type ShareLink struct {
+	Token      string     `json:"token"`
+	ScriptID   string     `json:"script_id"`
+	MaxUses    *int64     `json:"max_uses"`
+	UseCount   int64      `json:"use_count"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Revoked    int64      `json:"revoked"`
+	Note       *string    `json:"note"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type VariantStat struct {
+	ScriptID     string     `json:"script_id"`
+	Variant      string     `json:"variant"`
+	Serves       int64      `json:"serves"`
+	LastServedAt *time.Time `json:"last_served_at"`
+}
+
+type Webhook struct {
+	ID        string    `json:"id"`
+	Url       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    string    `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+	Kind      string    `json:"kind"`
+}
+
+type WebhookDelivery struct {
+	ID            int64      `json:"id"`
+	DeliveryID    string     `json:"delivery_id"`
+	WebhookID     string     `json:"webhook_id"`
+	Event         string     `json:"event"`
+	Payload       string     `json:"payload"`
+	Attempt       int64      `json:"attempt"`
+	StatusCode    *int64     `json:"status_code"`
+	Response      *string    `json:"response"`
+	Error         *string    `json:"error"`
+	DurationMs    int64      `json:"duration_ms"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	Dead          int64      `json:"dead"`
+	CreatedAt     time.Time  `json:"created_at"`
 }
@@ -10,6 +10,39 @@ import (
 	"time"
 )
 
+const countScriptContentRefs = `-- name: CountScriptContentRefs :one
+SELECT COUNT(*) FROM scripts WHERE content_ref = ?
+`
+
+func (q *Queries) CountScriptContentRefs(ctx context.Context, contentRef *string) (int64, error) {
+	row := q.queryRow(ctx, q.countScriptContentRefsStmt, countScriptContentRefs, contentRef)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countScripts = `-- name: CountScripts :one
+SELECT COUNT(*) FROM scripts
+`
+
+func (q *Queries) CountScripts(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.countScriptsStmt, countScripts)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countStoredContent = `-- name: CountStoredContent :one
+SELECT COUNT(*) FROM scripts WHERE content_ref IS NOT NULL
+`
+
+func (q *Queries) CountStoredContent(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.countStoredContentStmt, countStoredContent)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createScript = `-- name: CreateScript :exec
 INSERT INTO scripts (id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, created_at, updated_at)
 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
@@ -32,7 +65,7 @@ type CreateScriptParams struct {
 }
 
 func (q *Queries) CreateScript(ctx context.Context, arg CreateScriptParams) error {
-	_, err := q.db.ExecContext(ctx, createScript,
+	_, err := q.exec(ctx, q.createScriptStmt, createScript,
 		arg.ID,
 		arg.Path,
 		arg.Name,
@@ -55,16 +88,35 @@ DELETE FROM scripts WHERE id = ?
 `
 
 func (q *Queries) DeleteScript(ctx context.Context, id string) error {
-	_, err := q.db.ExecContext(ctx, deleteScript, id)
+	_, err := q.exec(ctx, q.deleteScriptStmt, deleteScript, id)
 	return err
 }
 
+const getContentUsage = `-- name: GetContentUsage :one
+SELECT CAST(COUNT(*) AS INTEGER) AS scripts, CAST(COALESCE(SUM(content_size), 0) AS INTEGER) AS content_bytes FROM scripts
+`
+
+type GetContentUsageRow struct {
+	Scripts      int64 `json:"scripts"`
+	ContentBytes int64 `json:"content_bytes"`
+}
+
+func (q *Queries) GetContentUsage(ctx context.Context) (GetContentUsageRow, error) {
+	row := q.queryRow(ctx, q.getContentUsageStmt, getContentUsage)
+	var i GetContentUsageRow
+	err := row.Scan(
+		&i.Scripts,
+		&i.ContentBytes,
+	)
+	return i, err
+}
+
 const getScript = `-- name: GetScript :one
-SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at FROM scripts WHERE id = ?
+SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at, deprecated, replacement_path, sunset_at, disabled, disabled_reason, disabled_at, available_from, available_until, expires_at, archived, unlisted, private, unlock_ttl, allow_countries, deny_countries, source_url, source_ttl, source_sha256, source_fetched_at, content_ref, max_downloads_per_hour, max_downloads_per_day, content_size FROM scripts WHERE id = ?
 `
 
 func (q *Queries) GetScript(ctx context.Context, id string) (Script, error) {
-	row := q.db.QueryRowContext(ctx, getScript, id)
+	row := q.queryRow(ctx, q.getScriptStmt, getScript, id)
 	var i Script
 	err := row.Scan(
 		&i.ID,
@@ -81,16 +133,39 @@ func (q *Queries) GetScript(ctx context.Context, id string) (Script, error) {
 		&i.Favorite,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Deprecated,
+		&i.ReplacementPath,
+		&i.SunsetAt,
+		&i.Disabled,
+		&i.DisabledReason,
+		&i.DisabledAt,
+		&i.AvailableFrom,
+		&i.AvailableUntil,
+		&i.ExpiresAt,
+		&i.Archived,
+		&i.Unlisted,
+		&i.Private,
+		&i.UnlockTtl,
+		&i.AllowCountries,
+		&i.DenyCountries,
+		&i.SourceUrl,
+		&i.SourceTtl,
+		&i.SourceSha256,
+		&i.SourceFetchedAt,
+		&i.ContentRef,
+		&i.MaxDownloadsPerHour,
+		&i.MaxDownloadsPerDay,
+		&i.ContentSize,
 	)
 	return i, err
 }
 
 const getScriptByPath = `-- name: GetScriptByPath :one
-SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at FROM scripts WHERE path = ?
+SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at, deprecated, replacement_path, sunset_at, disabled, disabled_reason, disabled_at, available_from, available_until, expires_at, archived, unlisted, private, unlock_ttl, allow_countries, deny_countries, source_url, source_ttl, source_sha256, source_fetched_at, content_ref, max_downloads_per_hour, max_downloads_per_day, content_size FROM scripts WHERE path = ?
 `
 
 func (q *Queries) GetScriptByPath(ctx context.Context, path string) (Script, error) {
-	row := q.db.QueryRowContext(ctx, getScriptByPath, path)
+	row := q.queryRow(ctx, q.getScriptByPathStmt, getScriptByPath, path)
 	var i Script
 	err := row.Scan(
 		&i.ID,
@@ -107,16 +182,39 @@ func (q *Queries) GetScriptByPath(ctx context.Context, path string) (Script, err
 		&i.Favorite,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Deprecated,
+		&i.ReplacementPath,
+		&i.SunsetAt,
+		&i.Disabled,
+		&i.DisabledReason,
+		&i.DisabledAt,
+		&i.AvailableFrom,
+		&i.AvailableUntil,
+		&i.ExpiresAt,
+		&i.Archived,
+		&i.Unlisted,
+		&i.Private,
+		&i.UnlockTtl,
+		&i.AllowCountries,
+		&i.DenyCountries,
+		&i.SourceUrl,
+		&i.SourceTtl,
+		&i.SourceSha256,
+		&i.SourceFetchedAt,
+		&i.ContentRef,
+		&i.MaxDownloadsPerHour,
+		&i.MaxDownloadsPerDay,
+		&i.ContentSize,
 	)
 	return i, err
 }
 
 const listFavorites = `-- name: ListFavorites :many
-SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at FROM scripts WHERE favorite = 1 ORDER BY path
+SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at, deprecated, replacement_path, sunset_at, disabled, disabled_reason, disabled_at, available_from, available_until, expires_at, archived, unlisted, private, unlock_ttl, allow_countries, deny_countries, source_url, source_ttl, source_sha256, source_fetched_at, content_ref, max_downloads_per_hour, max_downloads_per_day, content_size FROM scripts WHERE favorite = 1 ORDER BY path
 `
 
 func (q *Queries) ListFavorites(ctx context.Context) ([]Script, error) {
-	rows, err := q.db.QueryContext(ctx, listFavorites)
+	rows, err := q.query(ctx, q.listFavoritesStmt, listFavorites)
 	if err != nil {
 		return nil, err
 	}
@@ -139,6 +237,64 @@ func (q *Queries) ListFavorites(ctx context.Context) ([]Script, error) {
 			&i.Favorite,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Deprecated,
+			&i.ReplacementPath,
+			&i.SunsetAt,
+			&i.Disabled,
+			&i.DisabledReason,
+			&i.DisabledAt,
+			&i.AvailableFrom,
+			&i.AvailableUntil,
+			&i.ExpiresAt,
+			&i.Archived,
+			&i.Unlisted,
+			&i.Private,
+			&i.UnlockTtl,
+			&i.AllowCountries,
+			&i.DenyCountries,
+			&i.SourceUrl,
+			&i.SourceTtl,
+			&i.SourceSha256,
+			&i.SourceFetchedAt,
+			&i.ContentRef,
+			&i.MaxDownloadsPerHour,
+			&i.MaxDownloadsPerDay,
+			&i.ContentSize,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlainScriptContentRefs = `-- name: ListPlainScriptContentRefs :many
+SELECT id, content_ref FROM scripts WHERE content_ref LIKE 'content/%'
+`
+
+type ListPlainScriptContentRefsRow struct {
+	ID         string  `json:"id"`
+	ContentRef *string `json:"content_ref"`
+}
+
+func (q *Queries) ListPlainScriptContentRefs(ctx context.Context) ([]ListPlainScriptContentRefsRow, error) {
+	rows, err := q.query(ctx, q.listPlainScriptContentRefsStmt, listPlainScriptContentRefs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPlainScriptContentRefsRow{}
+	for rows.Next() {
+		var i ListPlainScriptContentRefsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContentRef,
 		); err != nil {
 			return nil, err
 		}
@@ -154,11 +310,11 @@ func (q *Queries) ListFavorites(ctx context.Context) ([]Script, error) {
 }
 
 const listRecentlyUpdated = `-- name: ListRecentlyUpdated :many
-SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at FROM scripts ORDER BY updated_at DESC LIMIT ?
+SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at, deprecated, replacement_path, sunset_at, disabled, disabled_reason, disabled_at, available_from, available_until, expires_at, archived, unlisted, private, unlock_ttl, allow_countries, deny_countries, source_url, source_ttl, source_sha256, source_fetched_at, content_ref, max_downloads_per_hour, max_downloads_per_day, content_size FROM scripts ORDER BY updated_at DESC LIMIT ?
 `
 
 func (q *Queries) ListRecentlyUpdated(ctx context.Context, limit int64) ([]Script, error) {
-	rows, err := q.db.QueryContext(ctx, listRecentlyUpdated, limit)
+	rows, err := q.query(ctx, q.listRecentlyUpdatedStmt, listRecentlyUpdated, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -181,6 +337,135 @@ func (q *Queries) ListRecentlyUpdated(ctx context.Context, limit int64) ([]Scrip
 			&i.Favorite,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Deprecated,
+			&i.ReplacementPath,
+			&i.SunsetAt,
+			&i.Disabled,
+			&i.DisabledReason,
+			&i.DisabledAt,
+			&i.AvailableFrom,
+			&i.AvailableUntil,
+			&i.ExpiresAt,
+			&i.Archived,
+			&i.Unlisted,
+			&i.Private,
+			&i.UnlockTtl,
+			&i.AllowCountries,
+			&i.DenyCountries,
+			&i.SourceUrl,
+			&i.SourceTtl,
+			&i.SourceSha256,
+			&i.SourceFetchedAt,
+			&i.ContentRef,
+			&i.MaxDownloadsPerHour,
+			&i.MaxDownloadsPerDay,
+			&i.ContentSize,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScriptMetadata = `-- name: ListScriptMetadata :many
+SELECT id, path, name, CAST('' AS TEXT) AS content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at, deprecated, replacement_path, sunset_at, disabled, disabled_reason, disabled_at, available_from, available_until, expires_at, archived, unlisted, private, unlock_ttl, allow_countries, deny_countries, source_url, source_ttl, source_sha256, source_fetched_at, content_ref, max_downloads_per_hour, max_downloads_per_day, content_size
+FROM scripts ORDER BY path
+`
+
+type ListScriptMetadataRow struct {
+	ID                  string     `json:"id"`
+	Path                string     `json:"path"`
+	Name                string     `json:"name"`
+	Content             string     `json:"content"`
+	Description         *string    `json:"description"`
+	Tags                *string    `json:"tags"`
+	Locked              int64      `json:"locked"`
+	PasswordHash        *string    `json:"password_hash"`
+	DangerLevel         *int64     `json:"danger_level"`
+	Requires            *string    `json:"requires"`
+	Examples            *string    `json:"examples"`
+	Favorite            int64      `json:"favorite"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	Deprecated          int64      `json:"deprecated"`
+	ReplacementPath     *string    `json:"replacement_path"`
+	SunsetAt            *time.Time `json:"sunset_at"`
+	Disabled            int64      `json:"disabled"`
+	DisabledReason      *string    `json:"disabled_reason"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	AvailableFrom       *time.Time `json:"available_from"`
+	AvailableUntil      *time.Time `json:"available_until"`
+	ExpiresAt           *time.Time `json:"expires_at"`
+	Archived            int64      `json:"archived"`
+	Unlisted            int64      `json:"unlisted"`
+	Private             int64      `json:"private"`
+	UnlockTtl           *int64     `json:"unlock_ttl"`
+	AllowCountries      *string    `json:"allow_countries"`
+	DenyCountries       *string    `json:"deny_countries"`
+	SourceUrl           *string    `json:"source_url"`
+	SourceTtl           *int64     `json:"source_ttl"`
+	SourceSha256        *string    `json:"source_sha256"`
+	SourceFetchedAt     *time.Time `json:"source_fetched_at"`
+	ContentRef          *string    `json:"content_ref"`
+	MaxDownloadsPerHour *int64     `json:"max_downloads_per_hour"`
+	MaxDownloadsPerDay  *int64     `json:"max_downloads_per_day"`
+	ContentSize         *int64     `json:"content_size"`
+}
+
+func (q *Queries) ListScriptMetadata(ctx context.Context) ([]ListScriptMetadataRow, error) {
+	rows, err := q.query(ctx, q.listScriptMetadataStmt, listScriptMetadata)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListScriptMetadataRow{}
+	for rows.Next() {
+		var i ListScriptMetadataRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Path,
+			&i.Name,
+			&i.Content,
+			&i.Description,
+			&i.Tags,
+			&i.Locked,
+			&i.PasswordHash,
+			&i.DangerLevel,
+			&i.Requires,
+			&i.Examples,
+			&i.Favorite,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Deprecated,
+			&i.ReplacementPath,
+			&i.SunsetAt,
+			&i.Disabled,
+			&i.DisabledReason,
+			&i.DisabledAt,
+			&i.AvailableFrom,
+			&i.AvailableUntil,
+			&i.ExpiresAt,
+			&i.Archived,
+			&i.Unlisted,
+			&i.Private,
+			&i.UnlockTtl,
+			&i.AllowCountries,
+			&i.DenyCountries,
+			&i.SourceUrl,
+			&i.SourceTtl,
+			&i.SourceSha256,
+			&i.SourceFetchedAt,
+			&i.ContentRef,
+			&i.MaxDownloadsPerHour,
+			&i.MaxDownloadsPerDay,
+			&i.ContentSize,
 		); err != nil {
 			return nil, err
 		}
@@ -196,11 +481,11 @@ func (q *Queries) ListRecentlyUpdated(ctx context.Context, limit int64) ([]Scrip
 }
 
 const listScripts = `-- name: ListScripts :many
-SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at FROM scripts ORDER BY path
+SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at, deprecated, replacement_path, sunset_at, disabled, disabled_reason, disabled_at, available_from, available_until, expires_at, archived, unlisted, private, unlock_ttl, allow_countries, deny_countries, source_url, source_ttl, source_sha256, source_fetched_at, content_ref, max_downloads_per_hour, max_downloads_per_day, content_size FROM scripts ORDER BY path
 `
 
 func (q *Queries) ListScripts(ctx context.Context) ([]Script, error) {
-	rows, err := q.db.QueryContext(ctx, listScripts)
+	rows, err := q.query(ctx, q.listScriptsStmt, listScripts)
 	if err != nil {
 		return nil, err
 	}
@@ -223,6 +508,29 @@ func (q *Queries) ListScripts(ctx context.Context) ([]Script, error) {
 			&i.Favorite,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Deprecated,
+			&i.ReplacementPath,
+			&i.SunsetAt,
+			&i.Disabled,
+			&i.DisabledReason,
+			&i.DisabledAt,
+			&i.AvailableFrom,
+			&i.AvailableUntil,
+			&i.ExpiresAt,
+			&i.Archived,
+			&i.Unlisted,
+			&i.Private,
+			&i.UnlockTtl,
+			&i.AllowCountries,
+			&i.DenyCountries,
+			&i.SourceUrl,
+			&i.SourceTtl,
+			&i.SourceSha256,
+			&i.SourceFetchedAt,
+			&i.ContentRef,
+			&i.MaxDownloadsPerHour,
+			&i.MaxDownloadsPerDay,
+			&i.ContentSize,
 		); err != nil {
 			return nil, err
 		}
@@ -238,7 +546,7 @@ func (q *Queries) ListScripts(ctx context.Context) ([]Script, error) {
 }
 
 const listScriptsByFolder = `-- name: ListScriptsByFolder :many
-SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at FROM scripts WHERE path LIKE ? || '/%' AND path NOT LIKE ? || '/%/%' ORDER BY name
+SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at, deprecated, replacement_path, sunset_at, disabled, disabled_reason, disabled_at, available_from, available_until, expires_at, archived, unlisted, private, unlock_ttl, allow_countries, deny_countries, source_url, source_ttl, source_sha256, source_fetched_at, content_ref, max_downloads_per_hour, max_downloads_per_day, content_size FROM scripts WHERE path LIKE ? || '/%' AND path NOT LIKE ? || '/%/%' ORDER BY name
 `
 
 type ListScriptsByFolderParams struct {
@@ -247,7 +555,7 @@ type ListScriptsByFolderParams struct {
 }
 
 func (q *Queries) ListScriptsByFolder(ctx context.Context, arg ListScriptsByFolderParams) ([]Script, error) {
-	rows, err := q.db.QueryContext(ctx, listScriptsByFolder, arg.Column1, arg.Column2)
+	rows, err := q.query(ctx, q.listScriptsByFolderStmt, listScriptsByFolder, arg.Column1, arg.Column2)
 	if err != nil {
 		return nil, err
 	}
@@ -270,6 +578,29 @@ func (q *Queries) ListScriptsByFolder(ctx context.Context, arg ListScriptsByFold
 			&i.Favorite,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Deprecated,
+			&i.ReplacementPath,
+			&i.SunsetAt,
+			&i.Disabled,
+			&i.DisabledReason,
+			&i.DisabledAt,
+			&i.AvailableFrom,
+			&i.AvailableUntil,
+			&i.ExpiresAt,
+			&i.Archived,
+			&i.Unlisted,
+			&i.Private,
+			&i.UnlockTtl,
+			&i.AllowCountries,
+			&i.DenyCountries,
+			&i.SourceUrl,
+			&i.SourceTtl,
+			&i.SourceSha256,
+			&i.SourceFetchedAt,
+			&i.ContentRef,
+			&i.MaxDownloadsPerHour,
+			&i.MaxDownloadsPerDay,
+			&i.ContentSize,
 		); err != nil {
 			return nil, err
 		}
@@ -284,24 +615,162 @@ func (q *Queries) ListScriptsByFolder(ctx context.Context, arg ListScriptsByFold
 	return items, nil
 }
 
-const searchScripts = `-- name: SearchScripts :many
-SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at FROM scripts 
-WHERE name LIKE '%' || ? || '%' 
+const listScriptsReferencing = `-- name: ListScriptsReferencing :many
+SELECT id, path, name, content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at, deprecated, replacement_path, sunset_at, disabled, disabled_reason, disabled_at, available_from, available_until, expires_at, archived, unlisted, private, unlock_ttl, allow_countries, deny_countries, source_url, source_ttl, source_sha256, source_fetched_at, content_ref, max_downloads_per_hour, max_downloads_per_day, content_size FROM scripts WHERE instr(content, ?) > 0 AND id != ? ORDER BY path
+`
+
+type ListScriptsReferencingParams struct {
+	Column1 interface{} `json:"column_1"`
+	ID      string      `json:"id"`
+}
+
+func (q *Queries) ListScriptsReferencing(ctx context.Context, arg ListScriptsReferencingParams) ([]Script, error) {
+	rows, err := q.query(ctx, q.listScriptsReferencingStmt, listScriptsReferencing, arg.Column1, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Script{}
+	for rows.Next() {
+		var i Script
+		if err := rows.Scan(
+			&i.ID,
+			&i.Path,
+			&i.Name,
+			&i.Content,
+			&i.Description,
+			&i.Tags,
+			&i.Locked,
+			&i.PasswordHash,
+			&i.DangerLevel,
+			&i.Requires,
+			&i.Examples,
+			&i.Favorite,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Deprecated,
+			&i.ReplacementPath,
+			&i.SunsetAt,
+			&i.Disabled,
+			&i.DisabledReason,
+			&i.DisabledAt,
+			&i.AvailableFrom,
+			&i.AvailableUntil,
+			&i.ExpiresAt,
+			&i.Archived,
+			&i.Unlisted,
+			&i.Private,
+			&i.UnlockTtl,
+			&i.AllowCountries,
+			&i.DenyCountries,
+			&i.SourceUrl,
+			&i.SourceTtl,
+			&i.SourceSha256,
+			&i.SourceFetchedAt,
+			&i.ContentRef,
+			&i.MaxDownloadsPerHour,
+			&i.MaxDownloadsPerDay,
+			&i.ContentSize,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnsizedScriptIDs = `-- name: ListUnsizedScriptIDs :many
+SELECT id FROM scripts WHERE content_size IS NULL
+`
+
+func (q *Queries) ListUnsizedScriptIDs(ctx context.Context) ([]string, error) {
+	rows, err := q.query(ctx, q.listUnsizedScriptIDsStmt, listUnsizedScriptIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchScriptMetadata = `-- name: SearchScriptMetadata :many
+SELECT id, path, name, CAST('' AS TEXT) AS content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at, deprecated, replacement_path, sunset_at, disabled, disabled_reason, disabled_at, available_from, available_until, expires_at, archived, unlisted, private, unlock_ttl, allow_countries, deny_countries, source_url, source_ttl, source_sha256, source_fetched_at, content_ref, max_downloads_per_hour, max_downloads_per_day, content_size
+FROM scripts
+WHERE name LIKE '%' || ? || '%'
    OR path LIKE '%' || ? || '%'
    OR description LIKE '%' || ? || '%'
    OR tags LIKE '%' || ? || '%'
 ORDER BY path
 `
 
-type SearchScriptsParams struct {
+type SearchScriptMetadataParams struct {
 	Column1 *string `json:"column_1"`
 	Column2 *string `json:"column_2"`
 	Column3 *string `json:"column_3"`
 	Column4 *string `json:"column_4"`
 }
 
-func (q *Queries) SearchScripts(ctx context.Context, arg SearchScriptsParams) ([]Script, error) {
-	rows, err := q.db.QueryContext(ctx, searchScripts,
+type SearchScriptMetadataRow struct {
+	ID                  string     `json:"id"`
+	Path                string     `json:"path"`
+	Name                string     `json:"name"`
+	Content             string     `json:"content"`
+	Description         *string    `json:"description"`
+	Tags                *string    `json:"tags"`
+	Locked              int64      `json:"locked"`
+	PasswordHash        *string    `json:"password_hash"`
+	DangerLevel         *int64     `json:"danger_level"`
+	Requires            *string    `json:"requires"`
+	Examples            *string    `json:"examples"`
+	Favorite            int64      `json:"favorite"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	Deprecated          int64      `json:"deprecated"`
+	ReplacementPath     *string    `json:"replacement_path"`
+	SunsetAt            *time.Time `json:"sunset_at"`
+	Disabled            int64      `json:"disabled"`
+	DisabledReason      *string    `json:"disabled_reason"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	AvailableFrom       *time.Time `json:"available_from"`
+	AvailableUntil      *time.Time `json:"available_until"`
+	ExpiresAt           *time.Time `json:"expires_at"`
+	Archived            int64      `json:"archived"`
+	Unlisted            int64      `json:"unlisted"`
+	Private             int64      `json:"private"`
+	UnlockTtl           *int64     `json:"unlock_ttl"`
+	AllowCountries      *string    `json:"allow_countries"`
+	DenyCountries       *string    `json:"deny_countries"`
+	SourceUrl           *string    `json:"source_url"`
+	SourceTtl           *int64     `json:"source_ttl"`
+	SourceSha256        *string    `json:"source_sha256"`
+	SourceFetchedAt     *time.Time `json:"source_fetched_at"`
+	ContentRef          *string    `json:"content_ref"`
+	MaxDownloadsPerHour *int64     `json:"max_downloads_per_hour"`
+	MaxDownloadsPerDay  *int64     `json:"max_downloads_per_day"`
+	ContentSize         *int64     `json:"content_size"`
+}
+
+func (q *Queries) SearchScriptMetadata(ctx context.Context, arg SearchScriptMetadataParams) ([]SearchScriptMetadataRow, error) {
+	rows, err := q.query(ctx, q.searchScriptMetadataStmt, searchScriptMetadata,
 		arg.Column1,
 		arg.Column2,
 		arg.Column3,
@@ -311,9 +780,9 @@ func (q *Queries) SearchScripts(ctx context.Context, arg SearchScriptsParams) ([
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Script{}
+	items := []SearchScriptMetadataRow{}
 	for rows.Next() {
-		var i Script
+		var i SearchScriptMetadataRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Path,
@@ -329,6 +798,29 @@ func (q *Queries) SearchScripts(ctx context.Context, arg SearchScriptsParams) ([
 			&i.Favorite,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Deprecated,
+			&i.ReplacementPath,
+			&i.SunsetAt,
+			&i.Disabled,
+			&i.DisabledReason,
+			&i.DisabledAt,
+			&i.AvailableFrom,
+			&i.AvailableUntil,
+			&i.ExpiresAt,
+			&i.Archived,
+			&i.Unlisted,
+			&i.Private,
+			&i.UnlockTtl,
+			&i.AllowCountries,
+			&i.DenyCountries,
+			&i.SourceUrl,
+			&i.SourceTtl,
+			&i.SourceSha256,
+			&i.SourceFetchedAt,
+			&i.ContentRef,
+			&i.MaxDownloadsPerHour,
+			&i.MaxDownloadsPerDay,
+			&i.ContentSize,
 		); err != nil {
 			return nil, err
 		}
@@ -353,7 +845,84 @@ type SetFavoriteParams struct {
 }
 
 func (q *Queries) SetFavorite(ctx context.Context, arg SetFavoriteParams) error {
-	_, err := q.db.ExecContext(ctx, setFavorite, arg.Favorite, arg.ID)
+	_, err := q.exec(ctx, q.setFavoriteStmt, setFavorite, arg.Favorite, arg.ID)
+	return err
+}
+
+const setScriptArchived = `-- name: SetScriptArchived :exec
+UPDATE scripts SET archived = ? WHERE id = ?
+`
+
+type SetScriptArchivedParams struct {
+	Archived int64  `json:"archived"`
+	ID       string `json:"id"`
+}
+
+func (q *Queries) SetScriptArchived(ctx context.Context, arg SetScriptArchivedParams) error {
+	_, err := q.exec(ctx, q.setScriptArchivedStmt, setScriptArchived, arg.Archived, arg.ID)
+	return err
+}
+
+const setScriptContentRef = `-- name: SetScriptContentRef :exec
+UPDATE scripts SET content = '', content_ref = ? WHERE id = ?
+`
+
+type SetScriptContentRefParams struct {
+	ContentRef *string `json:"content_ref"`
+	ID         string  `json:"id"`
+}
+
+func (q *Queries) SetScriptContentRef(ctx context.Context, arg SetScriptContentRefParams) error {
+	_, err := q.exec(ctx, q.setScriptContentRefStmt, setScriptContentRef, arg.ContentRef, arg.ID)
+	return err
+}
+
+const setScriptContentSize = `-- name: SetScriptContentSize :exec
+UPDATE scripts SET content_size = ? WHERE id = ?
+`
+
+type SetScriptContentSizeParams struct {
+	ContentSize *int64 `json:"content_size"`
+	ID          string `json:"id"`
+}
+
+func (q *Queries) SetScriptContentSize(ctx context.Context, arg SetScriptContentSizeParams) error {
+	_, err := q.exec(ctx, q.setScriptContentSizeStmt, setScriptContentSize, arg.ContentSize, arg.ID)
+	return err
+}
+
+const setScriptDisabled = `-- name: SetScriptDisabled :exec
+UPDATE scripts SET disabled = ?, disabled_reason = ?, disabled_at = ? WHERE id = ?
+`
+
+type SetScriptDisabledParams struct {
+	Disabled       int64      `json:"disabled"`
+	DisabledReason *string    `json:"disabled_reason"`
+	DisabledAt     *time.Time `json:"disabled_at"`
+	ID             string     `json:"id"`
+}
+
+func (q *Queries) SetScriptDisabled(ctx context.Context, arg SetScriptDisabledParams) error {
+	_, err := q.exec(ctx, q.setScriptDisabledStmt, setScriptDisabled,
+		arg.Disabled,
+		arg.DisabledReason,
+		arg.DisabledAt,
+		arg.ID,
+	)
+	return err
+}
+
+const setScriptSourceFetched = `-- name: SetScriptSourceFetched :exec
+UPDATE scripts SET source_fetched_at = ? WHERE id = ?
+`
+
+type SetScriptSourceFetchedParams struct {
+	SourceFetchedAt *time.Time `json:"source_fetched_at"`
+	ID              string     `json:"id"`
+}
+
+func (q *Queries) SetScriptSourceFetched(ctx context.Context, arg SetScriptSourceFetchedParams) error {
+	_, err := q.exec(ctx, q.setScriptSourceFetchedStmt, setScriptSourceFetched, arg.SourceFetchedAt, arg.ID)
 	return err
 }
 
@@ -389,7 +958,7 @@ type UpdateScriptParams struct {
 }
 
 func (q *Queries) UpdateScript(ctx context.Context, arg UpdateScriptParams) error {
-	_, err := q.db.ExecContext(ctx, updateScript,
+	_, err := q.exec(ctx, q.updateScriptStmt, updateScript,
 		arg.Path,
 		arg.Name,
 		arg.Content,
@@ -406,6 +975,21 @@ func (q *Queries) UpdateScript(ctx context.Context, arg UpdateScriptParams) erro
 	return err
 }
 
+const updateScriptAvailability = `-- name: UpdateScriptAvailability :exec
+UPDATE scripts SET available_from = ?, available_until = ? WHERE id = ?
+`
+
+type UpdateScriptAvailabilityParams struct {
+	AvailableFrom  *time.Time `json:"available_from"`
+	AvailableUntil *time.Time `json:"available_until"`
+	ID             string     `json:"id"`
+}
+
+func (q *Queries) UpdateScriptAvailability(ctx context.Context, arg UpdateScriptAvailabilityParams) error {
+	_, err := q.exec(ctx, q.updateScriptAvailabilityStmt, updateScriptAvailability, arg.AvailableFrom, arg.AvailableUntil, arg.ID)
+	return err
+}
+
 const updateScriptContent = `-- name: UpdateScriptContent :exec
 UPDATE scripts SET content = ?, updated_at = ? WHERE id = ?
 `
@@ -417,7 +1001,58 @@ type UpdateScriptContentParams struct {
 }
 
 func (q *Queries) UpdateScriptContent(ctx context.Context, arg UpdateScriptContentParams) error {
-	_, err := q.db.ExecContext(ctx, updateScriptContent, arg.Content, arg.UpdatedAt, arg.ID)
+	_, err := q.exec(ctx, q.updateScriptContentStmt, updateScriptContent, arg.Content, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const updateScriptCountries = `-- name: UpdateScriptCountries :exec
+UPDATE scripts SET allow_countries = ?, deny_countries = ? WHERE id = ?
+`
+
+type UpdateScriptCountriesParams struct {
+	AllowCountries *string `json:"allow_countries"`
+	DenyCountries  *string `json:"deny_countries"`
+	ID             string  `json:"id"`
+}
+
+func (q *Queries) UpdateScriptCountries(ctx context.Context, arg UpdateScriptCountriesParams) error {
+	_, err := q.exec(ctx, q.updateScriptCountriesStmt, updateScriptCountries, arg.AllowCountries, arg.DenyCountries, arg.ID)
+	return err
+}
+
+const updateScriptDeprecation = `-- name: UpdateScriptDeprecation :exec
+UPDATE scripts SET deprecated = ?, replacement_path = ?, sunset_at = ? WHERE id = ?
+`
+
+type UpdateScriptDeprecationParams struct {
+	Deprecated      int64      `json:"deprecated"`
+	ReplacementPath *string    `json:"replacement_path"`
+	SunsetAt        *time.Time `json:"sunset_at"`
+	ID              string     `json:"id"`
+}
+
+func (q *Queries) UpdateScriptDeprecation(ctx context.Context, arg UpdateScriptDeprecationParams) error {
+	_, err := q.exec(ctx, q.updateScriptDeprecationStmt, updateScriptDeprecation,
+		arg.Deprecated,
+		arg.ReplacementPath,
+		arg.SunsetAt,
+		arg.ID,
+	)
+	return err
+}
+
+const updateScriptExpiration = `-- name: UpdateScriptExpiration :exec
+UPDATE scripts SET expires_at = ?, archived = ? WHERE id = ?
+`
+
+type UpdateScriptExpirationParams struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	Archived  int64      `json:"archived"`
+	ID        string     `json:"id"`
+}
+
+func (q *Queries) UpdateScriptExpiration(ctx context.Context, arg UpdateScriptExpirationParams) error {
+	_, err := q.exec(ctx, q.updateScriptExpirationStmt, updateScriptExpiration, arg.ExpiresAt, arg.Archived, arg.ID)
 	return err
 }
 
@@ -433,7 +1068,7 @@ type UpdateScriptLockParams struct {
 }
 
 func (q *Queries) UpdateScriptLock(ctx context.Context, arg UpdateScriptLockParams) error {
-	_, err := q.db.ExecContext(ctx, updateScriptLock,
+	_, err := q.exec(ctx, q.updateScriptLockStmt, updateScriptLock,
 		arg.Locked,
 		arg.PasswordHash,
 		arg.UpdatedAt,
@@ -441,3 +1076,84 @@ func (q *Queries) UpdateScriptLock(ctx context.Context, arg UpdateScriptLockPara
 	)
 	return err
 }
+
+const updateScriptPasswordHash = `-- name: UpdateScriptPasswordHash :exec
+UPDATE scripts SET password_hash = ? WHERE id = ?
+`
+
+type UpdateScriptPasswordHashParams struct {
+	PasswordHash *string `json:"password_hash"`
+	ID           string  `json:"id"`
+}
+
+func (q *Queries) UpdateScriptPasswordHash(ctx context.Context, arg UpdateScriptPasswordHashParams) error {
+	_, err := q.exec(ctx, q.updateScriptPasswordHashStmt, updateScriptPasswordHash, arg.PasswordHash, arg.ID)
+	return err
+}
+
+const updateScriptQuotas = `-- name: UpdateScriptQuotas :exec
+UPDATE scripts SET max_downloads_per_hour = ?, max_downloads_per_day = ? WHERE id = ?
+`
+
+type UpdateScriptQuotasParams struct {
+	MaxDownloadsPerHour *int64 `json:"max_downloads_per_hour"`
+	MaxDownloadsPerDay  *int64 `json:"max_downloads_per_day"`
+	ID                  string `json:"id"`
+}
+
+func (q *Queries) UpdateScriptQuotas(ctx context.Context, arg UpdateScriptQuotasParams) error {
+	_, err := q.exec(ctx, q.updateScriptQuotasStmt, updateScriptQuotas, arg.MaxDownloadsPerHour, arg.MaxDownloadsPerDay, arg.ID)
+	return err
+}
+
+const updateScriptSource = `-- name: UpdateScriptSource :exec
+UPDATE scripts SET source_url = ?, source_ttl = ?, source_sha256 = ?, source_fetched_at = ? WHERE id = ?
+`
+
+type UpdateScriptSourceParams struct {
+	SourceUrl       *string    `json:"source_url"`
+	SourceTtl       *int64     `json:"source_ttl"`
+	SourceSha256    *string    `json:"source_sha256"`
+	SourceFetchedAt *time.Time `json:"source_fetched_at"`
+	ID              string     `json:"id"`
+}
+
+func (q *Queries) UpdateScriptSource(ctx context.Context, arg UpdateScriptSourceParams) error {
+	_, err := q.exec(ctx, q.updateScriptSourceStmt, updateScriptSource,
+		arg.SourceUrl,
+		arg.SourceTtl,
+		arg.SourceSha256,
+		arg.SourceFetchedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const updateScriptUnlockTTL = `-- name: UpdateScriptUnlockTTL :exec
+UPDATE scripts SET unlock_ttl = ? WHERE id = ?
+`
+
+type UpdateScriptUnlockTTLParams struct {
+	UnlockTtl *int64 `json:"unlock_ttl"`
+	ID        string `json:"id"`
+}
+
+func (q *Queries) UpdateScriptUnlockTTL(ctx context.Context, arg UpdateScriptUnlockTTLParams) error {
+	_, err := q.exec(ctx, q.updateScriptUnlockTTLStmt, updateScriptUnlockTTL, arg.UnlockTtl, arg.ID)
+	return err
+}
+
+const updateScriptVisibility = `-- name: UpdateScriptVisibility :exec
+UPDATE scripts SET unlisted = ?, private = ? WHERE id = ?
+`
+
+type UpdateScriptVisibilityParams struct {
+	Unlisted int64  `json:"unlisted"`
+	Private  int64  `json:"private"`
+	ID       string `json:"id"`
+}
+
+func (q *Queries) UpdateScriptVisibility(ctx context.Context, arg UpdateScriptVisibilityParams) error {
+	_, err := q.exec(ctx, q.updateScriptVisibilityStmt, updateScriptVisibility, arg.Unlisted, arg.Private, arg.ID)
+	return err
+}
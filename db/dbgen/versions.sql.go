@@ -10,41 +10,110 @@ import (
 	"time"
 )
 
+const countVersionContentRefs = `-- name: CountVersionContentRefs :one
+SELECT COUNT(*) FROM script_versions WHERE content_ref = ?
+`
+
+func (q *Queries) CountVersionContentRefs(ctx context.Context, contentRef *string) (int64, error) {
+	row := q.queryRow(ctx, q.countVersionContentRefsStmt, countVersionContentRefs, contentRef)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createStoredVersion = `-- name: CreateStoredVersion :exec
+INSERT INTO script_versions (script_id, content, content_ref, version, message, created_at)
+VALUES (?, '', ?, ?, ?, ?)
+`
+
+type CreateStoredVersionParams struct {
+	ScriptID   string    `json:"script_id"`
+	ContentRef *string   `json:"content_ref"`
+	Version    int64     `json:"version"`
+	Message    *string   `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateStoredVersion(ctx context.Context, arg CreateStoredVersionParams) error {
+	_, err := q.exec(ctx, q.createStoredVersionStmt, createStoredVersion,
+		arg.ScriptID,
+		arg.ContentRef,
+		arg.Version,
+		arg.Message,
+		arg.CreatedAt,
+	)
+	return err
+}
+
 const createVersion = `-- name: CreateVersion :exec
-INSERT INTO script_versions (script_id, content, version, created_at)
-VALUES (?, ?, ?, ?)
+INSERT INTO script_versions (script_id, content, version, message, created_at)
+VALUES (?, ?, ?, ?, ?)
 `
 
 type CreateVersionParams struct {
 	ScriptID  string    `json:"script_id"`
 	Content   string    `json:"content"`
 	Version   int64     `json:"version"`
+	Message   *string   `json:"message"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
 func (q *Queries) CreateVersion(ctx context.Context, arg CreateVersionParams) error {
-	_, err := q.db.ExecContext(ctx, createVersion,
+	_, err := q.exec(ctx, q.createVersionStmt, createVersion,
 		arg.ScriptID,
 		arg.Content,
 		arg.Version,
+		arg.Message,
 		arg.CreatedAt,
 	)
 	return err
 }
 
+const getCurrentVersion = `-- name: GetCurrentVersion :one
+SELECT CAST(COALESCE(MAX(version), 0) AS INTEGER) FROM script_versions WHERE script_id = ?
+`
+
+func (q *Queries) GetCurrentVersion(ctx context.Context, scriptID string) (int64, error) {
+	row := q.queryRow(ctx, q.getCurrentVersionStmt, getCurrentVersion, scriptID)
+	var castCoalesceMaxVersion0AsInteger int64
+	err := row.Scan(&castCoalesceMaxVersion0AsInteger)
+	return castCoalesceMaxVersion0AsInteger, err
+}
+
 const getLatestVersion = `-- name: GetLatestVersion :one
 SELECT MAX(version) as version FROM script_versions WHERE script_id = ?
 `
 
 func (q *Queries) GetLatestVersion(ctx context.Context, scriptID string) (interface{}, error) {
-	row := q.db.QueryRowContext(ctx, getLatestVersion, scriptID)
+	row := q.queryRow(ctx, q.getLatestVersionStmt, getLatestVersion, scriptID)
 	var version interface{}
 	err := row.Scan(&version)
 	return version, err
 }
 
+const getMostVersionedScript = `-- name: GetMostVersionedScript :one
+SELECT scripts.path, CAST(COUNT(*) AS INTEGER) AS versions FROM script_versions
+JOIN scripts ON scripts.id = script_versions.script_id
+GROUP BY scripts.path ORDER BY versions DESC, scripts.path LIMIT 1
+`
+
+type GetMostVersionedScriptRow struct {
+	Path     string `json:"path"`
+	Versions int64  `json:"versions"`
+}
+
+func (q *Queries) GetMostVersionedScript(ctx context.Context) (GetMostVersionedScriptRow, error) {
+	row := q.queryRow(ctx, q.getMostVersionedScriptStmt, getMostVersionedScript)
+	var i GetMostVersionedScriptRow
+	err := row.Scan(
+		&i.Path,
+		&i.Versions,
+	)
+	return i, err
+}
+
 const getVersion = `-- name: GetVersion :one
-SELECT id, script_id, content, version, created_at FROM script_versions WHERE script_id = ? AND version = ?
+SELECT id, script_id, content, version, created_at, serves, message, content_ref FROM script_versions WHERE script_id = ? AND version = ?
 `
 
 type GetVersionParams struct {
@@ -53,7 +122,7 @@ type GetVersionParams struct {
 }
 
 func (q *Queries) GetVersion(ctx context.Context, arg GetVersionParams) (ScriptVersion, error) {
-	row := q.db.QueryRowContext(ctx, getVersion, arg.ScriptID, arg.Version)
+	row := q.queryRow(ctx, q.getVersionStmt, getVersion, arg.ScriptID, arg.Version)
 	var i ScriptVersion
 	err := row.Scan(
 		&i.ID,
@@ -61,16 +130,212 @@ func (q *Queries) GetVersion(ctx context.Context, arg GetVersionParams) (ScriptV
 		&i.Content,
 		&i.Version,
 		&i.CreatedAt,
+		&i.Serves,
+		&i.Message,
+		&i.ContentRef,
 	)
 	return i, err
 }
 
+const incrementVersionServes = `-- name: IncrementVersionServes :exec
+UPDATE script_versions SET serves = serves + 1 WHERE script_id = ? AND version = ?
+`
+
+type IncrementVersionServesParams struct {
+	ScriptID string `json:"script_id"`
+	Version  int64  `json:"version"`
+}
+
+func (q *Queries) IncrementVersionServes(ctx context.Context, arg IncrementVersionServesParams) error {
+	_, err := q.exec(ctx, q.incrementVersionServesStmt, incrementVersionServes, arg.ScriptID, arg.Version)
+	return err
+}
+
+const listAllVersions = `-- name: ListAllVersions :many
+SELECT script_id, version, content, content_ref, message, created_at FROM script_versions ORDER BY created_at, id
+`
+
+type ListAllVersionsRow struct {
+	ScriptID   string    `json:"script_id"`
+	Version    int64     `json:"version"`
+	Content    string    `json:"content"`
+	ContentRef *string   `json:"content_ref"`
+	Message    *string   `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListAllVersions(ctx context.Context) ([]ListAllVersionsRow, error) {
+	rows, err := q.query(ctx, q.listAllVersionsStmt, listAllVersions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAllVersionsRow{}
+	for rows.Next() {
+		var i ListAllVersionsRow
+		if err := rows.Scan(
+			&i.ScriptID,
+			&i.Version,
+			&i.Content,
+			&i.ContentRef,
+			&i.Message,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInlineVersions = `-- name: ListInlineVersions :many
+SELECT id, content FROM script_versions WHERE content_ref IS NULL
+`
+
+type ListInlineVersionsRow struct {
+	ID      int64  `json:"id"`
+	Content string `json:"content"`
+}
+
+func (q *Queries) ListInlineVersions(ctx context.Context) ([]ListInlineVersionsRow, error) {
+	rows, err := q.query(ctx, q.listInlineVersionsStmt, listInlineVersions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListInlineVersionsRow{}
+	for rows.Next() {
+		var i ListInlineVersionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlainVersionContentRefs = `-- name: ListPlainVersionContentRefs :many
+SELECT id, content_ref FROM script_versions WHERE content_ref LIKE 'content/%'
+`
+
+type ListPlainVersionContentRefsRow struct {
+	ID         int64   `json:"id"`
+	ContentRef *string `json:"content_ref"`
+}
+
+func (q *Queries) ListPlainVersionContentRefs(ctx context.Context) ([]ListPlainVersionContentRefsRow, error) {
+	rows, err := q.query(ctx, q.listPlainVersionContentRefsStmt, listPlainVersionContentRefs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPlainVersionContentRefsRow{}
+	for rows.Next() {
+		var i ListPlainVersionContentRefsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContentRef,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVersionContentRefs = `-- name: ListVersionContentRefs :many
+SELECT DISTINCT content_ref FROM script_versions WHERE script_id = ? AND content_ref IS NOT NULL
+`
+
+func (q *Queries) ListVersionContentRefs(ctx context.Context, scriptID string) ([]*string, error) {
+	rows, err := q.query(ctx, q.listVersionContentRefsStmt, listVersionContentRefs, scriptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*string{}
+	for rows.Next() {
+		var contentRef *string
+		if err := rows.Scan(&contentRef); err != nil {
+			return nil, err
+		}
+		items = append(items, contentRef)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVersionHistory = `-- name: ListVersionHistory :many
+SELECT version, message, serves, created_at FROM script_versions WHERE script_id = ? ORDER BY version DESC
+`
+
+type ListVersionHistoryRow struct {
+	Version   int64     `json:"version"`
+	Message   *string   `json:"message"`
+	Serves    int64     `json:"serves"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListVersionHistory(ctx context.Context, scriptID string) ([]ListVersionHistoryRow, error) {
+	rows, err := q.query(ctx, q.listVersionHistoryStmt, listVersionHistory, scriptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListVersionHistoryRow{}
+	for rows.Next() {
+		var i ListVersionHistoryRow
+		if err := rows.Scan(
+			&i.Version,
+			&i.Message,
+			&i.Serves,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listVersions = `-- name: ListVersions :many
-SELECT id, script_id, content, version, created_at FROM script_versions WHERE script_id = ? ORDER BY version DESC
+SELECT id, script_id, content, version, created_at, serves, message, content_ref FROM script_versions WHERE script_id = ? ORDER BY version DESC
 `
 
 func (q *Queries) ListVersions(ctx context.Context, scriptID string) ([]ScriptVersion, error) {
-	rows, err := q.db.QueryContext(ctx, listVersions, scriptID)
+	rows, err := q.query(ctx, q.listVersionsStmt, listVersions, scriptID)
 	if err != nil {
 		return nil, err
 	}
@@ -84,6 +349,9 @@ func (q *Queries) ListVersions(ctx context.Context, scriptID string) ([]ScriptVe
 			&i.Content,
 			&i.Version,
 			&i.CreatedAt,
+			&i.Serves,
+			&i.Message,
+			&i.ContentRef,
 		); err != nil {
 			return nil, err
 		}
@@ -97,3 +365,17 @@ func (q *Queries) ListVersions(ctx context.Context, scriptID string) ([]ScriptVe
 	}
 	return items, nil
 }
+
+const setVersionContentRef = `-- name: SetVersionContentRef :exec
+UPDATE script_versions SET content = '', content_ref = ? WHERE id = ?
+`
+
+type SetVersionContentRefParams struct {
+	ContentRef *string `json:"content_ref"`
+	ID         int64   `json:"id"`
+}
+
+func (q *Queries) SetVersionContentRef(ctx context.Context, arg SetVersionContentRefParams) error {
+	_, err := q.exec(ctx, q.setVersionContentRefStmt, setVersionContentRef, arg.ContentRef, arg.ID)
+	return err
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// IntegrityCheck runs SQLite's integrity_check and returns the problems it
+// finds; none means the database is intact. PostgreSQL checks its pages as
+// it reads them, so there it always passes.
+func IntegrityCheck(ctx context.Context, db *sql.DB, dialect Dialect) ([]string, error) {
+	if dialect == Postgres {
+		return nil, nil
+	}
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// Vacuum rebuilds the database to give back the space of deleted rows. On
+// SQLite the WAL is truncated afterwards, as the rebuild passes through it.
+func Vacuum(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	if _, err := db.ExecContext(ctx, "VACUUM"); err != nil {
+		return err
+	}
+	if dialect == Postgres {
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Analyze refreshes the statistics the query planner picks indexes by
+func Analyze(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "ANALYZE")
+	return err
+}
+
+// FreeSize returns the bytes of free pages in the SQLite file, which a
+// VACUUM would give back; PostgreSQL does not report it and gets 0
+func FreeSize(ctx context.Context, db *sql.DB, dialect Dialect) (int64, error) {
+	if dialect == Postgres {
+		return 0, nil
+	}
+	var size int64
+	err := db.QueryRowContext(ctx, "SELECT freelist_count * page_size FROM pragma_freelist_count(), pragma_page_size()").Scan(&size)
+	return size, err
+}
@@ -0,0 +1,85 @@
+-- Script templates for authoring new scripts
+--
+-- Placeholders use the {{NAME}} syntax and are filled in by
+-- POST /api/scripts/from-template.
+CREATE TABLE IF NOT EXISTS script_templates (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,        -- e.g., installer
+    description TEXT DEFAULT '',
+    content TEXT NOT NULL DEFAULT '', -- template body with {{PLACEHOLDER}} fields
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Built-in templates
+INSERT INTO script_templates (id, name, description, content) VALUES
+('builtin-installer', 'installer', 'Installer skeleton: download a binary and place it on PATH',
+'#!/bin/sh
+# {{SCRIPT_NAME}} - install {{BINARY}}
+set -eu
+
+BINARY="{{BINARY}}"
+URL="{{DOWNLOAD_URL}}"
+INSTALL_DIR="${INSTALL_DIR:-$HOME/.local/bin}"
+
+command -v curl >/dev/null 2>&1 || { echo "curl is required" >&2; exit 1; }
+
+TMP=$(mktemp -d)
+trap ''rm -rf "$TMP"'' EXIT INT TERM
+
+echo "Downloading ${BINARY}..."
+curl -fsSL "$URL" -o "$TMP/$BINARY"
+chmod +x "$TMP/$BINARY"
+
+mkdir -p "$INSTALL_DIR"
+mv "$TMP/$BINARY" "$INSTALL_DIR/$BINARY"
+echo "Installed ${BINARY} to ${INSTALL_DIR}"
+'),
+('builtin-service', 'service', 'systemd service setup for a long-running command',
+'#!/bin/sh
+# {{SCRIPT_NAME}} - set up the {{SERVICE}} systemd service
+set -eu
+
+SERVICE="{{SERVICE}}"
+UNIT="/etc/systemd/system/${SERVICE}.service"
+
+if [ "$(id -u)" -ne 0 ]; then
+    echo "Run as root (sudo)" >&2
+    exit 1
+fi
+
+cat > "$UNIT" <<UNIT_EOF
+[Unit]
+Description={{DESCRIPTION}}
+After=network.target
+
+[Service]
+Type=simple
+User={{USER}}
+ExecStart={{EXEC_START}}
+Restart=always
+RestartSec=5
+
+[Install]
+WantedBy=multi-user.target
+UNIT_EOF
+
+systemctl daemon-reload
+systemctl enable --now "$SERVICE"
+systemctl status "$SERVICE" --no-pager
+'),
+('builtin-cron', 'cron', 'Install a cron job for the current user',
+'#!/bin/sh
+# {{SCRIPT_NAME}} - install cron job "{{JOB_NAME}}"
+set -eu
+
+MARKER="# sh-server:{{JOB_NAME}}"
+ENTRY="{{SCHEDULE}} {{COMMAND}} $MARKER"
+
+( crontab -l 2>/dev/null | grep -v "$MARKER" || true; echo "$ENTRY" ) | crontab -
+echo "Installed cron job: $ENTRY"
+') ON CONFLICT DO NOTHING;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (002, '002-templates') ON CONFLICT DO NOTHING;
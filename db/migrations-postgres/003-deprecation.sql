@@ -0,0 +1,11 @@
+-- Script deprecation
+--
+-- Deprecated scripts are still served, prefixed with a warning that points
+-- at the replacement, and advertise sunset_at via the Sunset header.
+ALTER TABLE scripts ADD COLUMN deprecated BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE scripts ADD COLUMN replacement_path TEXT DEFAULT '';
+ALTER TABLE scripts ADD COLUMN sunset_at TIMESTAMPTZ;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (003, '003-deprecation') ON CONFLICT DO NOTHING;
@@ -0,0 +1,18 @@
+-- Incident/maintenance notices
+--
+-- A notice temporarily replaces what is served for a script path, or for every
+-- script under a folder path, until expires_at. Script content and versions
+-- are left untouched.
+CREATE TABLE IF NOT EXISTS notices (
+    id TEXT PRIMARY KEY,
+    path TEXT NOT NULL,               -- /tools/deploy.sh or folder /tools
+    message TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notices_expires_at ON notices(expires_at);
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (005, '005-notices') ON CONFLICT DO NOTHING;
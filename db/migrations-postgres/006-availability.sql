@@ -0,0 +1,10 @@
+-- Time-window availability
+--
+-- Outside [available_from, available_until) a script is not served.
+-- Either bound may be NULL.
+ALTER TABLE scripts ADD COLUMN available_from TIMESTAMPTZ;
+ALTER TABLE scripts ADD COLUMN available_until TIMESTAMPTZ;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (006, '006-availability') ON CONFLICT DO NOTHING;
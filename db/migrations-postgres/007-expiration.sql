@@ -0,0 +1,11 @@
+-- Script expiration
+--
+-- Scripts past expires_at are no longer served and are flagged in the
+-- catalog. With auto-archiving enabled, a background job marks them archived,
+-- which hides them from the catalog entirely.
+ALTER TABLE scripts ADD COLUMN expires_at TIMESTAMPTZ;
+ALTER TABLE scripts ADD COLUMN archived BIGINT NOT NULL DEFAULT 0;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (007, '007-expiration') ON CONFLICT DO NOTHING;
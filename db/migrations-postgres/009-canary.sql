@@ -0,0 +1,20 @@
+-- Canary rollout of script versions
+--
+-- While a canary is active, canary_version is served to a percentage of
+-- clients (by hash of client IP) or to requests with ?canary=1; everyone
+-- else gets the script's current content (stable_version).
+CREATE TABLE IF NOT EXISTS canaries (
+    script_id TEXT PRIMARY KEY,
+    stable_version BIGINT NOT NULL,
+    canary_version BIGINT NOT NULL,
+    percent BIGINT NOT NULL DEFAULT 0,  -- 0-100
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (script_id) REFERENCES scripts(id) ON DELETE CASCADE
+);
+
+-- Per-version serve counters, incremented while a rollout is in progress
+ALTER TABLE script_versions ADD COLUMN serves BIGINT NOT NULL DEFAULT 0;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (009, '009-canary') ON CONFLICT DO NOTHING;
@@ -0,0 +1,9 @@
+-- Unlisted scripts
+--
+-- An unlisted script is served normally by URL but left out of the public
+-- catalog (and therefore search.sh) and offline bundles.
+ALTER TABLE scripts ADD COLUMN unlisted BIGINT NOT NULL DEFAULT 0;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (011, '011-unlisted') ON CONFLICT DO NOTHING;
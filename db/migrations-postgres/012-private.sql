@@ -0,0 +1,10 @@
+-- Private scripts
+--
+-- A private script is only served to requests carrying the admin token and
+-- is omitted from every public listing. Unlike a locked script, its
+-- existence and metadata are not revealed to anyone else.
+ALTER TABLE scripts ADD COLUMN private BIGINT NOT NULL DEFAULT 0;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (012, '012-private') ON CONFLICT DO NOTHING;
@@ -0,0 +1,20 @@
+-- Web UI login sessions
+--
+-- The session cookie value is never stored; id is its SHA-256 hash so a
+-- leaked database cannot be replayed as a login. Each session carries its own
+-- CSRF token that mutating API calls must echo back.
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT PRIMARY KEY,              -- hex SHA-256 of the cookie value
+    csrf_token TEXT NOT NULL,
+    ip_address TEXT,
+    user_agent TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (013, '013-sessions') ON CONFLICT DO NOTHING;
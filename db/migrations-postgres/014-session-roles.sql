@@ -0,0 +1,10 @@
+-- Session roles
+--
+-- Sessions created through OIDC carry the role mapped from the user's groups
+-- and the identity provider's subject. Admin-token logins are always 'admin'.
+ALTER TABLE sessions ADD COLUMN role TEXT NOT NULL DEFAULT 'admin';
+ALTER TABLE sessions ADD COLUMN subject TEXT;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (014, '014-session-roles') ON CONFLICT DO NOTHING;
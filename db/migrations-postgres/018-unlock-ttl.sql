@@ -0,0 +1,9 @@
+-- Per-script unlock token lifetime
+--
+-- unlock_ttl is how long tokens issued by /_auth/unlock stay valid, in
+-- seconds. NULL uses the server default (UNLOCK_TOKEN_TTL).
+ALTER TABLE scripts ADD COLUMN unlock_ttl BIGINT;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (018, '018-unlock-ttl') ON CONFLICT DO NOTHING;
@@ -0,0 +1,11 @@
+-- Per-script country restrictions
+--
+-- allow_countries and deny_countries are comma-separated ISO 3166-1 alpha-2
+-- codes checked against the client's GeoIP country. NULL means no rule; the
+-- global GEOIP_ALLOW/GEOIP_DENY lists still apply.
+ALTER TABLE scripts ADD COLUMN allow_countries TEXT;
+ALTER TABLE scripts ADD COLUMN deny_countries TEXT;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (019, '019-geoip') ON CONFLICT DO NOTHING;
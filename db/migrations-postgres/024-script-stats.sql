@@ -0,0 +1,22 @@
+-- Script fetch statistics
+--
+-- Counters for every successful script serve: a running total with the last
+-- fetch time, and one row per script per UTC day for histograms.
+CREATE TABLE IF NOT EXISTS script_stats (
+    script_id TEXT PRIMARY KEY,
+    fetches BIGINT NOT NULL DEFAULT 0,
+    last_fetched_at TIMESTAMPTZ,
+    FOREIGN KEY (script_id) REFERENCES scripts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS script_daily_stats (
+    script_id TEXT NOT NULL,
+    day TEXT NOT NULL,                -- YYYY-MM-DD (UTC)
+    fetches BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (script_id, day),
+    FOREIGN KEY (script_id) REFERENCES scripts(id) ON DELETE CASCADE
+);
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (024, '024-script-stats') ON CONFLICT DO NOTHING;
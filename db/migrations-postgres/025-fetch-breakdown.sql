@@ -0,0 +1,20 @@
+-- Fetch breakdowns
+--
+-- Daily fetch counts per script split by a property of the request: kind
+-- 'client' counts client families (curl, wget, browser, ci, ...) and kind
+-- 'referrer' counts Referer hosts.
+CREATE TABLE IF NOT EXISTS fetch_breakdown (
+    script_id TEXT NOT NULL,
+    day TEXT NOT NULL,                -- YYYY-MM-DD (UTC)
+    kind TEXT NOT NULL,
+    value TEXT NOT NULL,
+    fetches BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (script_id, day, kind, value),
+    FOREIGN KEY (script_id) REFERENCES scripts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_fetch_breakdown_day ON fetch_breakdown(kind, day);
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (025, '025-fetch-breakdown') ON CONFLICT DO NOTHING;
@@ -0,0 +1,16 @@
+-- Outbound webhooks
+--
+-- Each webhook receives a signed JSON POST for the events it subscribes to
+-- (comma-separated names, or * for all). The secret is kept in the clear
+-- because it is needed to sign every delivery.
+CREATE TABLE IF NOT EXISTS webhooks (
+    id TEXT PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT NOT NULL DEFAULT '*',  -- script.created,script.deleted or *
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (028, '028-webhooks') ON CONFLICT DO NOTHING;
@@ -0,0 +1,29 @@
+-- Webhook delivery log
+--
+-- One row per delivery attempt. Retries of an event share delivery_id (sent
+-- as X-SH-Delivery). A failed attempt with next_attempt_at set is waiting
+-- for a retry; dead marks the last attempt of an event that never got
+-- through.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id TEXT NOT NULL,
+    webhook_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    attempt BIGINT NOT NULL,
+    status_code BIGINT,
+    response TEXT,                    -- first bytes of the response body
+    error TEXT,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    dead BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_retry ON webhook_deliveries(next_attempt_at);
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (029, '029-webhook-deliveries') ON CONFLICT DO NOTHING;
@@ -0,0 +1,9 @@
+-- Webhook kinds
+--
+-- 'json' webhooks get the raw signed event; 'slack' and 'discord' webhooks
+-- are incoming webhook URLs of those chat tools and get a readable message.
+ALTER TABLE webhooks ADD COLUMN kind TEXT NOT NULL DEFAULT 'json';
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (030, '030-webhook-kind') ON CONFLICT DO NOTHING;
@@ -0,0 +1,15 @@
+-- Scripts proxied from an upstream URL
+--
+-- A script with a source_url serves a cached copy of that URL as its
+-- content, fetched again once it is source_ttl seconds old (NULL uses the
+-- default). source_sha256 pins the content: fetches with another hash are
+-- refused and the cached copy stays. source_fetched_at is when the copy
+-- was last checked against the URL.
+ALTER TABLE scripts ADD COLUMN source_url TEXT;
+ALTER TABLE scripts ADD COLUMN source_ttl BIGINT;
+ALTER TABLE scripts ADD COLUMN source_sha256 TEXT;
+ALTER TABLE scripts ADD COLUMN source_fetched_at TIMESTAMPTZ;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (032, '032-proxied-scripts') ON CONFLICT DO NOTHING;
@@ -0,0 +1,20 @@
+-- Script content in a storage backend
+--
+-- With a storage backend configured, the content of scripts and versions
+-- is kept there under content_ref and the content column is left empty.
+-- Content is addressed by its SHA-256, so versions with the same content
+-- share one object. The sqlite backend keeps the objects in blobs.
+ALTER TABLE scripts ADD COLUMN content_ref TEXT;
+ALTER TABLE script_versions ADD COLUMN content_ref TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_scripts_content_ref ON scripts(content_ref);
+CREATE INDEX IF NOT EXISTS idx_versions_content_ref ON script_versions(content_ref);
+
+CREATE TABLE IF NOT EXISTS blobs (
+    key TEXT PRIMARY KEY,
+    data BYTEA NOT NULL
+);
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (033, '033-content-storage') ON CONFLICT DO NOTHING;
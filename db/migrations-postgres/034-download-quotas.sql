@@ -0,0 +1,10 @@
+-- Per-script download quotas
+--
+-- max_downloads_per_hour and max_downloads_per_day cap how often a script
+-- is served in a clock hour and a UTC day. NULL is unlimited.
+ALTER TABLE scripts ADD COLUMN max_downloads_per_hour BIGINT;
+ALTER TABLE scripts ADD COLUMN max_downloads_per_day BIGINT;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (034, '034-download-quotas') ON CONFLICT DO NOTHING;
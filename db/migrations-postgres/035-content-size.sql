@@ -0,0 +1,11 @@
+-- Content size of scripts
+--
+-- content_size is the length in bytes of a script's current content, kept
+-- here because the content itself may be in a storage backend. It counts
+-- towards the instance limit on total content. NULL is not measured yet;
+-- the server measures those scripts when it starts.
+ALTER TABLE scripts ADD COLUMN content_size BIGINT;
+
+-- Record execution of this migration
+INSERT INTO migrations (migration_number, migration_name)
+VALUES (035, '035-content-size') ON CONFLICT DO NOTHING;
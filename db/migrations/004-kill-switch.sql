@@ -0,0 +1,11 @@
+-- Script kill switch
+--
+-- A disabled script keeps its content and history but is no longer served;
+-- clients get the admin-provided reason instead.
+ALTER TABLE scripts ADD COLUMN disabled INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE scripts ADD COLUMN disabled_reason TEXT DEFAULT '';
+ALTER TABLE scripts ADD COLUMN disabled_at TIMESTAMP;
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (004, '004-kill-switch');
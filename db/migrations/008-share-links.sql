@@ -0,0 +1,22 @@
+-- Share links
+--
+-- A share link serves one script (locked or not) through /_share/{token}
+-- until it runs out of uses, passes its deadline or is revoked.
+CREATE TABLE IF NOT EXISTS share_links (
+    token TEXT PRIMARY KEY,
+    script_id TEXT NOT NULL,
+    max_uses INTEGER,                 -- NULL means unlimited (deadline only)
+    use_count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP,             -- NULL means no deadline (uses only)
+    revoked INTEGER NOT NULL DEFAULT 0,
+    note TEXT DEFAULT '',
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (script_id) REFERENCES scripts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_links_script ON share_links(script_id);
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (008, '008-share-links');
@@ -0,0 +1,31 @@
+-- Script variants (A/B experiments)
+--
+-- A variant is an alternative body served at the script's URL when its rule
+-- matches: ?variant=<name>, a client IP range, or a percentage bucket.
+CREATE TABLE IF NOT EXISTS script_variants (
+    id TEXT PRIMARY KEY,
+    script_id TEXT NOT NULL,
+    name TEXT NOT NULL,               -- e.g., rewrite
+    content TEXT NOT NULL,
+    match_cidr TEXT DEFAULT '',       -- comma-separated CIDRs or IPs
+    percent INTEGER NOT NULL DEFAULT 0, -- share of remaining clients, 0-100
+    priority INTEGER NOT NULL DEFAULT 0, -- lower is evaluated first
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (script_id, name),
+    FOREIGN KEY (script_id) REFERENCES scripts(id) ON DELETE CASCADE
+);
+
+-- Serve counters per variant; the original content is recorded as 'default'
+CREATE TABLE IF NOT EXISTS variant_stats (
+    script_id TEXT NOT NULL,
+    variant TEXT NOT NULL,
+    serves INTEGER NOT NULL DEFAULT 0,
+    last_served_at TIMESTAMP,
+    PRIMARY KEY (script_id, variant),
+    FOREIGN KEY (script_id) REFERENCES scripts(id) ON DELETE CASCADE
+);
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (010, '010-variants');
@@ -0,0 +1,21 @@
+-- Scoped API tokens
+--
+-- Tokens for automation (CI etc.) that can be limited to read-only access,
+-- to script path patterns and to specific API routes. Only the SHA-256 of
+-- the token is stored; the token itself is shown once at creation.
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    read_only INTEGER NOT NULL DEFAULT 0,
+    paths TEXT,                       -- comma-separated patterns, e.g. /ci/**,/tools/*.sh
+    endpoints TEXT,                   -- comma-separated routes, e.g. PUT /api/scripts/{id}
+    revoked_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    last_used_ip TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (015, '015-api-tokens');
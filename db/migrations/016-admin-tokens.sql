@@ -0,0 +1,18 @@
+-- Named admin tokens and audit attribution
+--
+-- Admin tokens with a label, stored as SHA-256, so every person or machine
+-- can have its own credential next to ADMIN_TOKEN. The audit log records
+-- which credential performed each action in actor.
+CREATE TABLE IF NOT EXISTS admin_tokens (
+    id TEXT PRIMARY KEY,
+    label TEXT NOT NULL UNIQUE,
+    token_hash TEXT NOT NULL UNIQUE,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE audit_log ADD COLUMN actor TEXT;
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (016, '016-admin-tokens');
@@ -0,0 +1,13 @@
+-- Folder-level locks
+--
+-- A locked folder protects every script beneath it, present and future, with
+-- one password. Unlock tokens issued for a folder lock record the folder path
+-- and are valid for any script under it.
+ALTER TABLE folders ADD COLUMN locked INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE folders ADD COLUMN password_hash TEXT;
+
+ALTER TABLE auth_tokens ADD COLUMN folder_path TEXT;
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (017, '017-folder-locks');
@@ -0,0 +1,15 @@
+-- Admin-defined content scanner rules
+--
+-- Each rule is a regular expression matched against every line of a script
+-- on create/update, in addition to the built-in rules. Matches are reported
+-- as warnings and flag the script in the catalog; saves are not rejected.
+CREATE TABLE IF NOT EXISTS scan_rules (
+    id TEXT PRIMARY KEY,
+    pattern TEXT NOT NULL,            -- Go regexp syntax
+    message TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (020, '020-scan-rules');
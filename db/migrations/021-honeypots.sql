@@ -0,0 +1,15 @@
+-- Honeypot paths
+--
+-- Decoy paths that are never real scripts. Any request for one is written to
+-- the audit log (HONEYPOT_HIT) and optionally posted to HONEYPOT_ALERT_URL,
+-- while the client just sees a 404.
+CREATE TABLE IF NOT EXISTS honeypots (
+    id TEXT PRIMARY KEY,
+    path TEXT NOT NULL UNIQUE,        -- /admin/backup.sh, /.env
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (021, '021-honeypots');
@@ -0,0 +1,22 @@
+-- Script access log
+--
+-- One row per script fetch when ACCESS_LOG is enabled, so questions like
+-- "which hosts pulled this script last week?" can be answered without
+-- digging through server logs.
+CREATE TABLE IF NOT EXISTS access_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    path TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    ip_address TEXT,
+    user_agent TEXT,
+    bytes INTEGER NOT NULL DEFAULT 0,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_log_path ON access_log(path, created_at);
+CREATE INDEX IF NOT EXISTS idx_access_log_created ON access_log(created_at);
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (022, '022-access-log');
@@ -0,0 +1,9 @@
+-- Request IDs in the audit log
+--
+-- Every request gets an ID that is sent back in X-Request-Id and attached
+-- to its log lines; storing it with audit entries ties the two together.
+ALTER TABLE audit_log ADD COLUMN request_id TEXT;
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (023, '023-audit-request-id');
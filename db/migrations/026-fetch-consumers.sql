@@ -0,0 +1,18 @@
+-- Unique consumers
+--
+-- One row per distinct client (salted hash of IP and User-Agent) that fetched
+-- a script on a day. The hash key changes every day, so clients cannot be
+-- followed across days and no raw identifiers are stored.
+CREATE TABLE IF NOT EXISTS fetch_consumers (
+    script_id TEXT NOT NULL,
+    day TEXT NOT NULL,                -- YYYY-MM-DD (UTC)
+    consumer TEXT NOT NULL,
+    PRIMARY KEY (script_id, day, consumer),
+    FOREIGN KEY (script_id) REFERENCES scripts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_fetch_consumers_day ON fetch_consumers(day);
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (026, '026-fetch-consumers');
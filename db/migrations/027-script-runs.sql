@@ -0,0 +1,24 @@
+-- Script run reports
+--
+-- Clients report how a run of a script ended through POST /_runs, so
+-- failure rates can be watched per script. day is the UTC day of the report
+-- for the daily histogram.
+CREATE TABLE IF NOT EXISTS script_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    script_id TEXT NOT NULL,
+    exit_code INTEGER NOT NULL,
+    duration_ms INTEGER,
+    version INTEGER,
+    message TEXT,
+    ip_address TEXT,
+    user_agent TEXT,
+    day TEXT NOT NULL,                -- YYYY-MM-DD (UTC)
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (script_id) REFERENCES scripts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_script_runs_script ON script_runs(script_id, created_at);
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (027, '027-script-runs');
@@ -0,0 +1,18 @@
+-- GitHub repository sync
+--
+-- Versions can carry a message (the commit message for synced changes).
+-- github_sync_files remembers which scripts came from the repository and
+-- at which blob, so unchanged files are skipped and files removed from the
+-- repository are deleted here too.
+ALTER TABLE script_versions ADD COLUMN message TEXT;
+
+CREATE TABLE IF NOT EXISTS github_sync_files (
+    path TEXT PRIMARY KEY,             -- script path
+    blob_sha TEXT NOT NULL,            -- git blob of the synced content
+    commit_sha TEXT NOT NULL,          -- last commit that touched the file
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Record execution of this migration
+INSERT OR IGNORE INTO migrations (migration_number, migration_name)
+VALUES (031, '031-github-sync');
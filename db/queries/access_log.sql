@@ -0,0 +1,25 @@
+-- name: CreateAccessLog :exec
+INSERT INTO access_log (path, status, ip_address, user_agent, bytes, duration_ms, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListAccessLog :many
+SELECT * FROM access_log
+WHERE created_at >= ? AND created_at < ?
+ORDER BY id DESC LIMIT ?;
+
+-- name: ListAccessLogByPath :many
+SELECT * FROM access_log
+WHERE path = ? AND created_at >= ? AND created_at < ?
+ORDER BY id DESC LIMIT ?;
+
+-- name: ListAccessLogByIP :many
+SELECT * FROM access_log
+WHERE ip_address = ? AND created_at >= ? AND created_at < ?
+ORDER BY id DESC LIMIT ?;
+
+-- name: ListAccessClientsByPath :many
+SELECT ip_address, COUNT(*) AS fetches, MAX(created_at) AS last_fetched_at
+FROM access_log
+WHERE path = ? AND created_at >= ? AND created_at < ?
+GROUP BY ip_address
+ORDER BY fetches DESC;
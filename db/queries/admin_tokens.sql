@@ -0,0 +1,21 @@
+-- name: CreateAdminToken :exec
+INSERT INTO admin_tokens (id, label, token_hash, created_at)
+VALUES (?, ?, ?, ?);
+
+-- name: GetAdminToken :one
+SELECT * FROM admin_tokens WHERE id = ?;
+
+-- name: GetAdminTokenByHash :one
+SELECT * FROM admin_tokens WHERE token_hash = ?;
+
+-- name: ListAdminTokens :many
+SELECT * FROM admin_tokens ORDER BY label;
+
+-- name: CountAdminTokens :one
+SELECT COUNT(*) FROM admin_tokens;
+
+-- name: TouchAdminToken :exec
+UPDATE admin_tokens SET last_used_at = ? WHERE id = ?;
+
+-- name: DeleteAdminToken :exec
+DELETE FROM admin_tokens WHERE id = ?;
@@ -0,0 +1,21 @@
+-- name: CreateAPIToken :exec
+INSERT INTO api_tokens (id, name, token_hash, read_only, paths, endpoints, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetAPIToken :one
+SELECT * FROM api_tokens WHERE id = ?;
+
+-- name: GetAPITokenByHash :one
+SELECT * FROM api_tokens WHERE token_hash = ?;
+
+-- name: ListAPITokens :many
+SELECT * FROM api_tokens ORDER BY created_at DESC;
+
+-- name: TouchAPIToken :exec
+UPDATE api_tokens SET last_used_at = ?, last_used_ip = ? WHERE id = ?;
+
+-- name: RevokeAPIToken :exec
+UPDATE api_tokens SET revoked_at = ? WHERE id = ?;
+
+-- name: CountActiveAPITokens :one
+SELECT COUNT(*) FROM api_tokens WHERE revoked_at IS NULL;
@@ -1,9 +1,30 @@
 -- name: CreateAuditLog :exec
-INSERT INTO audit_log (action, entity_type, entity_id, entity_path, details, ip_address, user_agent, created_at)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+INSERT INTO audit_log (action, entity_type, entity_id, entity_path, details, ip_address, user_agent, actor, request_id, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: ListAuditLogs :many
 SELECT * FROM audit_log ORDER BY created_at DESC LIMIT ?;
 
 -- name: ListAuditLogsByEntity :many
 SELECT * FROM audit_log WHERE entity_id = ? ORDER BY created_at DESC;
+
+-- name: ListAuditLogsBefore :many
+SELECT * FROM audit_log WHERE created_at < ? ORDER BY id LIMIT ?;
+
+-- name: DeleteAuditLogsThrough :execrows
+DELETE FROM audit_log WHERE id <= ? AND created_at < ?;
+
+-- name: ListAuditLogsRange :many
+SELECT * FROM audit_log
+WHERE created_at >= ? AND created_at < ? AND id > ?
+ORDER BY id LIMIT ?;
+
+-- name: ListAuditFailures :many
+SELECT * FROM audit_log
+WHERE action IN ('UNLOCK_FAILED', 'UNLOCK_LOCKOUT', 'UNLOCK_TOKEN_REJECTED', 'LOGIN_FAILED', 'GEO_BLOCKED', 'HONEYPOT_HIT', 'SECRET_DETECTED')
+ORDER BY id DESC LIMIT ?;
+
+-- name: CountAuditActions :many
+SELECT action, CAST(COUNT(*) AS INTEGER) AS count FROM audit_log
+WHERE created_at >= ? AND created_at < ?
+GROUP BY action;
@@ -1,6 +1,6 @@
 -- name: CreateAuthToken :exec
-INSERT INTO auth_tokens (token, script_id, expires_at, created_at, ip_address, user_agent)
-VALUES (?, ?, ?, ?, ?, ?);
+INSERT INTO auth_tokens (token, script_id, folder_path, expires_at, created_at, ip_address, user_agent)
+VALUES (?, ?, ?, ?, ?, ?, ?);
 
 -- name: GetAuthToken :one
 SELECT * FROM auth_tokens WHERE token = ?;
@@ -8,5 +8,21 @@ SELECT * FROM auth_tokens WHERE token = ?;
 -- name: DeleteExpiredTokens :exec
 DELETE FROM auth_tokens WHERE expires_at < ?;
 
--- name: DeleteTokensByScript :exec
+-- name: DeleteTokensByScript :execrows
 DELETE FROM auth_tokens WHERE script_id = ?;
+
+-- name: DeleteTokensByFolder :execrows
+DELETE FROM auth_tokens WHERE folder_path = ?;
+
+-- name: ListActiveAuthTokens :many
+SELECT auth_tokens.*, scripts.path AS script_path
+FROM auth_tokens
+JOIN scripts ON scripts.id = auth_tokens.script_id
+WHERE auth_tokens.expires_at > ?
+ORDER BY auth_tokens.created_at DESC;
+
+-- name: DeleteAuthToken :execrows
+DELETE FROM auth_tokens WHERE token = ?;
+
+-- name: CountActiveAuthTokens :one
+SELECT COUNT(*) FROM auth_tokens WHERE expires_at > ?;
@@ -0,0 +1,9 @@
+-- name: GetBlob :one
+SELECT data FROM blobs WHERE key = ?;
+
+-- name: PutBlob :exec
+INSERT INTO blobs (key, data) VALUES (?, ?)
+ON CONFLICT(key) DO UPDATE SET data = excluded.data;
+
+-- name: DeleteBlob :exec
+DELETE FROM blobs WHERE key = ?;
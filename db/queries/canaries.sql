@@ -0,0 +1,15 @@
+-- name: GetCanary :one
+SELECT * FROM canaries WHERE script_id = ?;
+
+-- name: CreateCanary :exec
+INSERT INTO canaries (script_id, stable_version, canary_version, percent, created_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: UpdateCanaryPercent :exec
+UPDATE canaries SET percent = ? WHERE script_id = ?;
+
+-- name: UpdateCanaryStable :exec
+UPDATE canaries SET stable_version = ? WHERE script_id = ?;
+
+-- name: DeleteCanary :exec
+DELETE FROM canaries WHERE script_id = ?;
@@ -18,3 +18,15 @@ DELETE FROM folders WHERE id = ?;
 
 -- name: DeleteFolderByPath :exec
 DELETE FROM folders WHERE path = ? OR path LIKE ? || '/%';
+
+-- name: ListLockedFolders :many
+SELECT * FROM folders WHERE locked = 1 ORDER BY path;
+
+-- name: UpdateFolderLock :exec
+UPDATE folders SET locked = ?, password_hash = ? WHERE id = ?;
+
+-- name: UpdateFolderPasswordHash :exec
+UPDATE folders SET password_hash = ? WHERE id = ?;
+
+-- name: CountFolders :one
+SELECT COUNT(*) FROM folders;
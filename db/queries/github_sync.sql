@@ -0,0 +1,10 @@
+-- name: ListGitHubSyncFiles :many
+SELECT * FROM github_sync_files ORDER BY path;
+
+-- name: UpsertGitHubSyncFile :exec
+INSERT INTO github_sync_files (path, blob_sha, commit_sha, synced_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(path) DO UPDATE SET blob_sha = excluded.blob_sha, commit_sha = excluded.commit_sha, synced_at = excluded.synced_at;
+
+-- name: DeleteGitHubSyncFile :exec
+DELETE FROM github_sync_files WHERE path = ?;
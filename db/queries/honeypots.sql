@@ -0,0 +1,15 @@
+-- name: CreateHoneypot :exec
+INSERT INTO honeypots (id, path, note, created_at)
+VALUES (?, ?, ?, ?);
+
+-- name: GetHoneypot :one
+SELECT * FROM honeypots WHERE id = ?;
+
+-- name: GetHoneypotByPath :one
+SELECT * FROM honeypots WHERE path = ?;
+
+-- name: ListHoneypots :many
+SELECT * FROM honeypots ORDER BY path;
+
+-- name: DeleteHoneypot :exec
+DELETE FROM honeypots WHERE id = ?;
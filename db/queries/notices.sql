@@ -0,0 +1,15 @@
+-- name: CreateNotice :exec
+INSERT INTO notices (id, path, message, expires_at, created_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetNotice :one
+SELECT * FROM notices WHERE id = ?;
+
+-- name: ListNotices :many
+SELECT * FROM notices ORDER BY expires_at;
+
+-- name: DeleteNotice :exec
+DELETE FROM notices WHERE id = ?;
+
+-- name: DeleteExpiredNotices :exec
+DELETE FROM notices WHERE expires_at < ?;
@@ -0,0 +1,27 @@
+-- name: CreateScriptRun :exec
+INSERT INTO script_runs (script_id, exit_code, duration_ms, version, message, ip_address, user_agent, day, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListScriptDailyRuns :many
+SELECT day, CAST(COUNT(*) AS INTEGER) AS runs, CAST(SUM(CASE WHEN exit_code != 0 THEN 1 ELSE 0 END) AS INTEGER) AS failures
+FROM script_runs
+WHERE script_id = ? AND day >= ?
+GROUP BY day
+ORDER BY day;
+
+-- name: CountScriptRunsSince :one
+SELECT CAST(COUNT(*) AS INTEGER) AS runs, CAST(COALESCE(SUM(CASE WHEN exit_code != 0 THEN 1 ELSE 0 END), 0) AS INTEGER) AS failures
+FROM script_runs
+WHERE script_id = ? AND created_at >= ?;
+
+-- name: ListScriptRunFailures :many
+SELECT * FROM script_runs
+WHERE script_id = ? AND exit_code != 0
+ORDER BY id DESC LIMIT ?;
+
+-- name: SumRunsByScript :many
+SELECT script_id, CAST(COUNT(*) AS INTEGER) AS runs, CAST(SUM(CASE WHEN exit_code != 0 THEN 1 ELSE 0 END) AS INTEGER) AS failures
+FROM script_runs
+WHERE created_at >= ? AND created_at < ?
+GROUP BY script_id
+ORDER BY failures DESC;
@@ -0,0 +1,12 @@
+-- name: CreateScanRule :exec
+INSERT INTO scan_rules (id, pattern, message, created_at)
+VALUES (?, ?, ?, ?);
+
+-- name: GetScanRule :one
+SELECT * FROM scan_rules WHERE id = ?;
+
+-- name: ListScanRules :many
+SELECT * FROM scan_rules ORDER BY created_at;
+
+-- name: DeleteScanRule :exec
+DELETE FROM scan_rules WHERE id = ?;
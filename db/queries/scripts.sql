@@ -10,9 +10,14 @@ SELECT * FROM scripts ORDER BY path;
 -- name: ListScriptsByFolder :many
 SELECT * FROM scripts WHERE path LIKE ? || '/%' AND path NOT LIKE ? || '/%/%' ORDER BY name;
 
--- name: SearchScripts :many
-SELECT * FROM scripts 
-WHERE name LIKE '%' || ? || '%' 
+-- name: ListScriptMetadata :many
+SELECT id, path, name, CAST('' AS TEXT) AS content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at, deprecated, replacement_path, sunset_at, disabled, disabled_reason, disabled_at, available_from, available_until, expires_at, archived, unlisted, private, unlock_ttl, allow_countries, deny_countries, source_url, source_ttl, source_sha256, source_fetched_at, content_ref, max_downloads_per_hour, max_downloads_per_day, content_size
+FROM scripts ORDER BY path;
+
+-- name: SearchScriptMetadata :many
+SELECT id, path, name, CAST('' AS TEXT) AS content, description, tags, locked, password_hash, danger_level, requires, examples, favorite, created_at, updated_at, deprecated, replacement_path, sunset_at, disabled, disabled_reason, disabled_at, available_from, available_until, expires_at, archived, unlisted, private, unlock_ttl, allow_countries, deny_countries, source_url, source_ttl, source_sha256, source_fetched_at, content_ref, max_downloads_per_hour, max_downloads_per_day, content_size
+FROM scripts
+WHERE name LIKE '%' || ? || '%'
    OR path LIKE '%' || ? || '%'
    OR description LIKE '%' || ? || '%'
    OR tags LIKE '%' || ? || '%'
@@ -43,6 +48,9 @@ UPDATE scripts SET content = ?, updated_at = ? WHERE id = ?;
 -- name: UpdateScriptLock :exec
 UPDATE scripts SET locked = ?, password_hash = ?, updated_at = ? WHERE id = ?;
 
+-- name: UpdateScriptPasswordHash :exec
+UPDATE scripts SET password_hash = ? WHERE id = ?;
+
 -- name: DeleteScript :exec
 DELETE FROM scripts WHERE id = ?;
 
@@ -54,3 +62,63 @@ SELECT * FROM scripts WHERE favorite = 1 ORDER BY path;
 
 -- name: ListRecentlyUpdated :many
 SELECT * FROM scripts ORDER BY updated_at DESC LIMIT ?;
+
+-- name: ListScriptsReferencing :many
+SELECT * FROM scripts WHERE instr(content, ?) > 0 AND id != ? ORDER BY path;
+
+-- name: UpdateScriptDeprecation :exec
+UPDATE scripts SET deprecated = ?, replacement_path = ?, sunset_at = ? WHERE id = ?;
+
+-- name: SetScriptDisabled :exec
+UPDATE scripts SET disabled = ?, disabled_reason = ?, disabled_at = ? WHERE id = ?;
+
+-- name: UpdateScriptAvailability :exec
+UPDATE scripts SET available_from = ?, available_until = ? WHERE id = ?;
+
+-- name: UpdateScriptExpiration :exec
+UPDATE scripts SET expires_at = ?, archived = ? WHERE id = ?;
+
+-- name: SetScriptArchived :exec
+UPDATE scripts SET archived = ? WHERE id = ?;
+
+-- name: UpdateScriptVisibility :exec
+UPDATE scripts SET unlisted = ?, private = ? WHERE id = ?;
+
+-- name: UpdateScriptUnlockTTL :exec
+UPDATE scripts SET unlock_ttl = ? WHERE id = ?;
+
+-- name: UpdateScriptCountries :exec
+UPDATE scripts SET allow_countries = ?, deny_countries = ? WHERE id = ?;
+
+-- name: UpdateScriptQuotas :exec
+UPDATE scripts SET max_downloads_per_hour = ?, max_downloads_per_day = ? WHERE id = ?;
+
+-- name: UpdateScriptSource :exec
+UPDATE scripts SET source_url = ?, source_ttl = ?, source_sha256 = ?, source_fetched_at = ? WHERE id = ?;
+
+-- name: SetScriptSourceFetched :exec
+UPDATE scripts SET source_fetched_at = ? WHERE id = ?;
+
+-- name: CountScripts :one
+SELECT COUNT(*) FROM scripts;
+
+-- name: SetScriptContentSize :exec
+UPDATE scripts SET content_size = ? WHERE id = ?;
+
+-- name: ListUnsizedScriptIDs :many
+SELECT id FROM scripts WHERE content_size IS NULL;
+
+-- name: GetContentUsage :one
+SELECT CAST(COUNT(*) AS INTEGER) AS scripts, CAST(COALESCE(SUM(content_size), 0) AS INTEGER) AS content_bytes FROM scripts;
+
+-- name: SetScriptContentRef :exec
+UPDATE scripts SET content = '', content_ref = ? WHERE id = ?;
+
+-- name: CountScriptContentRefs :one
+SELECT COUNT(*) FROM scripts WHERE content_ref = ?;
+
+-- name: CountStoredContent :one
+SELECT COUNT(*) FROM scripts WHERE content_ref IS NOT NULL;
+
+-- name: ListPlainScriptContentRefs :many
+SELECT id, content_ref FROM scripts WHERE content_ref LIKE 'content/%';
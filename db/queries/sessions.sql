@@ -0,0 +1,21 @@
+-- name: CreateSession :exec
+INSERT INTO sessions (id, csrf_token, role, subject, ip_address, user_agent, expires_at, last_seen_at, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetSession :one
+SELECT * FROM sessions WHERE id = ?;
+
+-- name: ListSessions :many
+SELECT * FROM sessions ORDER BY last_seen_at DESC;
+
+-- name: TouchSession :exec
+UPDATE sessions SET last_seen_at = ? WHERE id = ?;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions WHERE id = ?;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions WHERE expires_at < ?;
+
+-- name: CountActiveSessions :one
+SELECT COUNT(*) FROM sessions WHERE expires_at > ?;
@@ -0,0 +1,23 @@
+-- name: CreateShareLink :exec
+INSERT INTO share_links (token, script_id, max_uses, expires_at, note, created_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetShareLink :one
+SELECT * FROM share_links WHERE token = ?;
+
+-- name: ListShareLinks :many
+SELECT share_links.*, scripts.path AS script_path
+FROM share_links
+JOIN scripts ON scripts.id = share_links.script_id
+ORDER BY share_links.created_at DESC;
+
+-- name: ConsumeShareLink :execrows
+UPDATE share_links SET use_count = use_count + 1, last_used_at = ?
+WHERE token = ? AND revoked = 0 AND (max_uses IS NULL OR use_count < max_uses);
+
+-- name: RevokeShareLink :exec
+UPDATE share_links SET revoked = 1 WHERE token = ?;
+
+-- name: CountActiveShareLinks :one
+SELECT COUNT(*) FROM share_links
+WHERE revoked = 0 AND (expires_at IS NULL OR expires_at > ?) AND (max_uses IS NULL OR use_count < max_uses);
@@ -0,0 +1,59 @@
+-- name: RecordScriptFetch :exec
+INSERT INTO script_stats (script_id, fetches, last_fetched_at)
+VALUES (?, 1, ?)
+ON CONFLICT (script_id) DO UPDATE SET fetches = script_stats.fetches + 1, last_fetched_at = excluded.last_fetched_at;
+
+-- name: RecordScriptDailyFetch :exec
+INSERT INTO script_daily_stats (script_id, day, fetches)
+VALUES (?, ?, 1)
+ON CONFLICT (script_id, day) DO UPDATE SET fetches = script_daily_stats.fetches + 1;
+
+-- name: GetScriptStats :one
+SELECT * FROM script_stats WHERE script_id = ?;
+
+-- name: ListScriptDailyStats :many
+SELECT * FROM script_daily_stats WHERE script_id = ? AND day >= ? ORDER BY day;
+
+-- name: ListScriptStats :many
+SELECT * FROM script_stats ORDER BY fetches DESC;
+
+-- name: SumDailyFetchesByScript :many
+SELECT script_id, CAST(SUM(fetches) AS INTEGER) AS fetches FROM script_daily_stats
+WHERE day >= ?
+GROUP BY script_id;
+
+-- name: SumFetchesSince :one
+SELECT CAST(COALESCE(SUM(fetches), 0) AS INTEGER) FROM script_daily_stats WHERE day >= ?;
+
+-- name: RecordFetchBreakdown :exec
+INSERT INTO fetch_breakdown (script_id, day, kind, value, fetches)
+VALUES (?, ?, ?, ?, 1)
+ON CONFLICT (script_id, day, kind, value) DO UPDATE SET fetches = fetch_breakdown.fetches + 1;
+
+-- name: ListFetchBreakdown :many
+SELECT value, CAST(SUM(fetches) AS INTEGER) AS fetches FROM fetch_breakdown
+WHERE script_id = ? AND kind = ? AND day >= ?
+GROUP BY value
+ORDER BY fetches DESC;
+
+-- name: ListAllFetchBreakdown :many
+SELECT value, CAST(SUM(fetches) AS INTEGER) AS fetches FROM fetch_breakdown
+WHERE kind = ? AND day >= ?
+GROUP BY value
+ORDER BY fetches DESC;
+
+-- name: RecordFetchConsumer :exec
+INSERT OR IGNORE INTO fetch_consumers (script_id, day, consumer) VALUES (?, ?, ?);
+
+-- name: ListScriptDailyConsumers :many
+SELECT day, CAST(COUNT(*) AS INTEGER) AS consumers FROM fetch_consumers
+WHERE script_id = ? AND day >= ?
+GROUP BY day;
+
+-- name: SumDailyConsumersByScript :many
+SELECT script_id, CAST(COUNT(*) AS INTEGER) AS consumers FROM fetch_consumers
+WHERE day >= ?
+GROUP BY script_id;
+
+-- name: DeleteFetchConsumersBefore :execrows
+DELETE FROM fetch_consumers WHERE day < ?;
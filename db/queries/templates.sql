@@ -0,0 +1,18 @@
+-- name: GetTemplate :one
+SELECT * FROM script_templates WHERE id = ?;
+
+-- name: GetTemplateByName :one
+SELECT * FROM script_templates WHERE name = ?;
+
+-- name: ListTemplates :many
+SELECT * FROM script_templates ORDER BY name;
+
+-- name: CreateTemplate :exec
+INSERT INTO script_templates (id, name, description, content, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: UpdateTemplate :exec
+UPDATE script_templates SET name = ?, description = ?, content = ?, updated_at = ? WHERE id = ?;
+
+-- name: DeleteTemplate :exec
+DELETE FROM script_templates WHERE id = ?;
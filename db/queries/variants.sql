@@ -0,0 +1,23 @@
+-- name: GetVariant :one
+SELECT * FROM script_variants WHERE id = ?;
+
+-- name: ListVariants :many
+SELECT * FROM script_variants WHERE script_id = ? ORDER BY priority, name;
+
+-- name: CreateVariant :exec
+INSERT INTO script_variants (id, script_id, name, content, match_cidr, percent, priority, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: UpdateVariant :exec
+UPDATE script_variants SET name = ?, content = ?, match_cidr = ?, percent = ?, priority = ?, updated_at = ? WHERE id = ?;
+
+-- name: DeleteVariant :exec
+DELETE FROM script_variants WHERE id = ?;
+
+-- name: RecordVariantServe :exec
+INSERT INTO variant_stats (script_id, variant, serves, last_served_at)
+VALUES (?, ?, 1, ?)
+ON CONFLICT (script_id, variant) DO UPDATE SET serves = variant_stats.serves + 1, last_served_at = excluded.last_served_at;
+
+-- name: ListVariantStats :many
+SELECT * FROM variant_stats WHERE script_id = ? ORDER BY variant;
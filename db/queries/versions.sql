@@ -1,6 +1,10 @@
 -- name: CreateVersion :exec
-INSERT INTO script_versions (script_id, content, version, created_at)
-VALUES (?, ?, ?, ?);
+INSERT INTO script_versions (script_id, content, version, message, created_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: CreateStoredVersion :exec
+INSERT INTO script_versions (script_id, content, content_ref, version, message, created_at)
+VALUES (?, '', ?, ?, ?, ?);
 
 -- name: GetLatestVersion :one
 SELECT MAX(version) as version FROM script_versions WHERE script_id = ?;
@@ -10,3 +14,35 @@ SELECT * FROM script_versions WHERE script_id = ? ORDER BY version DESC;
 
 -- name: GetVersion :one
 SELECT * FROM script_versions WHERE script_id = ? AND version = ?;
+
+-- name: IncrementVersionServes :exec
+UPDATE script_versions SET serves = serves + 1 WHERE script_id = ? AND version = ?;
+
+-- name: GetCurrentVersion :one
+SELECT CAST(COALESCE(MAX(version), 0) AS INTEGER) FROM script_versions WHERE script_id = ?;
+
+-- name: ListVersionHistory :many
+SELECT version, message, serves, created_at FROM script_versions WHERE script_id = ? ORDER BY version DESC;
+
+-- name: ListAllVersions :many
+SELECT script_id, version, content, content_ref, message, created_at FROM script_versions ORDER BY created_at, id;
+
+-- name: ListInlineVersions :many
+SELECT id, content FROM script_versions WHERE content_ref IS NULL;
+
+-- name: SetVersionContentRef :exec
+UPDATE script_versions SET content = '', content_ref = ? WHERE id = ?;
+
+-- name: ListVersionContentRefs :many
+SELECT DISTINCT content_ref FROM script_versions WHERE script_id = ? AND content_ref IS NOT NULL;
+
+-- name: CountVersionContentRefs :one
+SELECT COUNT(*) FROM script_versions WHERE content_ref = ?;
+
+-- name: ListPlainVersionContentRefs :many
+SELECT id, content_ref FROM script_versions WHERE content_ref LIKE 'content/%';
+
+-- name: GetMostVersionedScript :one
+SELECT scripts.path, CAST(COUNT(*) AS INTEGER) AS versions FROM script_versions
+JOIN scripts ON scripts.id = script_versions.script_id
+GROUP BY scripts.path ORDER BY versions DESC, scripts.path LIMIT 1;
@@ -0,0 +1,32 @@
+-- name: CreateWebhook :exec
+INSERT INTO webhooks (id, url, secret, events, kind, created_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks WHERE id = ?;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks ORDER BY created_at;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks WHERE id = ?;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (delivery_id, webhook_id, event, payload, attempt, status_code, response, error, duration_ms, next_attempt_at, dead, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries WHERE id = ? AND webhook_id = ?;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?;
+
+-- name: ListDueWebhookRetries :many
+SELECT * FROM webhook_deliveries WHERE next_attempt_at <= ? ORDER BY next_attempt_at;
+
+-- name: ClaimWebhookRetry :execrows
+UPDATE webhook_deliveries SET next_attempt_at = NULL WHERE id = ? AND next_attempt_at IS NOT NULL;
+
+-- name: GetLastWebhookAttempt :one
+SELECT CAST(MAX(attempt) AS INTEGER) FROM webhook_deliveries WHERE delivery_id = ?;
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// Split returns what dbgen.New takes to send reads to reader and every
+// other statement to writer. With a writer of a single connection, writes
+// queue up in the process instead of contending for SQLite's write lock,
+// and reads never wait behind them.
+func Split(reader, writer dbgen.DBTX) dbgen.DBTX {
+	return &splitConn{reader: reader, writer: writer}
+}
+
+type splitConn struct {
+	reader, writer dbgen.DBTX
+}
+
+func (c *splitConn) conn(query string) dbgen.DBTX {
+	if isRead(query) {
+		return c.reader
+	}
+	return c.writer
+}
+
+func (c *splitConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.writer.ExecContext(ctx, query, args...)
+}
+
+func (c *splitConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.conn(query).PrepareContext(ctx, query)
+}
+
+func (c *splitConn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.conn(query).QueryContext(ctx, query, args...)
+}
+
+func (c *splitConn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return c.conn(query).QueryRowContext(ctx, query, args...)
+}
+
+// isRead reports whether query is a SELECT, skipping the leading comments
+// of dbgen's queries. INSERT ... RETURNING goes to the writer.
+func isRead(query string) bool {
+	for {
+		query = strings.TrimSpace(query)
+		if !strings.HasPrefix(query, "--") {
+			break
+		}
+		_, rest, ok := strings.Cut(query, "\n")
+		if !ok {
+			return false
+		}
+		query = rest
+	}
+	keyword, _, _ := strings.Cut(query, " ")
+	keyword = strings.ToUpper(strings.TrimSpace(keyword))
+	return keyword == "SELECT" || keyword == "WITH"
+}
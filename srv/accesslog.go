@@ -0,0 +1,226 @@
+package srv
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// Access log query limits
+const (
+	defaultAccessLogLimit = 100
+	maxAccessLogLimit     = 1000
+)
+
+// statusRecorder remembers the status and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// accessLogged records every request handled by next in the access log when
+// ACCESS_LOG is enabled
+func (s *Server) accessLogged(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.AccessLog {
+			next(w, r)
+			return
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		s.queries().CreateAccessLog(r.Context(), dbgen.CreateAccessLogParams{
+			Path:       r.URL.Path,
+			Status:     int64(rec.status),
+			IpAddress:  s.storedIP(r),
+			UserAgent:  strPtr(r.Header.Get("User-Agent")),
+			Bytes:      rec.bytes,
+			DurationMs: time.Since(start).Milliseconds(),
+			CreatedAt:  start,
+		})
+	}
+}
+
+// accessLogLimit reads the limit query parameter
+func accessLogLimit(r *http.Request) int64 {
+	limit, err := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	if err != nil || limit <= 0 {
+		return defaultAccessLogLimit
+	}
+	return min(limit, maxAccessLogLimit)
+}
+
+// APIListAccessLog returns script fetches, newest first, optionally filtered
+// by path and client IP
+func (s *Server) APIListAccessLog(w http.ResponseWriter, r *http.Request) {
+	from, to, err := exportRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := accessLogLimit(r)
+	path, ip := r.URL.Query().Get("path"), r.URL.Query().Get("ip")
+	if net.ParseIP(ip) != nil {
+		// Match what was stored when IPs are anonymized
+		ip = s.anonymizeIP(ip)
+	}
+
+	q := s.queries()
+	var entries []dbgen.AccessLog
+	switch {
+	case path != "":
+		entries, err = q.ListAccessLogByPath(r.Context(), dbgen.ListAccessLogByPathParams{
+			Path:        path,
+			CreatedAt:   from,
+			CreatedAt_2: to,
+			Limit:       limit,
+		})
+		if ip != "" {
+			filtered := entries[:0]
+			for _, e := range entries {
+				if e.IpAddress != nil && *e.IpAddress == ip {
+					filtered = append(filtered, e)
+				}
+			}
+			entries = filtered
+		}
+	case ip != "":
+		entries, err = q.ListAccessLogByIP(r.Context(), dbgen.ListAccessLogByIPParams{
+			IpAddress:   &ip,
+			CreatedAt:   from,
+			CreatedAt_2: to,
+			Limit:       limit,
+		})
+	default:
+		entries, err = q.ListAccessLog(r.Context(), dbgen.ListAccessLogParams{
+			CreatedAt:   from,
+			CreatedAt_2: to,
+			Limit:       limit,
+		})
+	}
+	if err != nil {
+		http.Error(w, "Failed to list access log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// AccessClient summarizes the fetches of one client
+type AccessClient struct {
+	IPAddress     string    `json:"ip_address"`
+	Fetches       int64     `json:"fetches"`
+	LastFetchedAt time.Time `json:"last_fetched_at"`
+}
+
+// ScriptAccessResponse is a script's fetch history
+type ScriptAccessResponse struct {
+	Path    string            `json:"path"`
+	Clients []AccessClient    `json:"clients"`
+	Entries []dbgen.AccessLog `json:"entries"`
+}
+
+// sqliteTime converts a timestamp read through an aggregate, which loses
+// its column type, back into a time
+func sqliteTime(v interface{}) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case string:
+		t, _, _ = strings.Cut(t, " m=")
+		for _, layout := range []string{"2006-01-02 15:04:05.999999999 -0700 MST", time.RFC3339Nano, "2006-01-02 15:04:05"} {
+			if parsed, err := time.Parse(layout, t); err == nil {
+				return parsed
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// APIScriptAccessLog returns who fetched a script in a time range: one entry
+// per client plus the most recent fetches
+func (s *Server) APIScriptAccessLog(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	from, to, err := exportRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := s.queries()
+	script, err := q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+
+	clients, err := q.ListAccessClientsByPath(r.Context(), dbgen.ListAccessClientsByPathParams{
+		Path:        script.Path,
+		CreatedAt:   from,
+		CreatedAt_2: to,
+	})
+	if err != nil {
+		http.Error(w, "Failed to list access log", http.StatusInternalServerError)
+		return
+	}
+	entries, err := q.ListAccessLogByPath(r.Context(), dbgen.ListAccessLogByPathParams{
+		Path:        script.Path,
+		CreatedAt:   from,
+		CreatedAt_2: to,
+		Limit:       accessLogLimit(r),
+	})
+	if err != nil {
+		http.Error(w, "Failed to list access log", http.StatusInternalServerError)
+		return
+	}
+
+	resp := ScriptAccessResponse{Path: script.Path, Clients: make([]AccessClient, len(clients)), Entries: entries}
+	for i, c := range clients {
+		resp.Clients[i] = AccessClient{
+			IPAddress:     derefStr(c.IpAddress),
+			Fetches:       c.Fetches,
+			LastFetchedAt: sqliteTime(c.LastFetchedAt),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
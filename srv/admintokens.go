@@ -0,0 +1,168 @@
+package srv
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hunydev/sh-server/db"
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+type actorKey struct{}
+
+// withActor records who is making an admin request, for the audit log
+func withActor(r *http.Request, actor string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), actorKey{}, actor))
+}
+
+// actor returns who is making the request, or nil for anonymous requests
+func actor(ctx context.Context) *string {
+	if a, ok := ctx.Value(actorKey{}).(string); ok && a != "" {
+		return &a
+	}
+	return nil
+}
+
+// adminTokenActor checks a raw token against ADMIN_TOKEN and the named admin
+// tokens and returns the actor it identifies
+func (s *Server) adminTokenActor(ctx context.Context, token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	if adminToken := s.adminToken(); adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+		return "admin-token", true
+	}
+
+	q := s.queries()
+	tok, err := q.GetAdminTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		return "", false
+	}
+	now := time.Now()
+	q.TouchAdminToken(ctx, dbgen.TouchAdminTokenParams{LastUsedAt: &now, ID: tok.ID})
+	return "token:" + tok.Label, true
+}
+
+// AdminTokenResponse describes a named admin token; Token is only set on creation
+type AdminTokenResponse struct {
+	ID         string     `json:"id"`
+	Label      string     `json:"label"`
+	Token      string     `json:"token,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func adminTokenToResponse(tok dbgen.AdminToken) AdminTokenResponse {
+	return AdminTokenResponse{
+		ID:         tok.ID,
+		Label:      tok.Label,
+		LastUsedAt: tok.LastUsedAt,
+		CreatedAt:  tok.CreatedAt,
+	}
+}
+
+// APIListAdminTokens returns the named admin tokens without their secrets
+func (s *Server) APIListAdminTokens(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	tokens, err := q.ListAdminTokens(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list admin tokens", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]AdminTokenResponse, len(tokens))
+	for i, tok := range tokens {
+		resp[i] = adminTokenToResponse(tok)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APICreateAdminToken mints a named admin token; the token is only returned here
+func (s *Server) APICreateAdminToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Label string `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	req.Label = strings.TrimSpace(req.Label)
+	if req.Label == "" {
+		http.Error(w, "Label is required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	id := uuid.New().String()
+	token := randomToken(32)
+
+	q := s.queries()
+	err := q.CreateAdminToken(r.Context(), dbgen.CreateAdminTokenParams{
+		ID:        id,
+		Label:     req.Label,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+	})
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			http.Error(w, "Admin token with this label already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create admin token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "CREATE",
+		EntityType: "admin_token",
+		EntityID:   &id,
+		Details:    &req.Label,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	tok, _ := q.GetAdminToken(r.Context(), id)
+	resp := adminTokenToResponse(tok)
+	resp.Token = token
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIDeleteAdminToken removes a named admin token
+func (s *Server) APIDeleteAdminToken(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	tok, err := q.GetAdminToken(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Admin token not found", http.StatusNotFound)
+		return
+	}
+	if err := q.DeleteAdminToken(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete admin token", http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "DELETE",
+		EntityType: "admin_token",
+		EntityID:   &id,
+		Details:    &tok.Label,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,13 +3,15 @@ package srv
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 
+	"github.com/hunydev/sh-server/db"
 	"github.com/hunydev/sh-server/db/dbgen"
 )
 
@@ -18,7 +20,7 @@ type ScriptResponse struct {
 	ID          string    `json:"id"`
 	Path        string    `json:"path"`
 	Name        string    `json:"name"`
-	Content     string    `json:"content"`
+	Content     string    `json:"content,omitempty"` // left out of search results
 	Description string    `json:"description"`
 	Tags        string    `json:"tags"`
 	Locked      bool      `json:"locked"`
@@ -26,8 +28,44 @@ type ScriptResponse struct {
 	Requires    string    `json:"requires"`
 	Examples    string    `json:"examples"`
 	Favorite    bool      `json:"favorite"`
+	Library     bool      `json:"library"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	
+	Deprecated      bool       `json:"deprecated"`
+	ReplacementPath string     `json:"replacement_path"`
+	SunsetAt        *time.Time `json:"sunset_at,omitempty"`
+	
+	Disabled       bool       `json:"disabled"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+	
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Expired   bool       `json:"expired"`
+	Archived  bool       `json:"archived"`
+	
+	Unlisted bool `json:"unlisted"`
+	Private  bool `json:"private"`
+	
+	UnlockTTL int64 `json:"unlock_ttl"` // seconds, 0 = server default
+	
+	// Download quotas per clock hour and UTC day, 0 = unlimited
+	MaxDownloadsPerHour int64 `json:"max_downloads_per_hour"`
+	MaxDownloadsPerDay  int64 `json:"max_downloads_per_day"`
+	
+	AllowCountries string `json:"allow_countries"` // comma-separated ISO country codes
+	DenyCountries  string `json:"deny_countries"`
+	
+	SourceURL       string     `json:"source_url,omitempty"` // proxied from this URL
+	SourceTTL       int64      `json:"source_ttl,omitempty"` // seconds, 0 = server default
+	SourceSHA256    string     `json:"source_sha256,omitempty"`
+	SourceFetchedAt *time.Time `json:"source_fetched_at,omitempty"`
+	
+	// Warnings lists risky constructs found in the content; only set on create/update
+	Warnings []ScanWarning `json:"warnings,omitempty"`
 }
 
 func scriptToResponse(s dbgen.Script) ScriptResponse {
@@ -38,6 +76,7 @@ func scriptToResponse(s dbgen.Script) ScriptResponse {
 		Content:   s.Content,
 		Locked:    s.Locked != 0,
 		Favorite:  s.Favorite != 0,
+		Library:   isLibraryPath(s.Path),
 		CreatedAt: s.CreatedAt,
 		UpdatedAt: s.UpdatedAt,
 	}
@@ -56,12 +95,46 @@ func scriptToResponse(s dbgen.Script) ScriptResponse {
 	if s.Examples != nil {
 		resp.Examples = *s.Examples
 	}
+	resp.Deprecated = s.Deprecated != 0
+	if s.ReplacementPath != nil {
+		resp.ReplacementPath = *s.ReplacementPath
+	}
+	resp.SunsetAt = s.SunsetAt
+	resp.Disabled = s.Disabled != 0
+	if s.DisabledReason != nil {
+		resp.DisabledReason = *s.DisabledReason
+	}
+	resp.DisabledAt = s.DisabledAt
+	resp.AvailableFrom = s.AvailableFrom
+	resp.AvailableUntil = s.AvailableUntil
+	resp.ExpiresAt = s.ExpiresAt
+	resp.Expired = scriptExpired(s, time.Now())
+	resp.Archived = s.Archived != 0
+	resp.Unlisted = s.Unlisted != 0
+	resp.Private = s.Private != 0
+	if s.UnlockTtl != nil {
+		resp.UnlockTTL = *s.UnlockTtl
+	}
+	resp.MaxDownloadsPerHour = derefInt(s.MaxDownloadsPerHour)
+	resp.MaxDownloadsPerDay = derefInt(s.MaxDownloadsPerDay)
+	if s.AllowCountries != nil {
+		resp.AllowCountries = *s.AllowCountries
+	}
+	if s.DenyCountries != nil {
+		resp.DenyCountries = *s.DenyCountries
+	}
+	resp.SourceURL = derefStr(s.SourceUrl)
+	if s.SourceTtl != nil {
+		resp.SourceTTL = *s.SourceTtl
+	}
+	resp.SourceSHA256 = derefStr(s.SourceSha256)
+	resp.SourceFetchedAt = s.SourceFetchedAt
 	return resp
 }
 
 // APIListScripts returns all scripts
 func (s *Server) APIListScripts(w http.ResponseWriter, r *http.Request) {
-	q := dbgen.New(s.DB)
+	q := s.queries()
 	scripts, err := q.ListScripts(r.Context())
 	if err != nil {
 		http.Error(w, "Failed to list scripts", http.StatusInternalServerError)
@@ -81,7 +154,7 @@ func (s *Server) APIListScripts(w http.ResponseWriter, r *http.Request) {
 func (s *Server) APIGetScript(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	
-	q := dbgen.New(s.DB)
+	q := s.queries()
 	script, err := q.GetScript(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Script not found", http.StatusNotFound)
@@ -103,31 +176,154 @@ type CreateScriptRequest struct {
 	DangerLevel int    `json:"danger_level"`
 	Requires    string `json:"requires"`
 	Examples    string `json:"examples"`
+	
+	Deprecated      bool       `json:"deprecated"`
+	ReplacementPath string     `json:"replacement_path"`
+	SunsetAt        *time.Time `json:"sunset_at,omitempty"`
+	
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	
+	Unlisted bool `json:"unlisted"`
+	Private  bool `json:"private"`
+	
+	UnlockTTL int64 `json:"unlock_ttl"` // seconds, 0 = server default
+	
+	// Download quotas per clock hour and UTC day, 0 = unlimited
+	MaxDownloadsPerHour int64 `json:"max_downloads_per_hour"`
+	MaxDownloadsPerDay  int64 `json:"max_downloads_per_day"`
+	
+	AllowCountries string `json:"allow_countries"` // comma-separated ISO country codes
+	DenyCountries  string `json:"deny_countries"`
+	
+	// SourceURL makes the script a proxy of an https URL; its content is
+	// fetched from there and refreshed after SourceTTL seconds
+	SourceURL    string `json:"source_url"`
+	SourceTTL    int64  `json:"source_ttl"`
+	SourceSHA256 string `json:"source_sha256"` // pins the content, if set
+	
+	Message string `json:"message"` // describes the change in the version history
 }
 
 // APICreateScript creates a new script
 func (s *Server) APICreateScript(w http.ResponseWriter, r *http.Request) {
+	if s.replicaReadOnly(w, r) {
+		return
+	}
 	var req CreateScriptRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		invalidBody(w, err)
 		return
 	}
 	
 	if err := validatePath(req.Path); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		invalidField(w, "path", err)
+		return
+	}
+	if err := validateReplacement(req.Path, req.ReplacementPath); err != nil {
+		invalidField(w, "replacement_path", err)
+		return
+	}
+	if err := validateAvailability(req.AvailableFrom, req.AvailableUntil); err != nil {
+		invalidField(w, "available_until", err)
+		return
+	}
+	if err := s.validateUnlockTTL(req.UnlockTTL); err != nil {
+		invalidField(w, "unlock_ttl", err)
+		return
+	}
+	if err := validateQuota("max_downloads_per_hour", req.MaxDownloadsPerHour); err != nil {
+		invalidField(w, "max_downloads_per_hour", err)
+		return
+	}
+	if err := validateQuota("max_downloads_per_day", req.MaxDownloadsPerDay); err != nil {
+		invalidField(w, "max_downloads_per_day", err)
+		return
+	}
+	if err := validateCountries("allow_countries", req.AllowCountries); err != nil {
+		invalidField(w, "allow_countries", err)
+		return
+	}
+	if err := validateCountries("deny_countries", req.DenyCountries); err != nil {
+		invalidField(w, "deny_countries", err)
+		return
+	}
+	if req.SourceURL != "" {
+		if field, err := validateSource(req.SourceURL, req.SourceTTL, req.SourceSHA256); err != nil {
+			invalidField(w, field, err)
+			return
+		}
+		if _, err := s.resolveSource(r.Context(), nil, req.SourceURL, req.SourceSHA256, &req.Content); err != nil {
+			http.Error(w, "Failed to fetch source: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+	
+	if !s.checkScriptSize(w, req.Content) {
+		return
+	}
+	q := s.queries()
+	limitWarnings, ok := s.checkInstanceLimits(w, r, q, nil, req.Content)
+	if !ok {
+		return
+	}
+	if !s.checkScriptSyntax(w, r, req.Path, req.Content) {
+		return
+	}
+	if !s.checkLint(w, r, req.Content) {
+		return
+	}
+	secrets := s.scanSecrets(req.Content)
+	if !s.checkSecrets(w, r, q, nil, req.Path, secrets) {
+		return
+	}
+	
+	script, err := s.createScript(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, errPathExists) {
+			http.Error(w, "Script with this path already exists", http.StatusConflict)
+			return
+		}
+		if writePreSaveError(w, err) || writeInstanceLimitError(w, err) {
+			return
+		}
+		http.Error(w, "Failed to create script: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 	
+	warnings := s.scanContent(scanRules(r.Context(), q), script.Content)
+	s.emitIfDangerous(r.Context(), q, script, warnings)
+	resp := scriptToResponse(script)
+	resp.Warnings = append(append(warnings, secrets...), limitWarnings...)
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// errPathExists is returned by createScript when another script already uses the path
+var errPathExists = errors.New("script with this path already exists")
+
+// createScript stores a new script with its initial version and audit entry,
+// if the instance limits and PRE_SAVE_HOOK allow it. The caller is
+// responsible for validating req.Path.
+func (s *Server) createScript(ctx context.Context, req CreateScriptRequest) (dbgen.Script, error) {
+	if _, err := s.instanceLimits(ctx, s.queries(), nil, req.Content); err != nil {
+		return dbgen.Script{}, err
+	}
+	if err := s.preSave(ctx, "create", req.Path, req.Content); err != nil {
+		return dbgen.Script{}, err
+	}
+	
 	// Hash password if locked
 	var passwordHash *string
 	if req.Locked && req.Password != "" {
-		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		hash, err := s.Passwords.hashPassword(req.Password)
 		if err != nil {
-			http.Error(w, "Failed to hash password", http.StatusInternalServerError)
-			return
+			return dbgen.Script{}, fmt.Errorf("hash password: %w", err)
 		}
-		hashStr := string(hash)
-		passwordHash = &hashStr
+		passwordHash = &hash
 	}
 	
 	now := time.Now()
@@ -140,12 +336,12 @@ func (s *Server) APICreateScript(w http.ResponseWriter, r *http.Request) {
 	}
 	dangerLevel := int64(req.DangerLevel)
 	
-	q := dbgen.New(s.DB)
+	q := s.queries()
 	
 	// Ensure parent folders exist
-	s.ensureFolders(r.Context(), q, req.Path)
+	s.ensureFolders(ctx, q, req.Path)
 	
-	err := q.CreateScript(r.Context(), dbgen.CreateScriptParams{
+	err := q.CreateScript(ctx, dbgen.CreateScriptParams{
 		ID:           id,
 		Path:         req.Path,
 		Name:         name,
@@ -162,36 +358,105 @@ func (s *Server) APICreateScript(w http.ResponseWriter, r *http.Request) {
 	})
 	
 	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint") {
-			http.Error(w, "Script with this path already exists", http.StatusConflict)
-			return
+		if db.IsUniqueViolation(err) {
+			return dbgen.Script{}, errPathExists
+		}
+		return dbgen.Script{}, err
+	}
+	
+	if req.Deprecated || req.ReplacementPath != "" {
+		if err := q.UpdateScriptDeprecation(ctx, deprecationParams(id, req.Deprecated, req.ReplacementPath, req.SunsetAt)); err != nil {
+			return dbgen.Script{}, fmt.Errorf("set deprecation: %w", err)
+		}
+	}
+	
+	if req.AvailableFrom != nil || req.AvailableUntil != nil {
+		if err := q.UpdateScriptAvailability(ctx, dbgen.UpdateScriptAvailabilityParams{
+			AvailableFrom:  req.AvailableFrom,
+			AvailableUntil: req.AvailableUntil,
+			ID:             id,
+		}); err != nil {
+			return dbgen.Script{}, fmt.Errorf("set availability: %w", err)
+		}
+	}
+	
+	if req.ExpiresAt != nil {
+		if err := q.UpdateScriptExpiration(ctx, dbgen.UpdateScriptExpirationParams{
+			ExpiresAt: req.ExpiresAt,
+			ID:        id,
+		}); err != nil {
+			return dbgen.Script{}, fmt.Errorf("set expiration: %w", err)
+		}
+	}
+	
+	if req.Unlisted || req.Private {
+		unlistedInt, privateInt := int64(0), int64(0)
+		if req.Unlisted {
+			unlistedInt = 1
+		}
+		if req.Private {
+			privateInt = 1
+		}
+		if err := q.UpdateScriptVisibility(ctx, dbgen.UpdateScriptVisibilityParams{
+			Unlisted: unlistedInt,
+			Private:  privateInt,
+			ID:       id,
+		}); err != nil {
+			return dbgen.Script{}, fmt.Errorf("set visibility: %w", err)
+		}
+	}
+	
+	if req.UnlockTTL > 0 {
+		if err := q.UpdateScriptUnlockTTL(ctx, unlockTTLParam(id, req.UnlockTTL)); err != nil {
+			return dbgen.Script{}, fmt.Errorf("set unlock TTL: %w", err)
+		}
+	}
+	
+	if req.MaxDownloadsPerHour > 0 || req.MaxDownloadsPerDay > 0 {
+		if err := q.UpdateScriptQuotas(ctx, quotasParam(id, req.MaxDownloadsPerHour, req.MaxDownloadsPerDay)); err != nil {
+			return dbgen.Script{}, fmt.Errorf("set quotas: %w", err)
+		}
+	}
+	
+	if req.AllowCountries != "" || req.DenyCountries != "" {
+		if err := q.UpdateScriptCountries(ctx, countriesParam(id, req.AllowCountries, req.DenyCountries)); err != nil {
+			return dbgen.Script{}, fmt.Errorf("set countries: %w", err)
+		}
+	}
+	
+	if req.SourceURL != "" {
+		if err := q.UpdateScriptSource(ctx, sourceParam(id, req.SourceURL, req.SourceTTL, req.SourceSHA256, &now)); err != nil {
+			return dbgen.Script{}, fmt.Errorf("set source: %w", err)
 		}
-		http.Error(w, "Failed to create script: "+err.Error(), http.StatusInternalServerError)
-		return
 	}
 	
 	// Create initial version
-	q.CreateVersion(r.Context(), dbgen.CreateVersionParams{
+	q.CreateVersion(ctx, dbgen.CreateVersionParams{
 		ScriptID:  id,
 		Content:   req.Content,
 		Version:   1,
+		Message:   versionMessage(req.Message),
 		CreatedAt: now,
 	})
 	
 	// Log creation
-	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+	q.CreateAuditLog(ctx, dbgen.CreateAuditLogParams{
 		Action:     "CREATE",
 		EntityType: "script",
 		EntityID:   &id,
 		EntityPath: &req.Path,
+		Actor:      actor(ctx),
+		RequestID:  requestID(ctx),
 		CreatedAt:  now,
 	})
 	
-	script, _ := q.GetScript(r.Context(), id)
-	
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(scriptToResponse(script))
+	script, err := q.GetScript(ctx, id)
+	if err == nil {
+		s.emit(ctx, s.scriptEvent(ctx, q, EventScriptCreated, script))
+		s.postSave(ctx, "create", script.Path, script.Content)
+		s.mirrorSave(ctx, "", script, req.Message)
+	}
+	return script, err
 }
 
 // UpdateScriptRequest represents a request to update a script
@@ -205,24 +470,83 @@ type UpdateScriptRequest struct {
 	DangerLevel int    `json:"danger_level"`
 	Requires    string `json:"requires"`
 	Examples    string `json:"examples"`
+	
+	Deprecated      bool       `json:"deprecated"`
+	ReplacementPath string     `json:"replacement_path"`
+	SunsetAt        *time.Time `json:"sunset_at,omitempty"`
+	
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	
+	Unlisted bool `json:"unlisted"`
+	Private  bool `json:"private"`
+	
+	UnlockTTL int64 `json:"unlock_ttl"` // seconds, 0 = server default
+	
+	// Download quotas per clock hour and UTC day, 0 = unlimited
+	MaxDownloadsPerHour int64 `json:"max_downloads_per_hour"`
+	MaxDownloadsPerDay  int64 `json:"max_downloads_per_day"`
+	
+	AllowCountries string `json:"allow_countries"` // comma-separated ISO country codes
+	DenyCountries  string `json:"deny_countries"`
+	
+	// SourceURL makes the script a proxy of an https URL; its content is
+	// fetched from there and refreshed after SourceTTL seconds
+	SourceURL    string `json:"source_url"`
+	SourceTTL    int64  `json:"source_ttl"`
+	SourceSHA256 string `json:"source_sha256"` // pins the content, if set
+	
+	Message string `json:"message"` // describes the change in the version history
 }
 
 // APIUpdateScript updates an existing script
 func (s *Server) APIUpdateScript(w http.ResponseWriter, r *http.Request) {
+	if s.replicaReadOnly(w, r) {
+		return
+	}
 	id := r.PathValue("id")
 	
 	var req UpdateScriptRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		invalidBody(w, err)
 		return
 	}
 	
 	if err := validatePath(req.Path); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		invalidField(w, "path", err)
+		return
+	}
+	if err := validateReplacement(req.Path, req.ReplacementPath); err != nil {
+		invalidField(w, "replacement_path", err)
+		return
+	}
+	if err := validateAvailability(req.AvailableFrom, req.AvailableUntil); err != nil {
+		invalidField(w, "available_until", err)
+		return
+	}
+	if err := s.validateUnlockTTL(req.UnlockTTL); err != nil {
+		invalidField(w, "unlock_ttl", err)
+		return
+	}
+	if err := validateQuota("max_downloads_per_hour", req.MaxDownloadsPerHour); err != nil {
+		invalidField(w, "max_downloads_per_hour", err)
+		return
+	}
+	if err := validateQuota("max_downloads_per_day", req.MaxDownloadsPerDay); err != nil {
+		invalidField(w, "max_downloads_per_day", err)
+		return
+	}
+	if err := validateCountries("allow_countries", req.AllowCountries); err != nil {
+		invalidField(w, "allow_countries", err)
+		return
+	}
+	if err := validateCountries("deny_countries", req.DenyCountries); err != nil {
+		invalidField(w, "deny_countries", err)
 		return
 	}
 	
-	q := dbgen.New(s.DB)
+	q := s.queries()
 	
 	// Get existing script
 	existing, err := q.GetScript(r.Context(), id)
@@ -230,18 +554,64 @@ func (s *Server) APIUpdateScript(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Script not found", http.StatusNotFound)
 		return
 	}
+	var sourceFetchedAt *time.Time
+	if req.SourceURL != "" {
+		if field, err := validateSource(req.SourceURL, req.SourceTTL, req.SourceSHA256); err != nil {
+			invalidField(w, field, err)
+			return
+		}
+		sourceFetchedAt, err = s.resolveSource(r.Context(), &existing, req.SourceURL, req.SourceSHA256, &req.Content)
+		if err != nil {
+			http.Error(w, "Failed to fetch source: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+	
+	if !s.checkScriptSize(w, req.Content) {
+		return
+	}
+	limitWarnings, ok := s.checkInstanceLimits(w, r, q, &existing, req.Content)
+	if !ok {
+		return
+	}
+	if !s.checkScriptSyntax(w, r, req.Path, req.Content) {
+		return
+	}
+	if !s.checkLint(w, r, req.Content) {
+		return
+	}
+	if !s.checkPreSave(w, r, "update", req.Path, req.Content) {
+		return
+	}
+	
+	secrets := s.scanSecrets(req.Content)
+	if !s.checkSecrets(w, r, q, &id, req.Path, secrets) {
+		return
+	}
+	
+	// Moving a library breaks every script that sources it
+	if isLibraryPath(existing.Path) && req.Path != existing.Path && r.URL.Query().Get("force") != "1" {
+		deps, err := findDependents(r.Context(), q, existing)
+		if err != nil {
+			http.Error(w, "Failed to check dependents", http.StatusInternalServerError)
+			return
+		}
+		if len(deps) > 0 {
+			dependentsConflict(w, deps)
+			return
+		}
+	}
 	
 	// Hash password if locked and password provided
 	var passwordHash *string
 	if req.Locked {
 		if req.Password != "" {
-			hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+			hash, err := s.Passwords.hashPassword(req.Password)
 			if err != nil {
 				http.Error(w, "Failed to hash password", http.StatusInternalServerError)
 				return
 			}
-			hashStr := string(hash)
-			passwordHash = &hashStr
+			passwordHash = &hash
 		} else {
 			// Keep existing password hash
 			passwordHash = existing.PasswordHash
@@ -277,6 +647,65 @@ func (s *Server) APIUpdateScript(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if err := q.UpdateScriptDeprecation(r.Context(), deprecationParams(id, req.Deprecated, req.ReplacementPath, req.SunsetAt)); err != nil {
+		http.Error(w, "Failed to update script: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := q.UpdateScriptAvailability(r.Context(), dbgen.UpdateScriptAvailabilityParams{
+		AvailableFrom:  req.AvailableFrom,
+		AvailableUntil: req.AvailableUntil,
+		ID:             id,
+	}); err != nil {
+		http.Error(w, "Failed to update script: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	// Moving the expiry into the future (or clearing it) brings an archived script back
+	archived := existing.Archived
+	if req.ExpiresAt == nil || req.ExpiresAt.After(now) {
+		archived = 0
+	}
+	if err := q.UpdateScriptExpiration(r.Context(), dbgen.UpdateScriptExpirationParams{
+		ExpiresAt: req.ExpiresAt,
+		Archived:  archived,
+		ID:        id,
+	}); err != nil {
+		http.Error(w, "Failed to update script: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	unlistedInt, privateInt := int64(0), int64(0)
+	if req.Unlisted {
+		unlistedInt = 1
+	}
+	if req.Private {
+		privateInt = 1
+	}
+	if err := q.UpdateScriptVisibility(r.Context(), dbgen.UpdateScriptVisibilityParams{
+		Unlisted: unlistedInt,
+		Private:  privateInt,
+		ID:       id,
+	}); err != nil {
+		http.Error(w, "Failed to update script: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := q.UpdateScriptUnlockTTL(r.Context(), unlockTTLParam(id, req.UnlockTTL)); err != nil {
+		http.Error(w, "Failed to update script: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := q.UpdateScriptQuotas(r.Context(), quotasParam(id, req.MaxDownloadsPerHour, req.MaxDownloadsPerDay)); err != nil {
+		http.Error(w, "Failed to update script: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := q.UpdateScriptCountries(r.Context(), countriesParam(id, req.AllowCountries, req.DenyCountries)); err != nil {
+		http.Error(w, "Failed to update script: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := q.UpdateScriptSource(r.Context(), sourceParam(id, req.SourceURL, req.SourceTTL, req.SourceSHA256, sourceFetchedAt)); err != nil {
+		http.Error(w, "Failed to update script: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
 	// Create new version if content changed
 	if existing.Content != req.Content {
 		versions, _ := q.ListVersions(r.Context(), id)
@@ -288,8 +717,14 @@ func (s *Server) APIUpdateScript(w http.ResponseWriter, r *http.Request) {
 			ScriptID:  id,
 			Content:   req.Content,
 			Version:   newVersion,
+			Message:   versionMessage(req.Message),
 			CreatedAt: now,
 		})
+		// An edit during a canary rollout becomes the new stable version
+		q.UpdateCanaryStable(r.Context(), dbgen.UpdateCanaryStableParams{
+			StableVersion: newVersion,
+			ScriptID:      id,
+		})
 	}
 	
 	// Log update
@@ -298,20 +733,32 @@ func (s *Server) APIUpdateScript(w http.ResponseWriter, r *http.Request) {
 		EntityType: "script",
 		EntityID:   &id,
 		EntityPath: &req.Path,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
 		CreatedAt:  now,
 	})
 	
 	script, _ := q.GetScript(r.Context(), id)
+	s.emit(r.Context(), s.scriptEvent(r.Context(), q, EventScriptUpdated, script))
+	s.postSave(r.Context(), "update", script.Path, script.Content)
+	s.mirrorSave(r.Context(), existing.Path, script, req.Message)
+	warnings := s.scanContent(scanRules(r.Context(), q), script.Content)
+	s.emitIfDangerous(r.Context(), q, script, warnings)
+	resp := scriptToResponse(script)
+	resp.Warnings = append(append(warnings, secrets...), limitWarnings...)
 	
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(scriptToResponse(script))
+	json.NewEncoder(w).Encode(resp)
 }
 
 // APIDeleteScript deletes a script
 func (s *Server) APIDeleteScript(w http.ResponseWriter, r *http.Request) {
+	if s.replicaReadOnly(w, r) {
+		return
+	}
 	id := r.PathValue("id")
 	
-	q := dbgen.New(s.DB)
+	q := s.queries()
 	
 	script, err := q.GetScript(r.Context(), id)
 	if err != nil {
@@ -319,6 +766,18 @@ func (s *Server) APIDeleteScript(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if isLibraryPath(script.Path) && r.URL.Query().Get("force") != "1" {
+		deps, err := findDependents(r.Context(), q, script)
+		if err != nil {
+			http.Error(w, "Failed to check dependents", http.StatusInternalServerError)
+			return
+		}
+		if len(deps) > 0 {
+			dependentsConflict(w, deps)
+			return
+		}
+	}
+	
 	if err := q.DeleteScript(r.Context(), id); err != nil {
 		http.Error(w, "Failed to delete script", http.StatusInternalServerError)
 		return
@@ -330,27 +789,52 @@ func (s *Server) APIDeleteScript(w http.ResponseWriter, r *http.Request) {
 		EntityType: "script",
 		EntityID:   &id,
 		EntityPath: &script.Path,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
 		CreatedAt:  time.Now(),
 	})
+	s.emit(r.Context(), s.scriptEvent(r.Context(), q, EventScriptDeleted, script))
+	s.postSave(r.Context(), "delete", script.Path, "")
+	s.mirrorDelete(r.Context(), script.Path)
 	
 	w.WriteHeader(http.StatusNoContent)
 }
 
 // TreeNode represents a node in the folder tree
 type TreeNode struct {
-	ID       string      `json:"id"`
-	Name     string      `json:"name"`
-	Path     string      `json:"path"`
-	Type     string      `json:"type"` // "folder" or "script"
-	Locked   bool        `json:"locked,omitempty"`
-	Children []*TreeNode `json:"children,omitempty"`
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Path       string      `json:"path"`
+	Type       string      `json:"type"` // "folder" or "script"
+	Locked     bool        `json:"locked,omitempty"`
+	Library    bool        `json:"library,omitempty"`
+	Deprecated bool        `json:"deprecated,omitempty"`
+	Disabled   bool        `json:"disabled,omitempty"`
+	Expired    bool        `json:"expired,omitempty"`
+	Archived   bool        `json:"archived,omitempty"`
+	Unlisted   bool        `json:"unlisted,omitempty"`
+	Private    bool        `json:"private,omitempty"`
+	Children   []*TreeNode `json:"children,omitempty"`
 }
 
 // APIGetTree returns the folder/script tree
 func (s *Server) APIGetTree(w http.ResponseWriter, r *http.Request) {
-	q := dbgen.New(s.DB)
-	scripts, _ := q.ListScripts(r.Context())
-	folders, _ := q.ListFolders(r.Context())
+	body, err := s.cachedResponse(r.Context(), "tree", s.buildTree)
+	if err != nil {
+		http.Error(w, "Failed to build tree", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// buildTree serializes the folder/script tree
+func (s *Server) buildTree(ctx context.Context) ([]byte, time.Time, error) {
+	q := s.queries()
+	scripts, _ := q.ListScriptMetadata(ctx)
+	folders, _ := q.ListFolders(ctx)
+	now := time.Now()
 	
 	// Build tree from paths
 	root := &TreeNode{
@@ -371,6 +855,7 @@ func (s *Server) APIGetTree(w http.ResponseWriter, r *http.Request) {
 			Name:     f.Name,
 			Path:     f.Path,
 			Type:     "folder",
+			Locked:   f.Locked != 0,
 			Children: []*TreeNode{},
 		}
 		nodeMap[f.Path] = node
@@ -379,11 +864,18 @@ func (s *Server) APIGetTree(w http.ResponseWriter, r *http.Request) {
 	// Add scripts
 	for _, sc := range scripts {
 		node := &TreeNode{
-			ID:     sc.ID,
-			Name:   sc.Name,
-			Path:   sc.Path,
-			Type:   "script",
-			Locked: sc.Locked != 0,
+			ID:         sc.ID,
+			Name:       sc.Name,
+			Path:       sc.Path,
+			Type:       "script",
+			Locked:     sc.Locked != 0,
+			Library:    isLibraryPath(sc.Path),
+			Deprecated: sc.Deprecated != 0,
+			Disabled:   sc.Disabled != 0,
+			Expired:    scriptExpired(sc, now),
+			Archived:   sc.Archived != 0,
+			Unlisted:   sc.Unlisted != 0,
+			Private:    sc.Private != 0,
 		}
 		nodeMap[sc.Path] = node
 	}
@@ -402,8 +894,8 @@ func (s *Server) APIGetTree(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(root)
+	body, err := marshalResponse(root)
+	return body, nextScriptChange(scripts, now), err
 }
 
 func getParentPath(path string) string {
@@ -422,12 +914,23 @@ type FolderResponse struct {
 	ID        string    `json:"id"`
 	Path      string    `json:"path"`
 	Name      string    `json:"name"`
+	Locked    bool      `json:"locked"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+func folderToResponse(f dbgen.Folder) FolderResponse {
+	return FolderResponse{
+		ID:        f.ID,
+		Path:      f.Path,
+		Name:      f.Name,
+		Locked:    f.Locked != 0,
+		CreatedAt: f.CreatedAt,
+	}
+}
+
 // APIListFolders returns all folders
 func (s *Server) APIListFolders(w http.ResponseWriter, r *http.Request) {
-	q := dbgen.New(s.DB)
+	q := s.queries()
 	folders, err := q.ListFolders(r.Context())
 	if err != nil {
 		http.Error(w, "Failed to list folders", http.StatusInternalServerError)
@@ -436,12 +939,7 @@ func (s *Server) APIListFolders(w http.ResponseWriter, r *http.Request) {
 	
 	resp := make([]FolderResponse, len(folders))
 	for i, f := range folders {
-		resp[i] = FolderResponse{
-			ID:        f.ID,
-			Path:      f.Path,
-			Name:      f.Name,
-			CreatedAt: f.CreatedAt,
-		}
+		resp[i] = folderToResponse(f)
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
@@ -455,9 +953,12 @@ type CreateFolderRequest struct {
 
 // APICreateFolder creates a new folder
 func (s *Server) APICreateFolder(w http.ResponseWriter, r *http.Request) {
+	if s.replicaReadOnly(w, r) {
+		return
+	}
 	var req CreateFolderRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		invalidBody(w, err)
 		return
 	}
 	
@@ -466,7 +967,7 @@ func (s *Server) APICreateFolder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	q := dbgen.New(s.DB)
+	q := s.queries()
 	s.ensureFolders(r.Context(), q, req.Path+"/dummy.sh")
 	
 	// Get the created folder
@@ -478,19 +979,17 @@ func (s *Server) APICreateFolder(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(FolderResponse{
-		ID:        folder.ID,
-		Path:      folder.Path,
-		Name:      folder.Name,
-		CreatedAt: folder.CreatedAt,
-	})
+	json.NewEncoder(w).Encode(folderToResponse(folder))
 }
 
 // APIDeleteFolder deletes a folder
 func (s *Server) APIDeleteFolder(w http.ResponseWriter, r *http.Request) {
+	if s.replicaReadOnly(w, r) {
+		return
+	}
 	id := r.PathValue("id")
 	
-	q := dbgen.New(s.DB)
+	q := s.queries()
 	
 	folder, err := q.GetFolder(r.Context(), id)
 	if err != nil {
@@ -498,6 +997,15 @@ func (s *Server) APIDeleteFolder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// Deleting a locked folder would silently expose the scripts inside
+	locked, _ := q.ListLockedFolders(r.Context())
+	for _, f := range locked {
+		if f.Path == folder.Path || scriptPrefixMatch(f.Path, folder.Path) {
+			http.Error(w, "Folder "+f.Path+" is locked, unlock it first", http.StatusConflict)
+			return
+		}
+	}
+	
 	if err := q.DeleteFolderByPath(r.Context(), dbgen.DeleteFolderByPathParams{
 		Path:    folder.Path,
 		Column2: &folder.Path,
@@ -517,8 +1025,8 @@ func (s *Server) APISearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	q := dbgen.New(s.DB)
-	scripts, err := q.SearchScripts(r.Context(), dbgen.SearchScriptsParams{
+	q := s.queries()
+	scripts, err := q.SearchScriptMetadata(r.Context(), dbgen.SearchScriptMetadataParams{
 		Column1: &query,
 		Column2: &query,
 		Column3: &query,
@@ -539,7 +1047,7 @@ func (s *Server) APISearch(w http.ResponseWriter, r *http.Request) {
 }
 
 // ensureFolders creates all parent folders for a given script path
-func (s *Server) ensureFolders(ctx context.Context, q *dbgen.Queries, scriptPath string) {
+func (s *Server) ensureFolders(ctx context.Context, q *queries, scriptPath string) {
 	parts := strings.Split(strings.TrimPrefix(scriptPath, "/"), "/")
 	if len(parts) <= 1 {
 		return // No parent folders needed
@@ -0,0 +1,165 @@
+package srv
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// apiPrefix is where the admin API is served. The unversioned /api paths
+// remain as deprecated aliases with plain-text errors.
+const apiPrefix = "/api/v1"
+
+// APIError describes a failed /api/v1 request
+type APIError struct {
+	Code    string       `json:"code"` // the HTTP status text in snake_case, e.g. not_found
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError is a problem with one field of the request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// APIErrorResponse is the body of every /api/v1 error response
+type APIErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// errorCode turns a status into an error code, e.g. 404 into not_found
+func errorCode(status int) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, http.StatusText(status))
+}
+
+// unversionedAPI maps an /api/v1 path or route pattern to its unversioned
+// form, so token scopes name either
+func unversionedAPI(s string) string {
+	if i := strings.Index(s, apiPrefix+"/"); i >= 0 {
+		return s[:i] + "/api/" + s[i+len(apiPrefix)+1:]
+	}
+	return s
+}
+
+// jsonErrorWriter turns plain-text error responses, as written by
+// http.Error, into an APIErrorResponse
+type jsonErrorWriter struct {
+	http.ResponseWriter
+	status  int // of the error being captured, or 0
+	body    bytes.Buffer
+	message string // replaces the captured body when set
+	fields  []FieldError
+}
+
+func (w *jsonErrorWriter) WriteHeader(status int) {
+	if status >= 400 && w.status == 0 && strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		w.status = status
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *jsonErrorWriter) Write(b []byte) (int, error) {
+	if w.status != 0 {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *jsonErrorWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && w.status == 0 {
+		f.Flush()
+	}
+}
+
+func (w *jsonErrorWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish writes the captured error, if any
+func (w *jsonErrorWriter) finish() {
+	if w.status == 0 {
+		return
+	}
+	message := w.message
+	if message == "" {
+		message = strings.TrimSpace(w.body.String())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.ResponseWriter.WriteHeader(w.status)
+	json.NewEncoder(w.ResponseWriter).Encode(APIErrorResponse{Error: APIError{
+		Code:    errorCode(w.status),
+		Message: message,
+		Fields:  w.fields,
+	}})
+}
+
+// jsonErrors answers errors from next with an APIErrorResponse
+func jsonErrors(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jw := &jsonErrorWriter{ResponseWriter: w}
+		next(jw, r)
+		jw.finish()
+	}
+}
+
+// deprecatedAPI marks responses from an unversioned /api path as
+// deprecated in favour of its /api/v1 successor
+func deprecatedAPI(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		successor := apiPrefix + strings.TrimPrefix(r.URL.Path, "/api")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
+
+// handleAPI registers an admin API endpoint, given as e.g. "GET /scripts",
+// under /api/v1 and as a deprecated alias under /api
+func (s *Server) handleAPI(mux *http.ServeMux, pattern string, h http.HandlerFunc) {
+	method, route, _ := strings.Cut(pattern, " ")
+	if s.replica != nil && replicaWrites(method, route) {
+		next := h
+		h = func(w http.ResponseWriter, r *http.Request) {
+			if !s.replicaReadOnly(w, r) {
+				next(w, r)
+			}
+		}
+	}
+	guarded := rateLimited(s.rateLimits.admin, s.adminOnly(h))
+	if !bodyLimited[method+" "+route] {
+		guarded = s.limitBody(guarded)
+	}
+	mux.HandleFunc(method+" "+apiPrefix+route, jsonErrors(guarded))
+	mux.HandleFunc(method+" /api"+route, deprecatedAPI(guarded))
+}
+
+// invalidField answers 400 for a request field that failed validation
+func invalidField(w http.ResponseWriter, field string, err error) {
+	if jw, ok := w.(*jsonErrorWriter); ok {
+		jw.fields = []FieldError{{Field: field, Message: err.Error()}}
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// rejectContent refuses a script's content with message followed by the
+// problems found, one per line; under /api/v1 each problem is a field error
+func rejectContent(w http.ResponseWriter, status int, message string, problems []string) {
+	if jw, ok := w.(*jsonErrorWriter); ok {
+		jw.message = message
+		for _, p := range problems {
+			jw.fields = append(jw.fields, FieldError{Field: "content", Message: p})
+		}
+	}
+	http.Error(w, message+":\n"+strings.Join(problems, "\n"), status)
+}
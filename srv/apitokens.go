@@ -0,0 +1,306 @@
+package srv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// apiTokenPrefix marks scoped API tokens so they are not mistaken for other bearer tokens
+const apiTokenPrefix = "shs_"
+
+// maxScopeBody caps how much of a request body is read to find its script path
+const maxScopeBody = 10 << 20
+
+// credentialRoutes can never be called with an API token, so a token cannot
+// mint or widen other credentials
+var credentialRoutes = []string{"/api/api-tokens", "/api/admin-tokens", "/api/session"}
+
+// splitScope splits a comma-separated scope column into its items
+func splitScope(v *string) []string {
+	if v == nil {
+		return nil
+	}
+	var out []string
+	for _, item := range strings.Split(*v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// pathScopeMatch reports whether a script path matches a scope pattern.
+// /ci/** matches everything under /ci; other patterns are path.Match globs.
+func pathScopeMatch(pattern, p string) bool {
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return scriptPrefixMatch(p, dir)
+	}
+	ok, _ := path.Match(pattern, p)
+	return ok
+}
+
+// apiToken returns the unrevoked API token presented as a bearer token
+func (s *Server) apiToken(r *http.Request) (dbgen.ApiToken, bool) {
+	raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !strings.HasPrefix(raw, apiTokenPrefix) {
+		return dbgen.ApiToken{}, false
+	}
+
+	q := s.queries()
+	tok, err := q.GetAPITokenByHash(r.Context(), hashToken(raw))
+	if err != nil || tok.RevokedAt != nil {
+		return dbgen.ApiToken{}, false
+	}
+	now := time.Now()
+	q.TouchAPIToken(r.Context(), dbgen.TouchAPITokenParams{
+		LastUsedAt: &now,
+		LastUsedIp: s.storedIP(r),
+		ID:         tok.ID,
+	})
+	return tok, true
+}
+
+// scriptTargets returns the script paths a request acts on, or ok=false when
+// the route does not target scripts
+func scriptTargets(r *http.Request, q *queries) ([]string, bool) {
+	var paths []string
+	pattern := unversionedAPI(r.Pattern)
+	if strings.Contains(pattern, "/api/scripts/{id}") {
+		script, err := q.GetScript(r.Context(), r.PathValue("id"))
+		if err != nil {
+			// Let the handler answer 404
+			return nil, true
+		}
+		paths = append(paths, script.Path)
+	}
+
+	// Raw uploads name the script in the URL
+	if pattern == "PUT /{path...}" || pattern == "DELETE /{path...}" {
+		paths = append(paths, r.URL.Path)
+	}
+
+	// Creates and renames carry the (new) path in the body
+	switch pattern {
+	case "POST /api/scripts", "POST /api/scripts/from-template", "PUT /api/scripts/{id}":
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxScopeBody))
+		if err != nil {
+			return nil, false
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		var req struct {
+			Path string `json:"path"`
+		}
+		if json.Unmarshal(body, &req) == nil && req.Path != "" {
+			paths = append(paths, req.Path)
+		}
+	}
+	return paths, len(paths) > 0
+}
+
+// checkTokenScope returns an error when the request is outside the token's scope
+func (s *Server) checkTokenScope(r *http.Request, tok dbgen.ApiToken) error {
+	for _, prefix := range credentialRoutes {
+		if strings.HasPrefix(unversionedAPI(r.URL.Path), prefix) {
+			return errors.New("API tokens cannot manage credentials")
+		}
+	}
+	if tok.ReadOnly != 0 && !safeMethod(r.Method) {
+		return errors.New("token is read-only")
+	}
+	pattern := unversionedAPI(r.Pattern)
+	if endpoints := splitScope(tok.Endpoints); len(endpoints) > 0 && !slices.ContainsFunc(endpoints, func(e string) bool { return unversionedAPI(e) == pattern }) {
+		return fmt.Errorf("token is not allowed to call %s", r.Pattern)
+	}
+
+	patterns := splitScope(tok.Paths)
+	if len(patterns) == 0 {
+		return nil
+	}
+	targets, ok := scriptTargets(r, s.queries())
+	if !ok {
+		return errors.New("token is restricted to script paths: " + strings.Join(patterns, ", "))
+	}
+	for _, p := range targets {
+		if !slices.ContainsFunc(patterns, func(pattern string) bool { return pathScopeMatch(pattern, p) }) {
+			return fmt.Errorf("token is not allowed to access %s", p)
+		}
+	}
+	return nil
+}
+
+// APITokenRequest represents a request to create an API token
+type APITokenRequest struct {
+	Name      string   `json:"name"`
+	ReadOnly  bool     `json:"read_only"`
+	Paths     []string `json:"paths"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// APITokenResponse describes an API token; Token is only set on creation
+type APITokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"`
+	ReadOnly   bool       `json:"read_only"`
+	Paths      []string   `json:"paths"`
+	Endpoints  []string   `json:"endpoints"`
+	Revoked    bool       `json:"revoked"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func apiTokenToResponse(tok dbgen.ApiToken) APITokenResponse {
+	resp := APITokenResponse{
+		ID:         tok.ID,
+		Name:       tok.Name,
+		ReadOnly:   tok.ReadOnly != 0,
+		Paths:      splitScope(tok.Paths),
+		Endpoints:  splitScope(tok.Endpoints),
+		Revoked:    tok.RevokedAt != nil,
+		RevokedAt:  tok.RevokedAt,
+		LastUsedAt: tok.LastUsedAt,
+		CreatedAt:  tok.CreatedAt,
+	}
+	if tok.LastUsedIp != nil {
+		resp.LastUsedIP = *tok.LastUsedIp
+	}
+	return resp
+}
+
+// validate checks the scope entries of the request
+func (req *APITokenRequest) validate() error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("name is required")
+	}
+	for _, p := range req.Paths {
+		if !strings.HasPrefix(p, "/") || strings.Contains(p, ",") {
+			return fmt.Errorf("invalid path pattern %q", p)
+		}
+		if _, err := path.Match(strings.TrimSuffix(p, "/**"), ""); err != nil {
+			return fmt.Errorf("invalid path pattern %q", p)
+		}
+	}
+	for _, e := range req.Endpoints {
+		method, route, ok := strings.Cut(e, " ")
+		if !ok || method == "" || !strings.HasPrefix(route, "/api/") || strings.Contains(e, ",") {
+			return fmt.Errorf("invalid endpoint %q, expected e.g. \"PUT /api/v1/scripts/{id}\"", e)
+		}
+	}
+	return nil
+}
+
+// APICreateAPIToken mints a scoped API token; the token is only returned here
+func (s *Server) APICreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	var req APITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	id := uuid.New().String()
+	token := apiTokenPrefix + randomToken(32)
+	readOnlyInt := int64(0)
+	if req.ReadOnly {
+		readOnlyInt = 1
+	}
+
+	q := s.queries()
+	err := q.CreateAPIToken(r.Context(), dbgen.CreateAPITokenParams{
+		ID:        id,
+		Name:      req.Name,
+		TokenHash: hashToken(token),
+		ReadOnly:  readOnlyInt,
+		Paths:     strPtr(strings.Join(req.Paths, ",")),
+		Endpoints: strPtr(strings.Join(req.Endpoints, ",")),
+		CreatedAt: now,
+	})
+	if err != nil {
+		http.Error(w, "Failed to create API token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "CREATE",
+		EntityType: "api_token",
+		EntityID:   &id,
+		Details:    &req.Name,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	tok, _ := q.GetAPIToken(r.Context(), id)
+	resp := apiTokenToResponse(tok)
+	resp.Token = token
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIListAPITokens returns all API tokens without their secrets
+func (s *Server) APIListAPITokens(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	tokens, err := q.ListAPITokens(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list API tokens", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]APITokenResponse, len(tokens))
+	for i, tok := range tokens {
+		resp[i] = apiTokenToResponse(tok)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIRevokeAPIToken revokes an API token; the record is kept for tracking
+func (s *Server) APIRevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	tok, err := q.GetAPIToken(r.Context(), id)
+	if err != nil {
+		http.Error(w, "API token not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	if err := q.RevokeAPIToken(r.Context(), dbgen.RevokeAPITokenParams{RevokedAt: &now, ID: id}); err != nil {
+		http.Error(w, "Failed to revoke API token", http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "REVOKE",
+		EntityType: "api_token",
+		EntityID:   &id,
+		Details:    &tok.Name,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
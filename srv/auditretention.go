@@ -0,0 +1,106 @@
+package srv
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// auditPruneInterval is how often old audit rows are pruned
+const auditPruneInterval = time.Hour
+
+// auditPruneBatch is how many rows are archived and deleted at a time
+const auditPruneBatch = 1000
+
+// AuditRetentionConfig controls how long audit rows are kept
+type AuditRetentionConfig struct {
+	Retention  time.Duration // rows older than this are pruned; 0 keeps everything
+	ArchiveDir string        // pruned rows are first appended here as gzipped JSONL
+}
+
+// pruneAuditLog deletes audit rows older than the retention period, archiving
+// them first if an archive directory is configured, and returns how many rows
+// were pruned
+func (s *Server) pruneAuditLog(ctx context.Context, now time.Time) (int64, error) {
+	cutoff := now.Add(-s.AuditRetention.Retention)
+	q := s.queries()
+
+	var archive *gzip.Writer
+	var archiveName string
+	if s.AuditRetention.ArchiveDir != "" {
+		archiveName = filepath.Join(s.AuditRetention.ArchiveDir, "audit-"+now.UTC().Format("20060102T150405")+".jsonl.gz")
+		f, err := os.OpenFile(archiveName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return 0, fmt.Errorf("open audit archive: %w", err)
+		}
+		defer f.Close()
+		archive = gzip.NewWriter(f)
+		defer archive.Close()
+	}
+
+	var pruned int64
+	for {
+		rows, err := q.ListAuditLogsBefore(ctx, dbgen.ListAuditLogsBeforeParams{
+			CreatedAt: cutoff,
+			Limit:     auditPruneBatch,
+		})
+		if err != nil {
+			return pruned, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		if archive != nil {
+			enc := json.NewEncoder(archive)
+			for _, row := range rows {
+				if err := enc.Encode(row); err != nil {
+					return pruned, fmt.Errorf("write audit archive: %w", err)
+				}
+			}
+			if err := archive.Flush(); err != nil {
+				return pruned, fmt.Errorf("write audit archive: %w", err)
+			}
+		}
+
+		n, err := q.DeleteAuditLogsThrough(ctx, dbgen.DeleteAuditLogsThroughParams{
+			ID:        rows[len(rows)-1].ID,
+			CreatedAt: cutoff,
+		})
+		if err != nil {
+			return pruned, err
+		}
+		pruned += n
+		if len(rows) < auditPruneBatch {
+			break
+		}
+	}
+
+	if archive != nil && pruned == 0 {
+		// Nothing was archived; don't leave an empty file behind
+		os.Remove(archiveName)
+	}
+	return pruned, nil
+}
+
+// runAuditPruneJob prunes the audit log now and then every auditPruneInterval
+func (s *Server) runAuditPruneJob() {
+	ticker := time.NewTicker(auditPruneInterval)
+	defer ticker.Stop()
+	for {
+		n, err := s.pruneAuditLog(context.Background(), time.Now())
+		if err != nil {
+			slog.Error("audit log pruning failed", "error", err)
+		} else if n > 0 {
+			slog.Info("pruned audit log", "rows", n, "retention", s.AuditRetention.Retention)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,58 @@
+package srv
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// authFailLogger writes one line per failed authentication in a fixed format
+// that fail2ban or CrowdSec can match:
+//
+//	2026-01-02T15:04:05Z sh-server[auth]: unlock failed from 203.0.113.7 path="/tools/secret.sh"
+//
+// Only the time, kind and client IP are guaranteed; the path is quoted so a
+// request can never inject a line of its own.
+type authFailLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// openAuthFailLog appends to file, or writes to stderr for "-"
+func openAuthFailLog(file string) (*authFailLogger, error) {
+	if file == "-" {
+		return &authFailLogger{w: os.Stderr}, nil
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open auth failure log: %w", err)
+	}
+	return &authFailLogger{w: f}, nil
+}
+
+// authFailed records a failed authentication of the given kind (unlock,
+// login, admin) if AUTH_FAIL_LOG is set
+func (s *Server) authFailed(r *http.Request, kind, path string) {
+	if s.authFails == nil {
+		return
+	}
+	line := fmt.Sprintf("%s sh-server[auth]: %s failed from %s path=%q\n",
+		time.Now().UTC().Format(time.RFC3339), kind, clientIP(r), path)
+
+	s.authFails.mu.Lock()
+	defer s.authFails.mu.Unlock()
+	io.WriteString(s.authFails.w, line)
+}
+
+// hasCredentials reports whether the request tried to authenticate to the
+// admin API at all, so plain logged-out requests are not reported
+func hasCredentials(r *http.Request) bool {
+	if r.Header.Get("X-Admin-Token") != "" || r.Header.Get("Authorization") != "" {
+		return true
+	}
+	_, _, ok := r.BasicAuth()
+	return ok
+}
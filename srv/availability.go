@@ -0,0 +1,48 @@
+package srv
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// availabilityLayout is how window bounds are shown to users
+const availabilityLayout = "2006-01-02 15:04 MST"
+
+// scriptAvailable reports whether now falls inside the script's availability
+// window. When it does not, the returned message explains why.
+func scriptAvailable(script dbgen.Script, now time.Time) (bool, string) {
+	if script.AvailableFrom != nil && now.Before(*script.AvailableFrom) {
+		return false, "not available until " + script.AvailableFrom.UTC().Format(availabilityLayout)
+	}
+	if script.AvailableUntil != nil && !now.Before(*script.AvailableUntil) {
+		return false, "no longer available since " + script.AvailableUntil.UTC().Format(availabilityLayout)
+	}
+	return true, ""
+}
+
+// serveUnavailable answers a request for a script outside its availability
+// window. Browsers get 404; CLI clients get a script that explains the window
+// and exits 1.
+func (s *Server) serveUnavailable(w http.ResponseWriter, r *http.Request, script dbgen.Script, reason string) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !isCLI(r) {
+		http.Error(w, fmt.Sprintf("%s is %s", script.Path, reason), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "#!/bin/sh\necho %s >&2\nexit 1\n", shellQuote(fmt.Sprintf("ERROR: %s is %s", script.Path, reason)))
+}
+
+// validateAvailability checks that an availability window is not empty
+func validateAvailability(from, until *time.Time) error {
+	if from != nil && until != nil && !until.After(*from) {
+		return errors.New("available_until must be after available_from")
+	}
+	return nil
+}
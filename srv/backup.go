@@ -0,0 +1,269 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db"
+	"github.com/hunydev/sh-server/db/dbgen"
+	"github.com/hunydev/sh-server/storage"
+)
+
+// maxRestoreSize caps the snapshot a restore accepts
+const maxRestoreSize = 1 << 30
+
+// BackupConfig takes a snapshot of the database every Interval and keeps
+// the newest Keep of them in a directory or an S3 bucket
+type BackupConfig struct {
+	Interval time.Duration // 0 disables scheduled backups
+	Keep     int           // older snapshots are deleted; 0 keeps them all
+	Dir      string
+	S3       storage.S3Config // used instead of Dir when Bucket is set
+}
+
+// backupStore is where scheduled backups go
+type backupStore interface {
+	storage.Backend
+	storage.Lister
+}
+
+func openBackupStore(cfg BackupConfig) (backupStore, error) {
+	if cfg.S3.Bucket != "" {
+		return storage.NewS3(cfg.S3)
+	}
+	if cfg.Dir == "" {
+		return nil, errors.New("scheduled backups need a directory or an S3 bucket")
+	}
+	return storage.NewFS(cfg.Dir)
+}
+
+const backupPrefix, backupSuffix = "sh-backup-", ".db"
+
+// backupName names a snapshot taken at t; names sort by time
+func backupName(t time.Time) string {
+	return backupPrefix + t.UTC().Format("20060102-150405") + backupSuffix
+}
+
+// backupsSupported answers 501 unless the database is SQLite
+func (s *Server) backupsSupported(w http.ResponseWriter) bool {
+	if s.dialect != db.SQLite {
+		http.Error(w, "Backups are only available on SQLite; use pg_dump for PostgreSQL", http.StatusNotImplemented)
+		return false
+	}
+	return true
+}
+
+// APIBackup streams a consistent snapshot of the database, taken with
+// SQLite's online backup API while the server keeps running
+func (s *Server) APIBackup(w http.ResponseWriter, r *http.Request) {
+	if !s.backupsSupported(w) {
+		return
+	}
+	dir, err := os.MkdirTemp("", "sh-backup")
+	if err != nil {
+		http.Error(w, "Failed to create backup", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup.db")
+	s.flushWrites()
+	if err := db.Backup(r.Context(), s.DB, path); err != nil {
+		http.Error(w, "Failed to create backup: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		http.Error(w, "Failed to create backup", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, "Failed to create backup", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	details := fmt.Sprintf("%d bytes", info.Size())
+	s.queries().CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "BACKUP",
+		EntityType: "database",
+		Details:    &details,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+backupName(now)+`"`)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	w.Header().Set("Cache-Control", "no-store")
+	io.Copy(w, f)
+}
+
+// APIRestore replaces the database with an uploaded snapshot. It needs
+// ?confirm=true, as everything written since the snapshot is lost.
+func (s *Server) APIRestore(w http.ResponseWriter, r *http.Request) {
+	if !s.backupsSupported(w) {
+		return
+	}
+	if confirm, _ := strconv.ParseBool(r.URL.Query().Get("confirm")); !confirm {
+		invalidField(w, "confirm", errors.New("restoring replaces the whole database; repeat with confirm=true"))
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "sh-restore")
+	if err != nil {
+		http.Error(w, "Failed to restore", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "restore.db")
+	f, err := os.Create(path)
+	if err != nil {
+		http.Error(w, "Failed to restore", http.StatusInternalServerError)
+		return
+	}
+	size, err := io.Copy(f, http.MaxBytesReader(w, r.Body, maxRestoreSize))
+	f.Close()
+	if err != nil {
+		http.Error(w, "Failed to read snapshot: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := db.CheckSnapshot(r.Context(), path); err != nil {
+		http.Error(w, "Invalid snapshot: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := db.Restore(r.Context(), s.DB, path); err != nil {
+		http.Error(w, "Failed to restore: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.invalidateCaches()
+	// The snapshot may be from an older version of the server
+	if err := db.RunMigrations(s.DB); err != nil {
+		http.Error(w, "Restored, but failed to run migrations: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := s.setUpStorage(r.Context()); err != nil {
+		http.Error(w, "Restored, but the storage backend doesn't fit: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	details := fmt.Sprintf("%d bytes", size)
+	s.queries().CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "RESTORE",
+		EntityType: "database",
+		Details:    &details,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"restored": true, "size": size})
+}
+
+// BackupInfo is a stored scheduled backup
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// listBackups returns the stored backups, newest first
+func (s *Server) listBackups(ctx context.Context) ([]BackupInfo, error) {
+	objects, err := s.backups.List(ctx, backupPrefix)
+	if err != nil {
+		return nil, err
+	}
+	backups := []BackupInfo{}
+	for _, o := range objects {
+		if strings.Contains(o.Key, "/") || !strings.HasSuffix(o.Key, backupSuffix) {
+			continue
+		}
+		created, err := time.Parse("20060102-150405", strings.TrimSuffix(strings.TrimPrefix(o.Key, backupPrefix), backupSuffix))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, BackupInfo{Name: o.Key, Size: o.Size, CreatedAt: created})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
+
+// takeBackup stores a snapshot of the database and deletes the ones past
+// the retention count
+func (s *Server) takeBackup(ctx context.Context, now time.Time) (BackupInfo, error) {
+	dir, err := os.MkdirTemp("", "sh-backup")
+	if err != nil {
+		return BackupInfo{}, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup.db")
+	s.flushWrites()
+	if err := db.Backup(ctx, s.DB, path); err != nil {
+		return BackupInfo{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return BackupInfo{}, err
+	}
+	info := BackupInfo{Name: backupName(now), Size: int64(len(data)), CreatedAt: now.UTC().Truncate(time.Second)}
+	if err := s.backups.Put(ctx, info.Name, data); err != nil {
+		return BackupInfo{}, fmt.Errorf("store backup: %w", err)
+	}
+
+	if s.Backups.Keep > 0 {
+		backups, err := s.listBackups(ctx)
+		if err != nil {
+			return info, fmt.Errorf("list backups: %w", err)
+		}
+		for _, old := range backups[min(s.Backups.Keep, len(backups)):] {
+			if err := s.backups.Delete(ctx, old.Name); err != nil {
+				slog.Warn("failed to delete old backup", "name", old.Name, "error", err)
+			}
+		}
+	}
+	return info, nil
+}
+
+func (s *Server) runBackupJob() {
+	ticker := time.NewTicker(s.Backups.Interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		info, err := s.takeBackup(context.Background(), time.Now())
+		if err != nil {
+			slog.Error("scheduled backup failed", "error", err)
+			continue
+		}
+		slog.Info("scheduled backup", "name", info.Name, "size", info.Size)
+	}
+}
+
+// APIListBackups lists the stored scheduled backups, newest first
+func (s *Server) APIListBackups(w http.ResponseWriter, r *http.Request) {
+	if s.backups == nil {
+		http.Error(w, "Scheduled backups are not configured", http.StatusNotFound)
+		return
+	}
+	backups, err := s.listBackups(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list backups: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backups)
+}
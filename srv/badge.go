@@ -0,0 +1,102 @@
+package srv
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// badgePrefix is where badges live: /badge/tools/foo.sh.svg is the badge
+// of /tools/foo.sh
+const badgePrefix = "/badge"
+
+// Badge colors, as used by shields.io
+const (
+	badgeLabelColor = "#555"
+	badgeOK         = "#4c1"
+	badgeWarning    = "#fe7d37"
+	badgeDown       = "#e05d44"
+)
+
+// isBadgePath reports whether a request path asks for a badge
+func isBadgePath(path string) bool {
+	return strings.HasPrefix(path, badgePrefix+"/") && strings.HasSuffix(path, ".svg")
+}
+
+// humanCount shortens a count the way badges usually show it: 999, 1.2k, 3.4M
+func humanCount(n int64) string {
+	switch {
+	case n < 1000:
+		return strconv.FormatInt(n, 10)
+	case n < 1000000:
+		return strings.TrimSuffix(strconv.FormatFloat(float64(n)/1000, 'f', 1, 64), ".0") + "k"
+	}
+	return strings.TrimSuffix(strconv.FormatFloat(float64(n)/1000000, 'f', 1, 64), ".0") + "M"
+}
+
+// badgeTextWidth estimates the rendered width of text in 11px Verdana
+func badgeTextWidth(text string) int {
+	return len([]rune(text))*7 + 10
+}
+
+// renderBadge draws a two-part badge in the flat shields.io style. link is
+// followed when the SVG is opened directly.
+func renderBadge(label, message, color, link string) string {
+	lw, mw := badgeTextWidth(label), badgeTextWidth(message)
+	label, message, link = html.EscapeString(label), html.EscapeString(message), html.EscapeString(link)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">
+<title>%[4]s: %[5]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<a xlink:href="%[8]s" target="_blank">
+<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="%[6]s"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[7]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[9]d" y="15" fill="#010101" fill-opacity=".3">%[4]s</text><text x="%[9]d" y="14">%[4]s</text>
+<text x="%[10]d" y="15" fill="#010101" fill-opacity=".3">%[5]s</text><text x="%[10]d" y="14">%[5]s</text>
+</g>
+</a>
+</svg>
+`, lw+mw, lw, mw, label, message, badgeLabelColor, color, link, lw/2, lw+mw/2)
+}
+
+// HandleBadge serves an SVG badge with the download count and current
+// version of a script, for embedding in wikis and READMEs. The label can be
+// changed with ?label=. Private and archived scripts have no badge.
+func (s *Server) HandleBadge(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, badgePrefix), ".svg")
+	q := s.queries()
+	script, err := q.GetScriptByPath(r.Context(), path)
+	if err != nil || script.Private != 0 || script.Archived != 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	var fetches int64
+	if st, err := q.GetScriptStats(r.Context(), script.ID); err == nil {
+		fetches = st.Fetches
+	}
+	message := humanCount(fetches) + " downloads"
+	if version, err := q.GetCurrentVersion(r.Context(), script.ID); err == nil && version > 0 {
+		message = "v" + strconv.FormatInt(version, 10) + " | " + message
+	}
+
+	color := badgeOK
+	switch {
+	case script.Disabled != 0:
+		color, message = badgeDown, "disabled"
+	case script.Deprecated != 0:
+		color = badgeWarning
+		message += " | deprecated"
+	}
+
+	label := r.URL.Query().Get("label")
+	if label == "" {
+		label = script.Name
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "max-age=300")
+	fmt.Fprint(w, renderBadge(label, message, color, path))
+}
@@ -0,0 +1,322 @@
+package srv
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// rolloutBucket maps a key to a stable bucket in [0, 100)
+func rolloutBucket(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % 100)
+}
+
+// applyCanary swaps in the canary version's content when a rollout is active
+// and this request falls into it. ?canary=1 forces the canary, ?canary=0 the
+// stable version. Serve counters are updated for whichever version is chosen.
+func (s *Server) applyCanary(w http.ResponseWriter, r *http.Request, q *queries, script dbgen.Script) dbgen.Script {
+	ctx := r.Context()
+	canary, err := q.GetCanary(ctx, script.ID)
+	if err != nil {
+		return script
+	}
+
+	var useCanary bool
+	switch r.URL.Query().Get("canary") {
+	case "1":
+		useCanary = true
+	case "0":
+		useCanary = false
+	default:
+		useCanary = rolloutBucket(script.ID+"|"+clientIP(r)) < int(canary.Percent)
+	}
+
+	version := canary.StableVersion
+	if useCanary {
+		v, err := q.GetVersion(ctx, dbgen.GetVersionParams{ScriptID: script.ID, Version: canary.CanaryVersion})
+		if err != nil {
+			return script
+		}
+		script.Content = v.Content
+		version = canary.CanaryVersion
+	}
+
+	q.IncrementVersionServes(ctx, dbgen.IncrementVersionServesParams{ScriptID: script.ID, Version: version})
+	w.Header().Set("X-Script-Version", strconv.FormatInt(version, 10))
+	return script
+}
+
+// CanaryRequest represents a request to start or adjust a canary rollout
+type CanaryRequest struct {
+	Content string `json:"content"`
+	Percent int    `json:"percent"`
+}
+
+// CanaryResponse describes an active rollout with per-version serve counters
+type CanaryResponse struct {
+	ScriptID      string    `json:"script_id"`
+	StableVersion int64     `json:"stable_version"`
+	CanaryVersion int64     `json:"canary_version"`
+	Percent       int64     `json:"percent"`
+	StableServes  int64     `json:"stable_serves"`
+	CanaryServes  int64     `json:"canary_serves"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func canaryToResponse(ctx context.Context, q *queries, c dbgen.Canary) CanaryResponse {
+	resp := CanaryResponse{
+		ScriptID:      c.ScriptID,
+		StableVersion: c.StableVersion,
+		CanaryVersion: c.CanaryVersion,
+		Percent:       c.Percent,
+		CreatedAt:     c.CreatedAt,
+	}
+	if v, err := q.GetVersion(ctx, dbgen.GetVersionParams{ScriptID: c.ScriptID, Version: c.StableVersion}); err == nil {
+		resp.StableServes = v.Serves
+	}
+	if v, err := q.GetVersion(ctx, dbgen.GetVersionParams{ScriptID: c.ScriptID, Version: c.CanaryVersion}); err == nil {
+		resp.CanaryServes = v.Serves
+	}
+	return resp
+}
+
+func validPercent(p int) bool {
+	return p >= 0 && p <= 100
+}
+
+// APIGetCanary returns the active rollout of a script
+func (s *Server) APIGetCanary(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	canary, err := q.GetCanary(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "No canary rollout for this script", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(canaryToResponse(r.Context(), q, canary))
+}
+
+// APIStartCanary stores new content as a version and starts serving it to a
+// percentage of clients, leaving the current content as the stable version
+func (s *Server) APIStartCanary(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req CanaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if req.Content == "" {
+		http.Error(w, "Content is required", http.StatusBadRequest)
+		return
+	}
+	if !s.checkScriptSize(w, req.Content) {
+		return
+	}
+	if !validPercent(req.Percent) {
+		http.Error(w, "Percent must be between 0 and 100", http.StatusBadRequest)
+		return
+	}
+
+	q := s.queries()
+	script, err := q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+	if _, ok := s.checkInstanceLimits(w, r, q, &script, req.Content); !ok {
+		return
+	}
+	if !s.checkPreSave(w, r, "update", script.Path, req.Content) {
+		return
+	}
+	if _, err := q.GetCanary(r.Context(), id); err == nil {
+		http.Error(w, "A canary rollout is already active; promote or abort it first", http.StatusConflict)
+		return
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Failed to check canary", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	versions, _ := q.ListVersions(r.Context(), id)
+	stable := int64(1)
+	if len(versions) > 0 {
+		stable = versions[0].Version
+	} else {
+		// Scripts created before versioning have no history; record the current content
+		q.CreateVersion(r.Context(), dbgen.CreateVersionParams{
+			ScriptID:  id,
+			Content:   script.Content,
+			Version:   stable,
+			CreatedAt: now,
+		})
+	}
+	canaryVersion := stable + 1
+
+	if err := q.CreateVersion(r.Context(), dbgen.CreateVersionParams{
+		ScriptID:  id,
+		Content:   req.Content,
+		Version:   canaryVersion,
+		CreatedAt: now,
+	}); err != nil {
+		http.Error(w, "Failed to create version: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := q.CreateCanary(r.Context(), dbgen.CreateCanaryParams{
+		ScriptID:      id,
+		StableVersion: stable,
+		CanaryVersion: canaryVersion,
+		Percent:       int64(req.Percent),
+		CreatedAt:     now,
+	}); err != nil {
+		http.Error(w, "Failed to start canary: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "CANARY_START",
+		EntityType: "script",
+		EntityID:   &id,
+		EntityPath: &script.Path,
+		Details:    strPtr("version " + strconv.FormatInt(canaryVersion, 10) + " at " + strconv.Itoa(req.Percent) + "%"),
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	canary, _ := q.GetCanary(r.Context(), id)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(canaryToResponse(r.Context(), q, canary))
+}
+
+// APIUpdateCanary changes the rollout percentage
+func (s *Server) APIUpdateCanary(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req CanaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if !validPercent(req.Percent) {
+		http.Error(w, "Percent must be between 0 and 100", http.StatusBadRequest)
+		return
+	}
+
+	q := s.queries()
+	if _, err := q.GetCanary(r.Context(), id); err != nil {
+		http.Error(w, "No canary rollout for this script", http.StatusNotFound)
+		return
+	}
+	if err := q.UpdateCanaryPercent(r.Context(), dbgen.UpdateCanaryPercentParams{
+		Percent:  int64(req.Percent),
+		ScriptID: id,
+	}); err != nil {
+		http.Error(w, "Failed to update canary", http.StatusInternalServerError)
+		return
+	}
+
+	canary, _ := q.GetCanary(r.Context(), id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(canaryToResponse(r.Context(), q, canary))
+}
+
+// APIPromoteCanary makes the canary version the script's content for everyone
+func (s *Server) APIPromoteCanary(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	canary, err := q.GetCanary(r.Context(), id)
+	if err != nil {
+		http.Error(w, "No canary rollout for this script", http.StatusNotFound)
+		return
+	}
+	version, err := q.GetVersion(r.Context(), dbgen.GetVersionParams{ScriptID: id, Version: canary.CanaryVersion})
+	if err != nil {
+		http.Error(w, "Canary version not found", http.StatusInternalServerError)
+		return
+	}
+	current, err := q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+	if !s.checkPreSave(w, r, "update", current.Path, version.Content) {
+		return
+	}
+
+	now := time.Now()
+	if err := q.UpdateScriptContent(r.Context(), dbgen.UpdateScriptContentParams{
+		Content:   version.Content,
+		UpdatedAt: now,
+		ID:        id,
+	}); err != nil {
+		http.Error(w, "Failed to promote canary: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	q.DeleteCanary(r.Context(), id)
+
+	script, _ := q.GetScript(r.Context(), id)
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "CANARY_PROMOTE",
+		EntityType: "script",
+		EntityID:   &id,
+		EntityPath: &script.Path,
+		Details:    strPtr("version " + strconv.FormatInt(canary.CanaryVersion, 10)),
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+	ev := s.scriptEvent(r.Context(), q, EventScriptUpdated, script)
+	ev.Version, ev.Diff = canary.CanaryVersion, nil
+	s.emit(r.Context(), ev)
+	s.postSave(r.Context(), "update", script.Path, script.Content)
+	s.mirrorSave(r.Context(), "", script, "Promote canary version "+strconv.FormatInt(canary.CanaryVersion, 10))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scriptToResponse(script))
+}
+
+// APIAbortCanary stops the rollout; the canary version stays in the history
+func (s *Server) APIAbortCanary(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	canary, err := q.GetCanary(r.Context(), id)
+	if err != nil {
+		http.Error(w, "No canary rollout for this script", http.StatusNotFound)
+		return
+	}
+	if err := q.DeleteCanary(r.Context(), id); err != nil {
+		http.Error(w, "Failed to abort canary", http.StatusInternalServerError)
+		return
+	}
+
+	script, _ := q.GetScript(r.Context(), id)
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "CANARY_ABORT",
+		EntityType: "script",
+		EntityID:   &id,
+		EntityPath: &script.Path,
+		Details:    strPtr("version " + strconv.FormatInt(canary.CanaryVersion, 10)),
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
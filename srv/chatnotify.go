@@ -0,0 +1,150 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// Webhook kinds
+const (
+	webhookJSON    = "json"
+	webhookSlack   = "slack"
+	webhookDiscord = "discord"
+	webhookNtfy    = "ntfy"
+)
+
+// webhookKinds lists every kind a webhook can have
+var webhookKinds = []string{webhookJSON, webhookSlack, webhookDiscord, webhookNtfy}
+
+// ntfyDefaultEvents are sent to ntfy topics registered without events: the
+// ones worth a phone push
+var ntfyDefaultEvents = []string{EventUnlockLockout, EventScriptDangerous}
+
+// DiffSummary counts the lines an update added and removed
+type DiffSummary struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// diffStat compares two contents line by line, ignoring order
+func diffStat(old, new string) DiffSummary {
+	counts := map[string]int{}
+	for _, line := range strings.Split(old, "\n") {
+		counts[line]++
+	}
+	var d DiffSummary
+	for _, line := range strings.Split(new, "\n") {
+		if counts[line] > 0 {
+			counts[line]--
+		} else {
+			d.Added++
+		}
+	}
+	for _, n := range counts {
+		d.Removed += n
+	}
+	return d
+}
+
+// chatMarkup formats links and code for one chat tool
+type chatMarkup struct {
+	link func(url, text string) string
+	code func(text string) string
+}
+
+var (
+	slackMarkup = chatMarkup{
+		link: func(url, text string) string { return "<" + url + "|" + text + ">" },
+		code: func(text string) string { return "`" + text + "`" },
+	}
+	discordMarkup = chatMarkup{
+		link: func(url, text string) string { return "[" + text + "](<" + url + ">)" },
+		code: func(text string) string { return "`" + text + "`" },
+	}
+	plainMarkup = chatMarkup{
+		link: func(url, text string) string { return text },
+		code: func(text string) string { return text },
+	}
+)
+
+// chatMessage renders an event as one line for humans
+func (s *Server) chatMessage(ev WebhookEvent, m chatMarkup) string {
+	who := ev.Actor
+	if who == "" {
+		who = "someone"
+	}
+	path := m.code(ev.Path)
+	if ev.Event != EventScriptDeleted && ev.ScriptID != "" {
+		path = m.link(s.virtualHostOf(ev.Path).scriptURL(s.Hostname, ev.Path), ev.Path)
+	}
+
+	var msg string
+	switch ev.Event {
+	case EventScriptCreated:
+		msg = fmt.Sprintf("📄 %s created %s", who, path)
+	case EventScriptUpdated:
+		msg = fmt.Sprintf("✏️ %s updated %s", who, path)
+		if ev.Version > 0 {
+			msg += fmt.Sprintf(" to v%d", ev.Version)
+		}
+		if ev.Diff != nil {
+			msg += fmt.Sprintf(" (+%d −%d lines)", ev.Diff.Added, ev.Diff.Removed)
+		}
+	case EventScriptDeleted:
+		msg = fmt.Sprintf("🗑️ %s deleted %s", who, path)
+	case EventUnlockFailed:
+		msg = fmt.Sprintf("🔐 Failed unlock of %s from %s", path, ev.IPAddress)
+	case EventUnlockLockout:
+		msg = fmt.Sprintf("🚨 Unlock lockout on %s: %s", path, ev.Details)
+	case EventHoneypotHit:
+		msg = fmt.Sprintf("🍯 Honeypot %s fetched from %s", path, ev.IPAddress)
+	case EventScriptDangerous:
+		msg = fmt.Sprintf("⚠️ %s saved dangerous script %s: %s", who, path, ev.Details)
+	default:
+		msg = fmt.Sprintf("%s %s", ev.Event, path)
+	}
+	return msg
+}
+
+// webhookBody is what a webhook of the given kind is sent for an event
+func (s *Server) webhookBody(kind string, ev WebhookEvent) []byte {
+	var body []byte
+	switch kind {
+	case webhookSlack:
+		body, _ = json.Marshal(map[string]string{"text": s.chatMessage(ev, slackMarkup)})
+	case webhookDiscord:
+		body, _ = json.Marshal(map[string]string{"content": s.chatMessage(ev, discordMarkup)})
+	case webhookNtfy:
+		body = []byte(s.chatMessage(ev, plainMarkup))
+	default:
+		body, _ = json.Marshal(ev)
+	}
+	return body
+}
+
+// ntfyPriority maps events to ntfy message priorities
+func ntfyPriority(event string) string {
+	switch event {
+	case EventUnlockLockout, EventScriptDangerous:
+		return "urgent"
+	case EventHoneypotHit, EventUnlockFailed:
+		return "high"
+	}
+	return "default"
+}
+
+// setNtfyHeaders sets the title, priority and tags of an ntfy message. The
+// webhook secret, if any, is the ntfy access token of the topic.
+func setNtfyHeaders(req *http.Request, hook dbgen.Webhook, event string) {
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("Title", "sh-server: "+event)
+	req.Header.Set("Priority", ntfyPriority(event))
+	req.Header.Set("Tags", strings.ReplaceAll(event, ".", ","))
+	if hook.Secret != "" {
+		req.Header.Set("Authorization", "Bearer "+hook.Secret)
+	}
+}
@@ -0,0 +1,201 @@
+package srv
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// Kinds of fetch_breakdown rows
+const (
+	breakdownClient   = "client"
+	breakdownReferrer = "referrer"
+	breakdownCountry  = "country"
+	breakdownRegion   = "region"
+)
+
+// directReferrer stands for fetches without a Referer header
+const directReferrer = "(direct)"
+
+// clientFamilies maps User-Agent substrings (lower case) to client families.
+// CI is checked first since pipelines usually run curl or wget underneath;
+// they are recognized by their own agents or by a "ci/" prefix, e.g.
+// curl -A "ci/deploy" ...
+var clientFamilies = []struct {
+	family   string
+	patterns []string
+}{
+	{"ci", []string{"ci/", "github-actions", "gitlab-runner", "jenkins", "buildkite", "circleci", "drone", "azure-pipelines", "teamcity", "bitbucket-pipelines"}},
+	{"crawler", []string{"bot", "crawler", "spider", "slurp", "facebookexternalhit"}},
+	{"curl", []string{"curl"}},
+	{"wget", []string{"wget"}},
+	{"powershell", []string{"powershell"}},
+	{"python", []string{"python"}},
+	{"go", []string{"go-http-client"}},
+	{"browser", []string{"mozilla/"}},
+}
+
+// clientFamily classifies a User-Agent
+func clientFamily(ua string) string {
+	ua = strings.ToLower(ua)
+	if ua == "" {
+		return "unknown"
+	}
+	for _, f := range clientFamilies {
+		for _, p := range f.patterns {
+			if strings.Contains(ua, p) {
+				return f.family
+			}
+		}
+	}
+	return "other"
+}
+
+// referrerHost returns the host of a Referer header
+func referrerHost(ref string) string {
+	if ref == "" {
+		return directReferrer
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" {
+		return "(invalid)"
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// fetchBreakdown returns the breakdown values of a fetch, by kind. The
+// location is only known with a GeoIP database.
+func (s *Server) fetchBreakdown(r *http.Request) map[string]string {
+	values := map[string]string{
+		breakdownClient:   clientFamily(r.Header.Get("User-Agent")),
+		breakdownReferrer: referrerHost(r.Header.Get("Referer")),
+	}
+	if s.geoip != nil {
+		country, region := s.geoip.location(net.ParseIP(clientIP(r)))
+		if country == "" {
+			country = "unknown"
+		}
+		values[breakdownCountry] = country
+		if region != "" {
+			values[breakdownRegion] = region
+		}
+	}
+	return values
+}
+
+// BreakdownEntry is the number of fetches with one value
+type BreakdownEntry struct {
+	Name    string `json:"name"`
+	Fetches int64  `json:"fetches"`
+}
+
+// ClientStatsResponse splits fetches by client family and referrer
+type ClientStatsResponse struct {
+	Days      int              `json:"days"`
+	Clients   []BreakdownEntry `json:"clients"`
+	Referrers []BreakdownEntry `json:"referrers"`
+}
+
+// GeoStatsResponse splits fetches by country and, with a City database,
+// by region
+type GeoStatsResponse struct {
+	Days      int              `json:"days"`
+	Countries []BreakdownEntry `json:"countries"`
+	Regions   []BreakdownEntry `json:"regions"`
+}
+
+// loadBreakdown sums fetch_breakdown rows of one kind since day, for one
+// script or, with an empty scriptID, for all scripts
+func loadBreakdown(r *http.Request, q *queries, scriptID, kind, since string) ([]BreakdownEntry, error) {
+	out := []BreakdownEntry{}
+	if scriptID == "" {
+		rows, err := q.ListAllFetchBreakdown(r.Context(), dbgen.ListAllFetchBreakdownParams{Kind: kind, Day: since})
+		for _, row := range rows {
+			out = append(out, BreakdownEntry{Name: row.Value, Fetches: row.Fetches})
+		}
+		return out, err
+	}
+	rows, err := q.ListFetchBreakdown(r.Context(), dbgen.ListFetchBreakdownParams{ScriptID: scriptID, Kind: kind, Day: since})
+	for _, row := range rows {
+		out = append(out, BreakdownEntry{Name: row.Value, Fetches: row.Fetches})
+	}
+	return out, err
+}
+
+// writeClientStats answers with the client and referrer breakdown
+func (s *Server) writeClientStats(w http.ResponseWriter, r *http.Request, scriptID string) {
+	days := statsDays(r)
+	since := statsSince(time.Now(), days)
+	q := s.queries()
+
+	resp := ClientStatsResponse{Days: days}
+	var err error
+	if resp.Clients, err = loadBreakdown(r, q, scriptID, breakdownClient, since); err != nil {
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return
+	}
+	if resp.Referrers, err = loadBreakdown(r, q, scriptID, breakdownReferrer, since); err != nil {
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIClientStats returns fetches of all scripts by client family and referrer
+func (s *Server) APIClientStats(w http.ResponseWriter, r *http.Request) {
+	s.writeClientStats(w, r, "")
+}
+
+// APIScriptClientStats returns fetches of one script by client family and
+// referrer
+func (s *Server) APIScriptClientStats(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := s.queries().GetScript(r.Context(), id); err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+	s.writeClientStats(w, r, id)
+}
+
+// writeGeoStats answers with the country and region breakdown
+func (s *Server) writeGeoStats(w http.ResponseWriter, r *http.Request, scriptID string) {
+	days := statsDays(r)
+	since := statsSince(time.Now(), days)
+	q := s.queries()
+
+	resp := GeoStatsResponse{Days: days}
+	var err error
+	if resp.Countries, err = loadBreakdown(r, q, scriptID, breakdownCountry, since); err != nil {
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return
+	}
+	if resp.Regions, err = loadBreakdown(r, q, scriptID, breakdownRegion, since); err != nil {
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIGeoStats returns fetches of all scripts by country and region
+func (s *Server) APIGeoStats(w http.ResponseWriter, r *http.Request) {
+	s.writeGeoStats(w, r, "")
+}
+
+// APIScriptGeoStats returns fetches of one script by country and region
+func (s *Server) APIScriptGeoStats(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := s.queries().GetScript(r.Context(), id); err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+	s.writeGeoStats(w, r, id)
+}
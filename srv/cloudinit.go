@@ -0,0 +1,151 @@
+package srv
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// cloudInitScriptDir is where embedded scripts are written on the target machine
+const cloudInitScriptDir = "/var/lib/sh-server/scripts"
+
+// yamlString quotes s for YAML; JSON strings are valid YAML flow scalars
+func yamlString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// HandleCloudInit emits a cloud-init user-data document that runs the listed
+// scripts in order on first boot. By default each script is fetched from this
+// server at boot time; with embed=1 the content is written into the document.
+// On a virtual host the paths are those the scripts have there. Embedded
+// scripts count as downloads: they are refused while a notice is in effect
+// or over their download quota, and carry the deprecation warning.
+func (s *Server) HandleCloudInit(w http.ResponseWriter, r *http.Request) {
+	vh := virtualHost(r.Context())
+	var paths []string
+	for _, p := range strings.Split(r.URL.Query().Get("scripts"), ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.HasPrefix(p, "/") {
+			p = "/" + p
+		}
+		if err := validatePath(p); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid script path %q: %v", p, err), http.StatusBadRequest)
+			return
+		}
+		paths = append(paths, p)
+	}
+	if len(paths) == 0 {
+		http.Error(w, "Query parameter 'scripts' is required", http.StatusBadRequest)
+		return
+	}
+	embed := r.URL.Query().Get("embed") == "1"
+
+	q := s.queries()
+	now := time.Now()
+	scripts := make([]dbgen.Script, 0, len(paths))
+	var missing []string
+	for _, p := range paths {
+		full := p
+		if vh != nil {
+			full = vh.Prefix + p
+		}
+		script, err := q.GetScriptByPath(r.Context(), full)
+		if err != nil || script.Archived != 0 || (script.Private != 0 && !s.isAdmin(r)) {
+			missing = append(missing, p)
+			continue
+		}
+		if script.Disabled != 0 {
+			http.Error(w, "Script is disabled: "+p, http.StatusGone)
+			return
+		}
+		if !servable(script, now) {
+			msg := "Script has expired"
+			if ok, reason := scriptAvailable(script, now); !ok {
+				msg = "Script is " + reason
+			}
+			http.Error(w, msg+": "+p, http.StatusGone)
+			return
+		}
+		if s.geoBlocked(r, q, script) {
+			http.Error(w, "Script is not available in your region: "+p, http.StatusForbidden)
+			return
+		}
+		if _, locked := s.scriptLockOf(r.Context(), q, script); locked {
+			http.Error(w, "Locked scripts cannot be used in cloud-init: "+p, http.StatusForbidden)
+			return
+		}
+		// Scripts fetched at boot get the notice then, if it is still in effect
+		if notice, ok := activeNotice(r.Context(), q, script.Path); ok && embed {
+			http.Error(w, "Script is unavailable until "+notice.ExpiresAt.UTC().Format("2006-01-02 15:04 MST")+": "+p+": "+notice.Message, http.StatusServiceUnavailable)
+			return
+		}
+		if script.Private != 0 && !embed {
+			// The instance fetches at boot without the admin token
+			http.Error(w, "Private scripts can only be used in cloud-init with embed=1: "+p, http.StatusForbidden)
+			return
+		}
+		script.Path = p
+		scripts = append(scripts, script)
+	}
+	if len(missing) > 0 {
+		http.Error(w, "Script not found: "+strings.Join(missing, ", "), http.StatusNotFound)
+		return
+	}
+	// A cloud-config document has no place for the script withinQuota
+	// gives CLI clients, so the quota is answered with a plain error
+	if embed {
+		for _, sc := range scripts {
+			if exceeded := s.quotas.take(sc, now); exceeded != nil {
+				w.Header().Set("Retry-After", strconv.Itoa(int(exceeded.reset.Sub(now).Seconds())+1))
+				http.Error(w, fmt.Sprintf("Script has reached its limit of %d downloads per %s: %s", exceeded.limit, exceeded.period, sc.Path), http.StatusTooManyRequests)
+				return
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("#cloud-config\n")
+	fmt.Fprintf(&b, "# Generated by SH Server (https://%s)\n", s.hostname(r))
+	for _, sc := range scripts {
+		fmt.Fprintf(&b, "#   %s\n", sc.Path)
+	}
+
+	if embed {
+		b.WriteString("write_files:\n")
+		for _, sc := range scripts {
+			fmt.Fprintf(&b, "  - path: %s\n", yamlString(cloudInitScriptDir+sc.Path))
+			b.WriteString("    permissions: '0755'\n")
+			b.WriteString("    encoding: b64\n")
+			fmt.Fprintf(&b, "    content: %s\n", base64.StdEncoding.EncodeToString([]byte(s.scriptBody(r, sc))))
+		}
+	}
+
+	b.WriteString("runcmd:\n")
+	for _, sc := range scripts {
+		if embed {
+			fmt.Fprintf(&b, "  - [%s]\n", yamlString(cloudInitScriptDir+sc.Path))
+			continue
+		}
+		cmd := fmt.Sprintf("curl -fsSL https://%s%s | sh", s.hostname(r), sc.Path)
+		fmt.Fprintf(&b, "  - [sh, -c, %s]\n", yamlString(cmd))
+	}
+
+	w.Header().Set("Content-Type", "text/cloud-config; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(b.String()))
+	if embed {
+		for _, sc := range scripts {
+			s.recordFetch(r, sc.ID)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package srv
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// EncodeAll and DecodeAll may be called concurrently
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+func compress(data []byte) []byte {
+	return zstdEncoder.EncodeAll(data, nil)
+}
+
+func decompress(data []byte) ([]byte, error) {
+	return zstdDecoder.DecodeAll(data, nil)
+}
+
+// compressedKey is where content stored under key goes once compressed.
+// The key tells how to read it back, so content stored before compression
+// was turned on, or after it was turned off, stays readable.
+func compressedKey(key string) string {
+	return "zstd/" + strings.TrimPrefix(key, "content/")
+}
+
+func isCompressedKey(key string) bool {
+	return strings.HasPrefix(key, "zstd/")
+}
+
+// compressStoredContent compresses the content of scripts and versions
+// that was stored uncompressed, as when compression is first turned on
+func compressStoredContent(ctx context.Context, q *queries) error {
+	var plain []*string
+	recompress := func(ref *string, setRef func(ref *string) error) error {
+		var content string
+		if err := q.load(ctx, ref, &content); err != nil {
+			return err
+		}
+		compressed, err := q.put(ctx, content)
+		if err != nil {
+			return err
+		}
+		if err := setRef(compressed); err != nil {
+			return err
+		}
+		plain = append(plain, ref)
+		return nil
+	}
+
+	scripts, err := q.ListPlainScriptContentRefs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, sc := range scripts {
+		err := recompress(sc.ContentRef, func(ref *string) error {
+			return q.SetScriptContentRef(ctx, dbgen.SetScriptContentRefParams{ContentRef: ref, ID: sc.ID})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	versions, err := q.ListPlainVersionContentRefs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		err := recompress(v.ContentRef, func(ref *string) error {
+			return q.SetVersionContentRef(ctx, dbgen.SetVersionContentRefParams{ContentRef: ref, ID: v.ID})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	q.deleteUnreferenced(ctx, plain)
+	if len(scripts)+len(versions) > 0 {
+		slog.Info("storage: compressed stored content", "scripts", len(scripts), "versions", len(versions))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package srv
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfigFile reads a YAML file of settings and returns them by the
+// name of the environment variable each one stands for. Keys are those
+// names in any case, and sections nest them, so
+//
+//	tls:
+//	  cert_file: /etc/sh/cert.pem
+//
+// is TLS_CERT_FILE. Lists become comma-separated values.
+func LoadConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	settings := map[string]string{}
+	if err := flattenSettings(settings, "", doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return settings, nil
+}
+
+func flattenSettings(settings map[string]string, prefix string, section map[string]any) error {
+	keys := make([]string, 0, len(section))
+	for k := range section {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := strings.ToUpper(prefix + k)
+		var value string
+		switch v := section[k].(type) {
+		case map[string]any:
+			if err := flattenSettings(settings, name+"_", v); err != nil {
+				return err
+			}
+			continue
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				if _, ok := item.(map[string]any); ok {
+					return fmt.Errorf("%s: a list can only hold plain values", name)
+				}
+				items[i] = fmt.Sprint(item)
+			}
+			value = strings.Join(items, ",")
+		case nil:
+		default:
+			value = fmt.Sprint(v)
+		}
+		if _, dup := settings[name]; dup {
+			return fmt.Errorf("%s is set twice", name)
+		}
+		settings[name] = value
+	}
+	return nil
+}
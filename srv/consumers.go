@@ -0,0 +1,45 @@
+package srv
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// consumerPruneInterval is how often consumer rows past the stats window
+// are deleted
+const consumerPruneInterval = 24 * time.Hour
+
+// consumerID fingerprints the client of a request for one day: a hash of
+// its IP and User-Agent keyed with the server salt and the day. The same
+// machine counts once per day however often it fetches, and the rows of
+// different days cannot be linked.
+func (s *Server) consumerID(r *http.Request, day string) string {
+	key := hmac.New(sha256.New, s.ipSalt)
+	key.Write([]byte("consumer:" + day))
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write([]byte(clientIP(r) + "\x00" + r.Header.Get("User-Agent")))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// pruneConsumers deletes consumer rows older than the longest stats window
+func (s *Server) pruneConsumers(ctx context.Context, now time.Time) (int64, error) {
+	return s.queries().DeleteFetchConsumersBefore(ctx, statsSince(now, maxStatsDays))
+}
+
+// runConsumerPruneJob prunes consumer rows now and then every
+// consumerPruneInterval
+func (s *Server) runConsumerPruneJob() {
+	ticker := time.NewTicker(consumerPruneInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.pruneConsumers(context.Background(), time.Now()); err != nil {
+			slog.Error("consumer prune failed", "error", err)
+		}
+		<-ticker.C
+	}
+}
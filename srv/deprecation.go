@@ -0,0 +1,87 @@
+package srv
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// deprecationNotice returns the shell snippet prepended to a deprecated script.
+// It only writes to stderr so piped output of the script stays untouched.
+func (s *Server) deprecationNotice(r *http.Request, script dbgen.Script) string {
+	lines := []string{fmt.Sprintf("WARNING: %s is deprecated", script.Path)}
+	if script.SunsetAt != nil {
+		lines[0] += fmt.Sprintf(" and will be removed after %s", script.SunsetAt.UTC().Format("2006-01-02"))
+	}
+	if script.ReplacementPath != nil && *script.ReplacementPath != "" {
+		lines = append(lines, fmt.Sprintf("  Use instead: curl -fsSL %s | sh", virtualHost(r.Context()).scriptURL(s.Hostname, *script.ReplacementPath)))
+	}
+
+	var b strings.Builder
+	b.WriteString("# --- deprecation notice added by SH Server ---\n")
+	for _, line := range lines {
+		b.WriteString("echo " + shellQuote(line) + " >&2\n")
+	}
+	b.WriteString("# ---\n")
+	return b.String()
+}
+
+// shellQuote wraps s in single quotes for safe use in a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// insertAfterShebang places snippet after the #! line of content (or at the top)
+func insertAfterShebang(content, snippet string) string {
+	if strings.HasPrefix(content, "#!") {
+		if i := strings.IndexByte(content, '\n'); i >= 0 {
+			return content[:i+1] + snippet + content[i+1:]
+		}
+		return content + "\n" + snippet
+	}
+	return snippet + content
+}
+
+// setDeprecationHeaders advertises deprecation per RFC 8594 (Sunset) and the
+// Deprecation header draft, linking to the replacement when one is set
+func (s *Server) setDeprecationHeaders(w http.ResponseWriter, r *http.Request, script dbgen.Script) {
+	w.Header().Set("Deprecation", "true")
+	if script.SunsetAt != nil {
+		w.Header().Set("Sunset", script.SunsetAt.UTC().Format(http.TimeFormat))
+	}
+	if script.ReplacementPath != nil && *script.ReplacementPath != "" {
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, virtualHost(r.Context()).scriptURL(s.Hostname, *script.ReplacementPath)))
+	}
+}
+
+// deprecationParams converts request fields into UpdateScriptDeprecation parameters
+func deprecationParams(id string, deprecated bool, replacement string, sunsetAt *time.Time) dbgen.UpdateScriptDeprecationParams {
+	deprecatedInt := int64(0)
+	if deprecated {
+		deprecatedInt = 1
+	}
+	return dbgen.UpdateScriptDeprecationParams{
+		Deprecated:      deprecatedInt,
+		ReplacementPath: &replacement,
+		SunsetAt:        sunsetAt,
+		ID:              id,
+	}
+}
+
+// validateReplacement checks the replacement path of a deprecated script
+func validateReplacement(path, replacement string) error {
+	if replacement == "" {
+		return nil
+	}
+	if replacement == path {
+		return errors.New("replacement_path must differ from the script path")
+	}
+	if err := validatePath(replacement); err != nil {
+		return fmt.Errorf("invalid replacement_path: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// digestTimeout bounds delivery of a single digest
+const digestTimeout = 10 * time.Second
+
+// digestTopScripts is how many of the most fetched scripts a digest lists
+const digestTopScripts = 10
+
+// digestActions are the audit actions counted in a digest
+var digestActions = []string{"UNLOCK_SUCCESS", "UNLOCK_FAILED", "UNLOCK_LOCKOUT", "UNLOCK_TOKEN_REJECTED", "LOGIN_FAILED", "GEO_BLOCKED", "HONEYPOT_HIT", "SECRET_DETECTED"}
+
+// DigestConfig schedules usage digests
+type DigestConfig struct {
+	URL      string        // receives each digest as a JSON POST
+	Interval time.Duration // 24h for daily, 168h for weekly digests
+}
+
+// DigestRuns counts the reported runs of a script that failed at least once
+type DigestRuns struct {
+	Path     string `json:"path"`
+	Runs     int64  `json:"runs"`
+	Failures int64  `json:"failures"`
+}
+
+// Digest summarizes a period for maintainers who don't open the UI
+type Digest struct {
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	Fetches        int64            `json:"fetches"`
+	TopScripts     []ScriptUsage    `json:"top_scripts"`
+	FailingScripts []DigestRuns     `json:"failing_scripts"`
+	Events         map[string]int64 `json:"events"` // unlock attempts and other security events, by audit action
+	NewScripts     []string         `json:"new_scripts"`
+	ChangedScripts []string         `json:"changed_scripts"`
+	Text           string           `json:"text"` // the same as plain text, for chat tools
+}
+
+// buildDigest compiles the digest of the period [from, to)
+func (s *Server) buildDigest(ctx context.Context, from, to time.Time) (*Digest, error) {
+	q := s.queries()
+	d := &Digest{
+		From:           from,
+		To:             to,
+		TopScripts:     []ScriptUsage{},
+		FailingScripts: []DigestRuns{},
+		Events:         map[string]int64{},
+		NewScripts:     []string{},
+		ChangedScripts: []string{},
+	}
+
+	scripts, err := q.ListScripts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]string, len(scripts))
+	for _, sc := range scripts {
+		paths[sc.ID] = sc.Path
+		switch {
+		case !sc.CreatedAt.Before(from) && sc.CreatedAt.Before(to):
+			d.NewScripts = append(d.NewScripts, sc.Path)
+		case !sc.UpdatedAt.Before(from) && sc.UpdatedAt.Before(to):
+			d.ChangedScripts = append(d.ChangedScripts, sc.Path)
+		}
+	}
+
+	fetches, err := q.SumDailyFetchesByScript(ctx, from.UTC().Format(statsDayLayout))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(fetches, func(i, j int) bool { return fetches[i].Fetches > fetches[j].Fetches })
+	for _, f := range fetches {
+		d.Fetches += f.Fetches
+		if path, ok := paths[f.ScriptID]; ok && len(d.TopScripts) < digestTopScripts {
+			d.TopScripts = append(d.TopScripts, ScriptUsage{ScriptID: f.ScriptID, Path: path, RecentFetches: f.Fetches})
+		}
+	}
+
+	runs, err := q.SumRunsByScript(ctx, dbgen.SumRunsByScriptParams{CreatedAt: from, CreatedAt_2: to})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range runs {
+		if path, ok := paths[r.ScriptID]; ok && r.Failures > 0 {
+			d.FailingScripts = append(d.FailingScripts, DigestRuns{Path: path, Runs: r.Runs, Failures: r.Failures})
+		}
+	}
+
+	actions, err := q.CountAuditActions(ctx, dbgen.CountAuditActionsParams{CreatedAt: from, CreatedAt_2: to})
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range actions {
+		for _, want := range digestActions {
+			if a.Action == want {
+				d.Events[a.Action] = a.Count
+			}
+		}
+	}
+
+	d.Text = d.text()
+	return d, nil
+}
+
+// text renders the digest for humans
+func (d *Digest) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "sh-server digest %s - %s\n", d.From.UTC().Format(time.DateOnly), d.To.UTC().Format(time.DateOnly))
+	fmt.Fprintf(&b, "Fetches: %d\n", d.Fetches)
+	if len(d.TopScripts) > 0 {
+		b.WriteString("\nTop scripts:\n")
+		for _, u := range d.TopScripts {
+			fmt.Fprintf(&b, "  %s  %d\n", u.Path, u.RecentFetches)
+		}
+	}
+	if len(d.FailingScripts) > 0 {
+		b.WriteString("\nFailing scripts:\n")
+		for _, r := range d.FailingScripts {
+			fmt.Fprintf(&b, "  %s  %d of %d runs failed\n", r.Path, r.Failures, r.Runs)
+		}
+	}
+	if len(d.Events) > 0 {
+		b.WriteString("\nSecurity events:\n")
+		for _, action := range digestActions {
+			if n := d.Events[action]; n > 0 {
+				fmt.Fprintf(&b, "  %s  %d\n", action, n)
+			}
+		}
+	}
+	if len(d.NewScripts) > 0 {
+		b.WriteString("\nNew scripts: " + strings.Join(d.NewScripts, ", ") + "\n")
+	}
+	if len(d.ChangedScripts) > 0 {
+		b.WriteString("\nChanged scripts: " + strings.Join(d.ChangedScripts, ", ") + "\n")
+	}
+	return b.String()
+}
+
+// sendDigest posts the digest as JSON; failures are only logged
+func (s *Server) sendDigest(ctx context.Context, d *Digest) {
+	body, _ := json.Marshal(d)
+	client := &http.Client{Timeout: digestTimeout}
+	resp, err := client.Post(s.digestURL(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.WarnContext(ctx, "digest delivery failed", "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.WarnContext(ctx, "digest rejected", "status", resp.StatusCode)
+	}
+}
+
+// runDigestJob sends a digest at the end of every period. Periods are
+// aligned to UTC, so daily digests cover midnight to midnight and weekly
+// ones Monday to Monday.
+func (s *Server) runDigestJob() {
+	for {
+		now := time.Now().UTC()
+		end := now.Truncate(s.Digest.Interval).Add(s.Digest.Interval)
+		time.Sleep(end.Sub(now))
+
+		// A reload may have unset it
+		if s.digestURL() == "" {
+			continue
+		}
+		ctx := context.Background()
+		d, err := s.buildDigest(ctx, end.Add(-s.Digest.Interval), end)
+		if err != nil {
+			slog.Error("digest failed", "error", err)
+			continue
+		}
+		s.sendDigest(ctx, d)
+	}
+}
+
+// APIDigest previews the digest of the last ?days= days (default 7)
+func (s *Server) APIDigest(w http.ResponseWriter, r *http.Request) {
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+	if err != nil || days <= 0 {
+		days = 7
+	}
+	days = min(days, maxStatsDays)
+	now := time.Now()
+	d, err := s.buildDigest(r.Context(), now.AddDate(0, 0, -days), now)
+	if err != nil {
+		http.Error(w, "Failed to build digest", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
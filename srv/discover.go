@@ -0,0 +1,134 @@
+package srv
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// Size of the popular and recent lists
+const (
+	defaultDiscoverLimit = 10
+	maxDiscoverLimit     = 50
+)
+
+// popularDays is the window fetches are counted over for /_popular.json
+const popularDays = 30
+
+// catalogVisible reports whether a script is listed publicly at all
+func catalogVisible(sc dbgen.Script, now time.Time) bool {
+	if sc.Archived != 0 || sc.Unlisted != 0 || sc.Private != 0 {
+		return false
+	}
+	ok, _ := scriptAvailable(sc, now)
+	return ok
+}
+
+// discoverable reports whether a script belongs in the popular and recent
+// lists: listed, runnable right now and not a library
+func discoverable(sc dbgen.Script, now time.Time) bool {
+	return catalogVisible(sc, now) && sc.Disabled == 0 && !scriptExpired(sc, now) && !isLibraryPath(sc.Path)
+}
+
+// discoverLimit reads the limit query parameter
+func discoverLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		return defaultDiscoverLimit
+	}
+	return min(limit, maxDiscoverLimit)
+}
+
+// DiscoverEntry is one script in /_popular.json or /_recent.json
+type DiscoverEntry struct {
+	Path        string     `json:"path"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Fetches     int64      `json:"fetches,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+func discoverEntry(sc dbgen.Script) DiscoverEntry {
+	entry := DiscoverEntry{Path: sc.Path, Name: sc.Name}
+	if sc.Description != nil {
+		entry.Description = *sc.Description
+	}
+	return entry
+}
+
+func writeDiscover(w http.ResponseWriter, entries []DiscoverEntry) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "max-age=60")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// HandlePopular lists the most fetched scripts of the last popularDays days
+func (s *Server) HandlePopular(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	q := s.queries()
+	scripts, err := q.ListScriptMetadata(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list scripts", http.StatusInternalServerError)
+		return
+	}
+	vh := virtualHost(r.Context())
+	scripts = vh.scoped(scripts)
+	recent, err := q.SumDailyFetchesByScript(r.Context(), statsSince(now, popularDays))
+	if err != nil {
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return
+	}
+	fetches := make(map[string]int64, len(recent))
+	for _, rc := range recent {
+		fetches[rc.ScriptID] = rc.Fetches
+	}
+
+	entries := []DiscoverEntry{}
+	for _, sc := range scripts {
+		if fetches[sc.ID] == 0 || !discoverable(sc, now) {
+			continue
+		}
+		entry := discoverEntry(sc)
+		entry.Path = vh.strip(sc.Path)
+		entry.Fetches = fetches[sc.ID]
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Fetches > entries[j].Fetches })
+	if limit := discoverLimit(r); len(entries) > limit {
+		entries = entries[:limit]
+	}
+	writeDiscover(w, entries)
+}
+
+// HandleRecent lists the most recently updated scripts
+func (s *Server) HandleRecent(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	q := s.queries()
+	scripts, err := q.ListScriptMetadata(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list scripts", http.StatusInternalServerError)
+		return
+	}
+	vh := virtualHost(r.Context())
+	scripts = vh.scoped(scripts)
+
+	entries := []DiscoverEntry{}
+	for _, sc := range scripts {
+		if !discoverable(sc, now) {
+			continue
+		}
+		entry := discoverEntry(sc)
+		entry.Path = vh.strip(sc.Path)
+		entry.UpdatedAt = &sc.UpdatedAt
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].UpdatedAt.After(*entries[j].UpdatedAt) })
+	if limit := discoverLimit(r); len(entries) > limit {
+		entries = entries[:limit]
+	}
+	writeDiscover(w, entries)
+}
@@ -0,0 +1,64 @@
+package srv
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// archiveInterval is how often the auto-archive job looks for expired scripts
+const archiveInterval = time.Hour
+
+// scriptExpired reports whether the script is past its expires_at
+func scriptExpired(script dbgen.Script, now time.Time) bool {
+	return script.ExpiresAt != nil && !now.Before(*script.ExpiresAt)
+}
+
+// archiveExpired marks every expired script as archived and returns how many
+// scripts were archived
+func (s *Server) archiveExpired(ctx context.Context) (int, error) {
+	q := s.queries()
+	scripts, err := q.ListScripts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	archived := 0
+	for _, sc := range scripts {
+		if sc.Archived != 0 || !scriptExpired(sc, now) {
+			continue
+		}
+		if err := q.SetScriptArchived(ctx, dbgen.SetScriptArchivedParams{Archived: 1, ID: sc.ID}); err != nil {
+			return archived, err
+		}
+		q.CreateAuditLog(ctx, dbgen.CreateAuditLogParams{
+			Action:     "ARCHIVE",
+			EntityType: "script",
+			EntityID:   &sc.ID,
+			EntityPath: &sc.Path,
+			Details:    strPtr("expired at " + sc.ExpiresAt.UTC().Format(time.RFC3339)),
+			Actor:      strPtr("system"),
+			CreatedAt:  now,
+		})
+		archived++
+	}
+	return archived, nil
+}
+
+// runArchiveJob archives expired scripts now and then every archiveInterval
+func (s *Server) runArchiveJob() {
+	ticker := time.NewTicker(archiveInterval)
+	defer ticker.Stop()
+	for {
+		n, err := s.archiveExpired(context.Background())
+		if err != nil {
+			slog.Error("auto-archive failed", "error", err)
+		} else if n > 0 {
+			slog.Info("auto-archived expired scripts", "count", n)
+		}
+		<-ticker.C
+	}
+}
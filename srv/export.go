@@ -0,0 +1,241 @@
+package srv
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// exportDir is the top-level directory inside a repository export
+const exportDir = "sh-export"
+
+// ExportedScript is a script's metadata as exported. Password hashes are
+// never exported; locked scripts need a new password after a restore.
+type ExportedScript struct {
+	Path                string     `json:"path"`
+	Name                string     `json:"name"`
+	Description         string     `json:"description,omitempty"`
+	Tags                string     `json:"tags,omitempty"`
+	DangerLevel         int        `json:"danger_level"`
+	Locked              bool       `json:"locked"`
+	Requires            string     `json:"requires,omitempty"`
+	Examples            string     `json:"examples,omitempty"`
+	Deprecated          bool       `json:"deprecated,omitempty"`
+	ReplacementPath     string     `json:"replacement_path,omitempty"`
+	SunsetAt            *time.Time `json:"sunset_at,omitempty"`
+	Disabled            bool       `json:"disabled,omitempty"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	AvailableFrom       *time.Time `json:"available_from,omitempty"`
+	AvailableUntil      *time.Time `json:"available_until,omitempty"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+	Archived            bool       `json:"archived,omitempty"`
+	Unlisted            bool       `json:"unlisted,omitempty"`
+	Private             bool       `json:"private,omitempty"`
+	UnlockTTL           int64      `json:"unlock_ttl,omitempty"`
+	MaxDownloadsPerHour int64      `json:"max_downloads_per_hour,omitempty"`
+	MaxDownloadsPerDay  int64      `json:"max_downloads_per_day,omitempty"`
+	AllowCountries      string     `json:"allow_countries,omitempty"`
+	DenyCountries       string     `json:"deny_countries,omitempty"`
+	SourceURL           string     `json:"source_url,omitempty"`
+	SourceTTL           int64      `json:"source_ttl,omitempty"`
+	SourceSHA256        string     `json:"source_sha256,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+func exportedScript(sc dbgen.Script) ExportedScript {
+	resp := scriptToResponse(sc)
+	return ExportedScript{
+		Path:                resp.Path,
+		Name:                resp.Name,
+		Description:         resp.Description,
+		Tags:                resp.Tags,
+		DangerLevel:         resp.DangerLevel,
+		Locked:              resp.Locked,
+		Requires:            resp.Requires,
+		Examples:            resp.Examples,
+		Deprecated:          resp.Deprecated,
+		ReplacementPath:     resp.ReplacementPath,
+		SunsetAt:            resp.SunsetAt,
+		Disabled:            resp.Disabled,
+		DisabledReason:      resp.DisabledReason,
+		AvailableFrom:       resp.AvailableFrom,
+		AvailableUntil:      resp.AvailableUntil,
+		ExpiresAt:           resp.ExpiresAt,
+		Archived:            resp.Archived,
+		Unlisted:            resp.Unlisted,
+		Private:             resp.Private,
+		UnlockTTL:           resp.UnlockTTL,
+		MaxDownloadsPerHour: resp.MaxDownloadsPerHour,
+		MaxDownloadsPerDay:  resp.MaxDownloadsPerDay,
+		AllowCountries:      resp.AllowCountries,
+		DenyCountries:       resp.DenyCountries,
+		SourceURL:           resp.SourceURL,
+		SourceTTL:           resp.SourceTTL,
+		SourceSHA256:        resp.SourceSHA256,
+		CreatedAt:           resp.CreatedAt,
+		UpdatedAt:           resp.UpdatedAt,
+	}
+}
+
+// ExportedFolder is a folder as exported, without its password hash
+type ExportedFolder struct {
+	Path      string    `json:"path"`
+	Locked    bool      `json:"locked"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// exportManifestEntry describes one file of the archive
+type exportManifestEntry struct {
+	ExportedScript
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+}
+
+// exportManifest is written as manifest.json at the root of the archive
+type exportManifest struct {
+	Source      string                `json:"source"`
+	Prefix      string                `json:"prefix,omitempty"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	Folders     []ExportedFolder      `json:"folders"`
+	Scripts     []exportManifestEntry `json:"scripts"`
+}
+
+// exportPrefix reads the ?prefix= of an export
+func exportPrefix(r *http.Request) string {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return prefix
+}
+
+// exportSelection lists the folders and scripts under prefix, all of them
+// if it is empty
+func (s *Server) exportSelection(ctx context.Context, q *queries, prefix string) ([]dbgen.Folder, []dbgen.Script, error) {
+	scripts, err := q.ListScripts(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	folders, err := q.ListFolders(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var selectedFolders []dbgen.Folder
+	for _, f := range folders {
+		if scriptPrefixMatch(f.Path, prefix) || f.Path == strings.TrimSuffix(prefix, "/") {
+			selectedFolders = append(selectedFolders, f)
+		}
+	}
+	var selected []dbgen.Script
+	for _, sc := range scripts {
+		if scriptPrefixMatch(sc.Path, prefix) {
+			selected = append(selected, sc)
+		}
+	}
+	return selectedFolders, selected, nil
+}
+
+// exportedFolder describes a folder for an export
+func exportedFolder(f dbgen.Folder) ExportedFolder {
+	return ExportedFolder{Path: f.Path, Locked: f.Locked != 0, CreatedAt: f.CreatedAt}
+}
+
+// auditExport records that the scripts under prefix were exported as format
+func auditExport(r *http.Request, q *queries, format, prefix string, now time.Time) {
+	details := format
+	if prefix != "" {
+		details += " of " + prefix
+	}
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "EXPORT",
+		EntityType: "script",
+		Details:    &details,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+}
+
+// APIExportArchive streams every script under ?prefix= as files of a
+// tar.gz, with a manifest of their metadata. Unlike the offline bundle it
+// includes locked, private, disabled and archived scripts.
+func (s *Server) APIExportArchive(w http.ResponseWriter, r *http.Request) {
+	prefix := exportPrefix(r)
+	q := s.queries()
+	folders, scripts, err := s.exportSelection(r.Context(), q, prefix)
+	if err != nil {
+		http.Error(w, "Failed to list scripts", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	manifest := exportManifest{
+		Source:      "https://" + s.hostname(r),
+		Prefix:      prefix,
+		GeneratedAt: now.UTC(),
+		Folders:     []ExportedFolder{},
+		Scripts:     []exportManifestEntry{},
+	}
+	for _, f := range folders {
+		manifest.Folders = append(manifest.Folders, exportedFolder(f))
+	}
+	for _, sc := range scripts {
+		sum := sha256.Sum256([]byte(sc.Content))
+		manifest.Scripts = append(manifest.Scripts, exportManifestEntry{
+			ExportedScript: exportedScript(sc),
+			SHA256:         hex.EncodeToString(sum[:]),
+			Size:           len(sc.Content),
+		})
+	}
+	manifestJSON, _ := json.MarshalIndent(manifest, "", "  ")
+	auditExport(r, q, "tar.gz", prefix, now)
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="sh-export-`+now.UTC().Format("20060102")+`.tar.gz"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	write := func(hdr *tar.Header, data []byte) error {
+		hdr.Name = exportDir + "/" + hdr.Name
+		hdr.Size = int64(len(data))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := write(&tar.Header{Name: "manifest.json", Mode: 0o644, ModTime: now}, append(manifestJSON, '\n')); err != nil {
+		slog.ErrorContext(r.Context(), "export: write failed", "file", "manifest.json", "error", err)
+		return
+	}
+	for _, f := range folders {
+		if err := write(&tar.Header{Name: "scripts" + f.Path + "/", Typeflag: tar.TypeDir, Mode: 0o755, ModTime: f.CreatedAt}, nil); err != nil {
+			slog.ErrorContext(r.Context(), "export: write failed", "folder", f.Path, "error", err)
+			return
+		}
+	}
+	for _, sc := range scripts {
+		if err := write(&tar.Header{Name: "scripts" + sc.Path, Mode: 0o755, ModTime: sc.UpdatedAt}, []byte(sc.Content)); err != nil {
+			slog.ErrorContext(r.Context(), "export: write failed", "file", sc.Path, "error", err)
+			return
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		slog.ErrorContext(r.Context(), "export: close tar", "error", err)
+		return
+	}
+	gz.Close()
+}
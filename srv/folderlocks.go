@@ -0,0 +1,154 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// scriptLock describes the password protecting a script: its own or, for
+// scripts without one, that of the nearest locked folder above it
+type scriptLock struct {
+	hash   *string
+	folder *dbgen.Folder // nil for the script's own lock
+}
+
+// folderLock returns the nearest locked folder containing scriptPath
+func folderLock(lockedFolders []dbgen.Folder, scriptPath string) *dbgen.Folder {
+	var nearest *dbgen.Folder
+	for i, f := range lockedFolders {
+		if f.Locked == 0 || !scriptPrefixMatch(scriptPath, f.Path) {
+			continue
+		}
+		if nearest == nil || len(f.Path) > len(nearest.Path) {
+			nearest = &lockedFolders[i]
+		}
+	}
+	return nearest
+}
+
+// scriptLockOf returns the lock protecting the script, if any
+func (s *Server) scriptLockOf(ctx context.Context, q *queries, script dbgen.Script) (scriptLock, bool) {
+	if script.Locked != 0 {
+		return scriptLock{hash: script.PasswordHash}, true
+	}
+	folders, _ := q.ListLockedFolders(ctx)
+	if f := folderLock(folders, script.Path); f != nil {
+		return scriptLock{hash: f.PasswordHash, folder: f}, true
+	}
+	return scriptLock{}, false
+}
+
+// limitKey identifies the lock for brute-force accounting
+func (l scriptLock) limitKey(script dbgen.Script) string {
+	if l.folder != nil {
+		return "folder:" + l.folder.Path
+	}
+	return "script:" + script.ID
+}
+
+// allows reports whether an unlock token opens this lock for the script.
+// Folder tokens open every script under their folder, script tokens only
+// their own script.
+func (l scriptLock) allows(tok dbgen.AuthToken, script dbgen.Script) bool {
+	if l.folder != nil {
+		return tok.FolderPath != nil && *tok.FolderPath == l.folder.Path
+	}
+	return tok.FolderPath == nil && tok.ScriptID == script.ID
+}
+
+// FolderLockRequest represents a request to lock a folder
+type FolderLockRequest struct {
+	Password string `json:"password"`
+}
+
+// APILockFolder password-protects every script under a folder. Locking an
+// already locked folder changes its password and revokes its unlock tokens.
+func (s *Server) APILockFolder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req FolderLockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if req.Password == "" {
+		http.Error(w, "Password is required", http.StatusBadRequest)
+		return
+	}
+
+	q := s.queries()
+	folder, err := q.GetFolder(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Folder not found", http.StatusNotFound)
+		return
+	}
+
+	hash, err := s.Passwords.hashPassword(req.Password)
+	if err != nil {
+		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		return
+	}
+	if err := q.UpdateFolderLock(r.Context(), dbgen.UpdateFolderLockParams{
+		Locked:       1,
+		PasswordHash: &hash,
+		ID:           id,
+	}); err != nil {
+		http.Error(w, "Failed to lock folder", http.StatusInternalServerError)
+		return
+	}
+	q.DeleteTokensByFolder(r.Context(), &folder.Path)
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "LOCK",
+		EntityType: "folder",
+		EntityID:   &id,
+		EntityPath: &folder.Path,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	folder, _ = q.GetFolder(r.Context(), id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(folderToResponse(folder))
+}
+
+// APIUnlockFolder removes a folder's lock and revokes its unlock tokens
+func (s *Server) APIUnlockFolder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	folder, err := q.GetFolder(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Folder not found", http.StatusNotFound)
+		return
+	}
+	if folder.Locked == 0 {
+		http.Error(w, "Folder is not locked", http.StatusBadRequest)
+		return
+	}
+
+	if err := q.UpdateFolderLock(r.Context(), dbgen.UpdateFolderLockParams{ID: id}); err != nil {
+		http.Error(w, "Failed to unlock folder", http.StatusInternalServerError)
+		return
+	}
+	q.DeleteTokensByFolder(r.Context(), &folder.Path)
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "UNLOCK",
+		EntityType: "folder",
+		EntityID:   &id,
+		EntityPath: &folder.Path,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	folder, _ = q.GetFolder(r.Context(), id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(folderToResponse(folder))
+}
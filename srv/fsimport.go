@@ -0,0 +1,236 @@
+package srv
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// FSImportConfig allows importing directory trees of .sh files from the
+// server's filesystem, e.g. Dir /srv/scripts with Prefix /ops maps
+// /srv/scripts/deploy/app.sh to /ops/deploy/app.sh
+type FSImportConfig struct {
+	Dir       string // only directories inside it can be imported; empty disables imports
+	Prefix    string // script path Dir maps to
+	OnStartup bool   // import Dir when the server starts
+}
+
+// FSImportRequest asks to import a directory inside IMPORT_DIR
+type FSImportRequest struct {
+	Dir       string `json:"dir"`       // relative to IMPORT_DIR; empty for all of it
+	Prefix    string `json:"prefix"`    // script path dir maps to; IMPORT_PREFIX if empty
+	Overwrite bool   `json:"overwrite"` // replace scripts that already exist
+}
+
+// FSImportResult lists what an import changed
+type FSImportResult struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Skipped []string `json:"skipped"`           // unchanged, or existing without overwrite
+	Folders []string `json:"folders"`           // folders created
+	Deleted []string `json:"deleted,omitempty"` // by directory sync only
+	Errors  []string `json:"errors"`            // files that could not be imported
+
+	found map[string]bool // script paths of all files, imported or not
+}
+
+// headerMetadata reads script metadata from front matter or, failing that,
+// from the comment block right after the shebang:
+//
+//	#!/bin/sh
+//	# Deploy the app
+//	# tags: deploy, ops
+//
+// "# key: value" lines set the keys of rawMetaHeaders; otherwise the first
+// line of the block is the description.
+func headerMetadata(content string) map[string]string {
+	if meta := parseFrontMatter(content); meta != nil {
+		return meta
+	}
+	lines := strings.Split(content, "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "#!") {
+		lines = lines[1:]
+	}
+	meta := map[string]string{}
+	first := true
+	for _, line := range lines {
+		text, ok := strings.CutPrefix(strings.TrimSpace(line), "#")
+		if !ok {
+			break
+		}
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "shellcheck ") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if _, known := rawMetaHeaders[key]; ok && known && key != "message" {
+			meta[key] = strings.TrimSpace(value)
+		} else if first {
+			meta["description"] = text
+		}
+		first = false
+	}
+	return meta
+}
+
+// importFS imports the .sh files under dir of fsys as scripts under
+// prefix, mapping directories to folders. Hidden files and directories and
+// symlinks are skipped. Changes go through the API handlers on behalf of r.
+func (s *Server) importFS(r *http.Request, fsys fs.FS, dir, prefix string, overwrite bool) (*FSImportResult, error) {
+	ctx := r.Context()
+	q := s.queries()
+	res := &FSImportResult{Created: []string{}, Updated: []string{}, Skipped: []string{}, Folders: []string{}, Errors: []string{}, found: map[string]bool{}}
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			res.Errors = append(res.Errors, name+": "+err.Error())
+			return nil
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, dir), "/")
+		if dir == "." {
+			rel = name
+		}
+		if strings.HasPrefix(d.Name(), ".") && name != dir {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		target := path.Join("/", prefix, rel)
+
+		if d.IsDir() {
+			if target == "/" {
+				return nil
+			}
+			if _, err := q.GetFolderByPath(ctx, target); errors.Is(err, sql.ErrNoRows) {
+				s.ensureFolders(ctx, q, target+"/dummy.sh")
+				res.Folders = append(res.Folders, target)
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".sh") || !d.Type().IsRegular() {
+			return nil
+		}
+		res.found[target] = true
+		if err := s.importFile(r, q, fsys, name, target, overwrite, res); err != nil {
+			res.Errors = append(res.Errors, name+": "+err.Error())
+		}
+		return nil
+	})
+	return res, err
+}
+
+// importFile publishes one file of an import
+func (s *Server) importFile(r *http.Request, q *queries, fsys fs.FS, name, target string, overwrite bool, res *FSImportResult) error {
+	if err := validatePath(target); err != nil {
+		return err
+	}
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+	if info.Size() > s.Limits.MaxScriptSize {
+		return errors.New("script too large")
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	content := string(data)
+
+	existing, err := q.GetScriptByPath(r.Context(), target)
+	if err == nil && (!overwrite || existing.Content == content) {
+		res.Skipped = append(res.Skipped, target)
+		return nil
+	}
+	_, created, err := s.publishScript(r, target, content, func(req *UpdateScriptRequest) error {
+		req.Message = "Imported from " + name
+		return applyMetadata(req, headerMetadata(content))
+	})
+	if err != nil {
+		return err
+	}
+	if created {
+		res.Created = append(res.Created, target)
+	} else {
+		res.Updated = append(res.Updated, target)
+	}
+	return nil
+}
+
+// importDir imports dir, relative to IMPORT_DIR, without letting symlinks
+// lead outside of it
+func (s *Server) importDir(r *http.Request, dir, prefix string, overwrite bool) (*FSImportResult, error) {
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+	if dir == "" {
+		dir = "."
+	}
+	root, err := os.OpenRoot(s.FSImport.Dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	fsys := root.FS()
+	if info, err := fs.Stat(fsys, dir); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return s.importFS(r, fsys, dir, prefix, overwrite)
+}
+
+// importOnStartup imports IMPORT_DIR and logs the outcome
+func (s *Server) importOnStartup(ctx context.Context) {
+	ctx = context.WithValue(ctx, actorKey{}, "fs-import")
+	res, err := s.importDir(originalRequest(ctx), "", s.FSImport.Prefix, false)
+	if err != nil {
+		slog.ErrorContext(ctx, "filesystem import failed", "dir", s.FSImport.Dir, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "filesystem import", "dir", s.FSImport.Dir,
+		"created", len(res.Created), "skipped", len(res.Skipped), "folders", len(res.Folders), "errors", len(res.Errors))
+	for _, e := range res.Errors {
+		slog.WarnContext(ctx, "filesystem import skipped a file", "error", e)
+	}
+}
+
+// APIImportFS imports a directory tree of scripts from IMPORT_DIR
+func (s *Server) APIImportFS(w http.ResponseWriter, r *http.Request) {
+	if s.FSImport.Dir == "" {
+		http.Error(w, "Filesystem import is not configured", http.StatusNotFound)
+		return
+	}
+	var req FSImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if req.Prefix == "" {
+		req.Prefix = s.FSImport.Prefix
+	}
+	if !strings.HasPrefix(req.Prefix, "/") {
+		invalidField(w, "prefix", errors.New("prefix must start with /"))
+		return
+	}
+
+	res, err := s.importDir(r, req.Dir, req.Prefix, req.Overwrite)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, "Directory not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Import failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
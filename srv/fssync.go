@@ -0,0 +1,137 @@
+package srv
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fsSyncDebounce is how long the directory must be quiet before it is
+// synced, so an editor's save or a git checkout is synced once
+const fsSyncDebounce = 500 * time.Millisecond
+
+// FSSyncConfig makes a directory the source of truth for the scripts
+// under Prefix: files added, changed or removed there are added, updated
+// or deleted as scripts while the server runs
+type FSSyncConfig struct {
+	Dir    string // empty disables syncing
+	Prefix string // script path Dir maps to
+}
+
+// syncFS brings the scripts under the prefix in line with the directory.
+// Scripts changed elsewhere are overwritten and scripts without a file are
+// deleted, all through the API handlers.
+func (s *Server) syncFS(ctx context.Context) (*FSImportResult, error) {
+	root, err := os.OpenRoot(s.FSSync.Dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	r := originalRequest(context.WithValue(ctx, actorKey{}, "fs-sync"))
+	res, err := s.importFS(r, root.FS(), ".", s.FSSync.Prefix, true)
+	if err != nil {
+		return nil, err
+	}
+	scripts, err := s.queries().ListScripts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, sc := range scripts {
+		if !scriptPrefixMatch(sc.Path, s.FSSync.Prefix) || res.found[sc.Path] {
+			continue
+		}
+		if err := s.unpublishScript(r, sc.Path); err != nil {
+			res.Errors = append(res.Errors, sc.Path+": "+err.Error())
+			continue
+		}
+		res.Deleted = append(res.Deleted, sc.Path)
+	}
+	return res, nil
+}
+
+// logFSSync runs a sync and logs its outcome
+func (s *Server) logFSSync(ctx context.Context, trigger string) {
+	res, err := s.syncFS(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "directory sync failed", "trigger", trigger, "dir", s.FSSync.Dir, "error", err)
+		return
+	}
+	if trigger == "watch" && len(res.Created)+len(res.Updated)+len(res.Deleted)+len(res.Errors) == 0 {
+		return
+	}
+	slog.InfoContext(ctx, "directory sync", "trigger", trigger, "dir", s.FSSync.Dir,
+		"created", len(res.Created), "updated", len(res.Updated), "deleted", len(res.Deleted), "errors", len(res.Errors))
+	for _, e := range res.Errors {
+		slog.WarnContext(ctx, "directory sync skipped a file", "error", e)
+	}
+}
+
+// watchDirs watches dir and every directory below it that the sync reads
+func watchDirs(w *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil && name == dir {
+			return err
+		}
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") && name != dir {
+			return filepath.SkipDir
+		}
+		return w.Add(name)
+	})
+}
+
+// startFSSync syncs the directory once, then keeps syncing on changes
+// until ctx is done
+func (s *Server) startFSSync(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watchDirs(w, s.FSSync.Dir); err != nil {
+		w.Close()
+		return fmt.Errorf("watch %s: %w", s.FSSync.Dir, err)
+	}
+	s.logFSSync(ctx, "startup")
+
+	go func() {
+		defer w.Close()
+		debounce := time.NewTimer(fsSyncDebounce)
+		debounce.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if ev.Has(fsnotify.Create) {
+					if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+						watchDirs(w, ev.Name)
+					}
+				}
+				debounce.Reset(fsSyncDebounce)
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				slog.WarnContext(ctx, "directory watch error", "dir", s.FSSync.Dir, "error", err)
+				// Events may have been dropped
+				debounce.Reset(fsSyncDebounce)
+			case <-debounce.C:
+				s.logFSSync(ctx, "watch")
+			}
+		}
+	}()
+	return nil
+}
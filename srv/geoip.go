@@ -0,0 +1,121 @@
+package srv
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// GeoIPConfig restricts script downloads by the client's country
+type GeoIPConfig struct {
+	DBFile string   // MaxMind GeoLite2/GeoIP2 Country or City database
+	Allow  []string // when set, only these countries are served
+	Deny   []string // these countries are never served
+}
+
+// parseCountries normalizes a comma-separated list of ISO 3166-1 alpha-2
+// country codes
+func parseCountries(list string) ([]string, error) {
+	var codes []string
+	for _, c := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' }) {
+		c = strings.ToUpper(c)
+		if len(c) != 2 || c[0] < 'A' || c[0] > 'Z' || c[1] < 'A' || c[1] > 'Z' {
+			return nil, fmt.Errorf("invalid country code %q", c)
+		}
+		codes = append(codes, c)
+	}
+	return codes, nil
+}
+
+// validateCountries checks one of a script's country lists
+func validateCountries(field, list string) error {
+	if _, err := parseCountries(list); err != nil {
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	return nil
+}
+
+// countriesParam converts a script's country lists for storage
+func countriesParam(id, allow, deny string) dbgen.UpdateScriptCountriesParams {
+	params := dbgen.UpdateScriptCountriesParams{ID: id}
+	if codes, _ := parseCountries(allow); len(codes) > 0 {
+		params.AllowCountries = strPtr(strings.Join(codes, ","))
+	}
+	if codes, _ := parseCountries(deny); len(codes) > 0 {
+		params.DenyCountries = strPtr(strings.Join(codes, ","))
+	}
+	return params
+}
+
+// countryAllowed applies the global and script rules to a country. Deny
+// lists always win; a script allow list replaces the global one. Clients
+// whose country is unknown (private networks, gaps in the database) are
+// only refused by allow lists.
+func (s *Server) countryAllowed(script dbgen.Script, country string) bool {
+	deny := s.GeoIP.Deny
+	if script.DenyCountries != nil {
+		codes, _ := parseCountries(*script.DenyCountries)
+		deny = append(codes, deny...)
+	}
+	allow := s.GeoIP.Allow
+	if script.AllowCountries != nil {
+		allow, _ = parseCountries(*script.AllowCountries)
+	}
+
+	if country != "" && slices.Contains(deny, country) {
+		return false
+	}
+	return len(allow) == 0 || slices.Contains(allow, country)
+}
+
+// geoBlocked reports whether the client's country may not download the
+// script and writes refusals to the audit log. Without a GeoIP database
+// nothing is blocked.
+func (s *Server) geoBlocked(r *http.Request, q *queries, script dbgen.Script) bool {
+	if s.geoip == nil {
+		return false
+	}
+	country := s.geoip.country(net.ParseIP(clientIP(r)))
+	if s.countryAllowed(script, country) {
+		return false
+	}
+
+	if country == "" {
+		country = "unknown"
+	}
+	details := "country " + country
+	entityType, entityID := "script", &script.ID
+	if script.ID == "" {
+		entityType, entityID = "download", nil
+	}
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "GEO_BLOCKED",
+		EntityType: entityType,
+		EntityID:   entityID,
+		EntityPath: &script.Path,
+		Details:    &details,
+		IpAddress:  s.storedIP(r),
+		UserAgent:  strPtr(r.Header.Get("User-Agent")),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+	return true
+}
+
+// hasCountryRules reports whether a script has country lists of its own.
+// Downloads of many scripts at once leave such scripts out.
+func hasCountryRules(script dbgen.Script) bool {
+	return script.AllowCountries != nil || script.DenyCountries != nil
+}
+
+// geoBlockedBulk is geoBlocked for a download of many scripts at once, such
+// as the offline bundle or the git repository. Those hold no script with
+// country lists of its own, so only the global lists apply to them.
+func (s *Server) geoBlockedBulk(r *http.Request, q *queries, path string) bool {
+	return s.geoBlocked(r, q, dbgen.Script{Path: path})
+}
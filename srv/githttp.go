@@ -0,0 +1,202 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/cgi"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// gitRepoPath is where the generated repository is served, for
+// git clone https://host/repo.git
+const gitRepoPath = "/repo.git"
+
+// gitHTTPRepo is a bare repository generated from the database, one commit
+// per script version. The history is regenerated whenever it would differ;
+// since commits are built only from stored data, unchanged history keeps
+// its commit IDs. Each virtual host gets a repository of its own folder.
+type gitHTTPRepo struct {
+	git  string // git executable
+	root string // GIT_PROJECT_ROOT of the main hostname, holding repo.git
+
+	mu   sync.Mutex
+	sums map[string][sha256.Size]byte // of the last imported history, by virtual host
+}
+
+// openGitHTTPRepo creates the bare repository under dir if missing
+func openGitHTTPRepo(dir string) (*gitHTTPRepo, error) {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		return nil, fmt.Errorf("git repository needs git: %w", err)
+	}
+	repo := &gitHTTPRepo{git: git, root: dir, sums: map[string][sha256.Size]byte{}}
+	if err := repo.init(dir); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// init creates the bare repository under a project root if missing
+func (g *gitHTTPRepo) init(root string) error {
+	bare := filepath.Join(root, strings.TrimPrefix(gitRepoPath, "/"))
+	if _, err := os.Stat(bare); errors.Is(err, fs.ErrNotExist) {
+		if out, err := exec.Command(g.git, "init", "-q", "--bare", "--initial-branch=main", bare).CombinedOutput(); err != nil {
+			return fmt.Errorf("git init: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// projectRoot is the GIT_PROJECT_ROOT of a virtual host, or of the main
+// hostname (nil). Only repo.git is routed, so the repositories of virtual
+// hosts under the main root can't be reached from it.
+func (g *gitHTTPRepo) projectRoot(vh *VirtualHost) string {
+	if vh == nil {
+		return g.root
+	}
+	return filepath.Join(g.root, "hosts", vh.Host)
+}
+
+// gitHistory renders the versions of the exportable scripts as a
+// git fast-import stream. A last commit brings the tree in line with the
+// current scripts where versions don't, e.g. during a canary rollout. On a
+// virtual host only its folder is included, with the paths the scripts
+// have there.
+func (s *Server) gitHistory(ctx context.Context) ([]byte, int, error) {
+	q := s.queries()
+	scripts, err := q.ListScripts(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	versions, err := q.ListAllVersions(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	lockedFolders, _ := q.ListLockedFolders(ctx)
+	now := time.Now()
+	vh := virtualHost(ctx)
+	included := map[string]dbgen.Script{}
+	var latest time.Time
+	for _, sc := range vh.scoped(scripts) {
+		if exportable(sc, lockedFolders, now) {
+			sc.Path = vh.strip(sc.Path)
+			included[sc.ID] = sc
+			if sc.UpdatedAt.After(latest) {
+				latest = sc.UpdatedAt
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString("reset refs/heads/main\n")
+	commits := 0
+	commit := func(when time.Time, message string, files map[string]string) {
+		fmt.Fprintf(&b, "commit refs/heads/main\ncommitter sh-server <sh-server@%s> %d +0000\ndata %d\n%s\n", gitMirrorEmail, when.Unix(), len(message), message)
+		for _, name := range slices.Sorted(maps.Keys(files)) {
+			fmt.Fprintf(&b, "M 100755 inline %s\ndata %d\n%s\n", strings.TrimPrefix(name, "/"), len(files[name]), files[name])
+		}
+		commits++
+	}
+	committed := map[string]string{}
+	for _, v := range versions {
+		sc, ok := included[v.ScriptID]
+		if !ok {
+			continue
+		}
+		message := "Update " + sc.Path
+		if _, seen := committed[sc.ID]; !seen {
+			message = "Create " + sc.Path
+		}
+		if v.Message != nil && *v.Message != "" {
+			message += "\n\n" + *v.Message
+		}
+		commit(v.CreatedAt, message, map[string]string{sc.Path: v.Content})
+		committed[sc.ID] = v.Content
+	}
+	changed := map[string]string{}
+	for id, sc := range included {
+		if content, ok := committed[id]; !ok || content != sc.Content {
+			changed[sc.Path] = sc.Content
+		}
+	}
+	if len(changed) > 0 {
+		commit(latest, "Sync with database", changed)
+	}
+	return b.Bytes(), commits, nil
+}
+
+// refresh regenerates the history if the database changed since the last
+// import
+func (g *gitHTTPRepo) refresh(ctx context.Context, s *Server) error {
+	stream, commits, err := s.gitHistory(ctx)
+	if err != nil {
+		return err
+	}
+	vh, host := virtualHost(ctx), ""
+	if vh != nil {
+		host = vh.Host
+	}
+	sum := sha256.Sum256(stream)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if sum == g.sums[host] {
+		return nil
+	}
+	root := g.projectRoot(vh)
+	if err := g.init(root); err != nil {
+		return err
+	}
+	bare := filepath.Join(root, strings.TrimPrefix(gitRepoPath, "/"))
+	args := []string{"--git-dir", bare, "fast-import", "--quiet", "--force"}
+	if commits == 0 {
+		args = []string{"--git-dir", bare, "update-ref", "-d", "refs/heads/main"}
+	}
+	cmd := exec.CommandContext(ctx, g.git, args...)
+	cmd.Stdin = bytes.NewReader(stream)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[2], err, strings.TrimSpace(string(out)))
+	}
+	g.sums[host] = sum
+	return nil
+}
+
+// HandleGitHTTP serves the repository over git's smart HTTP protocol, for
+// fetching only
+func (s *Server) HandleGitHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("service") == "git-receive-pack" {
+		http.Error(w, "Repository is read-only", http.StatusForbidden)
+		return
+	}
+	if s.geoBlockedBulk(r, s.queries(), gitRepoPath) {
+		http.Error(w, "The repository is not available in your region", http.StatusForbidden)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/info/refs") {
+		if err := s.gitHTTP.refresh(r.Context(), s); err != nil {
+			slog.ErrorContext(r.Context(), "failed to generate git repository", "error", err)
+			http.Error(w, "Failed to generate repository", http.StatusInternalServerError)
+			return
+		}
+	}
+	backend := &cgi.Handler{
+		Path: s.gitHTTP.git,
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + s.gitHTTP.projectRoot(virtualHost(r.Context())), "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	backend.ServeHTTP(w, r)
+}
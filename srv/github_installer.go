@@ -0,0 +1,234 @@
+package srv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var (
+	githubRepoPattern   = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	binaryNamePattern   = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	assetPatternPattern = regexp.MustCompile(`^[A-Za-z0-9_.+*?|()\[\]\\^$ -]*$`)
+)
+
+// GitHubInstallerRequest represents a request to generate a GitHub release installer
+type GitHubInstallerRequest struct {
+	Repo         string `json:"repo"`          // owner/name
+	Binary       string `json:"binary"`        // executable name inside the release asset
+	AssetPattern string `json:"asset_pattern"` // optional extended regex to pick among matching assets
+	Path         string `json:"path"`          // defaults to /install/<binary>.sh
+	Description  string `json:"description"`
+	Tags         string `json:"tags"`
+	DryRun       bool   `json:"dry_run"` // return the script without storing it
+}
+
+func (req *GitHubInstallerRequest) validate() error {
+	if !githubRepoPattern.MatchString(req.Repo) {
+		return errors.New("repo must be in owner/name form")
+	}
+	if !binaryNamePattern.MatchString(req.Binary) {
+		return errors.New("binary name contains invalid characters")
+	}
+	if !assetPatternPattern.MatchString(req.AssetPattern) {
+		return errors.New("asset_pattern contains invalid characters")
+	}
+	if _, err := regexp.Compile(req.AssetPattern); err != nil {
+		return fmt.Errorf("asset_pattern is not a valid regex: %w", err)
+	}
+	return nil
+}
+
+// generateGitHubInstaller renders a POSIX sh installer that downloads the latest
+// release asset for the current OS/arch, verifies its checksum when the release
+// publishes one, and installs the binary to ~/.local/bin.
+func generateGitHubInstaller(repo, binary, assetPattern string) string {
+	if assetPattern == "" {
+		assetPattern = "."
+	}
+	return fmt.Sprintf(`#!/bin/sh
+# Install %[2]s from the latest GitHub release of %[1]s
+# Generated by SH Server
+#
+# Environment:
+#   VERSION      release tag to install (default: latest)
+#   INSTALL_DIR  target directory (default: $HOME/.local/bin)
+set -eu
+
+REPO="%[1]s"
+BINARY="%[2]s"
+ASSET_FILTER='%[3]s'
+VERSION="${VERSION:-latest}"
+INSTALL_DIR="${INSTALL_DIR:-$HOME/.local/bin}"
+
+need() {
+    command -v "$1" >/dev/null 2>&1 || { echo "Error: $1 is required" >&2; exit 1; }
+}
+need curl
+need uname
+
+# Detect OS and architecture
+OS=$(uname -s | tr '[:upper:]' '[:lower:]')
+case "$OS" in
+    linux) OS_PATTERN="linux" ;;
+    darwin) OS_PATTERN="darwin|macos|apple" ;;
+    freebsd) OS_PATTERN="freebsd" ;;
+    *) echo "Unsupported OS: $OS" >&2; exit 1 ;;
+esac
+
+ARCH=$(uname -m)
+case "$ARCH" in
+    x86_64|amd64) ARCH_PATTERN="x86_64|amd64|x64" ;;
+    aarch64|arm64) ARCH_PATTERN="aarch64|arm64" ;;
+    armv7*|armv6*) ARCH_PATTERN="armv7|armv6|armhf|arm" ;;
+    i386|i686) ARCH_PATTERN="i386|i686|386|x86" ;;
+    *) echo "Unsupported architecture: $ARCH" >&2; exit 1 ;;
+esac
+
+# Look up the release
+if [ "$VERSION" = "latest" ]; then
+    API_URL="https://api.github.com/repos/${REPO}/releases/latest"
+else
+    API_URL="https://api.github.com/repos/${REPO}/releases/tags/${VERSION}"
+fi
+
+RELEASE=$(curl -fsSL -H "Accept: application/vnd.github+json" "$API_URL") || {
+    echo "Error: failed to query $API_URL" >&2
+    exit 1
+}
+
+TAG=$(echo "$RELEASE" | grep -o '"tag_name": *"[^"]*"' | head -n 1 | sed 's/.*"\([^"]*\)"$/\1/')
+ASSETS=$(echo "$RELEASE" | grep -o '"browser_download_url": *"[^"]*"' | sed 's/.*"\([^"]*\)"$/\1/')
+
+ASSET_URL=$(echo "$ASSETS" \
+    | grep -Ei "$OS_PATTERN" \
+    | grep -Ei "$ARCH_PATTERN" \
+    | grep -Ei "$ASSET_FILTER" \
+    | grep -Eiv '\.(sha256|sha512|sig|asc|pem|sbom|json|txt)$' \
+    | head -n 1 || true)
+
+if [ -z "$ASSET_URL" ]; then
+    echo "Error: no release asset of ${REPO} ${TAG} matches ${OS}/${ARCH}" >&2
+    echo "Available assets:" >&2
+    echo "$ASSETS" >&2
+    exit 1
+fi
+
+ASSET_NAME=$(basename "$ASSET_URL")
+TMP=$(mktemp -d)
+trap 'rm -rf "$TMP"' EXIT INT TERM
+
+echo "Downloading ${ASSET_NAME} (${TAG})..."
+curl -fsSL "$ASSET_URL" -o "$TMP/$ASSET_NAME"
+
+# Verify checksum when the release publishes one
+CHECKSUM_URL=$(echo "$ASSETS" | grep -Ei '(checksums?|sha256sums?)(\.txt)?$|'"${ASSET_NAME}"'\.sha256$' | head -n 1 || true)
+if [ -n "$CHECKSUM_URL" ]; then
+    if command -v sha256sum >/dev/null 2>&1; then
+        SHA_CMD="sha256sum"
+    elif command -v shasum >/dev/null 2>&1; then
+        SHA_CMD="shasum -a 256"
+    else
+        echo "Error: sha256sum or shasum is required to verify the download" >&2
+        exit 1
+    fi
+    curl -fsSL "$CHECKSUM_URL" -o "$TMP/checksums"
+    EXPECTED=$(grep -F "$ASSET_NAME" "$TMP/checksums" | awk '{print $1}' | head -n 1)
+    [ -z "$EXPECTED" ] && EXPECTED=$(awk 'NR==1 {print $1}' "$TMP/checksums")
+    ACTUAL=$($SHA_CMD "$TMP/$ASSET_NAME" | awk '{print $1}')
+    if [ "$EXPECTED" != "$ACTUAL" ]; then
+        echo "Error: checksum mismatch for ${ASSET_NAME}" >&2
+        echo "  expected: $EXPECTED" >&2
+        echo "  actual:   $ACTUAL" >&2
+        exit 1
+    fi
+    echo "Checksum verified."
+else
+    echo "Warning: release publishes no checksums; skipping verification" >&2
+fi
+
+# Extract
+case "$ASSET_NAME" in
+    *.tar.gz|*.tgz) tar -xzf "$TMP/$ASSET_NAME" -C "$TMP" ;;
+    *.tar.xz) tar -xJf "$TMP/$ASSET_NAME" -C "$TMP" ;;
+    *.zip) need unzip; unzip -q "$TMP/$ASSET_NAME" -d "$TMP" ;;
+    *) mv "$TMP/$ASSET_NAME" "$TMP/$BINARY" ;;
+esac
+
+BIN_PATH=$(find "$TMP" -type f -name "$BINARY" | head -n 1)
+if [ -z "$BIN_PATH" ]; then
+    echo "Error: ${BINARY} not found in ${ASSET_NAME}" >&2
+    exit 1
+fi
+
+mkdir -p "$INSTALL_DIR"
+mv "$BIN_PATH" "$INSTALL_DIR/$BINARY"
+chmod +x "$INSTALL_DIR/$BINARY"
+
+echo "Installed ${BINARY} ${TAG} to ${INSTALL_DIR}/${BINARY}"
+case ":$PATH:" in
+    *":$INSTALL_DIR:"*) ;;
+    *) echo "Note: add ${INSTALL_DIR} to your PATH" ;;
+esac
+`, repo, binary, assetPattern)
+}
+
+// APIGenerateGitHubInstaller generates an installer script for a GitHub release
+// and stores it, or returns it as text with dry_run
+func (s *Server) APIGenerateGitHubInstaller(w http.ResponseWriter, r *http.Request) {
+	var req GitHubInstallerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	content := generateGitHubInstaller(req.Repo, req.Binary, req.AssetPattern)
+
+	if req.DryRun {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(content))
+		return
+	}
+
+	if req.Path == "" {
+		req.Path = "/install/" + strings.TrimSuffix(req.Binary, ".sh") + ".sh"
+	}
+	if err := validatePath(req.Path); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Description == "" {
+		req.Description = fmt.Sprintf("Install %s from the latest GitHub release of %s", req.Binary, req.Repo)
+	}
+
+	script, err := s.createScript(r.Context(), CreateScriptRequest{
+		Path:        req.Path,
+		Content:     content,
+		Description: req.Description,
+		Tags:        req.Tags,
+		DangerLevel: 1,
+		Requires:    "curl,tar",
+	})
+	if err != nil {
+		if errors.Is(err, errPathExists) {
+			http.Error(w, "Script with this path already exists", http.StatusConflict)
+			return
+		}
+		if writePreSaveError(w, err) || writeInstanceLimitError(w, err) {
+			return
+		}
+		http.Error(w, "Failed to create script: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(scriptToResponse(script))
+}
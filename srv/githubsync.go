@@ -0,0 +1,408 @@
+package srv
+
+import (
+	"context"
+	"crypto/hmac"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// githubSyncTimeout bounds a single GitHub API request
+const githubSyncTimeout = 30 * time.Second
+
+// maxGitHubWebhookBody caps push event payloads
+const maxGitHubWebhookBody = 5 << 20
+
+// GitHubSyncConfig mirrors the .sh files of a directory of a GitHub
+// repository into scripts, e.g. Dir "scripts" with Prefix "/ops" maps
+// scripts/deploy/app.sh to /ops/deploy/app.sh
+type GitHubSyncConfig struct {
+	Repo     string        // owner/name; empty disables syncing
+	Ref      string        // branch to follow
+	Dir      string        // directory inside the repository; empty for the root
+	Prefix   string        // script path the directory maps to
+	Token    string        // for private repositories and higher rate limits
+	Secret   string        // verifies push webhooks on /_sync/github
+	Interval time.Duration // 0 syncs only on webhooks and API calls
+	APIURL   string        // https://api.github.com or a GitHub Enterprise API
+}
+
+// scriptPath maps a file of the repository to a script path
+func (c GitHubSyncConfig) scriptPath(file string) (string, bool) {
+	if !strings.HasSuffix(file, ".sh") {
+		return "", false
+	}
+	if dir := strings.Trim(c.Dir, "/"); dir != "" {
+		rel, ok := strings.CutPrefix(file, dir+"/")
+		if !ok {
+			return "", false
+		}
+		file = rel
+	}
+	return path.Join("/", c.Prefix, file), true
+}
+
+// GitHubSyncResult lists what one sync changed
+type GitHubSyncResult struct {
+	Commit  string   `json:"commit"`
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Deleted []string `json:"deleted"`
+	Errors  []string `json:"errors"` // files that could not be synced
+}
+
+type githubTreeEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+}
+
+type githubCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+		Tree struct {
+			SHA string `json:"sha"`
+		} `json:"tree"`
+	} `json:"commit"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+}
+
+// githubGet fetches a path of the GitHub API as JSON
+func (s *Server) githubGet(ctx context.Context, apiPath string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(s.GitHubSync.APIURL, "/")+apiPath, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if s.GitHubSync.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.GitHubSync.Token)
+	}
+	client := &http.Client{Timeout: githubSyncTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GET %s: %s: %s", apiPath, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// githubBlob fetches the content of a blob, refusing one over
+// MAX_SCRIPT_SIZE as fetchSource does
+func (s *Server) githubBlob(ctx context.Context, sha string) (string, error) {
+	var blob struct {
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+		Size     int64  `json:"size"`
+	}
+	if err := s.githubGet(ctx, "/repos/"+s.GitHubSync.Repo+"/git/blobs/"+sha, &blob); err != nil {
+		return "", err
+	}
+	if blob.Size > s.Limits.MaxScriptSize {
+		return "", errors.New("blob is too large")
+	}
+	content := blob.Content
+	if blob.Encoding == "base64" {
+		data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(blob.Content, "\n", ""))
+		if err != nil {
+			return "", err
+		}
+		content = string(data)
+	}
+	if int64(len(content)) > s.Limits.MaxScriptSize {
+		return "", errors.New("blob is too large")
+	}
+	return content, nil
+}
+
+// githubFileCommit returns the last commit up to ref that touched a file
+func (s *Server) githubFileCommit(ctx context.Context, ref, file string) (githubCommit, error) {
+	var commits []githubCommit
+	query := url.Values{"sha": {ref}, "path": {file}, "per_page": {"1"}}
+	if err := s.githubGet(ctx, "/repos/"+s.GitHubSync.Repo+"/commits?"+query.Encode(), &commits); err != nil {
+		return githubCommit{}, err
+	}
+	if len(commits) == 0 {
+		return githubCommit{}, fmt.Errorf("no commit touches %s", file)
+	}
+	return commits[0], nil
+}
+
+// commitActor is the audit actor of a synced change
+func commitActor(c githubCommit) string {
+	if c.Author != nil && c.Author.Login != "" {
+		return "github:" + c.Author.Login
+	}
+	return "github:" + c.Commit.Author.Name
+}
+
+// syncGitHub brings the scripts in line with the head of the configured
+// branch. Only files whose blob changed since the last sync are fetched;
+// scripts that came from files since removed from the repository are
+// deleted.
+func (s *Server) syncGitHub(ctx context.Context) (*GitHubSyncResult, error) {
+	s.githubSyncMu.Lock()
+	defer s.githubSyncMu.Unlock()
+
+	cfg := s.GitHubSync
+	var head githubCommit
+	if err := s.githubGet(ctx, "/repos/"+cfg.Repo+"/commits/"+url.PathEscape(cfg.Ref), &head); err != nil {
+		return nil, err
+	}
+	var tree struct {
+		Tree      []githubTreeEntry `json:"tree"`
+		Truncated bool              `json:"truncated"`
+	}
+	if err := s.githubGet(ctx, "/repos/"+cfg.Repo+"/git/trees/"+head.Commit.Tree.SHA+"?recursive=1", &tree); err != nil {
+		return nil, err
+	}
+	if tree.Truncated {
+		return nil, errors.New("repository tree is too large to sync")
+	}
+
+	q := s.queries()
+	synced, err := q.ListGitHubSyncFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]dbgen.GithubSyncFile, len(synced))
+	for _, f := range synced {
+		known[f.Path] = f
+	}
+
+	res := &GitHubSyncResult{Commit: head.SHA, Created: []string{}, Updated: []string{}, Deleted: []string{}, Errors: []string{}}
+	seen := map[string]bool{}
+	for _, entry := range tree.Tree {
+		scriptPath, ok := cfg.scriptPath(entry.Path)
+		if entry.Type != "blob" || !ok {
+			continue
+		}
+		seen[scriptPath] = true
+		if err := validatePath(scriptPath); err != nil {
+			res.Errors = append(res.Errors, entry.Path+": "+err.Error())
+			continue
+		}
+		if f, ok := known[scriptPath]; ok && f.BlobSha == entry.SHA {
+			continue
+		}
+		if err := s.syncGitHubFile(ctx, q, head.SHA, entry, scriptPath, res); err != nil {
+			res.Errors = append(res.Errors, entry.Path+": "+err.Error())
+		}
+	}
+
+	for _, f := range synced {
+		if seen[f.Path] {
+			continue
+		}
+		if script, err := q.GetScriptByPath(ctx, f.Path); err == nil {
+			if err := s.deleteSyncedScript(ctx, q, script); err != nil {
+				res.Errors = append(res.Errors, f.Path+": "+err.Error())
+				continue
+			}
+			res.Deleted = append(res.Deleted, f.Path)
+		}
+		q.DeleteGitHubSyncFile(ctx, f.Path)
+	}
+	return res, nil
+}
+
+// syncGitHubFile creates or updates the script of one changed file, with
+// the file's last commit message as the version message
+func (s *Server) syncGitHubFile(ctx context.Context, q *queries, ref string, entry githubTreeEntry, scriptPath string, res *GitHubSyncResult) error {
+	content, err := s.githubBlob(ctx, entry.SHA)
+	if err != nil {
+		return err
+	}
+	commit, err := s.githubFileCommit(ctx, ref, entry.Path)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, actorKey{}, commitActor(commit))
+
+	existing, err := q.GetScriptByPath(ctx, scriptPath)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if _, err := s.createScript(ctx, CreateScriptRequest{Path: scriptPath, Content: content, Message: commit.Commit.Message}); err != nil {
+			return err
+		}
+		res.Created = append(res.Created, scriptPath)
+	case err != nil:
+		return err
+	case existing.Content != content:
+		if err := s.updateSyncedScript(ctx, q, existing, content, commit.Commit.Message, "synced from GitHub"); err != nil {
+			return err
+		}
+		res.Updated = append(res.Updated, scriptPath)
+	}
+
+	return q.UpsertGitHubSyncFile(ctx, dbgen.UpsertGitHubSyncFileParams{
+		Path:      scriptPath,
+		BlobSha:   entry.SHA,
+		CommitSha: commit.SHA,
+		SyncedAt:  time.Now(),
+	})
+}
+
+// updateSyncedScript replaces a script's content with a new version, if
+// the instance limits and PRE_SAVE_HOOK allow it, with details saying
+// where it came from in the audit log
+func (s *Server) updateSyncedScript(ctx context.Context, q *queries, script dbgen.Script, content, message, details string) error {
+	if _, err := s.instanceLimits(ctx, q, &script, content); err != nil {
+		return err
+	}
+	if err := s.preSave(ctx, "update", script.Path, content); err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := q.UpdateScriptContent(ctx, dbgen.UpdateScriptContentParams{Content: content, UpdatedAt: now, ID: script.ID}); err != nil {
+		return err
+	}
+	version, _ := q.GetCurrentVersion(ctx, script.ID)
+	q.CreateVersion(ctx, dbgen.CreateVersionParams{
+		ScriptID:  script.ID,
+		Content:   content,
+		Version:   version + 1,
+		Message:   versionMessage(message),
+		CreatedAt: now,
+	})
+	q.UpdateCanaryStable(ctx, dbgen.UpdateCanaryStableParams{StableVersion: version + 1, ScriptID: script.ID})
+	q.CreateAuditLog(ctx, dbgen.CreateAuditLogParams{
+		Action:     "UPDATE",
+		EntityType: "script",
+		EntityID:   &script.ID,
+		EntityPath: &script.Path,
+		Details:    &details,
+		Actor:      actor(ctx),
+		RequestID:  requestID(ctx),
+		CreatedAt:  now,
+	})
+
+	script, err := q.GetScript(ctx, script.ID)
+	if err != nil {
+		return err
+	}
+	s.emit(ctx, s.scriptEvent(ctx, q, EventScriptUpdated, script))
+	s.postSave(ctx, "update", script.Path, script.Content)
+	s.mirrorSave(ctx, "", script, message)
+	return nil
+}
+
+// deleteSyncedScript deletes a script whose file left the repository
+func (s *Server) deleteSyncedScript(ctx context.Context, q *queries, script dbgen.Script) error {
+	ctx = context.WithValue(ctx, actorKey{}, "github-sync")
+	if err := q.DeleteScript(ctx, script.ID); err != nil {
+		return err
+	}
+	q.CreateAuditLog(ctx, dbgen.CreateAuditLogParams{
+		Action:     "DELETE",
+		EntityType: "script",
+		EntityID:   &script.ID,
+		EntityPath: &script.Path,
+		Details:    strPtr("removed from GitHub"),
+		Actor:      actor(ctx),
+		RequestID:  requestID(ctx),
+		CreatedAt:  time.Now(),
+	})
+	s.emit(ctx, s.scriptEvent(ctx, q, EventScriptDeleted, script))
+	s.postSave(ctx, "delete", script.Path, "")
+	s.mirrorDelete(ctx, script.Path)
+	return nil
+}
+
+// logGitHubSync runs a sync and logs its outcome
+func (s *Server) logGitHubSync(ctx context.Context, trigger string) {
+	res, err := s.syncGitHub(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "GitHub sync failed", "trigger", trigger, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "GitHub sync", "trigger", trigger, "commit", res.Commit,
+		"created", len(res.Created), "updated", len(res.Updated), "deleted", len(res.Deleted), "errors", len(res.Errors))
+	for _, e := range res.Errors {
+		slog.WarnContext(ctx, "GitHub sync skipped a file", "error", e)
+	}
+}
+
+// runGitHubSyncJob syncs on startup and then every interval
+func (s *Server) runGitHubSyncJob() {
+	for {
+		s.logGitHubSync(context.Background(), "schedule")
+		time.Sleep(s.GitHubSync.Interval)
+	}
+}
+
+// HandleGitHubSyncWebhook syncs after a push to the followed branch. The
+// payload must be signed with GITHUB_SYNC_SECRET (X-Hub-Signature-256).
+func (s *Server) HandleGitHubSyncWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.GitHubSync.Repo == "" || s.GitHubSync.Secret == "" {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxGitHubWebhookBody))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	// GitHub signs the same way outbound webhooks are signed
+	want := signWebhook(s.GitHubSync.Secret, body)
+	if !hmac.Equal([]byte(r.Header.Get("X-Hub-Signature-256")), []byte(want)) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Header.Get("X-GitHub-Event") != "push" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	var push struct {
+		Ref string `json:"ref"`
+	}
+	if err := json.Unmarshal(body, &push); err != nil {
+		http.Error(w, "Invalid push payload", http.StatusBadRequest)
+		return
+	}
+	if push.Ref != "refs/heads/"+s.GitHubSync.Ref {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	go s.logGitHubSync(context.WithoutCancel(r.Context()), "webhook")
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// APIGitHubSync syncs right away and returns what changed
+func (s *Server) APIGitHubSync(w http.ResponseWriter, r *http.Request) {
+	if s.GitHubSync.Repo == "" {
+		http.Error(w, "GitHub sync is not configured", http.StatusNotFound)
+		return
+	}
+	res, err := s.syncGitHub(r.Context())
+	if err != nil {
+		http.Error(w, "GitHub sync failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,257 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// gitMirrorQueue is how many changes may wait for the mirror before saves block
+const gitMirrorQueue = 256
+
+// Bounds on one git command, so that a hung remote can't stall the mirror
+// and, once its queue is full, every save
+const (
+	gitMirrorTimeout     = time.Minute
+	gitMirrorPushTimeout = 2 * time.Minute
+)
+
+// gitMirrorEmail is the email domain of mirror commit authors
+const gitMirrorEmail = "sh-server.invalid"
+
+// GitMirrorConfig writes every script change through to a git repository
+type GitMirrorConfig struct {
+	Dir    string // work tree, created and initialized if missing; empty disables mirroring
+	Remote string // remote name or URL pushed to after every commit; optional
+}
+
+// gitChange is one script change to commit
+type gitChange struct {
+	path    string // script path
+	oldPath string // previous path of a moved script
+	content string
+	deleted bool
+	message string
+	author  string
+}
+
+// gitMirror commits script changes one after another in the background
+type gitMirror struct {
+	cfg     GitMirrorConfig
+	changes chan gitChange
+	pending sync.WaitGroup
+}
+
+// openGitMirror initializes the repository, commits whatever differs from
+// the database (changes made while mirroring was off) and starts the
+// committer
+func openGitMirror(ctx context.Context, q *queries, cfg GitMirrorConfig) (*gitMirror, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git mirror needs git: %w", err)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	m := &gitMirror{cfg: cfg, changes: make(chan gitChange, gitMirrorQueue)}
+	if _, err := os.Stat(filepath.Join(cfg.Dir, ".git")); errors.Is(err, fs.ErrNotExist) {
+		if _, err := m.git("", "init", "-q"); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.snapshot(ctx, q); err != nil {
+		return nil, fmt.Errorf("git mirror: %w", err)
+	}
+	go m.run()
+	return m, nil
+}
+
+// git runs a git command in the work tree, committing as author when set
+func (m *gitMirror) git(author string, args ...string) (string, error) {
+	timeout := gitMirrorTimeout
+	if args[0] == "push" {
+		timeout = gitMirrorPushTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = m.cfg.Dir
+	// ssh may outlive a killed git with our output pipes open
+	cmd.WaitDelay = 5 * time.Second
+	if author == "" {
+		author = "sh-server"
+	}
+	email := strings.Map(func(r rune) rune {
+		if strings.ContainsRune("<> \t\n", r) {
+			return '_'
+		}
+		return r
+	}, author) + "@" + gitMirrorEmail
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+author, "GIT_AUTHOR_EMAIL="+email,
+		"GIT_COMMITTER_NAME=sh-server", "GIT_COMMITTER_EMAIL=sh-server@"+gitMirrorEmail,
+		// Nobody is there to type a password
+		"GIT_TERMINAL_PROMPT=0",
+	)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return out.String(), fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(out.String()))
+	}
+	return out.String(), nil
+}
+
+// file returns where a script lives in the work tree
+func (m *gitMirror) file(scriptPath string) (string, error) {
+	rel := strings.TrimPrefix(scriptPath, "/")
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("path %q is outside the repository", scriptPath)
+	}
+	return filepath.Join(m.cfg.Dir, filepath.FromSlash(rel)), nil
+}
+
+// commit stages everything and commits it unless nothing changed
+func (m *gitMirror) commit(author, message string) error {
+	if _, err := m.git(author, "add", "-A"); err != nil {
+		return err
+	}
+	if _, err := m.git(author, "diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+	if _, err := m.git(author, "commit", "-q", "-m", message); err != nil {
+		return err
+	}
+	if m.cfg.Remote != "" {
+		// A failed push is retried with the next commit
+		if _, err := m.git(author, "push", "-q", m.cfg.Remote, "HEAD"); err != nil {
+			slog.Warn("git mirror push failed", "remote", m.cfg.Remote, "error", err)
+		}
+	}
+	return nil
+}
+
+// snapshot makes the work tree match the database
+func (m *gitMirror) snapshot(ctx context.Context, q *queries) error {
+	scripts, err := q.ListScripts(ctx)
+	if err != nil {
+		return err
+	}
+	want := make(map[string]bool, len(scripts))
+	for _, sc := range scripts {
+		file, err := m.file(sc.Path)
+		if err != nil {
+			continue
+		}
+		want[file] = true
+		if err := writeMirrorFile(file, sc.Content); err != nil {
+			return err
+		}
+	}
+	err = filepath.WalkDir(m.cfg.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(p, ".sh") && !want[p] {
+			return os.Remove(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return m.commit("", "Sync with database")
+}
+
+// writeMirrorFile writes a script, creating its folders
+func writeMirrorFile(file, content string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(content), 0o644)
+}
+
+// apply commits one change
+func (m *gitMirror) apply(c gitChange) error {
+	file, err := m.file(c.path)
+	if err != nil {
+		return err
+	}
+	subject := "Update " + c.path
+	switch {
+	case c.deleted:
+		subject = "Delete " + c.path
+		if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	default:
+		if c.oldPath != "" && c.oldPath != c.path {
+			subject = "Move " + c.oldPath + " to " + c.path
+			if old, err := m.file(c.oldPath); err == nil {
+				os.Remove(old)
+			}
+		}
+		if _, err := os.Stat(file); errors.Is(err, fs.ErrNotExist) && c.oldPath == "" {
+			subject = "Create " + c.path
+		}
+		if err := writeMirrorFile(file, c.content); err != nil {
+			return err
+		}
+	}
+	if c.message != "" {
+		subject += "\n\n" + c.message
+	}
+	return m.commit(c.author, subject)
+}
+
+// run commits queued changes in order
+func (m *gitMirror) run() {
+	for c := range m.changes {
+		if err := m.apply(c); err != nil {
+			slog.Error("git mirror commit failed", "path", c.path, "error", err)
+		}
+		m.pending.Done()
+	}
+}
+
+// enqueue hands a change to the committer
+func (m *gitMirror) enqueue(c gitChange) {
+	m.pending.Add(1)
+	m.changes <- c
+}
+
+// close stops the committer once queued changes are committed
+func (m *gitMirror) close() {
+	close(m.changes)
+	m.pending.Wait()
+}
+
+// mirrorSave commits a created or changed script; oldPath is its path
+// before the change, if it moved
+func (s *Server) mirrorSave(ctx context.Context, oldPath string, script dbgen.Script, message string) {
+	if s.mirror == nil {
+		return
+	}
+	s.mirror.enqueue(gitChange{path: script.Path, oldPath: oldPath, content: script.Content, message: message, author: derefStr(actor(ctx))})
+}
+
+// mirrorDelete commits the removal of a script
+func (s *Server) mirrorDelete(ctx context.Context, scriptPath string) {
+	if s.mirror == nil {
+		return
+	}
+	s.mirror.enqueue(gitChange{path: scriptPath, deleted: true, author: derefStr(actor(ctx))})
+}
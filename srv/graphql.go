@@ -0,0 +1,456 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// Number of audit entries returned by auditLog fields
+const (
+	defaultGraphQLAuditLimit = 50
+	maxGraphQLAuditLimit     = 1000
+)
+
+// graphqlLoader holds what a query reads more than once, so that asking
+// for the same field on every script costs one database query
+type graphqlLoader struct {
+	s *Server
+	q *queries
+
+	scriptsOnce sync.Once
+	scripts     []dbgen.Script
+	scriptsErr  error
+
+	statsOnce sync.Once
+	stats     map[string]dbgen.ScriptStat
+	statsErr  error
+}
+
+type graphqlLoaderKey struct{}
+
+func loaderFrom(ctx context.Context) *graphqlLoader {
+	return ctx.Value(graphqlLoaderKey{}).(*graphqlLoader)
+}
+
+func (l *graphqlLoader) allScripts(ctx context.Context) ([]dbgen.Script, error) {
+	l.scriptsOnce.Do(func() { l.scripts, l.scriptsErr = l.q.ListScripts(ctx) })
+	return l.scripts, l.scriptsErr
+}
+
+func (l *graphqlLoader) scriptStats(ctx context.Context, id string) (dbgen.ScriptStat, error) {
+	l.statsOnce.Do(func() {
+		rows, err := l.q.ListScriptStats(ctx)
+		l.stats, l.statsErr = make(map[string]dbgen.ScriptStat, len(rows)), err
+		for _, row := range rows {
+			l.stats[row.ScriptID] = row
+		}
+	})
+	return l.stats[id], l.statsErr
+}
+
+// graphqlVersion is a version as listed; the content is loaded on request
+type graphqlVersion struct {
+	scriptID string
+	dbgen.ListVersionHistoryRow
+}
+
+// gqlField resolves a field from the source value of type T
+func gqlField[T any](t graphql.Output, value func(T) any) *graphql.Field {
+	return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (any, error) {
+		return value(p.Source.(T)), nil
+	}}
+}
+
+// limitArg reads an optional limit argument, capped at most
+func limitArg(p graphql.ResolveParams, def, most int) int {
+	n, ok := p.Args["limit"].(int)
+	if !ok || n <= 0 {
+		return def
+	}
+	return min(n, most)
+}
+
+func auditLogField() *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(auditEntryType))),
+		Args: graphql.FieldConfigArgument{
+			"limit":    &graphql.ArgumentConfig{Type: graphql.Int},
+			"entityId": &graphql.ArgumentConfig{Type: graphql.ID},
+		},
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			q := loaderFrom(p.Context).q
+			limit := limitArg(p, defaultGraphQLAuditLimit, maxGraphQLAuditLimit)
+			entityID, _ := p.Args["entityId"].(string)
+			if sc, ok := p.Source.(ScriptResponse); ok {
+				entityID = sc.ID
+			}
+			if entityID == "" {
+				return q.ListAuditLogs(p.Context, int64(limit))
+			}
+			logs, err := q.ListAuditLogsByEntity(p.Context, &entityID)
+			return logs[:min(limit, len(logs))], err
+		},
+	}
+}
+
+var auditEntryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "AuditEntry",
+	Fields: graphql.Fields{
+		"id":         gqlField(graphql.NewNonNull(graphql.ID), func(a dbgen.AuditLog) any { return a.ID }),
+		"action":     gqlField(graphql.NewNonNull(graphql.String), func(a dbgen.AuditLog) any { return a.Action }),
+		"entityType": gqlField(graphql.NewNonNull(graphql.String), func(a dbgen.AuditLog) any { return a.EntityType }),
+		"entityId":   gqlField(graphql.ID, func(a dbgen.AuditLog) any { return a.EntityID }),
+		"entityPath": gqlField(graphql.String, func(a dbgen.AuditLog) any { return a.EntityPath }),
+		"details":    gqlField(graphql.String, func(a dbgen.AuditLog) any { return a.Details }),
+		"ipAddress":  gqlField(graphql.String, func(a dbgen.AuditLog) any { return a.IpAddress }),
+		"userAgent":  gqlField(graphql.String, func(a dbgen.AuditLog) any { return a.UserAgent }),
+		"actor":      gqlField(graphql.String, func(a dbgen.AuditLog) any { return a.Actor }),
+		"requestId":  gqlField(graphql.String, func(a dbgen.AuditLog) any { return a.RequestID }),
+		"createdAt":  gqlField(graphql.NewNonNull(graphql.DateTime), func(a dbgen.AuditLog) any { return a.CreatedAt }),
+	},
+})
+
+var versionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Version",
+	Fields: graphql.Fields{
+		"version":   gqlField(graphql.NewNonNull(graphql.Int), func(v graphqlVersion) any { return v.Version }),
+		"message":   gqlField(graphql.String, func(v graphqlVersion) any { return v.Message }),
+		"serves":    gqlField(graphql.NewNonNull(graphql.Int), func(v graphqlVersion) any { return v.Serves }),
+		"createdAt": gqlField(graphql.NewNonNull(graphql.DateTime), func(v graphqlVersion) any { return v.CreatedAt }),
+		"content": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				v := p.Source.(graphqlVersion)
+				full, err := loaderFrom(p.Context).q.GetVersion(p.Context, dbgen.GetVersionParams{ScriptID: v.scriptID, Version: v.Version})
+				return full.Content, err
+			},
+		},
+	},
+})
+
+var dailyFetchesType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "DailyFetches",
+	Fields: graphql.Fields{
+		"day":       gqlField(graphql.NewNonNull(graphql.String), func(d DailyFetches) any { return d.Day }),
+		"fetches":   gqlField(graphql.NewNonNull(graphql.Int), func(d DailyFetches) any { return d.Fetches }),
+		"consumers": gqlField(graphql.NewNonNull(graphql.Int), func(d DailyFetches) any { return d.Consumers }),
+	},
+})
+
+var scriptStatsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ScriptStats",
+	Fields: graphql.Fields{
+		"fetches":       gqlField(graphql.NewNonNull(graphql.Int), func(st ScriptStatsResponse) any { return st.Fetches }),
+		"lastFetchedAt": gqlField(graphql.DateTime, func(st ScriptStatsResponse) any { return st.LastFetchedAt }),
+		"daily": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(dailyFetchesType))),
+			Args: graphql.FieldConfigArgument{"days": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 30}},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				st := p.Source.(ScriptStatsResponse)
+				days := min(max(p.Args["days"].(int), 1), 366)
+				now := time.Now()
+				since := statsSince(now, days)
+				q := loaderFrom(p.Context).q
+				rows, err := q.ListScriptDailyStats(p.Context, dbgen.ListScriptDailyStatsParams{ScriptID: st.ScriptID, Day: since})
+				if err != nil {
+					return nil, err
+				}
+				consumers, err := q.ListScriptDailyConsumers(p.Context, dbgen.ListScriptDailyConsumersParams{ScriptID: st.ScriptID, Day: since})
+				if err != nil {
+					return nil, err
+				}
+				return dailyHistogram(rows, consumers, now, days), nil
+			},
+		},
+	},
+})
+
+var (
+	scriptType *graphql.Object
+	folderType *graphql.Object
+)
+
+func init() {
+	scriptType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Script",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":              gqlField(graphql.NewNonNull(graphql.ID), func(sc ScriptResponse) any { return sc.ID }),
+				"path":            gqlField(graphql.NewNonNull(graphql.String), func(sc ScriptResponse) any { return sc.Path }),
+				"name":            gqlField(graphql.NewNonNull(graphql.String), func(sc ScriptResponse) any { return sc.Name }),
+				"content":         gqlField(graphql.NewNonNull(graphql.String), func(sc ScriptResponse) any { return sc.Content }),
+				"description":     gqlField(graphql.NewNonNull(graphql.String), func(sc ScriptResponse) any { return sc.Description }),
+				"tags":            gqlField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), func(sc ScriptResponse) any { return append([]string{}, splitScope(&sc.Tags)...) }),
+				"locked":          gqlField(graphql.NewNonNull(graphql.Boolean), func(sc ScriptResponse) any { return sc.Locked }),
+				"dangerLevel":     gqlField(graphql.NewNonNull(graphql.Int), func(sc ScriptResponse) any { return sc.DangerLevel }),
+				"requires":        gqlField(graphql.NewNonNull(graphql.String), func(sc ScriptResponse) any { return sc.Requires }),
+				"examples":        gqlField(graphql.NewNonNull(graphql.String), func(sc ScriptResponse) any { return sc.Examples }),
+				"library":         gqlField(graphql.NewNonNull(graphql.Boolean), func(sc ScriptResponse) any { return sc.Library }),
+				"deprecated":      gqlField(graphql.NewNonNull(graphql.Boolean), func(sc ScriptResponse) any { return sc.Deprecated }),
+				"replacementPath": gqlField(graphql.NewNonNull(graphql.String), func(sc ScriptResponse) any { return sc.ReplacementPath }),
+				"disabled":        gqlField(graphql.NewNonNull(graphql.Boolean), func(sc ScriptResponse) any { return sc.Disabled }),
+				"expired":         gqlField(graphql.NewNonNull(graphql.Boolean), func(sc ScriptResponse) any { return sc.Expired }),
+				"archived":        gqlField(graphql.NewNonNull(graphql.Boolean), func(sc ScriptResponse) any { return sc.Archived }),
+				"unlisted":        gqlField(graphql.NewNonNull(graphql.Boolean), func(sc ScriptResponse) any { return sc.Unlisted }),
+				"private":         gqlField(graphql.NewNonNull(graphql.Boolean), func(sc ScriptResponse) any { return sc.Private }),
+				"createdAt":       gqlField(graphql.NewNonNull(graphql.DateTime), func(sc ScriptResponse) any { return sc.CreatedAt }),
+				"updatedAt":       gqlField(graphql.NewNonNull(graphql.DateTime), func(sc ScriptResponse) any { return sc.UpdatedAt }),
+				"folder": &graphql.Field{
+					Type: folderType,
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return folderByPath(p.Context, path.Dir(p.Source.(ScriptResponse).Path))
+					},
+				},
+				"version": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return loaderFrom(p.Context).q.GetCurrentVersion(p.Context, p.Source.(ScriptResponse).ID)
+					},
+				},
+				"lastVersion": &graphql.Field{
+					Type: versionType,
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						versions, err := scriptVersions(p.Context, p.Source.(ScriptResponse).ID, 1)
+						if err != nil || len(versions) == 0 {
+							return nil, err
+						}
+						return versions[0], nil
+					},
+				},
+				"versions": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(versionType))),
+					Args: graphql.FieldConfigArgument{"limit": &graphql.ArgumentConfig{Type: graphql.Int}},
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						limit, _ := p.Args["limit"].(int)
+						return scriptVersions(p.Context, p.Source.(ScriptResponse).ID, limit)
+					},
+				},
+				"fetchCount": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						st, err := loaderFrom(p.Context).scriptStats(p.Context, p.Source.(ScriptResponse).ID)
+						return st.Fetches, err
+					},
+				},
+				"stats": &graphql.Field{
+					Type: graphql.NewNonNull(scriptStatsType),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						sc := p.Source.(ScriptResponse)
+						st, err := loaderFrom(p.Context).scriptStats(p.Context, sc.ID)
+						return ScriptStatsResponse{ScriptID: sc.ID, Path: sc.Path, Fetches: st.Fetches, LastFetchedAt: st.LastFetchedAt}, err
+					},
+				},
+				"auditLog": auditLogField(),
+			}
+		}),
+	})
+
+	folderType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Folder",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        gqlField(graphql.ID, func(f FolderResponse) any { return f.ID }),
+				"path":      gqlField(graphql.NewNonNull(graphql.String), func(f FolderResponse) any { return f.Path }),
+				"name":      gqlField(graphql.NewNonNull(graphql.String), func(f FolderResponse) any { return f.Name }),
+				"locked":    gqlField(graphql.NewNonNull(graphql.Boolean), func(f FolderResponse) any { return f.Locked }),
+				"createdAt": gqlField(graphql.DateTime, func(f FolderResponse) any { return f.CreatedAt }),
+				"scripts": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(scriptType))),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						dir := p.Source.(FolderResponse).Path
+						return filterScripts(p.Context, func(sc dbgen.Script) bool { return path.Dir(sc.Path) == dir })
+					},
+				},
+				"folders": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(folderType))),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return subfolders(p.Context, p.Source.(FolderResponse).Path)
+					},
+				},
+			}
+		}),
+	})
+}
+
+// filterScripts returns the scripts keep accepts
+func filterScripts(ctx context.Context, keep func(dbgen.Script) bool) ([]ScriptResponse, error) {
+	scripts, err := loaderFrom(ctx).allScripts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []ScriptResponse{}
+	for _, sc := range scripts {
+		if keep(sc) {
+			out = append(out, scriptToResponse(sc))
+		}
+	}
+	return out, nil
+}
+
+// scriptVersions lists a script's versions, newest first; limit 0 means all
+func scriptVersions(ctx context.Context, scriptID string, limit int) ([]graphqlVersion, error) {
+	rows, err := loaderFrom(ctx).q.ListVersionHistory(ctx, scriptID)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	out := make([]graphqlVersion, len(rows))
+	for i, row := range rows {
+		out[i] = graphqlVersion{scriptID: scriptID, ListVersionHistoryRow: row}
+	}
+	return out, nil
+}
+
+// folderByPath returns a folder; folders that only exist through the
+// scripts in them have no ID
+func folderByPath(ctx context.Context, dir string) (any, error) {
+	if f, err := loaderFrom(ctx).q.GetFolderByPath(ctx, dir); err == nil {
+		return folderToResponse(f), nil
+	}
+	if _, exists, err := (treeFS{s: loaderFrom(ctx).s}).children(ctx, dir); err != nil || !exists {
+		return nil, err
+	}
+	return FolderResponse{Path: dir, Name: path.Base(dir)}, nil
+}
+
+// subfolders lists the folders directly inside dir
+func subfolders(ctx context.Context, dir string) ([]FolderResponse, error) {
+	entries, _, err := (treeFS{s: loaderFrom(ctx).s}).children(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	out := []FolderResponse{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		f, err := folderByPath(ctx, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := f.(FolderResponse); ok {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+var graphqlQueryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.FieldsThunk(func() graphql.Fields {
+		return graphql.Fields{
+			"scripts": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(scriptType))),
+				Args: graphql.FieldConfigArgument{
+					"prefix": &graphql.ArgumentConfig{Type: graphql.String},
+					"tag":    &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					prefix, _ := p.Args["prefix"].(string)
+					tag, _ := p.Args["tag"].(string)
+					return filterScripts(p.Context, func(sc dbgen.Script) bool {
+						if !scriptPrefixMatch(sc.Path, prefix) {
+							return false
+						}
+						return tag == "" || slices.ContainsFunc(splitScope(sc.Tags), func(t string) bool { return strings.EqualFold(t, tag) })
+					})
+				},
+			},
+			"script": &graphql.Field{
+				Type: scriptType,
+				Args: graphql.FieldConfigArgument{
+					"id":   &graphql.ArgumentConfig{Type: graphql.ID},
+					"path": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, _ := p.Args["id"].(string)
+					scriptPath, _ := p.Args["path"].(string)
+					scripts, err := filterScripts(p.Context, func(sc dbgen.Script) bool {
+						return sc.ID == id || sc.Path == scriptPath
+					})
+					if err != nil || len(scripts) == 0 {
+						return nil, err
+					}
+					return scripts[0], nil
+				},
+			},
+			"folders": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(folderType))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return subfolders(p.Context, "/")
+				},
+			},
+			"folder": &graphql.Field{
+				Type: folderType,
+				Args: graphql.FieldConfigArgument{"path": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return folderByPath(p.Context, path.Clean("/"+p.Args["path"].(string)))
+				},
+			},
+			"auditLog": auditLogField(),
+		}
+	}),
+})
+
+// graphqlSchema is built once, on first use
+var graphqlSchema = sync.OnceValues(func() (graphql.Schema, error) {
+	return graphql.NewSchema(graphql.SchemaConfig{Query: graphqlQueryType})
+})
+
+// graphqlRequest is a GraphQL query sent as JSON or in the URL
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// APIGraphQL answers read-only GraphQL queries over scripts, folders,
+// versions, stats and the audit log
+func (s *Server) APIGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				http.Error(w, "Invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "Query is required", http.StatusBadRequest)
+		return
+	}
+	schema, err := graphqlSchema()
+	if err != nil {
+		http.Error(w, "GraphQL schema is invalid", http.StatusInternalServerError)
+		return
+	}
+
+	loader := &graphqlLoader{s: s, q: s.queries()}
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        context.WithValue(r.Context(), graphqlLoaderKey{}, loader),
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,350 @@
+package srv
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/hunydev/sh-server/adminpb"
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// grpcHandler serves the gRPC admin API (adminpb/admin.proto) over HTTP/2
+// on the main port. Authentication is adminOnly's, from the request
+// metadata.
+func (s *Server) grpcHandler() http.HandlerFunc {
+	server := grpc.NewServer()
+	adminpb.RegisterAdminServer(server, &adminService{s: s})
+	return func(w http.ResponseWriter, r *http.Request) {
+		server.ServeHTTP(w, withOriginalRequest(r))
+	}
+}
+
+// adminService implements the gRPC admin API on top of the REST handlers,
+// so that both behave the same
+type adminService struct {
+	adminpb.UnimplementedAdminServer
+	s *Server
+}
+
+// grpcCodes maps the REST API's answers to gRPC status codes
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.AlreadyExists,
+	http.StatusRequestEntityTooLarge: codes.InvalidArgument,
+	http.StatusUnprocessableEntity:   codes.InvalidArgument,
+	http.StatusLocked:                codes.FailedPrecondition,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+}
+
+// grpcError turns an error from callAPI or the database into a gRPC status
+func grpcError(err error) error {
+	var perr *PublishError
+	switch {
+	case errors.As(err, &perr):
+		code, ok := grpcCodes[perr.Status]
+		if !ok {
+			code = codes.Internal
+		}
+		return status.Error(code, perr.Message)
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "not found")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func timestampOrNil(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timeOrNil(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func scriptToProto(sc ScriptResponse) *adminpb.Script {
+	p := &adminpb.Script{
+		Id:               sc.ID,
+		Path:             sc.Path,
+		Name:             sc.Name,
+		Content:          sc.Content,
+		Description:      sc.Description,
+		Tags:             sc.Tags,
+		Locked:           sc.Locked,
+		DangerLevel:      int32(sc.DangerLevel),
+		Requires:         sc.Requires,
+		Examples:         sc.Examples,
+		Library:          sc.Library,
+		Deprecated:       sc.Deprecated,
+		ReplacementPath:  sc.ReplacementPath,
+		SunsetAt:         timestampOrNil(sc.SunsetAt),
+		Disabled:         sc.Disabled,
+		DisabledReason:   sc.DisabledReason,
+		AvailableFrom:    timestampOrNil(sc.AvailableFrom),
+		AvailableUntil:   timestampOrNil(sc.AvailableUntil),
+		ExpiresAt:        timestampOrNil(sc.ExpiresAt),
+		Expired:          sc.Expired,
+		Archived:         sc.Archived,
+		Unlisted:         sc.Unlisted,
+		Private:          sc.Private,
+		UnlockTtlSeconds: sc.UnlockTTL,
+		AllowCountries:   sc.AllowCountries,
+		DenyCountries:    sc.DenyCountries,
+		CreatedAt:        timestamppb.New(sc.CreatedAt),
+		UpdatedAt:        timestamppb.New(sc.UpdatedAt),
+	}
+	for _, w := range sc.Warnings {
+		p.Warnings = append(p.Warnings, &adminpb.ScanWarning{Rule: w.Rule, Line: int32(w.Line), Message: w.Message, Match: w.Match})
+	}
+	return p
+}
+
+// scriptFields copies each writable Script field, by its proto name, onto
+// a save request
+var scriptFields = map[string]func(*UpdateScriptRequest, *adminpb.Script){
+	"path":               func(r *UpdateScriptRequest, p *adminpb.Script) { r.Path = p.Path },
+	"content":            func(r *UpdateScriptRequest, p *adminpb.Script) { r.Content = p.Content },
+	"description":        func(r *UpdateScriptRequest, p *adminpb.Script) { r.Description = p.Description },
+	"tags":               func(r *UpdateScriptRequest, p *adminpb.Script) { r.Tags = p.Tags },
+	"locked":             func(r *UpdateScriptRequest, p *adminpb.Script) { r.Locked = p.Locked },
+	"danger_level":       func(r *UpdateScriptRequest, p *adminpb.Script) { r.DangerLevel = int(p.DangerLevel) },
+	"requires":           func(r *UpdateScriptRequest, p *adminpb.Script) { r.Requires = p.Requires },
+	"examples":           func(r *UpdateScriptRequest, p *adminpb.Script) { r.Examples = p.Examples },
+	"deprecated":         func(r *UpdateScriptRequest, p *adminpb.Script) { r.Deprecated = p.Deprecated },
+	"replacement_path":   func(r *UpdateScriptRequest, p *adminpb.Script) { r.ReplacementPath = p.ReplacementPath },
+	"sunset_at":          func(r *UpdateScriptRequest, p *adminpb.Script) { r.SunsetAt = timeOrNil(p.SunsetAt) },
+	"available_from":     func(r *UpdateScriptRequest, p *adminpb.Script) { r.AvailableFrom = timeOrNil(p.AvailableFrom) },
+	"available_until":    func(r *UpdateScriptRequest, p *adminpb.Script) { r.AvailableUntil = timeOrNil(p.AvailableUntil) },
+	"expires_at":         func(r *UpdateScriptRequest, p *adminpb.Script) { r.ExpiresAt = timeOrNil(p.ExpiresAt) },
+	"unlisted":           func(r *UpdateScriptRequest, p *adminpb.Script) { r.Unlisted = p.Unlisted },
+	"private":            func(r *UpdateScriptRequest, p *adminpb.Script) { r.Private = p.Private },
+	"unlock_ttl_seconds": func(r *UpdateScriptRequest, p *adminpb.Script) { r.UnlockTTL = p.UnlockTtlSeconds },
+	"allow_countries":    func(r *UpdateScriptRequest, p *adminpb.Script) { r.AllowCountries = p.AllowCountries },
+	"deny_countries":     func(r *UpdateScriptRequest, p *adminpb.Script) { r.DenyCountries = p.DenyCountries },
+}
+
+func folderToProto(f FolderResponse) *adminpb.Folder {
+	return &adminpb.Folder{Id: f.ID, Path: f.Path, Name: f.Name, Locked: f.Locked, CreatedAt: timestamppb.New(f.CreatedAt)}
+}
+
+func (a *adminService) ListScripts(req *adminpb.ListScriptsRequest, stream grpc.ServerStreamingServer[adminpb.Script]) error {
+	scripts, err := a.s.queries().ListScripts(stream.Context())
+	if err != nil {
+		return grpcError(err)
+	}
+	for _, sc := range scripts {
+		if !scriptPrefixMatch(sc.Path, req.Prefix) {
+			continue
+		}
+		if err := stream.Send(scriptToProto(scriptToResponse(sc))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *adminService) GetScript(ctx context.Context, req *adminpb.GetScriptRequest) (*adminpb.Script, error) {
+	q := a.s.queries()
+	var sc dbgen.Script
+	var err error
+	switch key := req.Key.(type) {
+	case *adminpb.GetScriptRequest_Id:
+		sc, err = q.GetScript(ctx, key.Id)
+	case *adminpb.GetScriptRequest_Path:
+		sc, err = q.GetScriptByPath(ctx, key.Path)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "id or path is required")
+	}
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return scriptToProto(scriptToResponse(sc)), nil
+}
+
+// saved decodes the script a create or update answered with
+func saved(rec *apiRecorder) *adminpb.Script {
+	var resp ScriptResponse
+	json.Unmarshal(rec.body.Bytes(), &resp)
+	return scriptToProto(resp)
+}
+
+func (a *adminService) CreateScript(ctx context.Context, req *adminpb.CreateScriptRequest) (*adminpb.Script, error) {
+	if req.Script == nil {
+		return nil, status.Error(codes.InvalidArgument, "script is required")
+	}
+	var create UpdateScriptRequest
+	for _, set := range scriptFields {
+		set(&create, req.Script)
+	}
+	create.Password, create.Message = req.Password, req.Message
+	rec, err := callAPI(originalRequest(ctx), a.s.APICreateScript, http.MethodPost, "", CreateScriptRequest(create))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return saved(rec), nil
+}
+
+func (a *adminService) UpdateScript(ctx context.Context, req *adminpb.UpdateScriptRequest) (*adminpb.Script, error) {
+	if req.Script == nil || req.Script.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "script.id is required")
+	}
+	existing, err := a.s.queries().GetScript(ctx, req.Script.Id)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	update := updateRequestFor(existing)
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		update = UpdateScriptRequest{}
+		for _, set := range scriptFields {
+			set(&update, req.Script)
+		}
+	}
+	for _, path := range paths {
+		set, ok := scriptFields[path]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "field %q cannot be updated", path)
+		}
+		set(&update, req.Script)
+	}
+	update.Password, update.Message = req.Password, req.Message
+	rec, err := callAPI(originalRequest(ctx), a.s.APIUpdateScript, http.MethodPut, existing.ID, update)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return saved(rec), nil
+}
+
+func (a *adminService) DeleteScript(ctx context.Context, req *adminpb.DeleteScriptRequest) (*emptypb.Empty, error) {
+	if _, err := callAPI(originalRequest(ctx), a.s.APIDeleteScript, http.MethodDelete, req.Id, nil); err != nil {
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (a *adminService) ListFolders(req *adminpb.ListFoldersRequest, stream grpc.ServerStreamingServer[adminpb.Folder]) error {
+	folders, err := a.s.queries().ListFolders(stream.Context())
+	if err != nil {
+		return grpcError(err)
+	}
+	for _, f := range folders {
+		if err := stream.Send(folderToProto(folderToResponse(f))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *adminService) CreateFolder(ctx context.Context, req *adminpb.CreateFolderRequest) (*adminpb.Folder, error) {
+	rec, err := callAPI(originalRequest(ctx), a.s.APICreateFolder, http.MethodPost, "", CreateFolderRequest{Path: req.Path})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	var resp FolderResponse
+	json.Unmarshal(rec.body.Bytes(), &resp)
+	return folderToProto(resp), nil
+}
+
+func (a *adminService) DeleteFolder(ctx context.Context, req *adminpb.DeleteFolderRequest) (*emptypb.Empty, error) {
+	if _, err := callAPI(originalRequest(ctx), a.s.APIDeleteFolder, http.MethodDelete, req.Id, nil); err != nil {
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (a *adminService) ListVersions(req *adminpb.ListVersionsRequest, stream grpc.ServerStreamingServer[adminpb.Version]) error {
+	q := a.s.queries()
+	if _, err := q.GetScript(stream.Context(), req.ScriptId); err != nil {
+		return grpcError(err)
+	}
+	versions, err := q.ListVersionHistory(stream.Context(), req.ScriptId)
+	if err != nil {
+		return grpcError(err)
+	}
+	for _, v := range versions {
+		err := stream.Send(&adminpb.Version{
+			ScriptId:  req.ScriptId,
+			Version:   v.Version,
+			Message:   derefStr(v.Message),
+			Serves:    v.Serves,
+			CreatedAt: timestamppb.New(v.CreatedAt),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *adminService) GetVersion(ctx context.Context, req *adminpb.GetVersionRequest) (*adminpb.Version, error) {
+	v, err := a.s.queries().GetVersion(ctx, dbgen.GetVersionParams{ScriptID: req.ScriptId, Version: req.Version})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &adminpb.Version{
+		ScriptId:  v.ScriptID,
+		Version:   v.Version,
+		Message:   derefStr(v.Message),
+		Serves:    v.Serves,
+		CreatedAt: timestamppb.New(v.CreatedAt),
+		Content:   v.Content,
+	}, nil
+}
+
+func (a *adminService) Watch(req *adminpb.WatchRequest, stream grpc.ServerStreamingServer[adminpb.Event]) error {
+	events, stop := a.s.watchers.subscribe()
+	defer stop()
+	// Headers go out now, so the client knows it is subscribed
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if len(req.Events) > 0 && !slices.Contains(req.Events, ev.Event) {
+				continue
+			}
+			if req.Prefix != "" && !scriptPrefixMatch(ev.Path, req.Prefix) {
+				continue
+			}
+			err := stream.Send(&adminpb.Event{
+				Event:    ev.Event,
+				ScriptId: ev.ScriptID,
+				Path:     ev.Path,
+				Actor:    ev.Actor,
+				Version:  ev.Version,
+				Details:  ev.Details,
+				Time:     timestamppb.New(ev.Time),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
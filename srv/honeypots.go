@@ -0,0 +1,195 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// honeypotAlertTimeout bounds delivery of a single honeypot alert
+const honeypotAlertTimeout = 10 * time.Second
+
+// HoneypotAlert is posted to HONEYPOT_ALERT_URL when a decoy path is fetched
+type HoneypotAlert struct {
+	Path      string    `json:"path"`
+	Method    string    `json:"method"`
+	Query     string    `json:"query,omitempty"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Referer   string    `json:"referer,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// honeypotHit answers requests for a honeypot path with a plain 404, records
+// the client in the audit log and sends an alert if configured. It reports
+// whether the request was for a honeypot.
+func (s *Server) honeypotHit(w http.ResponseWriter, r *http.Request) bool {
+	q := s.queries()
+	hp, err := q.GetHoneypotByPath(r.Context(), r.URL.Path)
+	if err != nil {
+		return false
+	}
+
+	alert := HoneypotAlert{
+		Path:      hp.Path,
+		Method:    r.Method,
+		Query:     r.URL.RawQuery,
+		IPAddress: clientIP(r),
+		UserAgent: r.Header.Get("User-Agent"),
+		Referer:   r.Header.Get("Referer"),
+		Time:      time.Now(),
+	}
+	details := r.Method + " " + r.URL.RequestURI()
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "HONEYPOT_HIT",
+		EntityType: "honeypot",
+		EntityID:   &hp.ID,
+		EntityPath: &hp.Path,
+		Details:    &details,
+		IpAddress:  s.storedIP(r),
+		UserAgent:  strPtr(alert.UserAgent),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  alert.Time,
+	})
+	s.emit(r.Context(), WebhookEvent{
+		Event:     EventHoneypotHit,
+		Path:      hp.Path,
+		IPAddress: derefStr(s.storedIP(r)),
+		Details:   details,
+		Time:      alert.Time,
+	})
+	if url := s.honeypotAlertURL(); url != "" {
+		go s.sendHoneypotAlert(context.WithoutCancel(r.Context()), url, alert)
+	}
+
+	http.Error(w, "Script not found", http.StatusNotFound)
+	return true
+}
+
+// sendHoneypotAlert posts the alert as JSON; failures are only logged
+func (s *Server) sendHoneypotAlert(ctx context.Context, url string, alert HoneypotAlert) {
+	body, _ := json.Marshal(alert)
+	client := &http.Client{Timeout: honeypotAlertTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.WarnContext(ctx, "honeypot alert failed", "path", alert.Path, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.WarnContext(ctx, "honeypot alert rejected", "path", alert.Path, "status", resp.StatusCode)
+	}
+}
+
+// HoneypotRequest represents a request to register a decoy path
+type HoneypotRequest struct {
+	Path string `json:"path"`
+	Note string `json:"note"`
+}
+
+// APIListHoneypots returns all honeypot paths
+func (s *Server) APIListHoneypots(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	honeypots, err := q.ListHoneypots(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list honeypots", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(honeypots)
+}
+
+// APICreateHoneypot registers a decoy path
+func (s *Server) APICreateHoneypot(w http.ResponseWriter, r *http.Request) {
+	var req HoneypotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if !strings.HasPrefix(req.Path, "/") || req.Path == "/" {
+		http.Error(w, "Path must start with /", http.StatusBadRequest)
+		return
+	}
+	if strings.HasPrefix(req.Path, "/api/") || strings.HasPrefix(req.Path, "/static/") || strings.HasPrefix(req.Path, "/_") {
+		http.Error(w, "Path is reserved", http.StatusBadRequest)
+		return
+	}
+
+	q := s.queries()
+	if _, err := q.GetScriptByPath(r.Context(), req.Path); err == nil {
+		http.Error(w, "A script already uses this path", http.StatusConflict)
+		return
+	}
+	if _, err := q.GetHoneypotByPath(r.Context(), req.Path); err == nil {
+		http.Error(w, "Honeypot already exists", http.StatusConflict)
+		return
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+	var note *string
+	if req.Note != "" {
+		note = &req.Note
+	}
+	if err := q.CreateHoneypot(r.Context(), dbgen.CreateHoneypotParams{
+		ID:        id,
+		Path:      req.Path,
+		Note:      note,
+		CreatedAt: now,
+	}); err != nil {
+		http.Error(w, "Failed to create honeypot: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "CREATE",
+		EntityType: "honeypot",
+		EntityID:   &id,
+		EntityPath: &req.Path,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	hp, _ := q.GetHoneypot(r.Context(), id)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hp)
+}
+
+// APIDeleteHoneypot removes a decoy path
+func (s *Server) APIDeleteHoneypot(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	hp, err := q.GetHoneypot(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Honeypot not found", http.StatusNotFound)
+		return
+	}
+	if err := q.DeleteHoneypot(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete honeypot", http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "DELETE",
+		EntityType: "honeypot",
+		EntityID:   &id,
+		EntityPath: &hp.Path,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
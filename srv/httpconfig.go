@@ -0,0 +1,66 @@
+package srv
+
+import (
+	"net/http"
+	"time"
+)
+
+// HTTPConfig bounds how long a client may take over a request and how
+// large its headers may be, so slow or idle clients can't hold on to
+// connections. Zero fields take the defaults below.
+type HTTPConfig struct {
+	ReadHeaderTimeout time.Duration // to send the request headers
+	ReadTimeout       time.Duration // to send the whole request, body included
+	WriteTimeout      time.Duration // from the end of the headers to the end of the response
+	IdleTimeout       time.Duration // a keep-alive connection waits for its next request
+	MaxHeaderBytes    int
+}
+
+var defaultHTTPConfig = HTTPConfig{
+	ReadHeaderTimeout: 10 * time.Second,
+	ReadTimeout:       time.Minute,
+	WriteTimeout:      2 * time.Minute,
+	IdleTimeout:       2 * time.Minute,
+	MaxHeaderBytes:    64 << 10,
+}
+
+func (c HTTPConfig) withDefaults() HTTPConfig {
+	d := defaultHTTPConfig
+	if c.ReadHeaderTimeout <= 0 {
+		c.ReadHeaderTimeout = d.ReadHeaderTimeout
+	}
+	if c.ReadTimeout <= 0 {
+		c.ReadTimeout = d.ReadTimeout
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = d.WriteTimeout
+	}
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = d.IdleTimeout
+	}
+	if c.MaxHeaderBytes <= 0 {
+		c.MaxHeaderBytes = d.MaxHeaderBytes
+	}
+	return c
+}
+
+// apply sets the timeouts and header limit of server
+func (c HTTPConfig) apply(server *http.Server) {
+	server.ReadHeaderTimeout = c.ReadHeaderTimeout
+	server.ReadTimeout = c.ReadTimeout
+	server.WriteTimeout = c.WriteTimeout
+	server.IdleTimeout = c.IdleTimeout
+	server.MaxHeaderBytes = c.MaxHeaderBytes
+}
+
+// longRunning lifts the read and write timeouts for endpoints that stream
+// for as long as they need to, like gRPC Watch, backups and large uploads.
+// Their headers still have to arrive in time.
+func longRunning(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		rc.SetReadDeadline(time.Time{})
+		rc.SetWriteDeadline(time.Time{})
+		next(w, r)
+	}
+}
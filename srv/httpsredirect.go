@@ -0,0 +1,67 @@
+package srv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// withHSTS tells browsers to reach the server only over HTTPS from now on,
+// on responses that came over HTTPS
+func (s *Server) withHSTS(next http.Handler) http.Handler {
+	if s.TLS.HSTSMaxAge <= 0 {
+		return next
+	}
+	value := "max-age=" + strconv.FormatInt(int64(s.TLS.HSTSMaxAge.Seconds()), 10)
+	if s.TLS.HSTSIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.isHTTPS(r) {
+			w.Header().Set("Strict-Transport-Security", value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// redirectToHTTPS sends every request to the same URL over HTTPS on
+// httpsPort. Command line clients that don't follow it get a script that
+// fails, not an HTML page for sh to run.
+func redirectToHTTPS(httpsPort string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		if !isCLI(r) {
+			http.Redirect(w, r, target, http.StatusPermanentRedirect)
+			return
+		}
+		w.Header().Set("Location", target)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusPermanentRedirect)
+		fmt.Fprintf(w, "echo %s >&2\nexit 1\n", shellQuote("Scripts are only served over HTTPS: "+target))
+	}
+}
+
+// serveRedirect answers plain HTTP on ln with redirects to HTTPS until
+// ctx is done
+func (s *Server) serveRedirect(ctx context.Context, ln net.Listener, httpsPort string) {
+	server := &http.Server{Handler: s.withLogging(redirectToHTTPS(httpsPort))}
+	s.HTTP.apply(server)
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.WithoutCancel(ctx))
+	}()
+	if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("HTTPS redirect listener failed", "error", err)
+	}
+}
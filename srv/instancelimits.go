@@ -0,0 +1,183 @@
+package srv
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// InstanceLimit caps something the instance holds. Saves past Soft succeed
+// with a warning; saves past Hard are refused. 0 is no cap.
+type InstanceLimit struct {
+	Soft, Hard int64
+}
+
+// InstanceLimitsConfig caps what a server shared by a team may hold, so
+// one user can't fill it up for everyone
+type InstanceLimitsConfig struct {
+	Scripts           InstanceLimit
+	VersionsPerScript InstanceLimit
+	ContentBytes      InstanceLimit // current content of all scripts
+}
+
+func (c InstanceLimitsConfig) validate() error {
+	for name, l := range map[string]InstanceLimit{
+		"scripts":             c.Scripts,
+		"versions per script": c.VersionsPerScript,
+		"content bytes":       c.ContentBytes,
+	} {
+		if l.Soft < 0 || l.Hard < 0 {
+			return fmt.Errorf("the limit on %s must not be negative", name)
+		}
+		if l.Soft > 0 && l.Hard > 0 && l.Soft > l.Hard {
+			return fmt.Errorf("the soft limit on %s is above the hard limit", name)
+		}
+	}
+	return nil
+}
+
+func (c InstanceLimitsConfig) enabled() bool {
+	return c != InstanceLimitsConfig{}
+}
+
+// UsageItem is how much of a limited resource is used
+type UsageItem struct {
+	Used      int64 `json:"used"`
+	SoftLimit int64 `json:"soft_limit,omitempty"`
+	HardLimit int64 `json:"hard_limit,omitempty"`
+}
+
+// InstanceUsage is what the instance holds against its limits
+type InstanceUsage struct {
+	Scripts           UsageItem `json:"scripts"`
+	ContentBytes      UsageItem `json:"content_bytes"`
+	VersionsPerScript UsageItem `json:"versions_per_script"` // of the script with the most
+	MostVersioned     string    `json:"most_versioned,omitempty"`
+}
+
+// instanceUsage counts what the instance holds
+func (s *Server) instanceUsage(ctx context.Context, q *queries) (InstanceUsage, error) {
+	lim := s.InstanceLimits
+	usage := InstanceUsage{
+		Scripts:           UsageItem{SoftLimit: lim.Scripts.Soft, HardLimit: lim.Scripts.Hard},
+		ContentBytes:      UsageItem{SoftLimit: lim.ContentBytes.Soft, HardLimit: lim.ContentBytes.Hard},
+		VersionsPerScript: UsageItem{SoftLimit: lim.VersionsPerScript.Soft, HardLimit: lim.VersionsPerScript.Hard},
+	}
+	totals, err := q.GetContentUsage(ctx)
+	if err != nil {
+		return usage, err
+	}
+	usage.Scripts.Used, usage.ContentBytes.Used = totals.Scripts, totals.ContentBytes
+	most, err := q.GetMostVersionedScript(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return usage, err
+	}
+	usage.VersionsPerScript.Used, usage.MostVersioned = most.Versions, most.Path
+	return usage, nil
+}
+
+// instanceLimitError is returned by instanceLimits when a save would take
+// the instance past a hard limit
+type instanceLimitError struct {
+	refused []string
+}
+
+func (e *instanceLimitError) Error() string {
+	return "Instance limit reached: " + strings.Join(e.refused, "; ")
+}
+
+// instanceLimits refuses a save that would take the instance past a hard
+// limit, and warns about one that takes it past a soft limit. existing is
+// the script being updated, or nil for a new one. createScript and
+// updateSyncedScript call it, so no write path gets around the limits.
+func (s *Server) instanceLimits(ctx context.Context, q *queries, existing *dbgen.Script, content string) ([]ScanWarning, error) {
+	// A replica holds whatever its upstream does
+	if !s.InstanceLimits.enabled() || ctx.Value(replicaSyncKey{}) != nil {
+		return nil, nil
+	}
+	totals, err := q.GetContentUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("check instance limits: %w", err)
+	}
+
+	scripts, bytes, versions := totals.Scripts, totals.ContentBytes+int64(len(content)), int64(0)
+	if existing == nil {
+		scripts++
+		versions = 1
+	} else {
+		bytes -= int64(len(existing.Content))
+		if existing.Content != content {
+			current, err := q.GetCurrentVersion(ctx, existing.ID)
+			if err != nil {
+				return nil, fmt.Errorf("check instance limits: %w", err)
+			}
+			versions = current + 1
+		}
+	}
+
+	var refused []string
+	var warnings []ScanWarning
+	check := func(l InstanceLimit, after int64, grows bool, what, setting string) {
+		switch {
+		case l.Hard > 0 && after > l.Hard && grows:
+			refused = append(refused, fmt.Sprintf("%d %s would be over the limit of %d (%s)", after, what, l.Hard, setting))
+		case l.Soft > 0 && after > l.Soft:
+			warnings = append(warnings, ScanWarning{
+				Rule:    "instance-limit",
+				Message: fmt.Sprintf("%d %s is over the soft limit of %d (%s_SOFT)", after, what, l.Soft, setting),
+			})
+		}
+	}
+	lim := s.InstanceLimits
+	check(lim.Scripts, scripts, existing == nil, "scripts", "MAX_SCRIPTS")
+	check(lim.ContentBytes, bytes, existing == nil || int64(len(content)) > int64(len(existing.Content)), "bytes of content", "MAX_CONTENT_BYTES")
+	if versions > 0 {
+		check(lim.VersionsPerScript, versions, true, "versions of this script", "MAX_VERSIONS_PER_SCRIPT")
+	}
+	if len(refused) > 0 {
+		return nil, &instanceLimitError{refused: refused}
+	}
+	return warnings, nil
+}
+
+// writeInstanceLimitError answers a request whose save instanceLimits
+// refused, and reports whether err was such a refusal
+func writeInstanceLimitError(w http.ResponseWriter, err error) bool {
+	var le *instanceLimitError
+	if !errors.As(err, &le) {
+		return false
+	}
+	http.Error(w, le.Error(), http.StatusInsufficientStorage)
+	return true
+}
+
+// checkInstanceLimits is instanceLimits for handlers that check before
+// doing any work. It reports whether the save may continue.
+func (s *Server) checkInstanceLimits(w http.ResponseWriter, r *http.Request, q *queries, existing *dbgen.Script, content string) ([]ScanWarning, bool) {
+	warnings, err := s.instanceLimits(r.Context(), q, existing, content)
+	if err != nil {
+		if !writeInstanceLimitError(w, err) {
+			http.Error(w, "Failed to check instance limits", http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return warnings, true
+}
+
+// APIUsage returns what the instance holds against its limits
+func (s *Server) APIUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := s.instanceUsage(r.Context(), s.queries())
+	if err != nil {
+		http.Error(w, "Failed to count usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
@@ -0,0 +1,109 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// defaultDisabledReason is shown when a script is disabled without a reason
+const defaultDisabledReason = "This script has been disabled by the administrator."
+
+// disabledReason returns the reason shown to clients of a disabled script
+func disabledReason(script dbgen.Script) string {
+	if script.DisabledReason != nil && *script.DisabledReason != "" {
+		return *script.DisabledReason
+	}
+	return defaultDisabledReason
+}
+
+// serveDisabled answers a request for a disabled script. Browsers get 410 Gone;
+// CLI clients get a script that prints the reason and exits 1, so that
+// "curl | sh" fails loudly instead of silently running nothing.
+func (s *Server) serveDisabled(w http.ResponseWriter, r *http.Request, script dbgen.Script) {
+	reason := disabledReason(script)
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !isCLI(r) {
+		http.Error(w, fmt.Sprintf("%s is disabled: %s", script.Path, reason), http.StatusGone)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, `#!/bin/sh
+# %s has been disabled on SH Server
+echo %s >&2
+echo %s >&2
+exit 1
+`, script.Path, shellQuote("ERROR: "+script.Path+" is disabled"), shellQuote("  Reason: "+reason))
+}
+
+// DisableScriptRequest represents a request to disable a script
+type DisableScriptRequest struct {
+	Reason string `json:"reason"`
+}
+
+// APIDisableScript turns on the kill switch for a script
+func (s *Server) APIDisableScript(w http.ResponseWriter, r *http.Request) {
+	var req DisableScriptRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			invalidBody(w, err)
+			return
+		}
+	}
+	now := time.Now()
+	s.setScriptDisabled(w, r, dbgen.SetScriptDisabledParams{
+		Disabled:       1,
+		DisabledReason: &req.Reason,
+		DisabledAt:     &now,
+	}, "DISABLE")
+}
+
+// APIEnableScript turns off the kill switch for a script
+func (s *Server) APIEnableScript(w http.ResponseWriter, r *http.Request) {
+	s.setScriptDisabled(w, r, dbgen.SetScriptDisabledParams{
+		DisabledReason: strPtr(""),
+	}, "ENABLE")
+}
+
+func (s *Server) setScriptDisabled(w http.ResponseWriter, r *http.Request, params dbgen.SetScriptDisabledParams, action string) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	script, err := q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+
+	params.ID = id
+	if err := q.SetScriptDisabled(r.Context(), params); err != nil {
+		http.Error(w, "Failed to update script: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     action,
+		EntityType: "script",
+		EntityID:   &id,
+		EntityPath: &script.Path,
+		Details:    params.DisabledReason,
+		IpAddress:  s.storedIP(r),
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	script, err = q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to get script", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scriptToResponse(script))
+}
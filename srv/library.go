@@ -0,0 +1,111 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// libraryPrefix is the folder holding shared function libraries.
+// Scripts under it are meant to be sourced by other scripts, not run directly.
+const libraryPrefix = "/lib/"
+
+// isLibraryPath reports whether path points into the shared library folder
+func isLibraryPath(path string) bool {
+	return strings.HasPrefix(path, libraryPrefix)
+}
+
+// ScriptRef is a lightweight reference to a script used in API responses
+type ScriptRef struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+// findDependents returns the scripts whose content references the given library path
+func findDependents(ctx context.Context, q *queries, lib dbgen.Script) ([]ScriptRef, error) {
+	scripts, err := q.ListScriptsReferencing(ctx, dbgen.ListScriptsReferencingParams{
+		Column1: lib.Path,
+		ID:      lib.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []ScriptRef{}
+	for _, sc := range scripts {
+		if referencesPath(sc.Content, lib.Path) {
+			refs = append(refs, ScriptRef{ID: sc.ID, Path: sc.Path, Name: sc.Name})
+		}
+	}
+	return refs, nil
+}
+
+// isPathChar reports whether c may appear in a script path
+func isPathChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_/.-", c) >= 0
+}
+
+// referencesPath reports whether content mentions path as a whole: not as
+// part of a longer name, as /lib/a.sh is in /lib/a.sh.bak, or of a path in
+// another folder, as in /x/lib/a.sh. A host may come before it, as in
+// https://sh.example/lib/a.sh.
+func referencesPath(content, path string) bool {
+	for i := 0; ; {
+		j := strings.Index(content[i:], path)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(path)
+		i = start + 1
+		if end < len(content) && isPathChar(content[end]) {
+			continue
+		}
+		if start == 0 || !isPathChar(content[start-1]) {
+			return true
+		}
+		// A word right before the path is a host if "//" comes before it,
+		// as in https://host/lib/a.sh, and a folder otherwise
+		before := content[:start]
+		k := strings.LastIndexFunc(before, func(r rune) bool { return r > 127 || !isPathChar(byte(r)) || r == '/' })
+		if k < 0 || k == len(before)-1 {
+			continue
+		}
+		if before[k] != '/' || k > 0 && before[k-1] == '/' {
+			return true
+		}
+	}
+}
+
+// dependentsConflict writes a 409 response listing the scripts that still use a library
+func dependentsConflict(w http.ResponseWriter, deps []ScriptRef) {
+	paths := make([]string, len(deps))
+	for i, d := range deps {
+		paths[i] = d.Path
+	}
+	http.Error(w, "Library is referenced by: "+strings.Join(paths, ", ")+" (use ?force=1 to override)", http.StatusConflict)
+}
+
+// APIListDependents returns the scripts that reference a library script (reverse dependencies)
+func (s *Server) APIListDependents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	script, err := q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+
+	deps, err := findDependents(r.Context(), q, script)
+	if err != nil {
+		http.Error(w, "Failed to find dependents", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deps)
+}
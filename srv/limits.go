@@ -0,0 +1,74 @@
+package srv
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// defaultMaxScriptSize is the maximum script size unless configured
+const defaultMaxScriptSize = 1 << 20
+
+// LimitsConfig caps what clients may send, so one can't exhaust memory or
+// disk with an oversized upload
+type LimitsConfig struct {
+	MaxScriptSize  int64 // bytes of content in a script, variant or template; 0 is 1 MiB
+	MaxRequestBody int64 // bytes of an API request body; 0 is four times MaxScriptSize
+}
+
+func (c LimitsConfig) withDefaults() LimitsConfig {
+	if c.MaxScriptSize <= 0 {
+		c.MaxScriptSize = defaultMaxScriptSize
+	}
+	if c.MaxRequestBody <= 0 {
+		// Room for the content escaped in JSON, and the other fields
+		c.MaxRequestBody = 4 * c.MaxScriptSize
+	}
+	return c
+}
+
+// bodyLimited lists the API routes that take bodies larger than the limit,
+// capped by limits of their own
+var bodyLimited = map[string]bool{
+	"POST /import":  true, // maxImportSize
+	"POST /restore": true, // maxRestoreSize
+}
+
+// limitBody answers 413 to a request whose body is over the request body
+// limit, whether it says so up front or not
+func (s *Server) limitBody(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > s.Limits.MaxRequestBody {
+			bodyTooLarge(w, s.Limits.MaxRequestBody)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, s.Limits.MaxRequestBody)
+		next(w, r)
+	}
+}
+
+func bodyTooLarge(w http.ResponseWriter, limit int64) {
+	http.Error(w, fmt.Sprintf("Request body too large: the limit is %d bytes", limit), http.StatusRequestEntityTooLarge)
+}
+
+// invalidBody answers a request body that failed to decode: 413 if it ran
+// past the limit, 400 otherwise
+func invalidBody(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		bodyTooLarge(w, tooLarge.Limit)
+		return
+	}
+	http.Error(w, "Invalid request body", http.StatusBadRequest)
+}
+
+// checkScriptSize refuses content over the maximum script size. It
+// reports whether the save may continue.
+func (s *Server) checkScriptSize(w http.ResponseWriter, content string) bool {
+	if int64(len(content)) <= s.Limits.MaxScriptSize {
+		return true
+	}
+	problem := fmt.Sprintf("content is %d bytes; the maximum script size is %d", len(content), s.Limits.MaxScriptSize)
+	rejectContent(w, http.StatusRequestEntityTooLarge, "Script too large", []string{problem})
+	return false
+}
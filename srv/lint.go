@@ -0,0 +1,149 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// lintTimeout bounds a single shellcheck run
+const lintTimeout = 10 * time.Second
+
+// errLintUnavailable is returned when shellcheck is not installed
+var errLintUnavailable = errors.New("shellcheck is not installed")
+
+// LintFinding is a single shellcheck comment
+type LintFinding struct {
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"end_line"`
+	EndColumn int    `json:"end_column"`
+	Level     string `json:"level"` // error, warning, info or style
+	Code      int    `json:"code"`  // SC number
+	Message   string `json:"message"`
+}
+
+// lintShells are the dialects shellcheck accepts for --shell
+var lintShells = []string{"sh", "bash", "dash", "ksh", "busybox"}
+
+// shellcheck runs shellcheck on content. shell overrides the dialect
+// otherwise taken from the shebang.
+func (s *Server) shellcheck(ctx context.Context, content, shell string) ([]LintFinding, error) {
+	bin := s.ShellCheckPath
+	if bin == "" {
+		bin = "shellcheck"
+	}
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return nil, errLintUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lintTimeout)
+	defer cancel()
+	args := []string{"--format=json1"}
+	if shell != "" {
+		args = append(args, "--shell="+shell)
+	}
+	cmd := exec.CommandContext(ctx, path, append(args, "-")...)
+	cmd.Stdin = strings.NewReader(content)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	// shellcheck exits 1 when it has findings; only unparseable output is a failure
+	runErr := cmd.Run()
+	var out struct {
+		Comments []struct {
+			Line      int    `json:"line"`
+			EndLine   int    `json:"endLine"`
+			Column    int    `json:"column"`
+			EndColumn int    `json:"endColumn"`
+			Level     string `json:"level"`
+			Code      int    `json:"code"`
+			Message   string `json:"message"`
+		} `json:"comments"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("shellcheck: %v: %s", runErr, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("shellcheck: %w", err)
+	}
+
+	findings := make([]LintFinding, len(out.Comments))
+	for i, c := range out.Comments {
+		findings[i] = LintFinding{
+			Line:      c.Line,
+			Column:    c.Column,
+			EndLine:   c.EndLine,
+			EndColumn: c.EndColumn,
+			Level:     c.Level,
+			Code:      c.Code,
+			Message:   c.Message,
+		}
+	}
+	return findings, nil
+}
+
+// checkLint refuses a save whose content has error-level shellcheck findings
+// when LINT_BLOCK_ERRORS is set. It reports whether the save may continue.
+func (s *Server) checkLint(w http.ResponseWriter, r *http.Request, content string) bool {
+	if !s.LintBlockErrors {
+		return true
+	}
+	findings, err := s.shellcheck(r.Context(), content, "")
+	if err != nil {
+		http.Error(w, "Failed to lint script: "+err.Error(), http.StatusInternalServerError)
+		return false
+	}
+
+	var errs []string
+	for _, f := range findings {
+		if f.Level == "error" {
+			errs = append(errs, fmt.Sprintf("line %d:%d: SC%d: %s", f.Line, f.Column, f.Code, f.Message))
+		}
+	}
+	if len(errs) > 0 {
+		rejectContent(w, http.StatusUnprocessableEntity, "shellcheck found errors", errs)
+		return false
+	}
+	return true
+}
+
+// LintRequest represents a request to lint script content
+type LintRequest struct {
+	Content string `json:"content"`
+	Shell   string `json:"shell,omitempty"` // sh, bash, dash, ksh or busybox
+}
+
+// APILint runs shellcheck on submitted content
+func (s *Server) APILint(w http.ResponseWriter, r *http.Request) {
+	var req LintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if req.Shell != "" && !slices.Contains(lintShells, req.Shell) {
+		http.Error(w, "shell must be one of "+strings.Join(lintShells, ", "), http.StatusBadRequest)
+		return
+	}
+
+	findings, err := s.shellcheck(r.Context(), req.Content, req.Shell)
+	if errors.Is(err, errLintUnavailable) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"findings": findings})
+}
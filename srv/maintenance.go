@@ -0,0 +1,164 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hunydev/sh-server/db"
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// MaintenanceConfig runs database maintenance on a schedule
+type MaintenanceConfig struct {
+	Interval time.Duration // 0 leaves it to POST /api/maintenance/vacuum
+}
+
+// MaintenanceReport is the outcome of a maintenance run: an integrity
+// check, then VACUUM and ANALYZE if the database is intact
+type MaintenanceReport struct {
+	StartedAt       time.Time `json:"started_at"`
+	DurationMs      int64     `json:"duration_ms"`
+	Integrity       []string  `json:"integrity"` // problems found; empty if intact
+	Vacuumed        bool      `json:"vacuumed"`
+	Analyzed        bool      `json:"analyzed"`
+	SizeBeforeBytes int64     `json:"size_before_bytes"`
+	SizeAfterBytes  int64     `json:"size_after_bytes"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// DatabaseStats describes the size of the database and its last maintenance
+type DatabaseStats struct {
+	Dialect         string             `json:"dialect"`
+	SizeBytes       int64              `json:"size_bytes"`
+	FreeBytes       int64              `json:"free_bytes"` // reclaimable by VACUUM; SQLite only
+	LastMaintenance *MaintenanceReport `json:"last_maintenance,omitempty"`
+}
+
+type maintenanceState struct {
+	mu sync.Mutex // one run at a time
+
+	lastMu sync.Mutex
+	last   *MaintenanceReport
+}
+
+var errMaintenanceRunning = errors.New("maintenance is already running")
+
+// maintainDB checks the database and, if it is intact, vacuums and analyzes
+// it. The report is returned even when a step fails.
+func (s *Server) maintainDB(ctx context.Context, now time.Time) (*MaintenanceReport, error) {
+	if !s.maintenance.mu.TryLock() {
+		return nil, errMaintenanceRunning
+	}
+	defer s.maintenance.mu.Unlock()
+
+	rep := &MaintenanceReport{StartedAt: now, Integrity: []string{}}
+	err := s.runMaintenance(ctx, rep)
+	if err != nil {
+		rep.Error = err.Error()
+	}
+	rep.DurationMs = time.Since(now).Milliseconds()
+	s.maintenance.lastMu.Lock()
+	s.maintenance.last = rep
+	s.maintenance.lastMu.Unlock()
+
+	details := fmt.Sprintf("%d bytes before, %d after", rep.SizeBeforeBytes, rep.SizeAfterBytes)
+	if err != nil {
+		details = "failed: " + err.Error()
+	}
+	s.queries().CreateAuditLog(ctx, dbgen.CreateAuditLogParams{
+		Action:     "MAINTENANCE",
+		EntityType: "database",
+		Details:    &details,
+		Actor:      actor(ctx),
+		RequestID:  requestID(ctx),
+		CreatedAt:  time.Now(),
+	})
+	return rep, err
+}
+
+func (s *Server) runMaintenance(ctx context.Context, rep *MaintenanceReport) error {
+	s.flushWrites()
+	var err error
+	if rep.SizeBeforeBytes, err = db.Size(ctx, s.DB, s.dialect); err != nil {
+		return fmt.Errorf("size: %w", err)
+	}
+	problems, err := db.IntegrityCheck(ctx, s.DB, s.dialect)
+	if err != nil {
+		return fmt.Errorf("integrity check: %w", err)
+	}
+	if len(problems) > 0 {
+		rep.Integrity = problems
+		rep.SizeAfterBytes = rep.SizeBeforeBytes
+		return fmt.Errorf("integrity check found %d problems; not vacuuming a damaged database", len(problems))
+	}
+	if err := db.Vacuum(ctx, s.DB, s.dialect); err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	rep.Vacuumed = true
+	if err := db.Analyze(ctx, s.DB); err != nil {
+		return fmt.Errorf("analyze: %w", err)
+	}
+	rep.Analyzed = true
+	if rep.SizeAfterBytes, err = db.Size(ctx, s.DB, s.dialect); err != nil {
+		return fmt.Errorf("size: %w", err)
+	}
+	return nil
+}
+
+// runMaintenanceJob maintains the database every Maintenance.Interval
+func (s *Server) runMaintenanceJob() {
+	ticker := time.NewTicker(s.Maintenance.Interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx := context.WithValue(context.Background(), actorKey{}, "maintenance")
+		rep, err := s.maintainDB(ctx, time.Now())
+		if err != nil {
+			slog.Error("scheduled maintenance failed", "error", err)
+			continue
+		}
+		slog.Info("scheduled maintenance", "size_before", rep.SizeBeforeBytes, "size_after", rep.SizeAfterBytes, "duration_ms", rep.DurationMs)
+	}
+}
+
+// APIDatabaseStats returns the size of the database and the report of its
+// last maintenance
+func (s *Server) APIDatabaseStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	stats := DatabaseStats{Dialect: string(s.dialect)}
+	var err error
+	if stats.SizeBytes, err = db.Size(ctx, s.DB, s.dialect); err != nil {
+		http.Error(w, "Failed to get database size: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if stats.FreeBytes, err = db.FreeSize(ctx, s.DB, s.dialect); err != nil {
+		http.Error(w, "Failed to get database size: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.maintenance.lastMu.Lock()
+	stats.LastMaintenance = s.maintenance.last
+	s.maintenance.lastMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// APIVacuum runs database maintenance now and returns its report
+func (s *Server) APIVacuum(w http.ResponseWriter, r *http.Request) {
+	rep, err := s.maintainDB(r.Context(), time.Now())
+	if errors.Is(err, errMaintenanceRunning) {
+		http.Error(w, "Maintenance is already running", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(rep)
+}
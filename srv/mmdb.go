@@ -0,0 +1,273 @@
+package srv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+// mmdbReader looks up records in a MaxMind DB file (GeoLite2/GeoIP2 Country or
+// City). Only what a country lookup needs is implemented.
+type mmdbReader struct {
+	buf        []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	treeSize   uint
+	dataStart  uint
+	ipv4Start  uint
+}
+
+var mmdbMetadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// openMMDB reads a .mmdb file into memory
+func openMMDB(file string) (*mmdbReader, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	i := bytes.LastIndex(buf, mmdbMetadataMarker)
+	if i < 0 {
+		return nil, errors.New("not a MaxMind DB file")
+	}
+	meta := &mmdbReader{buf: buf[i+len(mmdbMetadataMarker):]}
+	v, _, err := meta.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("read metadata: %w", err)
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid metadata")
+	}
+
+	r := &mmdbReader{
+		buf:        buf,
+		nodeCount:  mmdbUint(m["node_count"]),
+		recordSize: mmdbUint(m["record_size"]),
+		ipVersion:  mmdbUint(m["ip_version"]),
+	}
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, fmt.Errorf("unsupported record size %d", r.recordSize)
+	}
+	r.treeSize = r.recordSize * 2 / 8 * r.nodeCount
+	r.dataStart = r.treeSize + 16
+	if r.dataStart > uint(len(buf)) {
+		return nil, errors.New("corrupt search tree")
+	}
+
+	// IPv4 addresses live under ::/96 in IPv6 databases
+	if r.ipVersion == 6 {
+		for i := 0; i < 96 && r.ipv4Start < r.nodeCount; i++ {
+			r.ipv4Start = r.readRecord(r.ipv4Start, 0)
+		}
+	}
+	return r, nil
+}
+
+func mmdbUint(v interface{}) uint {
+	switch n := v.(type) {
+	case uint64:
+		return uint(n)
+	case uint32:
+		return uint(n)
+	case uint16:
+		return uint(n)
+	}
+	return 0
+}
+
+// readRecord returns the left (bit 0) or right (bit 1) record of a node
+func (r *mmdbReader) readRecord(node uint, bit uint) uint {
+	b := r.buf[node*r.recordSize/4:]
+	switch r.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+// lookup returns the record for ip, or nil when the database has none
+func (r *mmdbReader) lookup(ip net.IP) (interface{}, error) {
+	node := uint(0)
+	bits := ip.To16()
+	if ip4 := ip.To4(); ip4 != nil {
+		bits = ip4
+		node = r.ipv4Start
+	} else if r.ipVersion == 4 {
+		return nil, nil
+	}
+
+	for i := 0; i < len(bits)*8 && node < r.nodeCount; i++ {
+		bit := uint(bits[i/8]>>(7-uint(i%8))) & 1
+		node = r.readRecord(node, bit)
+	}
+	if node <= r.nodeCount {
+		return nil, nil
+	}
+	offset := node - r.nodeCount - 16
+	data := &mmdbReader{buf: r.buf[r.dataStart:]}
+	if offset >= uint(len(data.buf)) {
+		return nil, errors.New("corrupt search tree")
+	}
+	v, _, err := data.decode(offset)
+	return v, err
+}
+
+// country returns the ISO country code for ip, or "" when unknown
+func (r *mmdbReader) country(ip net.IP) string {
+	country, _ := r.location(ip)
+	return country
+}
+
+// location returns the ISO country code for ip and, with a City database,
+// its first-level subdivision as an ISO 3166-2 code such as "US-CA"
+func (r *mmdbReader) location(ip net.IP) (country, region string) {
+	v, err := r.lookup(ip)
+	if err != nil {
+		return "", ""
+	}
+	rec, _ := v.(map[string]interface{})
+	for _, key := range []string{"country", "registered_country"} {
+		c, _ := rec[key].(map[string]interface{})
+		if code, ok := c["iso_code"].(string); ok {
+			country = code
+			break
+		}
+	}
+	if subs, _ := rec["subdivisions"].([]interface{}); country != "" && len(subs) > 0 {
+		sub, _ := subs[0].(map[string]interface{})
+		if code, ok := sub["iso_code"].(string); ok {
+			region = country + "-" + code
+		}
+	}
+	return country, region
+}
+
+// decode decodes the data field at offset in r.buf and returns it with the
+// offset of the next field
+func (r *mmdbReader) decode(offset uint) (interface{}, uint, error) {
+	if offset >= uint(len(r.buf)) {
+		return nil, 0, errors.New("unexpected end of data")
+	}
+	ctrl := r.buf[offset]
+	offset++
+	typ := uint(ctrl >> 5)
+
+	if typ == 1 { // pointer
+		ss, vvv := uint(ctrl>>3)&3, uint(ctrl&7)
+		n := ss + 1
+		if offset+n > uint(len(r.buf)) {
+			return nil, 0, errors.New("unexpected end of data")
+		}
+		var ptr uint
+		if ss < 3 {
+			ptr = vvv
+		}
+		for _, b := range r.buf[offset : offset+n] {
+			ptr = ptr<<8 | uint(b)
+		}
+		ptr += [4]uint{0, 2048, 526336, 0}[ss]
+		v, _, err := r.decode(ptr)
+		return v, offset + n, err
+	}
+
+	if typ == 0 { // extended
+		if offset >= uint(len(r.buf)) {
+			return nil, 0, errors.New("unexpected end of data")
+		}
+		typ = 7 + uint(r.buf[offset])
+		offset++
+	}
+
+	size := uint(ctrl & 0x1F)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > uint(len(r.buf)) {
+			return nil, 0, errors.New("unexpected end of data")
+		}
+		var ext uint
+		for _, b := range r.buf[offset : offset+n] {
+			ext = ext<<8 | uint(b)
+		}
+		offset += n
+		size = [4]uint{0, 29, 285, 65821}[n] + ext
+	}
+
+	switch typ {
+	case 7: // map
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			k, next, err := r.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			v, next, err := r.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, _ := k.(string)
+			m[key] = v
+			offset = next
+		}
+		return m, offset, nil
+	case 11: // array
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			v, next, err := r.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, v)
+			offset = next
+		}
+		return a, offset, nil
+	case 14: // boolean
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(r.buf)) {
+		return nil, 0, errors.New("unexpected end of data")
+	}
+	b := r.buf[offset : offset+size]
+	offset += size
+	switch typ {
+	case 2: // UTF-8 string
+		return string(b), offset, nil
+	case 3: // double
+		if size != 8 {
+			return nil, 0, errors.New("invalid double")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), offset, nil
+	case 15: // float
+		if size != 4 {
+			return nil, 0, errors.New("invalid float")
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), offset, nil
+	case 5, 6, 9: // uint16, uint32, uint64
+		var n uint64
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		return n, offset, nil
+	case 8: // int32
+		var n uint32
+		for _, c := range b {
+			n = n<<8 | uint32(c)
+		}
+		return int32(n), offset, nil
+	case 4, 10: // bytes, uint128
+		return b, offset, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported data type %d", typ)
+}
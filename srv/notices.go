@@ -0,0 +1,199 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// noticeMatches reports whether a notice set on noticePath covers scriptPath.
+// A notice on a .sh path covers only that script; any other path is a folder.
+func noticeMatches(noticePath, scriptPath string) bool {
+	if strings.HasSuffix(noticePath, ".sh") {
+		return noticePath == scriptPath
+	}
+	return scriptPrefixMatch(scriptPath, noticePath)
+}
+
+// activeNotice returns the most specific unexpired notice covering path, if any
+func activeNotice(ctx context.Context, q *queries, path string) (dbgen.Notice, bool) {
+	notices, err := q.ListNotices(ctx)
+	if err != nil {
+		return dbgen.Notice{}, false
+	}
+
+	now := time.Now()
+	var best dbgen.Notice
+	found := false
+	for _, n := range notices {
+		if !n.ExpiresAt.After(now) || !noticeMatches(n.Path, path) {
+			continue
+		}
+		if !found || len(n.Path) > len(best.Path) {
+			best = n
+			found = true
+		}
+	}
+	return best, found
+}
+
+// serveNotice answers a script request with the notice instead of the script.
+// Browsers get 503; CLI clients get a script that prints the notice and exits 1.
+func (s *Server) serveNotice(w http.ResponseWriter, r *http.Request, path string, notice dbgen.Notice) {
+	until := notice.ExpiresAt.UTC().Format("2006-01-02 15:04 MST")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(notice.ExpiresAt).Seconds())+1))
+
+	if !isCLI(r) {
+		http.Error(w, fmt.Sprintf("%s\n(until %s)", notice.Message, until), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	var b strings.Builder
+	fmt.Fprintf(&b, "#!/bin/sh\n# Notice in effect for %s until %s\n", path, until)
+	fmt.Fprintf(&b, "echo %s >&2\n", shellQuote("NOTICE: "+path+" is temporarily unavailable (until "+until+")"))
+	for _, line := range strings.Split(notice.Message, "\n") {
+		fmt.Fprintf(&b, "echo %s >&2\n", shellQuote("  "+line))
+	}
+	b.WriteString("exit 1\n")
+	w.Write([]byte(b.String()))
+}
+
+// NoticeRequest represents a request to overlay a notice on a script or folder.
+// Either ExpiresAt or Duration (e.g. "2h", "72h") must be given.
+type NoticeRequest struct {
+	Path      string     `json:"path"`
+	Message   string     `json:"message"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Duration  string     `json:"duration,omitempty"`
+}
+
+// APIListNotices returns all notices that have not expired yet
+func (s *Server) APIListNotices(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+
+	// Expired notices have already reverted; drop them
+	q.DeleteExpiredNotices(r.Context(), time.Now())
+
+	notices, err := q.ListNotices(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list notices", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notices)
+}
+
+// APICreateNotice overlays a notice on a script or folder until it expires
+func (s *Server) APICreateNotice(w http.ResponseWriter, r *http.Request) {
+	var req NoticeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if !strings.HasPrefix(req.Path, "/") {
+		http.Error(w, "Path must start with /", http.StatusBadRequest)
+		return
+	}
+	if strings.HasSuffix(req.Path, ".sh") {
+		if err := validatePath(req.Path); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	var expiresAt time.Time
+	switch {
+	case req.ExpiresAt != nil:
+		expiresAt = *req.ExpiresAt
+	case req.Duration != "":
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			http.Error(w, "Invalid duration: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		expiresAt = now.Add(d)
+	default:
+		http.Error(w, "expires_at or duration is required", http.StatusBadRequest)
+		return
+	}
+	if !expiresAt.After(now) {
+		http.Error(w, "Expiry must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	id := uuid.New().String()
+	q := s.queries()
+	err := q.CreateNotice(r.Context(), dbgen.CreateNoticeParams{
+		ID:        id,
+		Path:      req.Path,
+		Message:   req.Message,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	})
+	if err != nil {
+		http.Error(w, "Failed to create notice: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "CREATE",
+		EntityType: "notice",
+		EntityID:   &id,
+		EntityPath: &req.Path,
+		Details:    &req.Message,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	notice, _ := q.GetNotice(r.Context(), id)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(notice)
+}
+
+// APIDeleteNotice lifts a notice before it expires
+func (s *Server) APIDeleteNotice(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	notice, err := q.GetNotice(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Notice not found", http.StatusNotFound)
+		return
+	}
+
+	if err := q.DeleteNotice(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete notice", http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "DELETE",
+		EntityType: "notice",
+		EntityID:   &id,
+		EntityPath: &notice.Path,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,299 @@
+package srv
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// offlineBundleDir is the top-level directory inside the offline archive
+const offlineBundleDir = "sh-offline"
+
+// offlineManifestEntry describes one script in the offline bundle manifest
+type offlineManifestEntry struct {
+	Path        string    `json:"path"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Tags        string    `json:"tags,omitempty"`
+	SHA256      string    `json:"sha256"`
+	Size        int       `json:"size"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// offlineManifest is written as manifest.json at the root of the bundle
+type offlineManifest struct {
+	Source      string                 `json:"source"`
+	Prefix      string                 `json:"prefix"`
+	GeneratedAt time.Time              `json:"generated_at"`
+	Scripts     []offlineManifestEntry `json:"scripts"`
+}
+
+// scriptPrefixMatch reports whether path lies under the folder prefix ("" or "/" match everything)
+func scriptPrefixMatch(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+	return strings.HasPrefix(path, prefix+"/")
+}
+
+// servable reports whether a script is served at all right now: not
+// archived, disabled or expired, and within its availability window. Every
+// way of handing out content builds on it.
+func servable(sc dbgen.Script, now time.Time) bool {
+	ok, _ := scriptAvailable(sc, now)
+	return ok && sc.Archived == 0 && sc.Disabled == 0 && !scriptExpired(sc, now)
+}
+
+// exportable reports whether a script may be handed out in bulk, without
+// unlocking: listed, unlocked, enabled, without country lists of its own
+// and available right now
+func exportable(sc dbgen.Script, lockedFolders []dbgen.Folder, now time.Time) bool {
+	return servable(sc, now) && sc.Locked == 0 && folderLock(lockedFolders, sc.Path) == nil &&
+		sc.Unlisted == 0 && sc.Private == 0 && !hasCountryRules(sc)
+}
+
+// HandleOfflineBundle streams a tar.gz with the selected scripts, a manifest with
+// hashes and a local run.sh browser, for use on networks without internet access.
+// Locked, disabled, unlisted, private, expired, country-restricted and
+// currently unavailable scripts are never included. A virtual host bundles
+// its own folder, with the paths the scripts have there.
+func (s *Server) HandleOfflineBundle(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+
+	q := s.queries()
+	if s.geoBlockedBulk(r, q, "/_offline.tar.gz") {
+		http.Error(w, "The offline bundle is not available in your region", http.StatusForbidden)
+		return
+	}
+	scripts, err := q.ListScripts(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list scripts", http.StatusInternalServerError)
+		return
+	}
+
+	lockedFolders, _ := q.ListLockedFolders(r.Context())
+	now := time.Now()
+	vh := virtualHost(r.Context())
+	var selected []dbgen.Script
+	for _, sc := range vh.scoped(scripts) {
+		if !exportable(sc, lockedFolders, now) {
+			continue
+		}
+		if sc.Path = vh.strip(sc.Path); !scriptPrefixMatch(sc.Path, prefix) {
+			continue
+		}
+		selected = append(selected, sc)
+	}
+	if len(selected) == 0 {
+		http.Error(w, "No scripts match prefix", http.StatusNotFound)
+		return
+	}
+
+	manifest := offlineManifest{
+		Source:      "https://" + s.hostname(r),
+		Prefix:      prefix,
+		GeneratedAt: now.UTC(),
+	}
+	var sums strings.Builder
+	for _, sc := range selected {
+		sum := sha256.Sum256([]byte(sc.Content))
+		hash := hex.EncodeToString(sum[:])
+		entry := offlineManifestEntry{
+			Path:      sc.Path,
+			Name:      sc.Name,
+			SHA256:    hash,
+			Size:      len(sc.Content),
+			UpdatedAt: sc.UpdatedAt,
+		}
+		if sc.Description != nil {
+			entry.Description = *sc.Description
+		}
+		if sc.Tags != nil {
+			entry.Tags = *sc.Tags
+		}
+		manifest.Scripts = append(manifest.Scripts, entry)
+		fmt.Fprintf(&sums, "%s  scripts%s\n", hash, sc.Path)
+	}
+	manifestJSON, _ := json.MarshalIndent(manifest, "", "  ")
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="sh-offline.tar.gz"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	writeFile := func(name string, mode int64, modTime time.Time, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    offlineBundleDir + "/" + name,
+			Mode:    mode,
+			Size:    int64(len(data)),
+			ModTime: modTime,
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	files := []struct {
+		name string
+		mode int64
+		data []byte
+	}{
+		{"run.sh", 0o755, []byte(offlineRunScript)},
+		{"manifest.json", 0o644, append(manifestJSON, '\n')},
+		{"SHA256SUMS", 0o644, []byte(sums.String())},
+	}
+	for _, f := range files {
+		if err := writeFile(f.name, f.mode, now, f.data); err != nil {
+			slog.ErrorContext(r.Context(), "offline bundle: write failed", "file", f.name, "error", err)
+			return
+		}
+	}
+	for _, sc := range selected {
+		if err := writeFile("scripts"+sc.Path, 0o755, sc.UpdatedAt, []byte(sc.Content)); err != nil {
+			slog.ErrorContext(r.Context(), "offline bundle: write failed", "file", sc.Path, "error", err)
+			return
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		slog.ErrorContext(r.Context(), "offline bundle: close tar", "error", err)
+		return
+	}
+	gz.Close()
+}
+
+// offlineRunScript is the local browser shipped inside the offline bundle
+const offlineRunScript = `#!/bin/sh
+# Offline script browser for an SH Server bundle
+#
+# Usage:
+#   ./run.sh               # interactive menu
+#   ./run.sh <path>        # run a script, e.g. ./run.sh tools/sysinfo.sh
+#   ./run.sh --list        # list scripts
+#   ./run.sh --verify      # verify all checksums
+set -e
+
+BUNDLE_DIR=$(cd "$(dirname "$0")" && pwd)
+SCRIPTS_DIR="$BUNDLE_DIR/scripts"
+SUMS="$BUNDLE_DIR/SHA256SUMS"
+
+sha256() {
+    if command -v sha256sum >/dev/null 2>&1; then
+        sha256sum "$1" | awk '{print $1}'
+    elif command -v shasum >/dev/null 2>&1; then
+        shasum -a 256 "$1" | awk '{print $1}'
+    fi
+}
+
+# List runnable scripts (libraries under lib/ are sourced, not run)
+list_scripts() {
+    (cd "$SCRIPTS_DIR" && find . -type f -name '*.sh' | sed 's|^\./||' | grep -v '^lib/' | sort)
+}
+
+# Verify a script against SHA256SUMS; returns non-zero on mismatch
+verify() {
+    _rel="$1"
+    _expected=$(grep "  scripts/${_rel}\$" "$SUMS" | awk '{print $1}')
+    _actual=$(sha256 "$SCRIPTS_DIR/$_rel")
+    if [ -z "$_actual" ]; then
+        echo "Warning: sha256sum/shasum not found; skipping verification" >&2
+        return 0
+    fi
+    if [ "$_expected" != "$_actual" ]; then
+        echo "Checksum mismatch: $_rel" >&2
+        return 1
+    fi
+}
+
+run_script() {
+    _rel="${1#/}"
+    case "$_rel" in
+        *.sh) ;;
+        *) _rel="${_rel}.sh" ;;
+    esac
+    if [ ! -f "$SCRIPTS_DIR/$_rel" ]; then
+        echo "Script not found: $_rel" >&2
+        exit 1
+    fi
+    verify "$_rel" || exit 1
+    echo "Running: $_rel"
+    echo ""
+    sh "$SCRIPTS_DIR/$_rel"
+}
+
+case "${1:-}" in
+    --list)
+        list_scripts
+        exit 0
+        ;;
+    --verify)
+        _failed=0
+        for _s in $(cd "$SCRIPTS_DIR" && find . -type f -name '*.sh' | sed 's|^\./||'); do
+            if verify "$_s"; then
+                echo "OK  $_s"
+            else
+                _failed=1
+            fi
+        done
+        exit $_failed
+        ;;
+    "")
+        ;;
+    *)
+        run_script "$1"
+        exit $?
+        ;;
+esac
+
+ITEMS=$(list_scripts)
+if [ -z "$ITEMS" ]; then
+    echo "No scripts in bundle"
+    exit 0
+fi
+
+echo ""
+echo "SH Server - Offline Bundle"
+echo "=========================="
+i=1
+echo "$ITEMS" | while read -r item; do
+    printf "  %2d) %s\n" "$i" "$item"
+    i=$((i+1))
+done
+COUNT=$(echo "$ITEMS" | grep -c .)
+echo ""
+echo "   0) Exit"
+echo ""
+printf "Select [0-%d]: " "$COUNT"
+if [ -t 0 ] || ! { true </dev/tty; } 2>/dev/null; then
+    read -r CHOICE
+else
+    read -r CHOICE </dev/tty
+fi
+
+case "$CHOICE" in
+    ''|0) exit 0 ;;
+    *[!0-9]*) echo "Invalid selection"; exit 1 ;;
+esac
+if [ "$CHOICE" -gt "$COUNT" ]; then
+    echo "Invalid selection"
+    exit 1
+fi
+
+run_script "$(echo "$ITEMS" | sed -n "${CHOICE}p")"
+`
@@ -0,0 +1,490 @@
+package srv
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+const (
+	oidcStateCookie = "shs_oidc"
+	oidcStateTTL    = 10 * time.Minute
+
+	// oidcKeyRefresh is the minimum time between JWKS fetches for unknown key ids
+	oidcKeyRefresh = time.Minute
+
+	// oidcClockSkew is tolerated when checking exp and iat
+	oidcClockSkew = time.Minute
+)
+
+// OIDCConfig configures login through an OpenID Connect identity provider.
+// Users are mapped to a role by the groups in their ID token: members of
+// AdminGroups get full access, members of ViewerGroups read-only access. With
+// no AdminGroups every user the provider authenticates is an admin.
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // defaults to https://<hostname>/oidc/callback
+	GroupsClaim  string // defaults to "groups"
+	AdminGroups  []string
+	ViewerGroups []string
+}
+
+// oidcProvider holds the provider metadata and signing keys, fetched lazily
+type oidcProvider struct {
+	cfg    OIDCConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	discovery   *oidcDiscovery
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func newOIDCProvider(cfg OIDCConfig, hostname string) *oidcProvider {
+	if cfg.RedirectURL == "" {
+		cfg.RedirectURL = "https://" + hostname + "/oidc/callback"
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	return &oidcProvider{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (p *oidcProvider) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// metadata returns the provider's discovery document
+func (p *oidcProvider) metadata(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d oidcDiscovery
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if d.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match configured %q", d.Issuer, p.cfg.Issuer)
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// key returns the signing key with the given id, refetching the JWKS when the
+// id is unknown so key rotation is picked up
+func (p *oidcProvider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	d, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+	if time.Since(p.keysFetched) < oidcKeyRefresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+	p.keys = map[string]crypto.PublicKey{}
+	p.keysFetched = time.Now()
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub, err := k.publicKey(); err == nil {
+			p.keys[k.Kid] = pub
+		}
+	}
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// verifyIDToken checks the signature, issuer, audience and lifetime of an ID
+// token and returns its claims. RS256 and ES256 signatures are supported.
+func (p *oidcProvider) verifyIDToken(ctx context.Context, raw string) (map[string]interface{}, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	b64 := base64.RawURLEncoding
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	hb, err := b64.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(hb, &header) != nil {
+		return nil, errors.New("malformed token header")
+	}
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch header.Alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) != nil {
+			return nil, errors.New("invalid token signature")
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return nil, errors.New("invalid token signature")
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return nil, errors.New("invalid token signature")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported token algorithm %q", header.Alg)
+	}
+
+	var claims map[string]interface{}
+	pb, err := b64.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(pb, &claims) != nil {
+		return nil, errors.New("malformed token claims")
+	}
+
+	d, _ := p.metadata(ctx)
+	if iss, _ := claims["iss"].(string); iss != d.Issuer {
+		return nil, errors.New("token issuer mismatch")
+	}
+	if !slices.Contains(stringsClaim(claims, "aud"), p.cfg.ClientID) {
+		return nil, errors.New("token audience mismatch")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || time.Now().Add(-oidcClockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("token expired")
+	}
+	if iat, ok := claims["iat"].(float64); ok && time.Now().Add(oidcClockSkew).Before(time.Unix(int64(iat), 0)) {
+		return nil, errors.New("token issued in the future")
+	}
+	return claims, nil
+}
+
+// stringsClaim reads a claim that may be a single string or a list of strings
+func stringsClaim(claims map[string]interface{}, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var out []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// role maps the token's groups to a session role, or "" if the user has none
+func (p *oidcProvider) role(claims map[string]interface{}) string {
+	if len(p.cfg.AdminGroups) == 0 {
+		return roleAdmin
+	}
+	groups := stringsClaim(claims, p.cfg.GroupsClaim)
+	for _, g := range groups {
+		if slices.Contains(p.cfg.AdminGroups, g) {
+			return roleAdmin
+		}
+	}
+	for _, g := range groups {
+		if slices.Contains(p.cfg.ViewerGroups, g) {
+			return roleViewer
+		}
+	}
+	return ""
+}
+
+// subject returns a readable identity for sessions and the audit log
+func oidcSubject(claims map[string]interface{}) string {
+	for _, name := range []string{"email", "preferred_username", "sub"} {
+		if v, _ := claims[name].(string); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// bearerRole authenticates an OIDC ID token sent as a bearer token to the API
+// and returns the user's role and subject
+func (s *Server) bearerRole(r *http.Request) (string, string, bool) {
+	if s.OIDC == nil {
+		return "", "", false
+	}
+	raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || strings.Count(raw, ".") != 2 {
+		return "", "", false
+	}
+	claims, err := s.OIDC.verifyIDToken(r.Context(), raw)
+	if err != nil {
+		return "", "", false
+	}
+	role := s.OIDC.role(claims)
+	return role, oidcSubject(claims), role != ""
+}
+
+// HandleOIDCLogin redirects the browser to the identity provider
+func (s *Server) HandleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if s.OIDC == nil {
+		http.NotFound(w, r)
+		return
+	}
+	d, err := s.OIDC.metadata(r.Context())
+	if err != nil {
+		http.Error(w, "Identity provider unavailable: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	state, nonce, verifier := randomToken(16), randomToken(16), randomToken(32)
+	challenge := sha256.Sum256([]byte(verifier))
+
+	// Lax so the cookie comes back on the provider's top-level redirect
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state + "." + nonce + "." + verifier,
+		Path:     "/oidc/",
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   s.isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {s.OIDC.cfg.ClientID},
+		"redirect_uri":          {s.OIDC.cfg.RedirectURL},
+		"scope":                 {"openid profile email"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, d.AuthorizationEndpoint+sep+params.Encode(), http.StatusFound)
+}
+
+// HandleOIDCCallback completes the authorization code flow and starts a session
+func (s *Server) HandleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if s.OIDC == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if e := r.URL.Query().Get("error"); e != "" {
+		http.Error(w, "Login failed: "+e+" "+r.URL.Query().Get("error_description"), http.StatusUnauthorized)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		http.Error(w, "Login expired, please try again", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/oidc/", MaxAge: -1})
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 || r.URL.Query().Get("state") != parts[0] {
+		http.Error(w, "Invalid login state", http.StatusBadRequest)
+		return
+	}
+	nonce, verifier := parts[1], parts[2]
+
+	rawIDToken, err := s.OIDC.exchange(r.Context(), r.URL.Query().Get("code"), verifier)
+	if err != nil {
+		http.Error(w, "Login failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	claims, err := s.OIDC.verifyIDToken(r.Context(), rawIDToken)
+	if err != nil {
+		http.Error(w, "Login failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		http.Error(w, "Login failed: nonce mismatch", http.StatusUnauthorized)
+		return
+	}
+
+	q := s.queries()
+	subject := oidcSubject(claims)
+	role := s.OIDC.role(claims)
+	if role == "" {
+		q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+			Action:     "LOGIN_FAILED",
+			EntityType: "session",
+			Details:    strPtr(subject + ": no matching group"),
+			IpAddress:  s.storedIP(r),
+			UserAgent:  strPtr(r.Header.Get("User-Agent")),
+			RequestID:  requestID(r.Context()),
+			CreatedAt:  time.Now(),
+		})
+		http.Error(w, "Your account is not allowed to use this server", http.StatusForbidden)
+		return
+	}
+
+	sess, err := s.createSession(w, r, role, &subject)
+	if err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "LOGIN",
+		EntityType: "session",
+		EntityID:   &sess.ID,
+		Details:    strPtr(subject + " (" + role + ")"),
+		IpAddress:  s.storedIP(r),
+		UserAgent:  strPtr(r.Header.Get("User-Agent")),
+		Actor:      &subject,
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// exchange trades an authorization code for the ID token
+func (p *oidcProvider) exchange(ctx context.Context, code, verifier string) (string, error) {
+	if code == "" {
+		return "", errors.New("missing authorization code")
+	}
+	d, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var tok struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("token endpoint: %s", resp.Status)
+	}
+	if tok.Error != "" {
+		return "", fmt.Errorf("token endpoint: %s %s", tok.Error, tok.ErrorDescription)
+	}
+	if tok.IDToken == "" {
+		return "", errors.New("token endpoint returned no id_token")
+	}
+	return tok.IDToken, nil
+}
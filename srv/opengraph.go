@@ -0,0 +1,222 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// previewSnippetLines is how much of a script link previews show
+const previewSnippetLines = 10
+
+// Default size of the oEmbed card
+const (
+	oembedWidth      = 600
+	oembedLineHeight = 18
+)
+
+// linkPreviewAgents identify the crawlers chat apps send to unfurl links
+var linkPreviewAgents = []string{
+	"slackbot", "skypeuripreview", "microsoftpreview", "discordbot", "twitterbot",
+	"facebookexternalhit", "linkedinbot", "telegrambot", "whatsapp", "mattermost",
+	"rocket.chat", "embedly", "iframely",
+}
+
+// isLinkPreview reports whether the request comes from a chat app building a
+// preview of a shared link
+func isLinkPreview(r *http.Request) bool {
+	ua := strings.ToLower(r.Header.Get("User-Agent"))
+	for _, p := range linkPreviewAgents {
+		if strings.Contains(ua, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// dangerLabels name the danger levels the way the UI does
+var dangerLabels = []string{"Safe", "Caution", "Dangerous"}
+
+// dangerLabel names a script's danger level
+func dangerLabel(script dbgen.Script) string {
+	if script.DangerLevel == nil || *script.DangerLevel < 0 || int(*script.DangerLevel) >= len(dangerLabels) {
+		return dangerLabels[0]
+	}
+	return dangerLabels[*script.DangerLevel]
+}
+
+// scriptPreview is what link previews show of a script
+type scriptPreview struct {
+	URL         string
+	Name        string
+	Description string
+	Danger      string
+	Snippet     string // empty for locked scripts
+	Lines       int    // of the whole script
+}
+
+// previewOf summarizes a script for link previews, linking to it on the
+// host the request came in on. Locked scripts show no content.
+func (s *Server) previewOf(r *http.Request, q *queries, script dbgen.Script) scriptPreview {
+	ctx := r.Context()
+	p := scriptPreview{
+		URL:    "https://" + s.hostname(r) + virtualHost(ctx).strip(script.Path),
+		Name:   script.Name,
+		Danger: dangerLabel(script),
+	}
+	if script.Description != nil {
+		p.Description = *script.Description
+	}
+	lines := strings.Split(strings.TrimRight(script.Content, "\n"), "\n")
+	p.Lines = len(lines)
+	if _, locked := s.scriptLockOf(ctx, q, script); !locked {
+		p.Snippet = strings.Join(lines[:min(len(lines), previewSnippetLines)], "\n")
+	}
+	return p
+}
+
+// summary is the one-line description shown under the title
+func (p scriptPreview) summary() string {
+	if p.Description != "" {
+		return p.Description + " · Danger level: " + p.Danger
+	}
+	return "Shell script · Danger level: " + p.Danger
+}
+
+// serveScriptPreview answers with an HTML page describing the script, with
+// OpenGraph tags and oEmbed discovery for chat apps
+func (s *Server) serveScriptPreview(w http.ResponseWriter, r *http.Request, q *queries, script dbgen.Script) {
+	p := s.previewOf(r, q, script)
+	oembed := "https://" + s.hostname(r) + "/_oembed?format=json&url=" + url.QueryEscape(p.URL)
+	esc := html.EscapeString
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%[1]s</title>
+<meta property="og:type" content="website">
+<meta property="og:site_name" content="SH Server">
+<meta property="og:title" content="%[1]s">
+<meta property="og:description" content="%[2]s">
+<meta property="og:url" content="%[3]s">
+<meta name="description" content="%[2]s">
+<meta name="twitter:card" content="summary">
+<meta name="twitter:label1" content="Danger level">
+<meta name="twitter:data1" content="%[4]s">
+<meta name="twitter:label2" content="Run">
+<meta name="twitter:data2" content="%[5]s">
+<link rel="alternate" type="application/json+oembed" href="%[6]s" title="%[1]s">
+</head>
+<body>
+<h1>%[1]s</h1>
+<p>%[2]s</p>
+`, esc(p.Name), esc(p.summary()), esc(p.URL), esc(p.Danger), esc("curl -fsSL "+p.URL+" | sh"), esc(oembed))
+	if p.Snippet != "" {
+		fmt.Fprintf(&b, "<pre>%s</pre>\n", esc(p.Snippet))
+		if p.Lines > previewSnippetLines {
+			fmt.Fprintf(&b, "<p>… %d more lines</p>\n", p.Lines-previewSnippetLines)
+		}
+	}
+	b.WriteString("</body>\n</html>\n")
+
+	// The same URL serves the script itself, which must never be replaced
+	// by this page in a cache
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write([]byte(b.String()))
+}
+
+// OEmbedResponse is a rich oEmbed card; description, danger_level and
+// snippet are extensions for clients that render their own card
+type OEmbedResponse struct {
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+	Title        string `json:"title"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	CacheAge     int    `json:"cache_age"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Description  string `json:"description,omitempty"`
+	DangerLevel  string `json:"danger_level"`
+	Snippet      string `json:"snippet,omitempty"`
+}
+
+// previewable reports whether link previews may describe a script: it has
+// to be servable to anyone
+func previewable(script dbgen.Script, now time.Time) bool {
+	return servable(script, now) && script.Private == 0
+}
+
+// HandleOEmbed describes the script at ?url= as an oEmbed card
+func (s *Server) HandleOEmbed(w http.ResponseWriter, r *http.Request) {
+	if f := r.URL.Query().Get("format"); f != "" && f != "json" {
+		http.Error(w, "Only the json format is supported", http.StatusNotImplemented)
+		return
+	}
+	u, err := url.Parse(r.URL.Query().Get("url"))
+	if err != nil || !strings.HasSuffix(u.Path, ".sh") {
+		http.Error(w, "url must be a script URL", http.StatusBadRequest)
+		return
+	}
+
+	path := u.Path
+	if vh := virtualHost(r.Context()); vh != nil {
+		path = vh.Prefix + path
+	}
+	q := s.queries()
+	script, err := q.GetScriptByPath(r.Context(), path)
+	if err != nil || !previewable(script, time.Now()) {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+	if s.geoBlocked(r, q, script) {
+		http.Error(w, "This script is not available in your region", http.StatusForbidden)
+		return
+	}
+	p := s.previewOf(r, q, script)
+
+	esc := html.EscapeString
+	var card strings.Builder
+	fmt.Fprintf(&card, `<div class="sh-server-script"><a href="%s"><strong>%s</strong></a><p>%s</p>`, esc(p.URL), esc(p.Name), esc(p.summary()))
+	if p.Snippet != "" {
+		fmt.Fprintf(&card, "<pre>%s</pre>", esc(p.Snippet))
+	}
+	fmt.Fprintf(&card, "<code>%s</code></div>", esc("curl -fsSL "+p.URL+" | sh"))
+
+	width := oembedWidth
+	if mw, err := strconv.Atoi(r.URL.Query().Get("maxwidth")); err == nil && mw > 0 {
+		width = min(width, mw)
+	}
+	height := (strings.Count(p.Snippet, "\n") + 5) * oembedLineHeight
+	if mh, err := strconv.Atoi(r.URL.Query().Get("maxheight")); err == nil && mh > 0 {
+		height = min(height, mh)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "max-age=60")
+	json.NewEncoder(w).Encode(OEmbedResponse{
+		Type:         "rich",
+		Version:      "1.0",
+		Title:        p.Name,
+		ProviderName: "SH Server",
+		ProviderURL:  "https://" + s.hostname(r) + "/",
+		CacheAge:     60,
+		HTML:         card.String(),
+		Width:        width,
+		Height:       height,
+		Description:  p.Description,
+		DangerLevel:  p.Danger,
+		Snippet:      p.Snippet,
+	})
+}
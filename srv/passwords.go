@@ -0,0 +1,119 @@
+package srv
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms for locked scripts
+const (
+	hashBcrypt   = "bcrypt"
+	hashArgon2id = "argon2id"
+)
+
+// PasswordConfig selects how script passwords are hashed. Hashes made with
+// either algorithm keep verifying; a successful unlock rehashes the password
+// when its hash does not match the current settings.
+type PasswordConfig struct {
+	Algorithm         string // bcrypt (default) or argon2id
+	Argon2Memory      uint32 // KiB
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+}
+
+// withDefaults fills in unset argon2id parameters
+func (c PasswordConfig) withDefaults() PasswordConfig {
+	if c.Algorithm == "" {
+		c.Algorithm = hashBcrypt
+	}
+	if c.Argon2Memory == 0 {
+		c.Argon2Memory = 64 * 1024
+	}
+	if c.Argon2Iterations == 0 {
+		c.Argon2Iterations = 3
+	}
+	if c.Argon2Parallelism == 0 {
+		c.Argon2Parallelism = 4
+	}
+	return c
+}
+
+// hashPassword hashes a script password with the configured algorithm
+func (c PasswordConfig) hashPassword(password string) (string, error) {
+	c = c.withDefaults()
+	if c.Algorithm != hashArgon2id {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		return string(hash), err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, c.Argon2Iterations, c.Argon2Memory, c.Argon2Parallelism, 32)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		c.Argon2Memory, c.Argon2Iterations, c.Argon2Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// argon2Hash is a decoded $argon2id$ hash
+type argon2Hash struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt, key   []byte
+}
+
+func parseArgon2Hash(hash string) (argon2Hash, bool) {
+	var h argon2Hash
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != hashArgon2id {
+		return h, false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return h, false
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.iterations, &h.parallelism); err != nil {
+		return h, false
+	}
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return h, false
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.key) == 0 {
+		return h, false
+	}
+	return h, true
+}
+
+// verifyPassword checks a password against a bcrypt or argon2id hash
+func verifyPassword(hash, password string) bool {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+	h, ok := parseArgon2Hash(hash)
+	if !ok {
+		return false
+	}
+	key := argon2.IDKey([]byte(password), h.salt, h.iterations, h.memory, h.parallelism, uint32(len(h.key)))
+	return subtle.ConstantTimeCompare(key, h.key) == 1
+}
+
+// needsRehash reports whether a hash was made with other settings than the
+// configured ones
+func (c PasswordConfig) needsRehash(hash string) bool {
+	c = c.withDefaults()
+	if c.Algorithm != hashArgon2id {
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != bcrypt.DefaultCost
+	}
+	h, ok := parseArgon2Hash(hash)
+	return !ok || h.memory != c.Argon2Memory || h.iterations != c.Argon2Iterations || h.parallelism != c.Argon2Parallelism
+}
@@ -0,0 +1,489 @@
+package srv
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// portableFormat identifies the portable export format and its version
+const portableFormat = "sh-server/v1"
+
+// maxImportSize caps the body of an import
+const maxImportSize = 64 << 20
+
+// PortableHeader describes where and when an export was made
+type PortableHeader struct {
+	Format      string    `json:"format"`
+	Source      string    `json:"source"`
+	Prefix      string    `json:"prefix,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// PortableVersion is one version of a script's history
+type PortableVersion struct {
+	Version   int64     `json:"version"`
+	Content   string    `json:"content"`
+	Message   string    `json:"message,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PortableScript is a script with its content and, oldest first, its history
+type PortableScript struct {
+	ExportedScript
+	Content  string            `json:"content"`
+	Versions []PortableVersion `json:"versions,omitempty"`
+
+	// Unchanged is set instead of Content and Versions for scripts whose
+	// content has not changed since the ?since= of the export
+	Unchanged bool `json:"unchanged,omitempty"`
+}
+
+// PortableExport is the JSON export of an instance, or of the part of it
+// under a prefix
+type PortableExport struct {
+	PortableHeader
+	Folders []ExportedFolder `json:"folders"`
+	Scripts []PortableScript `json:"scripts"`
+}
+
+// portableLine is one line of the JSONL export: the header first, then a
+// folder or a script per line
+type portableLine struct {
+	Export *PortableHeader `json:"export,omitempty"`
+	Folder *ExportedFolder `json:"folder,omitempty"`
+	Script *PortableScript `json:"script,omitempty"`
+}
+
+// APIExportPortable exports the scripts under ?prefix= with their content,
+// folders and version history as one JSON document, or as JSONL with
+// ?format=jsonl. ?versions=false leaves out the history, and ?since= the
+// content of scripts not changed since then.
+func (s *Server) APIExportPortable(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "jsonl" {
+		http.Error(w, "format must be json or jsonl", http.StatusBadRequest)
+		return
+	}
+	withVersions := r.URL.Query().Get("versions") != "false"
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			invalidField(w, "since", errors.New("since must be an RFC 3339 time"))
+			return
+		}
+		since = t
+	}
+	prefix := exportPrefix(r)
+
+	// Taken before reading so that a change made meanwhile is in the next
+	// export since this one
+	now := time.Now()
+	q := s.queries()
+	folders, scripts, err := s.exportSelection(r.Context(), q, prefix)
+	if err != nil {
+		http.Error(w, "Failed to list scripts", http.StatusInternalServerError)
+		return
+	}
+	versions := map[string][]PortableVersion{}
+	if withVersions {
+		all, err := q.ListAllVersions(r.Context())
+		if err != nil {
+			http.Error(w, "Failed to list versions", http.StatusInternalServerError)
+			return
+		}
+		for _, v := range all {
+			versions[v.ScriptID] = append(versions[v.ScriptID], PortableVersion{
+				Version:   v.Version,
+				Content:   v.Content,
+				Message:   derefStr(v.Message),
+				CreatedAt: v.CreatedAt,
+			})
+		}
+	}
+
+	export := PortableExport{
+		PortableHeader: PortableHeader{
+			Format:      portableFormat,
+			Source:      "https://" + s.hostname(r),
+			Prefix:      prefix,
+			GeneratedAt: now.UTC(),
+		},
+		Folders: []ExportedFolder{},
+		Scripts: []PortableScript{},
+	}
+	for _, f := range folders {
+		export.Folders = append(export.Folders, exportedFolder(f))
+	}
+	for _, sc := range scripts {
+		if !since.IsZero() && !sc.UpdatedAt.After(since) {
+			export.Scripts = append(export.Scripts, PortableScript{ExportedScript: exportedScript(sc), Unchanged: true})
+			continue
+		}
+		history := versions[sc.ID]
+		slices.SortFunc(history, func(a, b PortableVersion) int { return int(a.Version - b.Version) })
+		export.Scripts = append(export.Scripts, PortableScript{
+			ExportedScript: exportedScript(sc),
+			Content:        sc.Content,
+			Versions:       history,
+		})
+	}
+	auditExport(r, q, format, prefix, now)
+
+	name := "sh-export-" + now.UTC().Format("20060102") + "." + format
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(export)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	enc.Encode(portableLine{Export: &export.PortableHeader})
+	for i := range export.Folders {
+		enc.Encode(portableLine{Folder: &export.Folders[i]})
+	}
+	for i := range export.Scripts {
+		enc.Encode(portableLine{Script: &export.Scripts[i]})
+	}
+}
+
+// decodePortable reads an export in either the JSON or the JSONL format
+func decodePortable(body io.Reader) (*PortableExport, error) {
+	dec := json.NewDecoder(body)
+	var first json.RawMessage
+	if err := dec.Decode(&first); err != nil {
+		return nil, err
+	}
+	var line portableLine
+	if err := json.Unmarshal(first, &line); err != nil || line.Export == nil {
+		var export PortableExport
+		if err := json.Unmarshal(first, &export); err != nil {
+			return nil, err
+		}
+		return &export, nil
+	}
+
+	export := &PortableExport{PortableHeader: *line.Export}
+	for n := 2; ; n++ {
+		line = portableLine{}
+		if err := dec.Decode(&line); err == io.EOF {
+			return export, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		switch {
+		case line.Folder != nil:
+			export.Folders = append(export.Folders, *line.Folder)
+		case line.Script != nil:
+			export.Scripts = append(export.Scripts, *line.Script)
+		default:
+			return nil, fmt.Errorf("line %d: expected a folder or a script", n)
+		}
+	}
+}
+
+// ImportConflict is a script that exists with different content or
+// metadata and was left as it is
+type ImportConflict struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// PortableImportResult lists what an import changed, or would change on a
+// dry run
+type PortableImportResult struct {
+	Mode          string           `json:"mode"`
+	DryRun        bool             `json:"dry_run"`
+	Created       []string         `json:"created"`
+	Updated       []string         `json:"updated"`
+	Skipped       []string         `json:"skipped"` // identical to the export
+	Deleted       []string         `json:"deleted"` // replace only
+	Folders       []string         `json:"folders"` // folders created
+	Conflicts     []ImportConflict `json:"conflicts"`
+	NeedsPassword []string         `json:"needs_password"` // locked without a password to unlock them
+	Errors        []string         `json:"errors"`
+}
+
+// applyExported sets the metadata of req to that of an exported script
+func applyExported(req *UpdateScriptRequest, sc ExportedScript) {
+	req.Description = sc.Description
+	req.Tags = sc.Tags
+	req.Locked = sc.Locked
+	req.DangerLevel = sc.DangerLevel
+	req.Requires = sc.Requires
+	req.Examples = sc.Examples
+	req.Deprecated = sc.Deprecated
+	req.ReplacementPath = sc.ReplacementPath
+	req.SunsetAt = sc.SunsetAt
+	req.AvailableFrom = sc.AvailableFrom
+	req.AvailableUntil = sc.AvailableUntil
+	req.ExpiresAt = sc.ExpiresAt
+	req.Unlisted = sc.Unlisted
+	req.Private = sc.Private
+	req.UnlockTTL = sc.UnlockTTL
+	req.MaxDownloadsPerHour = sc.MaxDownloadsPerHour
+	req.MaxDownloadsPerDay = sc.MaxDownloadsPerDay
+	req.AllowCountries = sc.AllowCountries
+	req.DenyCountries = sc.DenyCountries
+	req.SourceURL = sc.SourceURL
+	req.SourceTTL = sc.SourceTTL
+	req.SourceSHA256 = sc.SourceSHA256
+}
+
+// comparableMetadata renders the metadata an import sets in a form that
+// can be compared
+func comparableMetadata(sc ExportedScript) string {
+	sc.Name, sc.Archived = "", false
+	sc.CreatedAt, sc.UpdatedAt = time.Time{}, time.Time{}
+	if !sc.Disabled {
+		sc.DisabledReason = ""
+	}
+	for _, t := range []**time.Time{&sc.SunsetAt, &sc.AvailableFrom, &sc.AvailableUntil, &sc.ExpiresAt} {
+		if *t != nil {
+			utc := (*t).UTC()
+			*t = &utc
+		}
+	}
+	b, _ := json.Marshal(sc)
+	return string(b)
+}
+
+// importDifference says how an existing script differs from an exported
+// one, or returns "" if it does not
+func importDifference(existing dbgen.Script, sc PortableScript) string {
+	if existing.Content != sc.Content {
+		return "content differs"
+	}
+	if comparableMetadata(exportedScript(existing)) != comparableMetadata(sc.ExportedScript) {
+		return "metadata differs"
+	}
+	return ""
+}
+
+// importScript creates or overwrites one script of an import through the
+// API handlers. A new script gets the exported history replayed as
+// versions; an existing one gets a single new version.
+func (s *Server) importScript(r *http.Request, sc PortableScript, message string, created bool) error {
+	type step struct{ content, message string }
+	var steps []step
+	if created {
+		for _, v := range sc.Versions {
+			steps = append(steps, step{v.Content, v.Message})
+		}
+	}
+	if len(steps) == 0 || steps[len(steps)-1].content != sc.Content {
+		steps = append(steps, step{sc.Content, message})
+	}
+
+	var resp ScriptResponse
+	saved := false
+	var errs []string
+	for i, st := range steps {
+		if st.message == "" {
+			st.message = message
+		}
+		var err error
+		resp, _, err = s.publishScript(r, sc.Path, st.content, func(req *UpdateScriptRequest) error {
+			applyExported(req, sc.ExportedScript)
+			req.Message = st.message
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("step %d of %d: %v", i+1, len(steps), err))
+			continue
+		}
+		saved = true
+	}
+	if !saved || resp.Content != sc.Content {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	if resp.Disabled != sc.Disabled {
+		if sc.Disabled {
+			_, err := callAPI(r, s.APIDisableScript, http.MethodPost, resp.ID, DisableScriptRequest{Reason: sc.DisabledReason})
+			return err
+		}
+		_, err := callAPI(r, s.APIEnableScript, http.MethodPost, resp.ID, nil)
+		return err
+	}
+	return nil
+}
+
+// importPortable applies an export. In merge mode existing scripts are
+// kept and reported as conflicts when they differ; in replace mode they
+// are overwritten, and scripts under prefix that the export does not have
+// are deleted. Only scripts under prefix are imported.
+func (s *Server) importPortable(r *http.Request, export *PortableExport, mode, prefix string, dryRun bool) *PortableImportResult {
+	ctx := r.Context()
+	q := s.queries()
+	res := &PortableImportResult{
+		Mode: mode, DryRun: dryRun,
+		Created: []string{}, Updated: []string{}, Skipped: []string{}, Deleted: []string{}, Folders: []string{},
+		Conflicts: []ImportConflict{}, NeedsPassword: []string{}, Errors: []string{},
+	}
+	message := "Imported from " + export.Source
+	if export.Source == "" {
+		message = "Imported"
+	}
+
+	for _, f := range export.Folders {
+		if !scriptPrefixMatch(f.Path, prefix) {
+			continue
+		}
+		if err := validatePath(f.Path + "/x.sh"); err != nil {
+			res.Errors = append(res.Errors, f.Path+": "+err.Error())
+			continue
+		}
+		existing, err := q.GetFolderByPath(ctx, f.Path)
+		if errors.Is(err, sql.ErrNoRows) {
+			if !dryRun {
+				s.ensureFolders(ctx, q, f.Path+"/x.sh")
+			}
+			res.Folders = append(res.Folders, f.Path)
+		}
+		if f.Locked && (err != nil || existing.PasswordHash == nil) {
+			res.NeedsPassword = append(res.NeedsPassword, f.Path+"/")
+		}
+	}
+
+	imported := map[string]bool{}
+	for _, sc := range export.Scripts {
+		if !scriptPrefixMatch(sc.Path, prefix) {
+			continue
+		}
+		if err := validatePath(sc.Path); err != nil {
+			res.Errors = append(res.Errors, sc.Path+": "+err.Error())
+			continue
+		}
+		imported[sc.Path] = true
+
+		existing, err := q.GetScriptByPath(ctx, sc.Path)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			res.Errors = append(res.Errors, sc.Path+": "+err.Error())
+			continue
+		}
+		created := err != nil
+		if sc.Unchanged {
+			if created {
+				res.Errors = append(res.Errors, sc.Path+": content not included in the export")
+				continue
+			}
+			sc.Content = existing.Content
+		}
+		if !created {
+			diff := importDifference(existing, sc)
+			if diff == "" {
+				res.Skipped = append(res.Skipped, sc.Path)
+				continue
+			}
+			if mode == "merge" {
+				res.Conflicts = append(res.Conflicts, ImportConflict{Path: sc.Path, Reason: diff})
+				continue
+			}
+		}
+
+		if !dryRun {
+			if err := s.importScript(r, sc, message, created); err != nil {
+				res.Errors = append(res.Errors, sc.Path+": "+err.Error())
+				continue
+			}
+		}
+		if created {
+			res.Created = append(res.Created, sc.Path)
+		} else {
+			res.Updated = append(res.Updated, sc.Path)
+		}
+		if sc.Locked && (created || existing.PasswordHash == nil) {
+			res.NeedsPassword = append(res.NeedsPassword, sc.Path)
+		}
+	}
+
+	if mode == "replace" {
+		scripts, err := q.ListScripts(ctx)
+		if err != nil {
+			res.Errors = append(res.Errors, "list scripts: "+err.Error())
+			return res
+		}
+		for _, sc := range scripts {
+			if !scriptPrefixMatch(sc.Path, prefix) || imported[sc.Path] {
+				continue
+			}
+			if !dryRun {
+				if err := s.unpublishScript(r, sc.Path); err != nil {
+					res.Errors = append(res.Errors, sc.Path+": "+err.Error())
+					continue
+				}
+			}
+			res.Deleted = append(res.Deleted, sc.Path)
+		}
+	}
+	return res
+}
+
+// APIImportPortable imports an export made by APIExportPortable, in
+// ?mode=merge (the default) or ?mode=replace. ?prefix= restricts the import
+// to part of the export, and also bounds what replace deletes; it defaults
+// to the prefix the export was made with. ?dry_run=true reports what would
+// change without changing it.
+func (s *Server) APIImportPortable(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = "merge"
+	}
+	if mode != "merge" && mode != "replace" {
+		invalidField(w, "mode", errors.New("mode must be merge or replace"))
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	export, err := decodePortable(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		http.Error(w, "Invalid export: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if export.Format != portableFormat {
+		http.Error(w, fmt.Sprintf("Unsupported export format %q, expected %q", export.Format, portableFormat), http.StatusBadRequest)
+		return
+	}
+	prefix := exportPrefix(r)
+	if prefix == "" {
+		prefix = export.Prefix
+	}
+
+	res := s.importPortable(r, export, mode, prefix, dryRun)
+	if !dryRun {
+		details := fmt.Sprintf("%s from %s: %d created, %d updated, %d deleted, %d conflicts, %d errors",
+			mode, export.Source, len(res.Created), len(res.Updated), len(res.Deleted), len(res.Conflicts), len(res.Errors))
+		if prefix != "" {
+			details += " under " + prefix
+		}
+		s.queries().CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+			Action:     "IMPORT",
+			EntityType: "script",
+			Details:    &details,
+			Actor:      actor(r.Context()),
+			RequestID:  requestID(r.Context()),
+			CreatedAt:  time.Now(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
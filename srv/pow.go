@@ -0,0 +1,137 @@
+package srv
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// powChallengeTTL is how long a client has to solve a challenge
+const powChallengeTTL = 10 * time.Minute
+
+// powChallenges issues and checks hashcash-style unlock challenges. A
+// challenge is "<expiry>.<random>.<mac>", so issuing needs no state; solved
+// challenges are remembered until they expire so each pays for one attempt.
+type powChallenges struct {
+	difficulty int // leading zero hex digits required in sha256(challenge:nonce)
+	key        []byte
+
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+func newPoWChallenges(difficulty int) *powChallenges {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &powChallenges{difficulty: difficulty, key: key, used: make(map[string]time.Time)}
+}
+
+func (p *powChallenges) mac(path, body string) string {
+	m := hmac.New(sha256.New, p.key)
+	m.Write([]byte(path + "\n" + body))
+	return hex.EncodeToString(m.Sum(nil))[:32]
+}
+
+// issue returns a new challenge for unlocking path
+func (p *powChallenges) issue(path string) string {
+	body := strconv.FormatInt(time.Now().Add(powChallengeTTL).Unix(), 10) + "." + randomToken(12)
+	return body + "." + p.mac(path, body)
+}
+
+// solved reports whether nonce solves a challenge issued for path and marks
+// the challenge as used
+func (p *powChallenges) solved(path, challenge, nonce string) bool {
+	i := strings.LastIndex(challenge, ".")
+	if i < 0 || nonce == "" || len(nonce) > 32 {
+		return false
+	}
+	body, mac := challenge[:i], challenge[i+1:]
+	if !hmac.Equal([]byte(mac), []byte(p.mac(path, body))) {
+		return false
+	}
+	expStr, _, _ := strings.Cut(body, ".")
+	exp, err := strconv.ParseInt(expStr, 10, 64)
+	now := time.Now()
+	if err != nil || now.Unix() >= exp {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+	if !strings.HasPrefix(hex.EncodeToString(sum[:]), strings.Repeat("0", p.difficulty)) {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for c, until := range p.used {
+		if now.After(until) {
+			delete(p.used, c)
+		}
+	}
+	if _, ok := p.used[challenge]; ok {
+		return false
+	}
+	p.used[challenge] = time.Unix(exp, 0)
+	return true
+}
+
+// requirePoW answers an unlock request that lacks a solved challenge with a
+// fresh one and reports whether the request may proceed
+func (s *Server) requirePoW(w http.ResponseWriter, path, challenge, nonce string) bool {
+	if s.pow == nil || s.pow.solved(path, challenge, nonce) {
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPreconditionRequired)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":      "Proof of work required",
+		"challenge":  s.pow.issue(path),
+		"difficulty": s.pow.difficulty,
+	})
+	return false
+}
+
+// powPromptStep is the part of the password prompt that solves the unlock
+// challenge; it sets POW to extra JSON fields for the unlock request
+const powPromptStep = `
+# Solve the proof-of-work challenge the server asks for before each attempt
+if command -v sha256sum >/dev/null 2>&1; then
+    SHA256="sha256sum"
+elif command -v shasum >/dev/null 2>&1; then
+    SHA256="shasum -a 256"
+else
+    SHA256="openssl dgst -sha256 -r"
+fi
+CHALLENGE_RESPONSE=$(curl -sSL -X POST "${BASE_URL}/_auth/unlock" \
+    -H "Content-Type: application/json" \
+    -d "{\"path\":\"${SCRIPT_PATH}\"}" 2>&1)
+CHALLENGE=$(echo "$CHALLENGE_RESPONSE" | grep -o '"challenge":"[^"]*"' | sed 's/"challenge":"\([^"]*\)"/\1/')
+DIFFICULTY=$(echo "$CHALLENGE_RESPONSE" | grep -o '"difficulty":[0-9]*' | sed 's/"difficulty"://')
+POW=""
+if [ -n "$CHALLENGE" ]; then
+    ZEROS=""
+    i=0
+    while [ "$i" -lt "$DIFFICULTY" ]; do
+        ZEROS="${ZEROS}0"
+        i=$((i + 1))
+    done
+    printf "Solving proof-of-work challenge..."
+    NONCE=0
+    while :; do
+        HASH=$(printf '%s:%s' "$CHALLENGE" "$NONCE" | $SHA256)
+        case "$HASH" in
+            "$ZEROS"*) break ;;
+        esac
+        NONCE=$((NONCE + 1))
+    done
+    echo " done"
+    POW=",\"challenge\":\"${CHALLENGE}\",\"nonce\":\"${NONCE}\""
+fi
+`
@@ -0,0 +1,76 @@
+package srv
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// IPAnonymizeConfig controls how client IPs are stored in the audit log,
+// unlock tokens, sessions and analytics tables
+type IPAnonymizeConfig struct {
+	Mode   string        // "" keeps IPs, "truncate" zeroes the host part, "hash" stores a keyed hash
+	Salt   string        // secret for "hash"; random per process if empty
+	Rotate time.Duration // "hash" derives a new key every period; 0 never rotates
+}
+
+// truncateIP keeps the /24 of an IPv4 address or the /48 of an IPv6 address
+func truncateIP(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// ipHashKey returns the key for the rotation period containing now. Hashes
+// of one address are stable within a period, so abuse from a single client
+// can still be correlated, but not across periods.
+func (s *Server) ipHashKey(now time.Time) []byte {
+	var period int64
+	if s.IPAnonymize.Rotate > 0 {
+		period = now.Unix() / int64(s.IPAnonymize.Rotate/time.Second)
+	}
+	mac := hmac.New(sha256.New, s.ipSalt)
+	mac.Write([]byte(strconv.FormatInt(period, 10)))
+	return mac.Sum(nil)
+}
+
+// anonymizeIPAt returns ip as it should be stored at time now
+func (s *Server) anonymizeIPAt(ip string, now time.Time) string {
+	switch s.IPAnonymize.Mode {
+	case "truncate":
+		if parsed := net.ParseIP(ip); parsed != nil {
+			return truncateIP(parsed)
+		}
+	case "hash":
+		mac := hmac.New(sha256.New, s.ipHashKey(now))
+		mac.Write([]byte(ip))
+		return "anon-" + hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+	return ip
+}
+
+// anonymizeIP returns ip as it should be stored now
+func (s *Server) anonymizeIP(ip string) string {
+	return s.anonymizeIPAt(ip, time.Now())
+}
+
+// storedIP is the client IP of the request in the form written to the database
+func (s *Server) storedIP(r *http.Request) *string {
+	return strPtr(s.anonymizeIP(clientIP(r)))
+}
+
+// newIPSalt returns the configured salt, or a random one if none is set
+func newIPSalt(salt string) []byte {
+	if salt != "" {
+		return []byte(salt)
+	}
+	b := make([]byte, 32)
+	rand.Read(b)
+	return b
+}
@@ -0,0 +1,228 @@
+package srv
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// defaultSourceTTL is how long a proxied script's cached copy stays fresh
+// when the script doesn't set source_ttl
+const defaultSourceTTL = time.Hour
+
+// sourceRetry is how long a failed fetch keeps the cached copy before the
+// source is tried again
+const sourceRetry = time.Minute
+
+// sourceTimeout bounds a single fetch of a proxied script's source
+const sourceTimeout = 15 * time.Second
+
+// sourceClient fetches the sources of proxied scripts
+var sourceClient = &http.Client{Timeout: sourceTimeout}
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// errSourceMismatch is returned for a source that doesn't match its pin
+var errSourceMismatch = errors.New("source does not match the pinned SHA-256")
+
+// validateSource checks the source settings of a proxied script
+func validateSource(rawURL string, ttl int64, pin string) (field string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "source_url", errors.New("source_url must be an https URL")
+	}
+	if ttl < 0 {
+		return "source_ttl", errors.New("source_ttl must not be negative")
+	}
+	if pin != "" && !sha256Pattern.MatchString(pin) {
+		return "source_sha256", errors.New("source_sha256 must be 64 lowercase hex digits")
+	}
+	return "", nil
+}
+
+// fetchSource downloads a proxied script's source and checks it against
+// the pinned hash, if any
+func (s *Server) fetchSource(ctx context.Context, rawURL, pin string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := sourceClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, s.Limits.MaxScriptSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(body)) > s.Limits.MaxScriptSize {
+		return "", errors.New("source is too large")
+	}
+	if pin != "" {
+		sum := sha256.Sum256(body)
+		if got := hex.EncodeToString(sum[:]); got != pin {
+			return "", fmt.Errorf("%w: got %s", errSourceMismatch, got)
+		}
+	}
+	return string(body), nil
+}
+
+// sourceParam converts a script's source settings for storage
+func sourceParam(id, rawURL string, ttl int64, pin string, fetchedAt *time.Time) dbgen.UpdateScriptSourceParams {
+	params := dbgen.UpdateScriptSourceParams{ID: id}
+	if rawURL == "" {
+		return params
+	}
+	params.SourceUrl = &rawURL
+	if ttl > 0 {
+		params.SourceTtl = &ttl
+	}
+	if pin != "" {
+		params.SourceSha256 = &pin
+	}
+	params.SourceFetchedAt = fetchedAt
+	return params
+}
+
+// resolveSource sets the content of a proxied script from its source and
+// returns when it was fetched. An unchanged source keeps the cached copy,
+// and a replica keeps the copy its upstream fetched.
+func (s *Server) resolveSource(ctx context.Context, existing *dbgen.Script, rawURL, pin string, content *string) (*time.Time, error) {
+	now := time.Now()
+	if ctx.Value(replicaSyncKey{}) != nil {
+		return &now, nil
+	}
+	if existing != nil && derefStr(existing.SourceUrl) == rawURL && derefStr(existing.SourceSha256) == pin {
+		*content = existing.Content
+		return existing.SourceFetchedAt, nil
+	}
+	fetched, err := s.fetchSource(ctx, rawURL, pin)
+	if err != nil {
+		return nil, err
+	}
+	*content = fetched
+	return &now, nil
+}
+
+// sourceTTL is how long a script's cached copy of its source stays fresh
+func sourceTTL(script dbgen.Script) time.Duration {
+	if script.SourceTtl != nil && *script.SourceTtl > 0 {
+		return time.Duration(*script.SourceTtl) * time.Second
+	}
+	return defaultSourceTTL
+}
+
+// sourceStale reports whether a proxied script's cached copy should be
+// checked against its source
+func sourceStale(script dbgen.Script, now time.Time) bool {
+	return script.SourceFetchedAt == nil || now.Sub(*script.SourceFetchedAt) >= sourceTTL(script)
+}
+
+// sourceFetch serializes the fetches of one proxied script and remembers
+// when a failed one may be tried again
+type sourceFetch struct {
+	mu      sync.Mutex
+	retryAt time.Time
+}
+
+// refreshSource fetches a proxied script's source again once its cached
+// copy is stale and returns the script to serve. New content becomes a
+// new version; when the fetch fails or the content doesn't match the pin,
+// the cached copy is served.
+func (s *Server) refreshSource(ctx context.Context, q *queries, script dbgen.Script) dbgen.Script {
+	// A replica serves the copy its upstream fetched
+	if script.SourceUrl == nil || s.replica != nil || !sourceStale(script, time.Now()) {
+		return script
+	}
+	v, _ := s.sourceFetches.LoadOrStore(script.ID, &sourceFetch{})
+	f := v.(*sourceFetch)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Now().Before(f.retryAt) {
+		return script
+	}
+	// Another request may have fetched it while this one waited
+	if fresh, err := q.GetScript(ctx, script.ID); err == nil && !sourceStale(fresh, time.Now()) {
+		return fresh
+	}
+	updated, err := s.fetchScriptSource(ctx, q, script)
+	if err != nil {
+		f.retryAt = time.Now().Add(sourceRetry)
+		slog.WarnContext(ctx, "proxied script: serving cached copy", "path", script.Path, "source", *script.SourceUrl, "error", err)
+		return script
+	}
+	return updated
+}
+
+// fetchScriptSource fetches a proxied script's source and stores it if it
+// changed
+func (s *Server) fetchScriptSource(ctx context.Context, q *queries, script dbgen.Script) (dbgen.Script, error) {
+	source := *script.SourceUrl
+	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sourceTimeout)
+	defer cancel()
+	content, err := s.fetchSource(fetchCtx, source, derefStr(script.SourceSha256))
+	if err != nil {
+		if errors.Is(err, errSourceMismatch) {
+			q.CreateAuditLog(ctx, dbgen.CreateAuditLogParams{
+				Action:     "SOURCE_MISMATCH",
+				EntityType: "script",
+				EntityID:   &script.ID,
+				EntityPath: &script.Path,
+				Details:    strPtr(err.Error()),
+				RequestID:  requestID(ctx),
+				CreatedAt:  time.Now(),
+			})
+		}
+		return script, err
+	}
+
+	if content != script.Content {
+		ctx := context.WithValue(ctx, actorKey{}, "source")
+		if err := s.updateSyncedScript(ctx, q, script, content, "Fetched from "+source, "fetched from "+source); err != nil {
+			return script, err
+		}
+	}
+	now := time.Now()
+	if err := q.SetScriptSourceFetched(ctx, dbgen.SetScriptSourceFetchedParams{SourceFetchedAt: &now, ID: script.ID}); err != nil {
+		return script, err
+	}
+	return q.GetScript(ctx, script.ID)
+}
+
+// APIRefreshSource fetches a proxied script's source right away
+func (s *Server) APIRefreshSource(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	script, err := q.GetScript(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+	if script.SourceUrl == nil {
+		http.Error(w, "Script has no source URL", http.StatusBadRequest)
+		return
+	}
+	script, err = s.fetchScriptSource(r.Context(), q, script)
+	if err != nil {
+		http.Error(w, "Failed to fetch source: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scriptToResponse(script))
+}
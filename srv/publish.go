@@ -0,0 +1,178 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// Scripts can also be written through WebDAV and other interfaces that
+// don't speak the JSON API. They hand each change to the API handlers, so
+// validation, hooks, versioning and auditing behave exactly the same.
+
+// PublishError is an API refusal: the status and message the handler
+// answered with
+type PublishError struct {
+	Status  int
+	Message string
+}
+
+func (e *PublishError) Error() string {
+	return e.Message
+}
+
+// apiRecorder captures a handler's response
+type apiRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *apiRecorder) Header() http.Header         { return rec.header }
+func (rec *apiRecorder) Write(b []byte) (int, error) { return rec.body.Write(b) }
+func (rec *apiRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+// callAPI runs an API handler on behalf of r with a JSON body. The
+// request's context (actor, request ID) and client address are kept.
+func callAPI(r *http.Request, h http.HandlerFunc, method, id string, body any) (*apiRecorder, error) {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req, err := http.NewRequestWithContext(r.Context(), method, r.URL.String(), &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.RemoteAddr = r.RemoteAddr
+	req.Host = r.Host
+	req.Header.Set("User-Agent", r.UserAgent())
+	req.Header.Set("Content-Type", "application/json")
+	if id != "" {
+		req.SetPathValue("id", id)
+	}
+	rec := &apiRecorder{header: http.Header{}}
+	h(rec, req)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if rec.status >= 300 {
+		return rec, &PublishError{Status: rec.status, Message: strings.TrimSpace(rec.body.String())}
+	}
+	return rec, nil
+}
+
+// updateRequestFor describes a script as it is, to be changed and saved
+func updateRequestFor(s dbgen.Script) UpdateScriptRequest {
+	resp := scriptToResponse(s)
+	return UpdateScriptRequest{
+		Path:                resp.Path,
+		Content:             resp.Content,
+		Description:         resp.Description,
+		Tags:                resp.Tags,
+		Locked:              resp.Locked,
+		DangerLevel:         resp.DangerLevel,
+		Requires:            resp.Requires,
+		Examples:            resp.Examples,
+		Deprecated:          resp.Deprecated,
+		ReplacementPath:     resp.ReplacementPath,
+		SunsetAt:            resp.SunsetAt,
+		AvailableFrom:       resp.AvailableFrom,
+		AvailableUntil:      resp.AvailableUntil,
+		ExpiresAt:           resp.ExpiresAt,
+		Unlisted:            resp.Unlisted,
+		Private:             resp.Private,
+		UnlockTTL:           resp.UnlockTTL,
+		MaxDownloadsPerHour: resp.MaxDownloadsPerHour,
+		MaxDownloadsPerDay:  resp.MaxDownloadsPerDay,
+		AllowCountries:      resp.AllowCountries,
+		DenyCountries:       resp.DenyCountries,
+		SourceURL:           resp.SourceURL,
+		SourceTTL:           resp.SourceTTL,
+		SourceSHA256:        resp.SourceSHA256,
+	}
+}
+
+// publishScript creates the script at path or updates it. edit, if not
+// nil, adjusts the request after the content is set; metadata of an
+// existing script is kept otherwise. It reports whether the script is new.
+func (s *Server) publishScript(r *http.Request, path, content string, edit func(*UpdateScriptRequest) error) (ScriptResponse, bool, error) {
+	var resp ScriptResponse
+	existing, err := s.queries().GetScriptByPath(r.Context(), path)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return resp, false, err
+	}
+	created := err != nil
+
+	req := UpdateScriptRequest{Path: path}
+	if !created {
+		req = updateRequestFor(existing)
+	}
+	req.Content = content
+	if edit != nil {
+		if err := edit(&req); err != nil {
+			return resp, created, &PublishError{Status: http.StatusBadRequest, Message: err.Error()}
+		}
+	}
+	var rec *apiRecorder
+	if created {
+		rec, err = callAPI(r, s.APICreateScript, http.MethodPost, "", req)
+	} else {
+		rec, err = callAPI(r, s.APIUpdateScript, http.MethodPut, existing.ID, req)
+	}
+	if err != nil {
+		return resp, created, err
+	}
+	json.Unmarshal(rec.body.Bytes(), &resp)
+	return resp, created, nil
+}
+
+// moveScript renames a script
+func (s *Server) moveScript(r *http.Request, from, to string) error {
+	script, err := s.queries().GetScriptByPath(r.Context(), from)
+	if err != nil {
+		return err
+	}
+	req := updateRequestFor(script)
+	req.Path = to
+	_, err = callAPI(r, s.APIUpdateScript, http.MethodPut, script.ID, req)
+	return err
+}
+
+// unpublishScript deletes the script at path
+func (s *Server) unpublishScript(r *http.Request, path string) error {
+	script, err := s.queries().GetScriptByPath(r.Context(), path)
+	if err != nil {
+		return err
+	}
+	_, err = callAPI(r, s.APIDeleteScript, http.MethodDelete, script.ID, nil)
+	return err
+}
+
+// requestKey carries the original request through interfaces that only
+// pass a context along
+type requestKey struct{}
+
+// originalRequest returns the request stored by withOriginalRequest, or a
+// bare request with ctx
+func originalRequest(ctx context.Context) *http.Request {
+	if r, ok := ctx.Value(requestKey{}).(*http.Request); ok {
+		return r.WithContext(ctx)
+	}
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	return r
+}
+
+// withOriginalRequest stores r in its own context
+func withOriginalRequest(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestKey{}, r))
+}
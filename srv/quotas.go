@@ -0,0 +1,113 @@
+package srv
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// downloadQuotas counts the downloads of scripts with a quota in the
+// current clock hour and UTC day. Counts are per server, not shared with
+// others on the same database.
+type downloadQuotas struct {
+	mu     sync.Mutex
+	counts map[string]*downloadCount // by script ID
+}
+
+type downloadCount struct {
+	hour, day     time.Time // start of the windows counted
+	hourly, daily int64
+}
+
+// quotaExceeded describes the quota a download ran into
+type quotaExceeded struct {
+	limit  int64
+	period string // "hour" or "day"
+	reset  time.Time
+}
+
+// take counts a download of script, unless that goes over one of its
+// quotas
+func (d *downloadQuotas) take(script dbgen.Script, now time.Time) *quotaExceeded {
+	perHour, perDay := derefInt(script.MaxDownloadsPerHour), derefInt(script.MaxDownloadsPerDay)
+	if perHour <= 0 && perDay <= 0 {
+		return nil
+	}
+	now = now.UTC()
+	hour, day := now.Truncate(time.Hour), now.Truncate(24*time.Hour)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.counts == nil {
+		d.counts = map[string]*downloadCount{}
+	}
+	c, ok := d.counts[script.ID]
+	if !ok {
+		c = &downloadCount{}
+		d.counts[script.ID] = c
+	}
+	if !c.hour.Equal(hour) {
+		c.hour, c.hourly = hour, 0
+	}
+	if !c.day.Equal(day) {
+		c.day, c.daily = day, 0
+	}
+	if perDay > 0 && c.daily >= perDay {
+		return &quotaExceeded{limit: perDay, period: "day", reset: day.Add(24 * time.Hour)}
+	}
+	if perHour > 0 && c.hourly >= perHour {
+		return &quotaExceeded{limit: perHour, period: "hour", reset: hour.Add(time.Hour)}
+	}
+	c.hourly++
+	c.daily++
+	return nil
+}
+
+// withinQuota counts a download of script and, if it is over quota,
+// tells the client when to come back instead
+func (s *Server) withinQuota(w http.ResponseWriter, r *http.Request, script dbgen.Script) bool {
+	now := time.Now()
+	exceeded := s.quotas.take(script, now)
+	if exceeded == nil {
+		return true
+	}
+
+	wait := max(exceeded.reset.Sub(now).Round(time.Minute), time.Minute)
+	msg := fmt.Sprintf("%s has reached its limit of %d downloads per %s; try again in %s",
+		script.Path, exceeded.limit, exceeded.period, strings.TrimSuffix(wait.String(), "0s"))
+	w.Header().Set("Retry-After", strconv.Itoa(int(exceeded.reset.Sub(now).Seconds())+1))
+	w.Header().Set("Cache-Control", "no-store")
+	if !isCLI(r) {
+		http.Error(w, msg, http.StatusTooManyRequests)
+		return false
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "#!/bin/sh\necho %s >&2\nexit 1\n", shellQuote("ERROR: "+msg))
+	return false
+}
+
+// validateQuota checks a per-script download quota
+func validateQuota(field string, n int64) error {
+	if n < 0 {
+		return errors.New(field + " must not be negative")
+	}
+	return nil
+}
+
+// quotasParam converts per-script quotas for storage; zero means unlimited
+func quotasParam(id string, perHour, perDay int64) dbgen.UpdateScriptQuotasParams {
+	params := dbgen.UpdateScriptQuotasParams{ID: id}
+	if perHour > 0 {
+		params.MaxDownloadsPerHour = &perHour
+	}
+	if perDay > 0 {
+		params.MaxDownloadsPerDay = &perDay
+	}
+	return params
+}
@@ -0,0 +1,131 @@
+package srv
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket per client IP: Rate requests a second on
+// average, in bursts of up to Burst. A zero Rate disables it.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitConfig gives every client separate budgets for fetching
+// scripts, unlocking them and calling the admin API, so one client can't
+// saturate the server
+type RateLimitConfig struct {
+	Scripts RateLimit
+	Unlock  RateLimit
+	Admin   RateLimit // also login, WebDAV, gRPC and raw uploads
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per key
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   RateLimit
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	l := &rateLimiter{buckets: map[string]*tokenBucket{}}
+	l.setLimit(limit)
+	return l
+}
+
+// setLimit changes the limit and reports whether it changed; buckets
+// already filling keep their tokens
+func (l *rateLimiter) setLimit(limit RateLimit) bool {
+	if limit.Rate > 0 && limit.Burst < 1 {
+		limit.Burst = max(1, int(math.Ceil(limit.Rate)))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit == l.limit {
+		return false
+	}
+	l.limit = limit
+	return true
+}
+
+// allow takes a token for key, or returns how long until one is available
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit.Rate <= 0 {
+		return true, 0
+	}
+	burst := float64(l.limit.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		l.buckets[key] = b
+		l.prune(now)
+	}
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.limit.Rate * float64(time.Second))
+}
+
+// prune drops the buckets that have filled up again once the map grows;
+// callers hold l.mu
+func (l *rateLimiter) prune(now time.Time) {
+	if len(l.buckets) < 1024 {
+		return
+	}
+	full := time.Duration(float64(l.limit.Burst) / l.limit.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimits are the limiters of RateLimitConfig
+type rateLimits struct {
+	scripts, unlock, admin *rateLimiter
+}
+
+func newRateLimits(cfg RateLimitConfig) rateLimits {
+	return rateLimits{
+		scripts: newRateLimiter(cfg.Scripts),
+		unlock:  newRateLimiter(cfg.Unlock),
+		admin:   newRateLimiter(cfg.Admin),
+	}
+}
+
+// setConfig changes the limits and reports whether any changed
+func (r rateLimits) setConfig(cfg RateLimitConfig) bool {
+	scripts := r.scripts.setLimit(cfg.Scripts)
+	unlock := r.unlock.setLimit(cfg.Unlock)
+	admin := r.admin.setLimit(cfg.Admin)
+	return scripts || unlock || admin
+}
+
+// rateLimited answers 429 with Retry-After once the client has used up
+// its budget in l
+func rateLimited(l *rateLimiter, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if l != nil {
+			if ok, wait := l.allow(clientIP(r), time.Now()); !ok {
+				w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "Too many requests, try again later", http.StatusTooManyRequests)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
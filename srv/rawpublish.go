@@ -0,0 +1,175 @@
+package srv
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// rawMetaHeaders maps metadata keys to the headers that set them on a raw
+// upload, e.g. curl -T deploy.sh -H "X-Script-Tags: ops" ...
+var rawMetaHeaders = map[string]string{
+	"description":  "X-Script-Description",
+	"tags":         "X-Script-Tags",
+	"requires":     "X-Script-Requires",
+	"examples":     "X-Script-Examples",
+	"danger_level": "X-Script-Danger-Level",
+	"unlisted":     "X-Script-Unlisted",
+	"private":      "X-Script-Private",
+	"message":      "X-Script-Message",
+}
+
+// dangerLevels names the danger levels
+var dangerLevels = map[string]int{"safe": 0, "caution": 1, "dangerous": 2}
+
+// parseFrontMatter reads a "# ---" delimited block of "# key: value"
+// comment lines at the top of a script, after the shebang:
+//
+//	#!/bin/sh
+//	# ---
+//	# description: Deploy the app
+//	# tags: deploy, ops
+//	# ---
+//
+// The block stays in the content.
+func parseFrontMatter(content string) map[string]string {
+	lines := strings.Split(content, "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "#!") {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "# ---" {
+		return nil
+	}
+	meta := map[string]string{}
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line == "# ---" {
+			return meta
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "#"), ":")
+		if !strings.HasPrefix(line, "#") || !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if _, known := rawMetaHeaders[key]; known && key != "message" {
+			meta[key] = strings.TrimSpace(value)
+		}
+	}
+	return nil // unterminated block
+}
+
+// applyRawMetadata sets the metadata given in front matter and headers;
+// headers win
+func applyRawMetadata(req *UpdateScriptRequest, r *http.Request, content string) error {
+	meta := parseFrontMatter(content)
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	for key, header := range rawMetaHeaders {
+		if v := r.Header.Get(header); v != "" {
+			meta[key] = v
+		}
+	}
+	return applyMetadata(req, meta)
+}
+
+// applyMetadata sets the metadata keys of rawMetaHeaders on req
+func applyMetadata(req *UpdateScriptRequest, meta map[string]string) error {
+	for key, value := range meta {
+		switch key {
+		case "description":
+			req.Description = value
+		case "tags":
+			req.Tags = value
+		case "requires":
+			req.Requires = value
+		case "examples":
+			req.Examples = value
+		case "message":
+			req.Message = value
+		case "danger_level":
+			level, ok := dangerLevels[strings.ToLower(value)]
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 2 {
+				level, ok = n, true
+			}
+			if !ok {
+				return fmt.Errorf("danger_level must be safe, caution, dangerous or 0-2, got %q", value)
+			}
+			req.DangerLevel = level
+		case "unlisted", "private":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false, got %q", key, value)
+			}
+			if key == "unlisted" {
+				req.Unlisted = b
+			} else {
+				req.Private = b
+			}
+		}
+	}
+	return nil
+}
+
+// HandleRawPut publishes the request body as the script at the request
+// path, creating or updating it; metadata not given is kept
+func (s *Server) HandleRawPut(w http.ResponseWriter, r *http.Request) {
+	if err := validatePath(r.URL.Path); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, s.Limits.MaxScriptSize+1))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > s.Limits.MaxScriptSize {
+		http.Error(w, "Script too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	content := string(body)
+
+	script, created, err := s.publishScript(r, r.URL.Path, content, func(req *UpdateScriptRequest) error {
+		return applyRawMetadata(req, r, content)
+	})
+	var perr *PublishError
+	if errors.As(err, &perr) {
+		http.Error(w, perr.Message, perr.Status)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to save script", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	verb := "Updated"
+	if created {
+		w.WriteHeader(http.StatusCreated)
+		verb = "Created"
+	}
+	fmt.Fprintf(w, "%s %s\n", verb, script.Path)
+	for _, warn := range script.Warnings {
+		fmt.Fprintf(w, "warning: line %d: %s\n", warn.Line, warn.Message)
+	}
+}
+
+// HandleRawDelete deletes the script at the request path
+func (s *Server) HandleRawDelete(w http.ResponseWriter, r *http.Request) {
+	err := s.unpublishScript(r, r.URL.Path)
+	var perr *PublishError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Script not found", http.StatusNotFound)
+	case errors.As(err, &perr):
+		http.Error(w, perr.Message, perr.Status)
+	case err != nil:
+		http.Error(w, "Failed to delete script", http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,88 @@
+package srv
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// peerIP is the address the connection came from, which behind a reverse
+// proxy is the proxy's
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// clientIP is the address of the client, as forwarded by a trusted proxy
+// or else the peer's. Rate limits, audit entries and unlock tokens all go
+// by it.
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return peerIP(r)
+}
+
+func (s *Server) trustedProxy(ip net.IP) bool {
+	for _, n := range s.TrustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedIP finds the client a request from a trusted proxy was made for:
+// the last address in X-Forwarded-For that is not itself a trusted proxy,
+// or X-Real-IP when there is no X-Forwarded-For. It returns "" if the peer
+// is not trusted or forwarded nothing usable.
+func (s *Server) forwardedIP(r *http.Request) string {
+	peer := net.ParseIP(peerIP(r))
+	if peer == nil || !s.trustedProxy(peer) {
+		return ""
+	}
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	if len(hops) == 0 {
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+			return ip.String()
+		}
+		return ""
+	}
+	var client net.IP
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !s.trustedProxy(ip) {
+			break
+		}
+	}
+	if client == nil {
+		return ""
+	}
+	return client.String()
+}
+
+// withRealIP makes clientIP return the address forwarded by a trusted proxy
+func (s *Server) withRealIP(next http.Handler) http.Handler {
+	if len(s.TrustedProxies) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := s.forwardedIP(r); ip != "" {
+			r = r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
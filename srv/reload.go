@@ -0,0 +1,122 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// ReloadConfig holds the settings that can change while the server runs,
+// without restarting it and dropping the downloads in flight
+type ReloadConfig struct {
+	AdminToken       string
+	UnlockLimit      UnlockLimitConfig
+	RateLimits       RateLimitConfig
+	HoneypotAlertURL string
+	DigestURL        string
+	ScriptCacheTTL   time.Duration // only if the script cache is enabled
+	CatalogCacheTTL  time.Duration // only if the catalog cache is enabled
+}
+
+var errReloadNotConfigured = errors.New("reloading is not configured")
+
+// Reload reads the settings again with Config.Reload and applies them. It
+// returns the names of those that changed.
+func (s *Server) Reload(ctx context.Context) ([]string, error) {
+	if s.reload == nil {
+		return nil, errReloadNotConfigured
+	}
+	s.reloading.Lock()
+	defer s.reloading.Unlock()
+	cfg, err := s.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []string{}
+	s.reloadMu.Lock()
+	if cfg.AdminToken != s.AdminToken {
+		s.AdminToken = cfg.AdminToken
+		changed = append(changed, "admin_token")
+	}
+	if cfg.HoneypotAlertURL != s.HoneypotAlertURL {
+		s.HoneypotAlertURL = cfg.HoneypotAlertURL
+		changed = append(changed, "honeypot_alert_url")
+	}
+	if cfg.DigestURL != s.Digest.URL {
+		s.Digest.URL = cfg.DigestURL
+		changed = append(changed, "digest_url")
+	}
+	s.reloadMu.Unlock()
+	if s.unlockLimit != nil && s.unlockLimit.setConfig(cfg.UnlockLimit) {
+		changed = append(changed, "unlock_limit")
+	}
+	if s.rateLimits.scripts != nil && s.rateLimits.setConfig(cfg.RateLimits) {
+		changed = append(changed, "rate_limits")
+	}
+	if s.scriptCache != nil && s.scriptCache.setTTL(cfg.ScriptCacheTTL) {
+		changed = append(changed, "script_cache_ttl")
+	}
+	if s.responses != nil && s.responses.setTTL(cfg.CatalogCacheTTL) {
+		changed = append(changed, "catalog_cache_ttl")
+	}
+
+	slog.InfoContext(ctx, "settings reloaded", "changed", changed)
+	if len(changed) > 0 {
+		who := actor(ctx)
+		if who == nil {
+			who = strPtr("system")
+		}
+		details := strings.Join(changed, ", ")
+		s.queries().CreateAuditLog(ctx, dbgen.CreateAuditLogParams{
+			Action:     "RELOAD",
+			EntityType: "config",
+			Details:    &details,
+			Actor:      who,
+			RequestID:  requestID(ctx),
+			CreatedAt:  time.Now(),
+		})
+	}
+	return changed, nil
+}
+
+func (s *Server) adminToken() string {
+	s.reloadMu.RLock()
+	defer s.reloadMu.RUnlock()
+	return s.AdminToken
+}
+
+func (s *Server) honeypotAlertURL() string {
+	s.reloadMu.RLock()
+	defer s.reloadMu.RUnlock()
+	return s.HoneypotAlertURL
+}
+
+func (s *Server) digestURL() string {
+	s.reloadMu.RLock()
+	defer s.reloadMu.RUnlock()
+	return s.Digest.URL
+}
+
+// APIReload applies changed settings like SIGHUP does and returns the
+// names of those that changed
+func (s *Server) APIReload(w http.ResponseWriter, r *http.Request) {
+	changed, err := s.Reload(r.Context())
+	if errors.Is(err, errReloadNotConfigured) {
+		http.Error(w, "Reloading is not configured", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to reload settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"changed": changed})
+}
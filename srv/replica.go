@@ -0,0 +1,250 @@
+package srv
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// replicaTimeout bounds a single export request to the upstream
+const replicaTimeout = 2 * time.Minute
+
+// maxReplicaWebhookBody caps the webhook payloads that trigger a sync
+const maxReplicaWebhookBody = 1 << 20
+
+// ReplicaConfig makes the server a read-only replica of another sh-server:
+// the scripts under Prefix are pulled from Upstream and can't be changed
+// here
+type ReplicaConfig struct {
+	Upstream string        // base URL of the upstream server; empty disables replication
+	Token    string        // admin or API token for GET /api/v1/export on the upstream
+	Prefix   string        // part of the upstream catalog to replicate; empty for all of it
+	Interval time.Duration // between syncs; webhooks trigger one right away
+	Secret   string        // verifies upstream webhooks on /_sync/replica
+}
+
+// ReplicaStatus describes the last sync with the upstream
+type ReplicaStatus struct {
+	Upstream    string                `json:"upstream"`
+	LastSync    *time.Time            `json:"last_sync,omitempty"`    // last attempt
+	LastSuccess *time.Time            `json:"last_success,omitempty"` // last sync without errors
+	Error       string                `json:"error,omitempty"`
+	Result      *PortableImportResult `json:"result,omitempty"`
+}
+
+// replicaState is the sync state of a replica
+type replicaState struct {
+	mu    sync.Mutex    // one sync at a time
+	since time.Time     // upstream time of the last complete sync
+	kick  chan struct{} // asks the sync job for a sync right away
+
+	statusMu sync.Mutex
+	status   ReplicaStatus
+}
+
+func newReplicaState(upstream string) *replicaState {
+	return &replicaState{kick: make(chan struct{}, 1), status: ReplicaStatus{Upstream: upstream}}
+}
+
+// replicaSyncKey marks the context of a replica sync, the only writer of
+// the scripts of a replica
+type replicaSyncKey struct{}
+
+// replicaReadOnly refuses a change to the scripts of a replica, unless it
+// comes from the sync itself, and reports whether it did
+func (s *Server) replicaReadOnly(w http.ResponseWriter, r *http.Request) bool {
+	if s.replica == nil || r.Context().Value(replicaSyncKey{}) != nil {
+		return false
+	}
+	http.Error(w, "This server is a read-only replica of "+s.Replica.Upstream, http.StatusForbidden)
+	return true
+}
+
+// replicaWrites reports whether an admin API route changes scripts or
+// folders, which a replica only takes from its upstream
+func replicaWrites(method, route string) bool {
+	if method == http.MethodGet {
+		return false
+	}
+	for _, local := range []string{"/shares", "/signed-url", "/tokens/revoke_all"} {
+		if strings.HasSuffix(route, local) {
+			return false
+		}
+	}
+	for _, prefix := range []string{"/scripts", "/folders", "/import", "/sync/", "/generators/"} {
+		if strings.HasPrefix(route, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchUpstream gets the upstream's export of the replicated scripts.
+// After a complete sync only content changed since is included.
+func (s *Server) fetchUpstream(ctx context.Context, since time.Time) (*PortableExport, error) {
+	query := url.Values{"versions": {"false"}}
+	if s.Replica.Prefix != "" {
+		query.Set("prefix", s.Replica.Prefix)
+	}
+	if !since.IsZero() {
+		query.Set("since", since.Format(time.RFC3339Nano))
+	}
+	ctx, cancel := context.WithTimeout(ctx, replicaTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(s.Replica.Upstream, "/")+apiPrefix+"/export?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.Replica.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Replica.Token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("upstream export: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	export, err := decodePortable(io.LimitReader(resp.Body, maxImportSize))
+	if err != nil {
+		return nil, fmt.Errorf("upstream export: %w", err)
+	}
+	if export.Format != portableFormat {
+		return nil, fmt.Errorf("upstream export has format %q, expected %q", export.Format, portableFormat)
+	}
+	return export, nil
+}
+
+// syncReplica brings the replicated scripts in line with the upstream. A
+// sync with errors is followed by a full one.
+func (s *Server) syncReplica(ctx context.Context) (*PortableImportResult, error) {
+	s.replica.mu.Lock()
+	defer s.replica.mu.Unlock()
+
+	now := time.Now()
+	export, err := s.fetchUpstream(ctx, s.replica.since)
+	if err != nil {
+		s.setReplicaStatus(now, nil, err)
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, actorKey{}, "replica")
+	ctx = context.WithValue(ctx, replicaSyncKey{}, true)
+	res := s.importPortable(originalRequest(ctx), export, "replace", s.Replica.Prefix, false)
+	if len(res.Errors) == 0 {
+		s.replica.since = export.GeneratedAt
+	} else {
+		s.replica.since = time.Time{}
+	}
+	s.setReplicaStatus(now, res, nil)
+	return res, nil
+}
+
+// setReplicaStatus records the outcome of a sync started at now
+func (s *Server) setReplicaStatus(now time.Time, res *PortableImportResult, err error) {
+	s.replica.statusMu.Lock()
+	defer s.replica.statusMu.Unlock()
+	st := &s.replica.status
+	st.LastSync, st.Result, st.Error = &now, res, ""
+	if err != nil {
+		st.Error = err.Error()
+	} else if len(res.Errors) > 0 {
+		st.Error = fmt.Sprintf("%d scripts could not be synced", len(res.Errors))
+	} else {
+		st.LastSuccess = &now
+	}
+}
+
+// logReplicaSync runs a sync and logs its outcome
+func (s *Server) logReplicaSync(ctx context.Context, trigger string) {
+	res, err := s.syncReplica(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "replica sync failed", "trigger", trigger, "upstream", s.Replica.Upstream, "error", err)
+		return
+	}
+	if len(res.Created)+len(res.Updated)+len(res.Deleted)+len(res.Errors) == 0 {
+		return
+	}
+	slog.InfoContext(ctx, "replica sync", "trigger", trigger, "upstream", s.Replica.Upstream,
+		"created", len(res.Created), "updated", len(res.Updated), "deleted", len(res.Deleted), "errors", len(res.Errors))
+	for _, e := range res.Errors {
+		slog.WarnContext(ctx, "replica sync skipped a script", "error", e)
+	}
+}
+
+// runReplicaJob syncs on startup, then every interval or when asked to
+func (s *Server) runReplicaJob() {
+	trigger := "startup"
+	for {
+		s.logReplicaSync(context.Background(), trigger)
+		select {
+		case <-time.After(s.Replica.Interval):
+			trigger = "schedule"
+		case <-s.replica.kick:
+			trigger = "webhook"
+		}
+	}
+}
+
+// HandleReplicaWebhook syncs after a change on the upstream. Register it
+// there as a json webhook with REPLICA_SECRET as its secret.
+func (s *Server) HandleReplicaWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.replica == nil || s.Replica.Secret == "" {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxReplicaWebhookBody))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !hmac.Equal([]byte(r.Header.Get("X-SH-Signature")), []byte(signWebhook(s.Replica.Secret, body))) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+	// Events arriving during a sync are coalesced into the next one
+	select {
+	case s.replica.kick <- struct{}{}:
+	default:
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// APIReplicaStatus describes the last sync of a replica
+func (s *Server) APIReplicaStatus(w http.ResponseWriter, r *http.Request) {
+	if s.replica == nil {
+		http.Error(w, "This server is not a replica", http.StatusNotFound)
+		return
+	}
+	s.replica.statusMu.Lock()
+	st := s.replica.status
+	s.replica.statusMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(st)
+}
+
+// APIReplicaSync syncs with the upstream right away and returns what changed
+func (s *Server) APIReplicaSync(w http.ResponseWriter, r *http.Request) {
+	if s.replica == nil {
+		http.Error(w, "This server is not a replica", http.StatusNotFound)
+		return
+	}
+	res, err := s.syncReplica(r.Context())
+	if err != nil {
+		http.Error(w, "Replica sync failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,136 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// responseCache keeps responses built from full table scans, like the
+// catalog and the tree, serialized and ready to send. Writes to the tables
+// they are built from empty it, and concurrent rebuilds of a response are
+// coalesced into one.
+type responseCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedResponse
+	gen     uint64 // bumped by invalidate
+
+	builds singleflight.Group
+}
+
+type cachedResponse struct {
+	body    []byte
+	expires time.Time
+}
+
+// responseBuilder builds a response and the time it stops being valid
+// even without writes, e.g. when a script expires; zero if never
+type responseBuilder func(ctx context.Context) (body []byte, validUntil time.Time, err error)
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, entries: map[string]cachedResponse{}}
+}
+
+func (c *responseCache) lookup(key string, now time.Time) ([]byte, bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok && now.Before(e.expires) {
+		return e.body, true, c.gen
+	}
+	return nil, false, c.gen
+}
+
+// store caches a response built at generation gen, unless the cache was
+// invalidated since
+func (c *responseCache) store(key string, gen uint64, body []byte, validUntil time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	expires := time.Now().Add(c.ttl)
+	if !validUntil.IsZero() && validUntil.Before(expires) {
+		expires = validUntil
+	}
+	c.entries[key] = cachedResponse{body: body, expires: expires}
+}
+
+func (c *responseCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	clear(c.entries)
+}
+
+// setTTL changes how long responses are kept, dropping those cached
+// under the old one, and reports whether it changed
+func (c *responseCache) setTTL(ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ttl == c.ttl {
+		return false
+	}
+	c.ttl = ttl
+	c.gen++
+	clear(c.entries)
+	return true
+}
+
+// cachedResponse returns the response under key, building it if it isn't
+// cached. Requests that miss at the same time wait for a single build.
+func (s *Server) cachedResponse(ctx context.Context, key string, build responseBuilder) ([]byte, error) {
+	c := s.responses
+	if c == nil {
+		body, _, err := build(ctx)
+		return body, err
+	}
+	if body, ok, _ := c.lookup(key, time.Now()); ok {
+		return body, nil
+	}
+	v, err, _ := c.builds.Do(key, func() (any, error) {
+		body, ok, gen := c.lookup(key, time.Now())
+		if ok {
+			return body, nil
+		}
+		// Other requests wait on this build; one hanging up mustn't fail them
+		body, validUntil, err := build(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		c.store(key, gen, body, validUntil)
+		return body, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+// marshalResponse encodes v as json.Encoder does, with a trailing newline
+func marshalResponse(v any) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(body, '\n'), nil
+}
+
+// nextScriptChange returns the first time after now at which a script
+// becomes available, unavailable or expired; zero if none will
+func nextScriptChange(scripts []dbgen.Script, now time.Time) time.Time {
+	var next time.Time
+	for _, sc := range scripts {
+		for _, t := range []*time.Time{sc.AvailableFrom, sc.AvailableUntil, sc.ExpiresAt} {
+			if t != nil && t.After(now) && (next.IsZero() || t.Before(next)) {
+				next = *t
+			}
+		}
+	}
+	return next
+}
@@ -0,0 +1,163 @@
+package srv
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// maxRunMessage caps the message stored with a run report, in characters
+const maxRunMessage = 500
+
+// Number of recent failures returned by the runs API
+const (
+	defaultRunFailures = 10
+	maxRunFailures     = 100
+)
+
+// HandleRunReport records how a run of a script ended. Scripts, or the
+// one-liners that run them, report with
+//
+//	POST /_runs {"path": "/deploy.sh", "exit_code": 1, "duration_ms": 5300, "message": "..."}
+func (s *Server) HandleRunReport(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Path       string `json:"path"`
+		ExitCode   int64  `json:"exit_code"`
+		DurationMs *int64 `json:"duration_ms"`
+		Version    *int64 `json:"version"`
+		Message    string `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+
+	q := s.queries()
+	script, err := q.GetScriptByPath(r.Context(), req.Path)
+	if err != nil || (script.Private != 0 && !s.isAdmin(r)) {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+
+	var message *string
+	if req.Message != "" {
+		if m := []rune(req.Message); len(m) > maxRunMessage {
+			req.Message = string(m[:maxRunMessage])
+		}
+		message = &req.Message
+	}
+	now := time.Now()
+	if err := q.CreateScriptRun(r.Context(), dbgen.CreateScriptRunParams{
+		ScriptID:   script.ID,
+		ExitCode:   req.ExitCode,
+		DurationMs: req.DurationMs,
+		Version:    req.Version,
+		Message:    message,
+		IpAddress:  s.storedIP(r),
+		UserAgent:  strPtr(r.Header.Get("User-Agent")),
+		Day:        now.UTC().Format(statsDayLayout),
+		CreatedAt:  now,
+	}); err != nil {
+		http.Error(w, "Failed to record run", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DailyRuns is one bar of a run histogram
+type DailyRuns struct {
+	Day      string `json:"day"`
+	Runs     int64  `json:"runs"`
+	Failures int64  `json:"failures"`
+}
+
+// RunCounts counts runs and failed runs over some window
+type RunCounts struct {
+	Runs        int64   `json:"runs"`
+	Failures    int64   `json:"failures"`
+	SuccessRate float64 `json:"success_rate"` // 0..1, 1 without runs
+}
+
+func runCounts(runs, failures int64) RunCounts {
+	c := RunCounts{Runs: runs, Failures: failures, SuccessRate: 1}
+	if runs > 0 {
+		c.SuccessRate = float64(runs-failures) / float64(runs)
+	}
+	return c
+}
+
+// RunHistoryResponse is the reliability view of one script
+type RunHistoryResponse struct {
+	ScriptID       string            `json:"script_id"`
+	Path           string            `json:"path"`
+	Days           int               `json:"days"`
+	Total          RunCounts         `json:"total"`
+	LastHour       RunCounts         `json:"last_hour"`
+	Daily          []DailyRuns       `json:"daily"`
+	RecentFailures []dbgen.ScriptRun `json:"recent_failures"`
+}
+
+// APIScriptRuns returns a script's success rate over ?days= with a daily
+// histogram, the last hour on its own so a breakage shows up quickly, and
+// the ?limit= most recent failures
+func (s *Server) APIScriptRuns(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	days := statsDays(r)
+	now := time.Now()
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultRunFailures
+	}
+	limit = min(limit, maxRunFailures)
+
+	q := s.queries()
+	script, err := q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+
+	rows, err := q.ListScriptDailyRuns(r.Context(), dbgen.ListScriptDailyRunsParams{ScriptID: id, Day: statsSince(now, days)})
+	if err != nil {
+		http.Error(w, "Failed to load runs", http.StatusInternalServerError)
+		return
+	}
+	lastHour, err := q.CountScriptRunsSince(r.Context(), dbgen.CountScriptRunsSinceParams{ScriptID: id, CreatedAt: now.Add(-time.Hour)})
+	if err != nil {
+		http.Error(w, "Failed to load runs", http.StatusInternalServerError)
+		return
+	}
+	failures, err := q.ListScriptRunFailures(r.Context(), dbgen.ListScriptRunFailuresParams{ScriptID: id, Limit: int64(limit)})
+	if err != nil {
+		http.Error(w, "Failed to load runs", http.StatusInternalServerError)
+		return
+	}
+
+	byDay := make(map[string]dbgen.ListScriptDailyRunsRow, len(rows))
+	var runs, failed int64
+	for _, row := range rows {
+		byDay[row.Day] = row
+		runs += row.Runs
+		failed += row.Failures
+	}
+	resp := RunHistoryResponse{
+		ScriptID:       script.ID,
+		Path:           script.Path,
+		Days:           days,
+		Total:          runCounts(runs, failed),
+		LastHour:       runCounts(lastHour.Runs, lastHour.Failures),
+		Daily:          make([]DailyRuns, days),
+		RecentFailures: failures,
+	}
+	start := now.UTC().AddDate(0, 0, -(days - 1))
+	for i := range resp.Daily {
+		day := start.AddDate(0, 0, i).Format(statsDayLayout)
+		resp.Daily[i] = DailyRuns{Day: day, Runs: byDay[day].Runs, Failures: byDay[day].Failures}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
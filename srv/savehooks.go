@@ -0,0 +1,134 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// saveHookTimeout bounds a single pre- or post-save hook run
+const saveHookTimeout = 30 * time.Second
+
+// maxHookOutput caps how much hook output is passed on to the client
+const maxHookOutput = 4096
+
+// runSaveHook runs a hook executable as "<hook> <script path> <content file>"
+// with SH_ACTION (create, update or delete), SH_SCRIPT_PATH and SH_ACTOR in
+// its environment. The content file is removed once the hook exits.
+func runSaveHook(ctx context.Context, hook, action, scriptPath, content string) (string, error) {
+	f, err := os.CreateTemp("", "sh-server-hook-*.sh")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, saveHookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, hook, scriptPath, f.Name())
+	cmd.Env = append(os.Environ(),
+		"SH_ACTION="+action,
+		"SH_SCRIPT_PATH="+scriptPath,
+		"SH_ACTOR="+derefStr(actor(ctx)),
+	)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	output := strings.TrimSpace(out.String())
+	if len(output) > maxHookOutput {
+		output = output[:maxHookOutput]
+	}
+	return output, err
+}
+
+// preSaveError is returned by preSave when PRE_SAVE_HOOK refuses a save
+// or can't be run
+type preSaveError struct {
+	output   string
+	rejected bool // the hook exited non-zero
+	err      error
+}
+
+func (e *preSaveError) Error() string {
+	if !e.rejected {
+		return "pre-save hook failed: " + e.err.Error()
+	}
+	msg := "Rejected by pre-save hook"
+	if e.output != "" {
+		msg += ":\n" + e.output
+	}
+	return msg
+}
+
+func (e *preSaveError) Unwrap() error { return e.err }
+
+// preSave runs PRE_SAVE_HOOK before content is saved. Every path that
+// writes script content goes through it, so the hook can't be bypassed.
+func (s *Server) preSave(ctx context.Context, action, scriptPath, content string) error {
+	if s.PreSaveHook == "" {
+		return nil
+	}
+	output, err := runSaveHook(ctx, s.PreSaveHook, action, scriptPath, content)
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	rejected := errors.As(err, &exitErr)
+	if !rejected {
+		slog.ErrorContext(ctx, "pre-save hook failed", "hook", s.PreSaveHook, "error", err)
+	}
+	return &preSaveError{output: output, rejected: rejected, err: err}
+}
+
+// writePreSaveError answers a request whose save preSave refused, and
+// reports whether err was such a refusal
+func writePreSaveError(w http.ResponseWriter, err error) bool {
+	var pe *preSaveError
+	if !errors.As(err, &pe) {
+		return false
+	}
+	if pe.rejected {
+		http.Error(w, pe.Error(), http.StatusUnprocessableEntity)
+	} else {
+		http.Error(w, "Pre-save hook failed: "+pe.err.Error(), http.StatusInternalServerError)
+	}
+	return true
+}
+
+// checkPreSave runs PRE_SAVE_HOOK before content is saved and refuses the
+// save if the hook exits non-zero, passing its output on. It reports
+// whether the save may continue.
+func (s *Server) checkPreSave(w http.ResponseWriter, r *http.Request, action, scriptPath, content string) bool {
+	if err := s.preSave(r.Context(), action, scriptPath, content); err != nil {
+		writePreSaveError(w, err)
+		return false
+	}
+	return true
+}
+
+// postSave runs POST_SAVE_HOOK in the background after a script was saved
+// or deleted; failures are only logged
+func (s *Server) postSave(ctx context.Context, action, scriptPath, content string) {
+	if s.PostSaveHook == "" {
+		return
+	}
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		if output, err := runSaveHook(ctx, s.PostSaveHook, action, scriptPath, content); err != nil {
+			slog.WarnContext(ctx, "post-save hook failed", "hook", s.PostSaveHook, "path", scriptPath, "error", err, "output", output)
+		}
+	}()
+}
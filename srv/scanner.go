@@ -0,0 +1,308 @@
+package srv
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// ScanWarning is a risky construct found in script content
+type ScanWarning struct {
+	Rule    string `json:"rule"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+	Match   string `json:"match"`
+}
+
+// scanRule flags lines matching re. allow, if set, can let a match through
+// based on its submatches.
+type scanRule struct {
+	id       string
+	message  string
+	re       *regexp.Regexp
+	allow    func(s *Server, m []string) bool
+	comments bool // also match comment lines
+}
+
+// builtinScanRules are always applied; admins add more through /api/scan-rules
+var builtinScanRules = []scanRule{
+	{
+		id:      "rm-rf-root",
+		message: "Recursively deletes the root directory",
+		re:      regexp.MustCompile(`\brm\s+(?:-[-\w]+\s+)*-\w*[rR]\w*\s+(?:-[-\w]+\s+)*["']?/\*?["']?(?:\s|;|&|\||$)`),
+	},
+	{
+		id:      "no-preserve-root",
+		message: "Disables rm's protection of the root directory",
+		re:      regexp.MustCompile(`\brm\b.*--no-preserve-root`),
+	},
+	{
+		id:      "pipe-to-shell",
+		message: "Pipes a download from an untrusted host into a shell",
+		re:      regexp.MustCompile(`\b(?:curl|wget)\b[^|\n]*?https?://([^/\s"':|]+)[^|\n]*\|\s*(?:sudo\s+)?(?:ba|z|da|k)?sh\b`),
+		allow: func(s *Server, m []string) bool {
+			return s.trustedScanHost(m[1])
+		},
+	},
+	{
+		id:      "chmod-777",
+		message: "Makes files world-writable",
+		re:      regexp.MustCompile(`\bchmod\s+(?:-\w+\s+)*0?777\b`),
+	},
+	{
+		id:      "fork-bomb",
+		message: "Fork bomb",
+		re:      regexp.MustCompile(`:\s*\(\s*\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`),
+	},
+	{
+		id:      "disk-overwrite",
+		message: "Writes directly to a block device",
+		re:      regexp.MustCompile(`(?:\bdd\b.*\bof=|>\s*|\bmkfs(?:\.\w+)?\s+(?:-\S+\s+)*)/dev/(?:sd|hd|vd|xvd|nvme|mmcblk|disk)\w*`),
+	},
+}
+
+// trustedScanHost reports whether piping downloads from host into a shell
+// is expected: the server itself and SCAN_TRUSTED_HOSTS
+func (s *Server) trustedScanHost(host string) bool {
+	host = strings.ToLower(host)
+	own, _, _ := strings.Cut(s.Hostname, ":")
+	return host == strings.ToLower(own) || slices.Contains(s.ScanTrustedHosts, host)
+}
+
+// scanRules returns the built-in rules followed by the admin-defined ones.
+// Rules whose pattern no longer compiles are skipped.
+func scanRules(ctx context.Context, q *queries) []scanRule {
+	rules := slices.Clone(builtinScanRules)
+	custom, _ := q.ListScanRules(ctx)
+	for _, c := range custom {
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, scanRule{id: c.ID, message: c.Message, re: re})
+	}
+	return rules
+}
+
+// scanContent returns a warning for each line matching a rule. Comment lines
+// are skipped unless the rule says otherwise.
+func (s *Server) scanContent(rules []scanRule, content string) []ScanWarning {
+	var warnings []ScanWarning
+	for i, line := range strings.Split(content, "\n") {
+		comment := strings.HasPrefix(strings.TrimSpace(line), "#")
+		for _, rule := range rules {
+			if comment && !rule.comments {
+				continue
+			}
+			m := rule.re.FindStringSubmatch(line)
+			if m == nil || (rule.allow != nil && rule.allow(s, m)) {
+				continue
+			}
+			warnings = append(warnings, ScanWarning{
+				Rule:    rule.id,
+				Line:    i + 1,
+				Message: rule.message,
+				Match:   strings.TrimSpace(m[0]),
+			})
+		}
+	}
+	return warnings
+}
+
+// flagMemo remembers which scripts the scanner flagged, so the catalog only
+// reads the content of scripts changed since it was last built
+type flagMemo struct {
+	mu      sync.Mutex
+	rules   string // what the flags were scanned with; a change drops them
+	flagged map[flagKey]bool
+}
+
+// flagKey identifies a script's content: saving it sets a new update time
+// and moving it to storage a new reference
+type flagKey struct {
+	id, ref   string
+	updatedAt int64
+}
+
+// rulesFingerprint sums up everything the scanner's verdict depends on
+// besides content: the rules and the hosts they trust
+func (s *Server) rulesFingerprint(rules []scanRule) string {
+	var b strings.Builder
+	b.WriteString(s.Hostname + "\n" + strings.Join(s.ScanTrustedHosts, ",") + "\n")
+	for _, rule := range rules {
+		b.WriteString(rule.id + "\x00" + rule.re.String() + "\n")
+	}
+	return b.String()
+}
+
+// scanFlags reports by ID which of scripts, listed without content, the
+// scanner flags. Only scripts changed since the last call are loaded.
+func (s *Server) scanFlags(ctx context.Context, q *queries, scripts []dbgen.Script) (map[string]bool, error) {
+	rules := scanRules(ctx, q)
+	fingerprint := s.rulesFingerprint(rules)
+	m := &s.flags
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	known := m.flagged
+	if m.rules != fingerprint {
+		known = nil
+	}
+	m.rules, m.flagged = fingerprint, make(map[flagKey]bool, len(scripts))
+
+	flags := make(map[string]bool, len(scripts))
+	for _, sc := range scripts {
+		key := flagKey{id: sc.ID, ref: derefStr(sc.ContentRef), updatedAt: sc.UpdatedAt.UnixNano()}
+		flagged, ok := known[key]
+		if !ok {
+			full, err := q.GetScript(ctx, sc.ID)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue // deleted since it was listed
+			} else if err != nil {
+				return nil, err
+			}
+			flagged = len(s.scanContent(rules, full.Content)) > 0
+		}
+		m.flagged[key] = flagged
+		flags[sc.ID] = flagged
+	}
+	return flags, nil
+}
+
+// ScanRuleRequest represents a request to add a scanner rule
+type ScanRuleRequest struct {
+	Pattern string `json:"pattern"`
+	Message string `json:"message"`
+}
+
+// ScanRuleResponse describes a scanner rule
+type ScanRuleResponse struct {
+	ID        string     `json:"id"`
+	Pattern   string     `json:"pattern"`
+	Message   string     `json:"message"`
+	Builtin   bool       `json:"builtin"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// APIListScanRules returns the built-in and admin-defined scanner rules
+func (s *Server) APIListScanRules(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	custom, err := q.ListScanRules(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list scan rules", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ScanRuleResponse, 0, len(builtinScanRules)+len(custom))
+	for _, rule := range builtinScanRules {
+		resp = append(resp, ScanRuleResponse{
+			ID:      rule.id,
+			Pattern: rule.re.String(),
+			Message: rule.message,
+			Builtin: true,
+		})
+	}
+	for _, c := range custom {
+		resp = append(resp, ScanRuleResponse{
+			ID:        c.ID,
+			Pattern:   c.Pattern,
+			Message:   c.Message,
+			CreatedAt: &c.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APICreateScanRule adds a scanner rule
+func (s *Server) APICreateScanRule(w http.ResponseWriter, r *http.Request) {
+	var req ScanRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if req.Pattern == "" {
+		http.Error(w, "Pattern is required", http.StatusBadRequest)
+		return
+	}
+	if _, err := regexp.Compile(req.Pattern); err != nil {
+		http.Error(w, "Invalid pattern: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+	q := s.queries()
+	err := q.CreateScanRule(r.Context(), dbgen.CreateScanRuleParams{
+		ID:        id,
+		Pattern:   req.Pattern,
+		Message:   req.Message,
+		CreatedAt: now,
+	})
+	if err != nil {
+		http.Error(w, "Failed to create scan rule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "CREATE",
+		EntityType: "scan_rule",
+		EntityID:   &id,
+		Details:    &req.Pattern,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ScanRuleResponse{
+		ID:        id,
+		Pattern:   req.Pattern,
+		Message:   req.Message,
+		CreatedAt: &now,
+	})
+}
+
+// APIDeleteScanRule removes an admin-defined scanner rule
+func (s *Server) APIDeleteScanRule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	rule, err := q.GetScanRule(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Scan rule not found", http.StatusNotFound)
+		return
+	}
+	if err := q.DeleteScanRule(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete scan rule", http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "DELETE",
+		EntityType: "scan_rule",
+		EntityID:   &id,
+		Details:    &rule.Pattern,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
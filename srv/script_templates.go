@@ -0,0 +1,334 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hunydev/sh-server/db"
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// placeholderPattern matches {{FIELD}} placeholders in template content
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// templateFields returns the distinct placeholder names used in content, sorted
+func templateFields(content string) []string {
+	seen := map[string]bool{}
+	fields := []string{}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			fields = append(fields, m[1])
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// renderTemplate fills placeholders from values and reports the fields left without a value
+func renderTemplate(content string, values map[string]string) (string, []string) {
+	var missing []string
+	seen := map[string]bool{}
+	out := placeholderPattern.ReplaceAllStringFunc(content, func(m string) string {
+		name := placeholderPattern.FindStringSubmatch(m)[1]
+		v, ok := values[name]
+		if !ok {
+			if !seen[name] {
+				seen[name] = true
+				missing = append(missing, name)
+			}
+			return m
+		}
+		return v
+	})
+	return out, missing
+}
+
+// TemplateResponse represents a script template in API responses
+type TemplateResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Content     string    `json:"content"`
+	Fields      []string  `json:"fields"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func templateToResponse(t dbgen.ScriptTemplate) TemplateResponse {
+	resp := TemplateResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		Content:   t.Content,
+		Fields:    templateFields(t.Content),
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+	if t.Description != nil {
+		resp.Description = *t.Description
+	}
+	return resp
+}
+
+// lookupTemplate finds a template by ID, falling back to its name
+func lookupTemplate(ctx context.Context, q *queries, ref string) (dbgen.ScriptTemplate, error) {
+	t, err := q.GetTemplate(ctx, ref)
+	if err == nil {
+		return t, nil
+	}
+	return q.GetTemplateByName(ctx, ref)
+}
+
+// APIListTemplates returns all script templates
+func (s *Server) APIListTemplates(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	templates, err := q.ListTemplates(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list templates", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]TemplateResponse, len(templates))
+	for i, t := range templates {
+		resp[i] = templateToResponse(t)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIGetTemplate returns a single template by ID or name
+func (s *Server) APIGetTemplate(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	t, err := lookupTemplate(r.Context(), q, r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templateToResponse(t))
+}
+
+// TemplateRequest represents a request to create or update a template
+type TemplateRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Content     string `json:"content"`
+}
+
+// APICreateTemplate creates a new script template
+func (s *Server) APICreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var req TemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "Template name is required", http.StatusBadRequest)
+		return
+	}
+	if !s.checkScriptSize(w, req.Content) {
+		return
+	}
+
+	now := time.Now()
+	id := uuid.New().String()
+
+	q := s.queries()
+	err := q.CreateTemplate(r.Context(), dbgen.CreateTemplateParams{
+		ID:          id,
+		Name:        req.Name,
+		Description: &req.Description,
+		Content:     req.Content,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			http.Error(w, "Template with this name already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "CREATE",
+		EntityType: "template",
+		EntityID:   &id,
+		EntityPath: &req.Name,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	t, _ := q.GetTemplate(r.Context(), id)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(templateToResponse(t))
+}
+
+// APIUpdateTemplate updates an existing template
+func (s *Server) APIUpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req TemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "Template name is required", http.StatusBadRequest)
+		return
+	}
+	if !s.checkScriptSize(w, req.Content) {
+		return
+	}
+
+	q := s.queries()
+	if _, err := q.GetTemplate(r.Context(), id); err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	if err := q.UpdateTemplate(r.Context(), dbgen.UpdateTemplateParams{
+		Name:        req.Name,
+		Description: &req.Description,
+		Content:     req.Content,
+		UpdatedAt:   now,
+		ID:          id,
+	}); err != nil {
+		http.Error(w, "Failed to update template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "UPDATE",
+		EntityType: "template",
+		EntityID:   &id,
+		EntityPath: &req.Name,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	t, _ := q.GetTemplate(r.Context(), id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templateToResponse(t))
+}
+
+// APIDeleteTemplate deletes a template
+func (s *Server) APIDeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	t, err := q.GetTemplate(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	if err := q.DeleteTemplate(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete template", http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "DELETE",
+		EntityType: "template",
+		EntityID:   &id,
+		EntityPath: &t.Name,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FromTemplateRequest represents a request to instantiate a template as a new script.
+// Content is ignored; it is rendered from the template and Values.
+type FromTemplateRequest struct {
+	CreateScriptRequest
+	Template string            `json:"template"` // template ID or name
+	Values   map[string]string `json:"values"`
+}
+
+// APICreateFromTemplate renders a template with the given values and stores it at a path.
+// SCRIPT_PATH, SCRIPT_NAME and HOSTNAME are filled in automatically unless provided.
+func (s *Server) APICreateFromTemplate(w http.ResponseWriter, r *http.Request) {
+	var req FromTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+
+	if err := validatePath(req.Path); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := s.queries()
+	t, err := lookupTemplate(r.Context(), q, req.Template)
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	// On a virtual host the script is fetched there, by its path on the host
+	host, path := virtualHost(r.Context()).locate(s.Hostname, req.Path)
+	values := map[string]string{
+		"SCRIPT_PATH": path,
+		"SCRIPT_NAME": extractName(req.Path),
+		"HOSTNAME":    host,
+	}
+	if req.Description != "" {
+		values["DESCRIPTION"] = req.Description
+	}
+	for k, v := range req.Values {
+		values[k] = v
+	}
+
+	content, missing := renderTemplate(t.Content, values)
+	if len(missing) > 0 {
+		http.Error(w, "Missing template values: "+strings.Join(missing, ", "), http.StatusBadRequest)
+		return
+	}
+	if !s.checkScriptSize(w, content) {
+		return
+	}
+
+	req.Content = content
+	if req.Description == "" && t.Description != nil {
+		req.Description = *t.Description
+	}
+
+	script, err := s.createScript(r.Context(), req.CreateScriptRequest)
+	if err != nil {
+		if errors.Is(err, errPathExists) {
+			http.Error(w, "Script with this path already exists", http.StatusConflict)
+			return
+		}
+		if writePreSaveError(w, err) || writeInstanceLimitError(w, err) {
+			return
+		}
+		http.Error(w, "Failed to create script: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(scriptToResponse(script))
+}
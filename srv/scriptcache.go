@@ -0,0 +1,204 @@
+package srv
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// ScriptCacheConfig keeps recently fetched scripts in memory so a popular
+// path doesn't cost a database query per request
+type ScriptCacheConfig struct {
+	Size int           // scripts kept; 0 disables the cache
+	TTL  time.Duration // bounds staleness when other servers share the database
+}
+
+// scriptCache is an LRU of scripts by path. Every write to the scripts
+// table empties it, so it never serves a script older than the last save
+// made by this server.
+type scriptCache struct {
+	size int
+
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+	gen     uint64     // bumped by invalidate
+
+	hits, misses uint64
+}
+
+type scriptCacheEntry struct {
+	path    string
+	script  dbgen.Script
+	expires time.Time
+}
+
+func newScriptCache(cfg ScriptCacheConfig) *scriptCache {
+	return &scriptCache{size: cfg.Size, ttl: cfg.TTL, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// get returns the cached script at path and the generation to pass to put
+// after loading it on a miss
+func (c *scriptCache) get(path string) (dbgen.Script, bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[path]; ok {
+		e := el.Value.(*scriptCacheEntry)
+		if c.ttl <= 0 || time.Now().Before(e.expires) {
+			c.order.MoveToFront(el)
+			c.hits++
+			return e.script, true, c.gen
+		}
+		c.order.Remove(el)
+		delete(c.entries, path)
+	}
+	c.misses++
+	return dbgen.Script{}, false, c.gen
+}
+
+// put caches a script loaded at generation gen, unless the cache was
+// invalidated since, as the script may then be stale
+func (c *scriptCache) put(gen uint64, script dbgen.Script) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	e := &scriptCacheEntry{path: script.Path, script: script, expires: time.Now().Add(c.ttl)}
+	if el, ok := c.entries[script.Path]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[script.Path] = c.order.PushFront(e)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*scriptCacheEntry).path)
+	}
+}
+
+// ScriptCacheStats shows how well the script cache works
+type ScriptCacheStats struct {
+	Entries int    `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+}
+
+func (c *scriptCache) stats() *ScriptCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &ScriptCacheStats{Entries: c.order.Len(), Hits: c.hits, Misses: c.misses}
+}
+
+func (c *scriptCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	clear(c.entries)
+	c.order.Init()
+}
+
+// setTTL changes how long scripts are kept, dropping those cached under
+// the old one, and reports whether it changed
+func (c *scriptCache) setTTL(ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ttl == c.ttl {
+		return false
+	}
+	c.ttl = ttl
+	c.gen++
+	clear(c.entries)
+	c.order.Init()
+	return true
+}
+
+// writtenTable matches statements that change a table and captures its name
+var writtenTable = regexp.MustCompile(`(?im)^\s*(?:UPDATE|INSERT(?:\s+OR\s+\w+)?\s+INTO|DELETE\s+FROM)\s+(\w+)`)
+
+// invalidatingConn reports every statement that writes a table, whichever
+// query or code path it comes from, so caches built from the table can be
+// emptied
+type invalidatingConn struct {
+	dbgen.DBTX
+	onWrite func(table string)
+	tables  sync.Map // query -> table it writes, or ""
+}
+
+// table returns the table query writes, or ""
+func (c *invalidatingConn) table(query string) string {
+	if table, ok := c.tables.Load(query); ok {
+		return table.(string)
+	}
+	table := ""
+	if m := writtenTable.FindStringSubmatch(query); m != nil {
+		table = strings.ToLower(m[1])
+	}
+	c.tables.Store(query, table)
+	return table
+}
+
+func (c *invalidatingConn) after(query string) {
+	if table := c.table(query); table != "" {
+		c.onWrite(table)
+	}
+}
+
+// PrepareContext leaves writes unprepared: dbgen runs a query without a
+// prepared statement through ExecContext or QueryContext, where the write
+// is seen
+func (c *invalidatingConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if c.table(query) != "" {
+		return nil, nil
+	}
+	return c.DBTX.PrepareContext(ctx, query)
+}
+
+func (c *invalidatingConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	res, err := c.DBTX.ExecContext(ctx, query, args...)
+	c.after(query)
+	return res, err
+}
+
+func (c *invalidatingConn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	rows, err := c.DBTX.QueryContext(ctx, query, args...)
+	c.after(query)
+	return rows, err
+}
+
+func (c *invalidatingConn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	row := c.DBTX.QueryRowContext(ctx, query, args...)
+	c.after(query)
+	return row
+}
+
+// tableWritten empties the caches built from table
+func (s *Server) tableWritten(table string) {
+	switch table {
+	case "scripts":
+		s.invalidateCaches()
+	case "folders", "scan_rules":
+		if s.responses != nil {
+			s.responses.invalidate()
+		}
+	}
+}
+
+// invalidateCaches empties every cache of database content, as after the
+// whole database is replaced
+func (s *Server) invalidateCaches() {
+	if s.scriptCache != nil {
+		s.scriptCache.invalidate()
+	}
+	if s.responses != nil {
+		s.responses.invalidate()
+	}
+}
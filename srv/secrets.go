@@ -0,0 +1,114 @@
+package srv
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// secretScanRules detect credentials that should never be published in a
+// script. Matches are redacted before they are reported.
+var secretScanRules = []scanRule{
+	{
+		id:       "secret-aws-access-key",
+		message:  "AWS access key ID",
+		re:       regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+		comments: true,
+	},
+	{
+		id:       "secret-aws-secret-key",
+		message:  "AWS secret access key",
+		re:       regexp.MustCompile(`(?i)aws_secret_access_key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`),
+		comments: true,
+	},
+	{
+		id:       "secret-private-key",
+		message:  "Private key block",
+		re:       regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )*PRIVATE KEY( BLOCK)?-----`),
+		comments: true,
+	},
+	{
+		id:       "secret-bearer-token",
+		message:  "Hardcoded bearer token",
+		re:       regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{20,}=*`),
+		comments: true,
+	},
+	{
+		id:       "secret-github-token",
+		message:  "GitHub token",
+		re:       regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),
+		comments: true,
+	},
+	{
+		id:       "secret-slack-token",
+		message:  "Slack token",
+		re:       regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+		comments: true,
+	},
+	{
+		id:       "secret-assignment",
+		message:  "Hardcoded password or API key",
+		re:       regexp.MustCompile(`(?i)\b\w*(?:password|passwd|secret|api_?key|access_?token)\w*\s*=\s*["']?[^\s"'$]{8,}`),
+		comments: true,
+	},
+}
+
+// scanSecrets returns a warning for each likely credential in content, with
+// the credential itself redacted. With SECRET_SCAN=off nothing is reported.
+func (s *Server) scanSecrets(content string) []ScanWarning {
+	if s.SecretScan == "off" {
+		return nil
+	}
+	found := s.scanContent(secretScanRules, content)
+	for i := range found {
+		found[i].Match = redactSecret(found[i].Match)
+	}
+	return found
+}
+
+// redactSecret keeps just enough of a match to locate it
+func redactSecret(match string) string {
+	if len(match) <= 8 {
+		return strings.Repeat("*", len(match))
+	}
+	return match[:8] + strings.Repeat("*", min(len(match)-8, 16))
+}
+
+// checkSecrets audits credentials found in content being saved to path and,
+// with SECRET_SCAN=reject, refuses the save. It reports whether the save may
+// continue.
+func (s *Server) checkSecrets(w http.ResponseWriter, r *http.Request, q *queries, id *string, path string, found []ScanWarning) bool {
+	if len(found) == 0 {
+		return true
+	}
+	reject := s.SecretScan == "reject"
+
+	lines := make([]string, len(found))
+	for i, f := range found {
+		lines[i] = fmt.Sprintf("line %d: %s (%s)", f.Line, f.Message, f.Match)
+	}
+	details := strings.Join(lines, "; ")
+	if reject {
+		details = "rejected: " + details
+	}
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "SECRET_DETECTED",
+		EntityType: "script",
+		EntityID:   id,
+		EntityPath: &path,
+		Details:    &details,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	if reject {
+		rejectContent(w, http.StatusUnprocessableEntity, "Content appears to contain secrets", lines)
+		return false
+	}
+	return true
+}
@@ -1,24 +1,32 @@
 package srv
 
 import (
+	"context"
+	"crypto/x509"
 	"database/sql"
 	"embed"
 	"encoding/json"
 	"fmt"
 	"io/fs"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/ssh"
 
+	"github.com/hunydev/sh-server/adminpb"
 	"github.com/hunydev/sh-server/db"
 	"github.com/hunydev/sh-server/db/dbgen"
+	"github.com/hunydev/sh-server/storage"
 )
 
 //go:embed static/*
@@ -28,40 +36,433 @@ var staticFS embed.FS
 var templatesFS embed.FS
 
 type Server struct {
-	DB         *sql.DB
-	Hostname   string
-	AdminToken string
+	DB             *sql.DB // on SQLite, the one connection writes go through
+	readDB         *sql.DB // read-only pool; DB itself on PostgreSQL
+	dialect        db.Dialect
+	conn           dbgen.DBTX // DB, translated for its dialect
+	Hostname       string
+	AdminToken     string
+	ArchiveExpired bool
+	OIDC           *oidcProvider // nil unless an OIDC issuer is configured
+	TrustedHeader  TrustedHeaderConfig
+	TrustedProxies []*net.IPNet // X-Forwarded-*, X-Real-IP and TRUSTED_HEADER are only believed from these
+	VirtualHosts   []VirtualHost
+	TLS            TLSConfig
+	Passwords      PasswordConfig
+	UnlockTTL      time.Duration // default unlock token lifetime
+	UnlockMaxTTL   time.Duration // longest lifetime a script or client may ask for
+	UnlockBindIP   bool          // unlock tokens only work from the IP they were issued to
+	UnlockBindUA   bool          // unlock tokens only work with the User-Agent they were issued to
+	SigningKey     string        // HMAC key for signed script URLs; empty disables them
+	GeoIP          GeoIPConfig
+	
+	// ScanTrustedHosts may be piped into a shell without a scanner warning
+	ScanTrustedHosts []string
+	
+	// SecretScan decides what happens when saved content looks like it
+	// contains credentials: "warn" (default), "reject" or "off"
+	SecretScan string
+	
+	// AuditRetention prunes old audit rows in the background
+	AuditRetention AuditRetentionConfig
+	
+	// HoneypotAlertURL receives a JSON POST whenever a honeypot path is fetched
+	HoneypotAlertURL string
+	
+	// ValidateSyntax refuses saves without a recognized shebang or that
+	// fail to parse with "sh -n"
+	ValidateSyntax bool
+	
+	// ShellCheckPath is the shellcheck binary used by /api/lint; with
+	// LintBlockErrors, saves with error-level findings are refused
+	ShellCheckPath  string
+	LintBlockErrors bool
+	
+	// UnlockPoWDifficulty makes every unlock attempt solve a hashcash
+	// challenge with this many leading zero hex digits; 0 disables it
+	UnlockPoWDifficulty int
+	
+	// BasicAuthChallenge answers unauthenticated admin requests with a
+	// WWW-Authenticate header so browsers show a login prompt
+	BasicAuthChallenge bool
+	
+	// AccessLog records every script fetch in the access_log table
+	AccessLog bool
+	
+	// IPAnonymize hashes or truncates client IPs before they are stored
+	IPAnonymize IPAnonymizeConfig
+	
+	// Digest posts a usage digest to a URL every day or week
+	Digest DigestConfig
+	
+	// PreSaveHook and PostSaveHook are executables run with the script path
+	// and a file holding the content; a failing pre-save hook refuses the save
+	PreSaveHook  string
+	PostSaveHook string
+	
+	// GitHubSync mirrors scripts from a GitHub repository
+	GitHubSync GitHubSyncConfig
+	
+	// GitMirror commits every script change to a local git repository
+	GitMirror GitMirrorConfig
+	
+	// SSH serves the script tree over SFTP and exec commands
+	SSH SSHConfig
+	
+	// GitHTTPDir holds the read-only repository of the public scripts
+	// served at /repo.git; empty disables it
+	GitHTTPDir string
+	
+	// FSImport imports directory trees of scripts from the filesystem
+	FSImport FSImportConfig
+	
+	// FSSync keeps the scripts under a prefix in line with a directory
+	FSSync FSSyncConfig
+	
+	// Replica makes the server a read-only copy of another sh-server
+	Replica ReplicaConfig
+	
+	// Backups snapshots the database on a schedule
+	Backups BackupConfig
+	
+	// Maintenance vacuums the database on a schedule
+	Maintenance MaintenanceConfig
+	
+	// Limits caps the request bodies and scripts clients may send
+	Limits LimitsConfig
+	
+	// InstanceLimits caps the scripts, versions and content the server holds
+	InstanceLimits InstanceLimitsConfig
+	
+	// ShutdownTimeout bounds how long Serve waits for requests in flight
+	// once its context is done; 0 waits for them all
+	ShutdownTimeout time.Duration
+	
+	// HTTP bounds slow and idle clients
+	HTTP HTTPConfig
+	
+	// SecurityHeaders are sent with every response but raw scripts
+	SecurityHeaders SecurityHeadersConfig
+	
+	scriptCache *scriptCache   // nil unless enabled
+	responses   *responseCache // of the catalog and tree; nil unless enabled
+	writes      *writeQueue    // nil unless enabled
+	prepared    *dbgen.Queries // every query, prepared once at startup
+	flags       flagMemo       // of the catalog
+	
+	clientCAs   *x509.CertPool
+	authFails   *authFailLogger
+	ipSalt      []byte
+	unlockLimit *unlockLimiter
+	rateLimits  rateLimits // per client IP
+	quotas      downloadQuotas
+	pow         *powChallenges
+	geoip       *mmdbReader
+	mirror      *gitMirror
+	sshConfig   *ssh.ServerConfig
+	gitHTTP     *gitHTTPRepo
+	replica     *replicaState
+	backups     backupStore
+	maintenance maintenanceState
+	
+	githubSyncMu  sync.Mutex      // one GitHub sync at a time
+	sourceFetches sync.Map        // proxied script ID -> *sourceFetch
+	store         storage.Backend // script content; nil keeps it in the scripts table
+	compress      bool            // zstd-compress content put in store
+	watchers      watchers        // gRPC Watch streams
+	
+	reload    func() (ReloadConfig, error) // nil unless reloading is configured
+	reloading sync.Mutex                   // one Reload at a time
+	reloadMu  sync.RWMutex                 // guards the exported fields Reload changes
 }
 
 type Config struct {
-	DBPath     string
-	Hostname   string
-	AdminToken string
+	DBPath         string
+	DatabaseURL    string // PostgreSQL connection string; empty uses the SQLite file at DBPath
+	SQLite         db.Options
+	Hostname       string
+	AdminToken     string
+	ArchiveExpired bool // periodically archive scripts past expires_at
+	OIDC           OIDCConfig
+	TrustedHeader  TrustedHeaderConfig
+	TrustedProxies string // comma-separated CIDRs or IPs of reverse proxies
+	VirtualHosts   string // comma-separated host=/folder pairs
+	TLS            TLSConfig
+	UnlockLimit    UnlockLimitConfig
+	Passwords      PasswordConfig
+	UnlockTTL      time.Duration
+	UnlockMaxTTL   time.Duration
+	UnlockBindIP   bool
+	UnlockBindUA   bool
+	SigningKey     string
+	GeoIP          GeoIPConfig
+	
+	ScanTrustedHosts    []string
+	SecretScan          string
+	AuditRetention      AuditRetentionConfig
+	HoneypotAlertURL    string
+	ValidateSyntax      bool
+	ShellCheckPath      string
+	LintBlockErrors     bool
+	UnlockPoWDifficulty int
+	BasicAuthChallenge  bool
+	AccessLog           bool
+	AuthFailLog         string // file for fail2ban-style auth failure lines; "-" is stderr
+	IPAnonymize         IPAnonymizeConfig
+	Digest              DigestConfig
+	PreSaveHook         string
+	PostSaveHook        string
+	GitHubSync          GitHubSyncConfig
+	GitMirror           GitMirrorConfig
+	SSH                 SSHConfig
+	GitHTTPDir          string
+	FSImport            FSImportConfig
+	FSSync              FSSyncConfig
+	Replica             ReplicaConfig
+	Storage             StorageConfig
+	Backups             BackupConfig
+	Maintenance         MaintenanceConfig
+	ScriptCache         ScriptCacheConfig
+	CatalogCacheTTL     time.Duration // 0 disables caching the catalog and tree
+	WriteQueue          WriteQueueConfig
+	Limits              LimitsConfig
+	InstanceLimits      InstanceLimitsConfig
+	ShutdownTimeout     time.Duration
+	HTTP                HTTPConfig
+	SecurityHeaders     SecurityHeadersConfig
+	RateLimits          RateLimitConfig
+	
+	// Reload reads the settings again for SIGHUP and POST /api/reload;
+	// nil disables reloading
+	Reload func() (ReloadConfig, error)
 }
 
 func New(cfg Config) (*Server, error) {
 	srv := &Server{
-		Hostname:   cfg.Hostname,
-		AdminToken: cfg.AdminToken,
+		Hostname:       cfg.Hostname,
+		AdminToken:     cfg.AdminToken,
+		ArchiveExpired: cfg.ArchiveExpired,
+		TrustedHeader:  cfg.TrustedHeader,
+		TLS:            cfg.TLS,
+		Passwords:      cfg.Passwords,
+		UnlockTTL:      cfg.UnlockTTL,
+		UnlockMaxTTL:   cfg.UnlockMaxTTL,
+		UnlockBindIP:   cfg.UnlockBindIP,
+		UnlockBindUA:   cfg.UnlockBindUA,
+		SigningKey:     cfg.SigningKey,
+		GeoIP:          cfg.GeoIP,
+		
+		ScanTrustedHosts:    cfg.ScanTrustedHosts,
+		SecretScan:          cfg.SecretScan,
+		AuditRetention:      cfg.AuditRetention,
+		HoneypotAlertURL:    cfg.HoneypotAlertURL,
+		ValidateSyntax:      cfg.ValidateSyntax,
+		ShellCheckPath:      cfg.ShellCheckPath,
+		LintBlockErrors:     cfg.LintBlockErrors,
+		UnlockPoWDifficulty: cfg.UnlockPoWDifficulty,
+		BasicAuthChallenge:  cfg.BasicAuthChallenge,
+		AccessLog:           cfg.AccessLog,
+		IPAnonymize:         cfg.IPAnonymize,
+		Digest:              cfg.Digest,
+		PreSaveHook:         cfg.PreSaveHook,
+		PostSaveHook:        cfg.PostSaveHook,
+		GitHubSync:          cfg.GitHubSync,
+		GitMirror:           cfg.GitMirror,
+		SSH:                 cfg.SSH,
+		GitHTTPDir:          cfg.GitHTTPDir,
+		FSImport:            cfg.FSImport,
+		FSSync:              cfg.FSSync,
+		Replica:             cfg.Replica,
+		Backups:             cfg.Backups,
+		Maintenance:         cfg.Maintenance,
+		Limits:              cfg.Limits.withDefaults(),
+		InstanceLimits:      cfg.InstanceLimits,
+		ShutdownTimeout:     cfg.ShutdownTimeout,
+		HTTP:                cfg.HTTP.withDefaults(),
+		SecurityHeaders:     cfg.SecurityHeaders.withDefaults(),
+		reload:              cfg.Reload,
+		ipSalt:              newIPSalt(cfg.IPAnonymize.Salt),
 	}
-	if err := srv.setUpDatabase(cfg.DBPath); err != nil {
+	if srv.Limits.MaxRequestBody < srv.Limits.MaxScriptSize {
+		return nil, fmt.Errorf("the request body limit must be at least the maximum script size")
+	}
+	if err := cfg.InstanceLimits.validate(); err != nil {
 		return nil, err
 	}
+	if cfg.GitHubSync.Repo != "" && !githubRepoPattern.MatchString(cfg.GitHubSync.Repo) {
+		return nil, fmt.Errorf("GitHub sync repo must be in owner/name form")
+	}
+	if cfg.Replica.Upstream != "" {
+		if u, err := url.Parse(cfg.Replica.Upstream); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("replica upstream must be an http or https URL")
+		}
+		srv.replica = newReplicaState(cfg.Replica.Upstream)
+	}
+	proxies, err := parseCIDRs(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+	srv.TrustedProxies = proxies
+	if srv.VirtualHosts, err = parseVirtualHosts(cfg.VirtualHosts); err != nil {
+		return nil, fmt.Errorf("virtual hosts: %w", err)
+	}
+	if cfg.UnlockPoWDifficulty > 0 {
+		srv.pow = newPoWChallenges(cfg.UnlockPoWDifficulty)
+	}
+	// Even with no limits, so a reload can set some
+	srv.unlockLimit = newUnlockLimiter(cfg.UnlockLimit)
+	srv.rateLimits = newRateLimits(cfg.RateLimits)
+	if cfg.GeoIP.DBFile != "" {
+		allow, err := parseCountries(strings.Join(cfg.GeoIP.Allow, ","))
+		if err != nil {
+			return nil, fmt.Errorf("GeoIP allow list: %w", err)
+		}
+		deny, err := parseCountries(strings.Join(cfg.GeoIP.Deny, ","))
+		if err != nil {
+			return nil, fmt.Errorf("GeoIP deny list: %w", err)
+		}
+		srv.GeoIP.Allow, srv.GeoIP.Deny = allow, deny
+		geoip, err := openMMDB(cfg.GeoIP.DBFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+		}
+		srv.geoip = geoip
+	}
+	if cfg.AuthFailLog != "" {
+		authFails, err := openAuthFailLog(cfg.AuthFailLog)
+		if err != nil {
+			return nil, err
+		}
+		srv.authFails = authFails
+	}
+	if cfg.TLS.ClientCAFile != "" {
+		pool, err := loadClientCAs(cfg.TLS.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		srv.clientCAs = pool
+	}
+	if cfg.OIDC.Issuer != "" {
+		srv.OIDC = newOIDCProvider(cfg.OIDC, cfg.Hostname)
+	}
+	if err := srv.setUpDatabase(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.ScriptCache.Size > 0 {
+		srv.scriptCache = newScriptCache(cfg.ScriptCache)
+	}
+	if cfg.CatalogCacheTTL > 0 {
+		srv.responses = newResponseCache(cfg.CatalogCacheTTL)
+	}
+	if srv.scriptCache != nil || srv.responses != nil {
+		srv.conn = &invalidatingConn{DBTX: srv.conn, onWrite: srv.tableWritten}
+	}
+	prepared, err := dbgen.Prepare(context.Background(), srv.conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare queries: %w", err)
+	}
+	srv.prepared = prepared
+	if cfg.WriteQueue.Size > 0 {
+		srv.writes = newWriteQueue(srv, cfg.WriteQueue)
+	}
+	store, err := openStorage(cfg.Storage, srv.conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage: %w", err)
+	}
+	srv.store = store
+	srv.compress = cfg.Storage.Compression == "zstd"
+	if err := srv.setUpStorage(context.Background()); err != nil {
+		return nil, err
+	}
+	if cfg.Backups.Interval > 0 {
+		if srv.dialect != db.SQLite {
+			return nil, db.ErrBackupUnsupported
+		}
+		backups, err := openBackupStore(cfg.Backups)
+		if err != nil {
+			return nil, err
+		}
+		srv.backups = backups
+	}
+	if cfg.GitMirror.Dir != "" {
+		mirror, err := openGitMirror(context.Background(), srv.queries(), cfg.GitMirror)
+		if err != nil {
+			return nil, err
+		}
+		srv.mirror = mirror
+	}
+	if cfg.SSH.Addr != "" {
+		sshConfig, err := sshServerConfig(cfg.SSH)
+		if err != nil {
+			return nil, err
+		}
+		srv.sshConfig = sshConfig
+	}
+	if cfg.GitHTTPDir != "" {
+		gitHTTP, err := openGitHTTPRepo(cfg.GitHTTPDir)
+		if err != nil {
+			return nil, err
+		}
+		srv.gitHTTP = gitHTTP
+	}
 	return srv, nil
 }
 
-func (s *Server) setUpDatabase(dbPath string) error {
-	wdb, err := db.Open(dbPath)
+// setUpDatabase opens the PostgreSQL database at cfg.DatabaseURL or,
+// without one, the SQLite file at cfg.DBPath, and migrates it. SQLite is
+// opened twice: DB is a single connection all writes go through, and
+// readDB a pool of read-only connections.
+func (s *Server) setUpDatabase(cfg Config) error {
+	if cfg.DatabaseURL != "" {
+		pdb, err := db.OpenPostgres(cfg.DatabaseURL)
+		if err != nil {
+			return fmt.Errorf("failed to open db: %w", err)
+		}
+		s.DB, s.readDB, s.dialect = pdb, pdb, db.Postgres
+		s.conn = db.Conn(pdb, s.dialect)
+		if err := db.RunPostgresMigrations(pdb); err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+		return nil
+	}
+	
+	wdb, err := db.Open(cfg.DBPath, db.Options{BusyTimeout: cfg.SQLite.BusyTimeout, MaxOpenConns: 1})
 	if err != nil {
 		return fmt.Errorf("failed to open db: %w", err)
 	}
-	s.DB = wdb
+	s.DB, s.dialect = wdb, db.SQLite
 	if err := db.RunMigrations(wdb); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
+	rdb, err := db.OpenReader(cfg.DBPath, cfg.SQLite)
+	if err != nil {
+		return fmt.Errorf("failed to open db for reading: %w", err)
+	}
+	s.readDB = rdb
+	s.conn = db.Split(rdb, wdb)
 	return nil
 }
 
+// Close writes what is left in the write queue and closes the database
+func (s *Server) Close() error {
+	if s.writes != nil {
+		s.writes.close()
+	}
+	s.prepared.Close()
+	if s.readDB != s.DB {
+		s.readDB.Close()
+	}
+	return s.DB.Close()
+}
+
+// flushWrites waits until the audit and analytics writes queued so far are
+// written
+func (s *Server) flushWrites() {
+	if s.writes != nil {
+		s.writes.flush()
+	}
+}
+
 // isCLI checks if the request is from a CLI tool (curl, wget, etc)
 func isCLI(r *http.Request) bool {
 	ua := strings.ToLower(r.Header.Get("User-Agent"))
@@ -91,8 +492,8 @@ func (s *Server) HandleRoot(w http.ResponseWriter, r *http.Request) {
 	if isCLI(r) {
 		// CLI response: 2 lines
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		fmt.Fprintf(w, "curl -fsSL https://%s/help.sh | sh\n", s.Hostname)
-		fmt.Fprintf(w, "curl -fsSL https://%s/search.sh | sh\n", s.Hostname)
+		fmt.Fprintf(w, "curl -fsSL https://%s/help.sh | sh\n", s.hostname(r))
+		fmt.Fprintf(w, "curl -fsSL https://%s/search.sh | sh\n", s.hostname(r))
 		return
 	}
 	
@@ -112,6 +513,7 @@ func (s *Server) serveHTML(w http.ResponseWriter, r *http.Request) {
 
 // HandleHelp serves the help.sh script
 func (s *Server) HandleHelp(w http.ResponseWriter, r *http.Request) {
+	host := s.hostname(r)
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.Header().Set("Cache-Control", "max-age=300")
 	fmt.Fprintf(w, `#!/bin/sh
@@ -139,10 +541,13 @@ After installing 'shs' alias:
   shs tools/sysinfo           # Run tools/sysinfo.sh
   shs uninstall               # Remove shs alias
 
+Libraries (shared functions under /lib):
+  eval "$(curl -fsSL https://%s/lib/<name>.sh)"
+
 Browse scripts at: https://%s
 
 EOF
-`, s.Hostname, s.Hostname, s.Hostname, s.Hostname, s.Hostname, s.Hostname, s.Hostname)
+`, host, host, host, host, host, host, host, host)
 }
 
 // HandleSearch serves the search.sh TUI script
@@ -167,16 +572,47 @@ has_cmd() {
     command -v "$1" >/dev/null 2>&1
 }
 
-# Get all script paths from catalog
+# Get all runnable script paths from catalog (libraries under /lib are sourced, not run)
 get_all_paths() {
-    echo "$CATALOG" | sed 's/},{/}\n{/g' | grep -o '"path":"[^"]*"' | sed 's/"path":"\([^"]*\)"/\1/' | sort
+    echo "$CATALOG" | sed 's/},{/}\n{/g' | grep -v '"expired":true' | grep -o '"path":"[^"]*"' | sed 's/"path":"\([^"]*\)"/\1/' | grep -v '^/lib/' | sort
+}
+
+# Get script paths from /_popular.json or /_recent.json
+get_list_paths() {
+    curl -fsSL "${BASE_URL}/_$1.json" 2>/dev/null | sed 's/},{/}\n{/g' | grep -o '"path":"[^"]*"' | sed 's/"path":"\([^"]*\)"/\1/'
+}
+
+# Full path of a script picked in the current view
+script_path() {
+    case "$CURRENT_PATH" in
+        /) echo "/$1" ;;
+        @*) echo "$1" ;;
+        *) echo "$CURRENT_PATH/$1" ;;
+    esac
+}
+
+# Get deprecated script paths from catalog
+get_deprecated_paths() {
+    echo "$CATALOG" | sed 's/},{/}\n{/g' | grep '"deprecated":true' | grep -o '"path":"[^"]*"' | sed 's/"path":"\([^"]*\)"/\1/'
 }
 
 # Get items (folders and scripts) in current path
 # Returns: folder names (with /) and script names for current directory only
 get_current_items() {
     _cur_path="$1"
+    
+    # Popular and recently updated views list full paths
+    case "$_cur_path" in
+        @popular|@recent)
+            for _path in $(get_list_paths "${_cur_path#@}"); do
+                echo "📄 $_path"
+            done
+            return
+            ;;
+    esac
+    
     _all_paths=$(get_all_paths)
+    _deprecated=" "$(echo $(get_deprecated_paths))" "
     
     # Normalize current path
     if [ "$_cur_path" = "/" ]; then
@@ -207,20 +643,30 @@ get_current_items() {
                         esac
                         ;;
                     *)
-                        # Direct child script
-                        _scripts="$_scripts $_remainder"
+                        # Direct child script (deprecated ones are flagged with ⚠️)
+                        case "$_deprecated" in
+                            *" $_path "*) _scripts="$_scripts !$_remainder" ;;
+                            *) _scripts="$_scripts $_remainder" ;;
+                        esac
                         ;;
                 esac
                 ;;
         esac
     done
     
-    # Output folders first (with / suffix), then scripts
+    # Output shortcuts and folders first (with / suffix), then scripts
+    if [ "$_cur_path" = "/" ]; then
+        echo "🔥 popular/"
+        echo "🆕 recent/"
+    fi
     for _f in $_folders; do
         [ -n "$_f" ] && echo "📁 $_f/"
     done
     for _s in $_scripts; do
-        [ -n "$_s" ] && echo "📄 $_s"
+        case "$_s" in
+            !*) echo "⚠️ ${_s#!}" ;;
+            ?*) echo "📄 $_s" ;;
+        esac
     done
 }
 
@@ -259,13 +705,17 @@ browse_fzf() {
                     echo "📁 Folder - press Enter to navigate"
                 elif echo "$item" | grep -q "^\\.\\./\\|^⬆️"; then
                     echo "⬆️  Go to parent folder"
+                elif echo "$item" | grep -q "^🔥"; then
+                    echo "🔥 Most fetched scripts of the last 30 days"
+                elif echo "$item" | grep -q "^🆕"; then
+                    echo "🆕 Recently updated scripts"
                 else
-                    name=$(echo "$item" | sed "s/^📄 //")
-                    if [ "$cur" = "/" ]; then
-                        path="/${name}"
-                    else
-                        path="${cur}/${name}"
-                    fi
+                    name=$(echo "$item" | sed "s/^📄 //;s/^⚠️ //")
+                    case "$cur" in
+                        /) path="/${name}" ;;
+                        @*) path="${name}" ;;
+                        *) path="${cur}/${name}" ;;
+                    esac
                     curl -fsSL "${base_url}${path}?preview=1" 2>/dev/null || echo "Preview not available"
                 fi
             ')
@@ -279,6 +729,12 @@ browse_fzf() {
                 CURRENT_PATH=$(dirname "$CURRENT_PATH")
                 [ "$CURRENT_PATH" = "." ] && CURRENT_PATH="/"
                 ;;
+            "🔥 "*)
+                CURRENT_PATH="@popular"
+                ;;
+            "🆕 "*)
+                CURRENT_PATH="@recent"
+                ;;
             "📁 "*)
                 # Enter folder
                 FOLDER=$(echo "$SELECTED" | sed 's/^📁 //' | sed 's/\/$//')
@@ -288,14 +744,10 @@ browse_fzf() {
                     CURRENT_PATH="$CURRENT_PATH/$FOLDER"
                 fi
                 ;;
-            "📄 "*)
+            "📄 "*|"⚠️ "*)
                 # Run script
-                SCRIPT=$(echo "$SELECTED" | sed 's/^📄 //')
-                if [ "$CURRENT_PATH" = "/" ]; then
-                    SCRIPT_PATH="/$SCRIPT"
-                else
-                    SCRIPT_PATH="$CURRENT_PATH/$SCRIPT"
-                fi
+                SCRIPT=$(echo "$SELECTED" | sed 's/^📄 //;s/^⚠️ //')
+                SCRIPT_PATH=$(script_path "$SCRIPT")
                 echo ""
                 echo "Running: ${BASE_URL}${SCRIPT_PATH}"
                 echo ""
@@ -356,6 +808,12 @@ browse_dialog() {
         SELECTED=$(echo "$ITEMS" | sed -n "${CHOICE}p")
         
         case "$SELECTED" in
+            "🔥 "*)
+                CURRENT_PATH="@popular"
+                ;;
+            "🆕 "*)
+                CURRENT_PATH="@recent"
+                ;;
             "📁 "*)
                 # Enter folder
                 FOLDER=$(echo "$SELECTED" | sed 's/^📁 //' | sed 's/\/$//')
@@ -365,14 +823,10 @@ browse_dialog() {
                     CURRENT_PATH="$CURRENT_PATH/$FOLDER"
                 fi
                 ;;
-            "📄 "*)
+            "📄 "*|"⚠️ "*)
                 # Run script
-                SCRIPT=$(echo "$SELECTED" | sed 's/^📄 //')
-                if [ "$CURRENT_PATH" = "/" ]; then
-                    SCRIPT_PATH="/$SCRIPT"
-                else
-                    SCRIPT_PATH="$CURRENT_PATH/$SCRIPT"
-                fi
+                SCRIPT=$(echo "$SELECTED" | sed 's/^📄 //;s/^⚠️ //')
+                SCRIPT_PATH=$(script_path "$SCRIPT")
                 clear
                 echo "Running: ${BASE_URL}${SCRIPT_PATH}"
                 echo ""
@@ -421,7 +875,7 @@ browse_fallback() {
         echo ""
         echo "   0) Exit"
         echo ""
-        printf "Select [0-%d or ..]: " "$ITEM_COUNT"
+        printf "Select [0-%%d or ..]: " "$ITEM_COUNT"
         read -r CHOICE
         
         # Handle exit
@@ -458,6 +912,12 @@ browse_fallback() {
         SELECTED=$(echo "$ITEMS" | sed -n "${CHOICE}p")
         
         case "$SELECTED" in
+            "🔥 "*)
+                CURRENT_PATH="@popular"
+                ;;
+            "🆕 "*)
+                CURRENT_PATH="@recent"
+                ;;
             "📁 "*)
                 # Enter folder
                 FOLDER=$(echo "$SELECTED" | sed 's/^📁 //' | sed 's/\/$//')
@@ -467,14 +927,10 @@ browse_fallback() {
                     CURRENT_PATH="$CURRENT_PATH/$FOLDER"
                 fi
                 ;;
-            "📄 "*)
+            "📄 "*|"⚠️ "*)
                 # Run script
-                SCRIPT=$(echo "$SELECTED" | sed 's/^📄 //')
-                if [ "$CURRENT_PATH" = "/" ]; then
-                    SCRIPT_PATH="/$SCRIPT"
-                else
-                    SCRIPT_PATH="$CURRENT_PATH/$SCRIPT"
-                fi
+                SCRIPT=$(echo "$SELECTED" | sed 's/^📄 //;s/^⚠️ //')
+                SCRIPT_PATH=$(script_path "$SCRIPT")
                 echo ""
                 echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
                 echo "Running: ${BASE_URL}${SCRIPT_PATH}"
@@ -507,7 +963,7 @@ elif has_cmd dialog; then
 else
     browse_fallback
 fi
-`, s.Hostname)
+`, s.hostname(r))
 	
 	w.Write([]byte(script))
 }
@@ -521,12 +977,53 @@ func (s *Server) HandleScript(w http.ResponseWriter, r *http.Request) {
 		path = "/" + path
 	}
 	
-	q := dbgen.New(s.DB)
+	q := s.queries()
 	script, err := q.GetScriptByPath(r.Context(), path)
 	if err != nil {
 		http.Error(w, "Script not found", http.StatusNotFound)
 		return
 	}
+	// Private scripts look exactly like missing ones to everyone but admins
+	if script.Archived != 0 || (script.Private != 0 && !s.isAdmin(r)) {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+	if s.geoBlocked(r, q, script) {
+		http.Error(w, "This script is not available in your region", http.StatusForbidden)
+		return
+	}
+	
+	// Kill switch takes precedence over everything else
+	if script.Disabled != 0 {
+		s.serveDisabled(w, r, script)
+		return
+	}
+	
+	// Expired scripts and scripts outside their availability window are not served
+	if scriptExpired(script, time.Now()) {
+		s.serveUnavailable(w, r, script, "past its expiry ("+script.ExpiresAt.UTC().Format(availabilityLayout)+")")
+		return
+	}
+	if ok, reason := scriptAvailable(script, time.Now()); !ok {
+		s.serveUnavailable(w, r, script, reason)
+		return
+	}
+	
+	// Maintenance notices temporarily replace the script
+	if notice, ok := activeNotice(r.Context(), q, script.Path); ok {
+		s.serveNotice(w, r, script.Path, notice)
+		return
+	}
+	
+	// Proxied scripts are fetched again once their cached copy is stale
+	script = s.refreshSource(r.Context(), q, script)
+	
+	// Chat apps unfurling a shared link, and browsers asking for a
+	// preview, get a page describing the script
+	if isLinkPreview(r) || (r.URL.Query().Get("preview") == "1" && !isCLI(r)) {
+		s.serveScriptPreview(w, r, q, script)
+		return
+	}
 	
 	// Check if preview mode
 	if r.URL.Query().Get("preview") == "1" {
@@ -539,6 +1036,19 @@ func (s *Server) HandleScript(w http.ResponseWriter, r *http.Request) {
 		if script.Tags != nil && *script.Tags != "" {
 			fmt.Fprintf(w, "# Tags: %s\n", *script.Tags)
 		}
+		if script.Deprecated != 0 {
+			if script.ReplacementPath != nil && *script.ReplacementPath != "" {
+				fmt.Fprintf(w, "# DEPRECATED: use %s instead\n", *script.ReplacementPath)
+			} else {
+				fmt.Fprintf(w, "# DEPRECATED\n")
+			}
+		}
+		// The content of locked scripts, by themselves or through a
+		// folder, is only served once unlocked
+		if _, locked := s.scriptLockOf(r.Context(), q, script); locked {
+			fmt.Fprintf(w, "\n# Locked: fetch the script to unlock it\n")
+			return
+		}
 		fmt.Fprintf(w, "\n# Content:\n")
 		// Show first 20 lines
 		lines := strings.Split(script.Content, "\n")
@@ -555,8 +1065,31 @@ func (s *Server) HandleScript(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// Check if script is locked
-	if script.Locked != 0 {
+	// Pick a matching A/B variant or, failing that, the canary version for
+	// clients inside an active rollout
+	script, picked := s.applyVariant(w, r, q, script)
+	if !picked {
+		script = s.applyCanary(w, r, q, script)
+	}
+	
+	// Check if script is locked, by itself or by a folder above it
+	if lock, locked := s.scriptLockOf(r.Context(), q, script); locked {
+		// Signed URLs open the script until they expire
+		if s.validSignedURL(r, path) {
+			q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+				Action:     "SIGNED_URL_USED",
+				EntityType: "script",
+				EntityID:   &script.ID,
+				EntityPath: &script.Path,
+				IpAddress:  s.storedIP(r),
+				UserAgent:  strPtr(r.Header.Get("User-Agent")),
+				RequestID:  requestID(r.Context()),
+				CreatedAt:  time.Now(),
+			})
+			s.writeScriptContent(w, r, script, "no-store")
+			return
+		}
+		
 		// Check for valid token
 		token := r.URL.Query().Get("token")
 		if token == "" {
@@ -566,31 +1099,68 @@ func (s *Server) HandleScript(w http.ResponseWriter, r *http.Request) {
 		if token != "" {
 			// Validate token
 			authToken, err := q.GetAuthToken(r.Context(), token)
-			if err == nil && authToken.ScriptID == script.ID && authToken.ExpiresAt.After(time.Now()) {
-				// Token valid, serve script
-				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-				w.Header().Set("Cache-Control", "no-store")
-				w.Write([]byte(script.Content))
-				return
+			if err == nil && lock.allows(authToken, script) && authToken.ExpiresAt.After(time.Now()) {
+				if s.unlockTokenClientMatches(r, q, authToken, script) {
+					// Token valid, serve script
+					s.writeScriptContent(w, r, script, "no-store")
+					return
+				}
 			}
 		}
 		
 		// Serve password prompt script
-		s.servePasswordPrompt(w, path)
+		s.servePasswordPrompt(w, r, path)
 		return
 	}
 	
-	// Serve script content
+	// Serve script content; private scripts must not land in shared caches
+	if script.Private != 0 {
+		s.writeScriptContent(w, r, script, "no-store")
+		return
+	}
+	s.writeScriptContent(w, r, script, "max-age=60")
+}
+
+// writeScriptContent writes the script body, injecting a stderr warning for
+// deprecated scripts, and counts the fetch
+func (s *Server) writeScriptContent(w http.ResponseWriter, r *http.Request, script dbgen.Script, cacheControl string) {
+	if !s.withinQuota(w, r, script) {
+		return
+	}
+	s.writeScriptBody(w, r, script, cacheControl)
+}
+
+// writeScriptBody is writeScriptContent for callers that already checked
+// the download quota
+func (s *Server) writeScriptBody(w http.ResponseWriter, r *http.Request, script dbgen.Script, cacheControl string) {
+	if script.Deprecated != 0 {
+		s.setDeprecationHeaders(w, r, script)
+	}
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.Header().Set("Cache-Control", "max-age=60")
-	w.Write([]byte(script.Content))
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Write([]byte(s.scriptBody(r, script)))
+	s.recordFetch(r, script.ID)
+}
+
+// scriptBody returns the content clients get for script, with the warning
+// of a deprecated script after its shebang
+func (s *Server) scriptBody(r *http.Request, script dbgen.Script) string {
+	if script.Deprecated == 0 {
+		return script.Content
+	}
+	return insertAfterShebang(script.Content, s.deprecationNotice(r, script))
 }
 
 // servePasswordPrompt serves a script that prompts for password
-func (s *Server) servePasswordPrompt(w http.ResponseWriter, scriptPath string) {
+func (s *Server) servePasswordPrompt(w http.ResponseWriter, r *http.Request, scriptPath string) {
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.Header().Set("Cache-Control", "no-store")
 	
+	powStep := ""
+	if s.pow != nil {
+		powStep = powPromptStep
+	}
+	
 	script := fmt.Sprintf(`#!/bin/sh
 # This script is locked and requires authentication
 set -e
@@ -633,11 +1203,15 @@ if [ -z "$PASSWORD" ]; then
     echo "Error: Password required"
     exit 1
 fi
-
-# Request token
+%s
+# Request token; SH_UNLOCK_TTL asks for a longer-lived token (seconds)
+TTL="${SH_UNLOCK_TTL:-0}"
+case "$TTL" in
+    ''|*[!0-9]*) TTL=0 ;;
+esac
 RESPONSE=$(curl -fsSL -X POST "${BASE_URL}/_auth/unlock" \
     -H "Content-Type: application/json" \
-    -d "{\"path\":\"${SCRIPT_PATH}\",\"password\":\"${PASSWORD}\"}" 2>&1) || {
+    -d "{\"path\":\"${SCRIPT_PATH}\",\"password\":\"${PASSWORD}\",\"ttl\":${TTL}${POW}}" 2>&1) || {
     echo "Authentication failed: ${RESPONSE}"
     exit 1
 }
@@ -656,7 +1230,7 @@ echo ""
 
 # Fetch and execute the actual script
 curl -fsSL "${BASE_URL}${SCRIPT_PATH}?token=${TOKEN}" | sh
-`, s.Hostname, scriptPath)
+`, s.hostname(r), virtualHost(r.Context()).strip(scriptPath), powStep)
 	
 	w.Write([]byte(script))
 }
@@ -671,61 +1245,111 @@ func (s *Server) HandleUnlock(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Path     string `json:"path"`
 		Password string `json:"password"`
+		TTL      int64  `json:"ttl"` // seconds, capped at UnlockMaxTTL
+		
+		// Solved proof-of-work challenge, when UnlockPoWDifficulty is set
+		Challenge string `json:"challenge"`
+		Nonce     string `json:"nonce"`
 	}
 	
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		invalidBody(w, err)
 		return
 	}
+	// The prompt of a virtual host sends the path the script has there
+	if vh := virtualHost(r.Context()); vh != nil {
+		req.Path = vh.Prefix + req.Path
+	}
 	
-	q := dbgen.New(s.DB)
+	q := s.queries()
 	script, err := q.GetScriptByPath(r.Context(), req.Path)
-	if err != nil {
+	if err != nil || (script.Private != 0 && !s.isAdmin(r)) {
 		http.Error(w, "Script not found", http.StatusNotFound)
 		return
 	}
 	
-	if script.Locked == 0 {
+	lock, locked := s.scriptLockOf(r.Context(), q, script)
+	if !locked {
 		http.Error(w, "Script is not locked", http.StatusBadRequest)
 		return
 	}
 	
-	if script.PasswordHash == nil {
+	if lock.hash == nil {
 		http.Error(w, "Script has no password set", http.StatusInternalServerError)
 		return
 	}
 	
+	if wait := s.unlockBlocked(r, lock.limitKey(script)); wait > 0 {
+		w.Header().Set("Retry-After", fmt.Sprint(int(wait.Seconds())+1))
+		http.Error(w, "Too many failed attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+	
+	if !s.requirePoW(w, req.Path, req.Challenge, req.Nonce) {
+		return
+	}
+	
 	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(*script.PasswordHash), []byte(req.Password)); err != nil {
+	if !verifyPassword(*lock.hash, req.Password) {
 		// Log failed attempt
 		q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
 			Action:     "UNLOCK_FAILED",
 			EntityType: "script",
 			EntityID:   &script.ID,
 			EntityPath: &req.Path,
-			IpAddress:  strPtr(r.RemoteAddr),
+			IpAddress:  s.storedIP(r),
 			UserAgent:  strPtr(r.Header.Get("User-Agent")),
+			RequestID:  requestID(r.Context()),
 			CreatedAt:  time.Now(),
 		})
+		s.emit(r.Context(), WebhookEvent{
+			Event:     EventUnlockFailed,
+			ScriptID:  script.ID,
+			Path:      script.Path,
+			IPAddress: derefStr(s.storedIP(r)),
+			Time:      time.Now(),
+		})
+		s.recordUnlockFailure(r, q, script, lock.limitKey(script))
+		s.authFailed(r, "unlock", req.Path)
 		http.Error(w, "Invalid password", http.StatusUnauthorized)
 		return
 	}
 	
-	// Generate token
+	// Generate token; a folder lock's token opens the whole folder
 	token := uuid.New().String()
-	expiresAt := time.Now().Add(5 * time.Minute)
+	expiresAt := time.Now().Add(s.unlockTTL(script, req.TTL))
+	var folderPath *string
+	if lock.folder != nil {
+		folderPath = &lock.folder.Path
+	}
 	
+	// The IP is hashed with the key of the token's creation time, which
+	// is what binding checks it against
+	now := time.Now()
 	if err := q.CreateAuthToken(r.Context(), dbgen.CreateAuthTokenParams{
-		Token:     token,
-		ScriptID:  script.ID,
-		ExpiresAt: expiresAt,
-		CreatedAt: time.Now(),
-		IpAddress: strPtr(r.RemoteAddr),
-		UserAgent: strPtr(r.Header.Get("User-Agent")),
+		Token:      token,
+		ScriptID:   script.ID,
+		FolderPath: folderPath,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  now,
+		IpAddress:  strPtr(s.anonymizeIPAt(clientIP(r), now)),
+		UserAgent:  strPtr(r.Header.Get("User-Agent")),
 	}); err != nil {
 		http.Error(w, "Failed to create token", http.StatusInternalServerError)
 		return
 	}
+	s.recordUnlockSuccess(r)
+	
+	// Upgrade the stored hash to the configured algorithm and parameters
+	if s.Passwords.needsRehash(*lock.hash) {
+		if hash, err := s.Passwords.hashPassword(req.Password); err == nil {
+			if lock.folder != nil {
+				q.UpdateFolderPasswordHash(r.Context(), dbgen.UpdateFolderPasswordHashParams{PasswordHash: &hash, ID: lock.folder.ID})
+			} else {
+				q.UpdateScriptPasswordHash(r.Context(), dbgen.UpdateScriptPasswordHashParams{PasswordHash: &hash, ID: script.ID})
+			}
+		}
+	}
 	
 	// Log successful unlock
 	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
@@ -733,61 +1357,108 @@ func (s *Server) HandleUnlock(w http.ResponseWriter, r *http.Request) {
 		EntityType: "script",
 		EntityID:   &script.ID,
 		EntityPath: &req.Path,
-		IpAddress:  strPtr(r.RemoteAddr),
+		IpAddress:  s.storedIP(r),
 		UserAgent:  strPtr(r.Header.Get("User-Agent")),
+		RequestID:  requestID(r.Context()),
 		CreatedAt:  time.Now(),
 	})
 	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	resp := map[string]interface{}{
 		"token":      token,
 		"expires_at": expiresAt.Format(time.RFC3339),
-	})
+	}
+	if folderPath != nil {
+		resp["folder"] = *folderPath
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
 }
 
 // HandleCatalog returns the script catalog as JSON
 func (s *Server) HandleCatalog(w http.ResponseWriter, r *http.Request) {
-	q := dbgen.New(s.DB)
-	scripts, err := q.ListScripts(r.Context())
+	key := "catalog"
+	if vh := virtualHost(r.Context()); vh != nil {
+		key += ":" + vh.Host
+	}
+	body, err := s.cachedResponse(r.Context(), key, s.buildCatalog)
 	if err != nil {
 		http.Error(w, "Failed to list scripts", http.StatusInternalServerError)
 		return
 	}
 	
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "max-age=60")
+	w.Write(body)
+}
+
+// buildCatalog serializes the catalog, which is the same for everyone on
+// the same host
+func (s *Server) buildCatalog(ctx context.Context) ([]byte, time.Time, error) {
+	q := s.queries()
+	scripts, err := q.ListScriptMetadata(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	vh := virtualHost(ctx)
+	scripts = vh.scoped(scripts)
+	
 	type catalogEntry struct {
 		Path        string `json:"path"`
 		Name        string `json:"name"`
 		Description string `json:"description,omitempty"`
 		Tags        string `json:"tags,omitempty"`
 		Locked      bool   `json:"locked"`
+		Library     bool   `json:"library,omitempty"`
+		Deprecated  bool   `json:"deprecated,omitempty"`
+		Replacement string `json:"replacement,omitempty"`
+		Disabled    bool   `json:"disabled,omitempty"`
+		Expired     bool   `json:"expired,omitempty"`
+		Flagged     bool   `json:"flagged,omitempty"` // content scanner found risky constructs
 	}
 	
-	entries := make([]catalogEntry, len(scripts))
-	for i, s := range scripts {
-		entries[i] = catalogEntry{
-			Path:   s.Path,
-			Name:   s.Name,
-			Locked: s.Locked != 0,
+	lockedFolders, _ := q.ListLockedFolders(ctx)
+	now := time.Now()
+	// Archived, unlisted, private and scripts outside their availability window are hidden
+	visible := slices.DeleteFunc(slices.Clone(scripts), func(sc dbgen.Script) bool { return !catalogVisible(sc, now) })
+	flagged, err := s.scanFlags(ctx, q, visible)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	entries := make([]catalogEntry, 0, len(visible))
+	for _, s := range visible {
+		entry := catalogEntry{
+			Path:       vh.strip(s.Path),
+			Name:       s.Name,
+			Locked:     s.Locked != 0 || folderLock(lockedFolders, s.Path) != nil,
+			Library:    isLibraryPath(s.Path),
+			Deprecated: s.Deprecated != 0,
+			Disabled:   s.Disabled != 0,
+			Expired:    scriptExpired(s, now),
+			Flagged:    flagged[s.ID],
+		}
+		if s.ReplacementPath != nil {
+			entry.Replacement = vh.strip(*s.ReplacementPath)
 		}
 		if s.Description != nil {
-			entries[i].Description = *s.Description
+			entry.Description = *s.Description
 		}
 		if s.Tags != nil {
-			entries[i].Tags = *s.Tags
+			entry.Tags = *s.Tags
 		}
+		entries = append(entries, entry)
 	}
 	
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "max-age=60")
-	json.NewEncoder(w).Encode(entries)
+	body, err := marshalResponse(entries)
+	return body, nextScriptChange(scripts, now), err
 }
 
 // HandleConfig returns server configuration for the UI
 func (s *Server) HandleConfig(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"hostname":       s.Hostname,
-		"auth_required":  s.AdminToken != "",
+		"hostname":      s.hostname(r),
+		"auth_required": s.authRequired(r.Context()),
+		"oidc":          s.OIDC != nil,
 	})
 }
 
@@ -942,7 +1613,7 @@ echo "  ${GREEN}source $SHELL_CONFIG${NC}"
 echo ""
 echo "Or restart your terminal."
 echo ""
-`, s.Hostname)
+`, s.hostname(r))
 	
 	w.Write([]byte(script))
 }
@@ -952,7 +1623,51 @@ func strPtr(s string) *string {
 }
 
 // Serve starts the HTTP server
-func (s *Server) Serve(addr string) error {
+func (s *Server) Serve(ctx context.Context, addr string) error {
+	if s.ArchiveExpired {
+		go s.runArchiveJob()
+	}
+	if s.AuditRetention.Retention > 0 {
+		go s.runAuditPruneJob()
+	}
+	go s.runConsumerPruneJob()
+	go s.runWebhookRetryJob()
+	// A reload may set the URL later
+	if s.Digest.URL != "" || (s.reload != nil && s.Digest.Interval > 0) {
+		go s.runDigestJob()
+	}
+	if s.GitHubSync.Repo != "" && s.GitHubSync.Interval > 0 {
+		go s.runGitHubSyncJob()
+	}
+	if s.replica != nil {
+		go s.runReplicaJob()
+	}
+	if s.backups != nil {
+		go s.runBackupJob()
+	}
+	if s.Maintenance.Interval > 0 {
+		go s.runMaintenanceJob()
+	}
+	if s.FSImport.Dir != "" && s.FSImport.OnStartup {
+		s.importOnStartup(context.Background())
+	}
+	if s.FSSync.Dir != "" {
+		if err := s.startFSSync(context.Background()); err != nil {
+			return err
+		}
+	}
+	if s.sshConfig != nil {
+		ln, err := net.Listen("tcp", s.SSH.Addr)
+		if err != nil {
+			return err
+		}
+		go s.serveSSH(ln)
+		go func() {
+			<-ctx.Done()
+			ln.Close()
+		}()
+	}
+	
 	mux := http.NewServeMux()
 	
 	// Static files
@@ -964,35 +1679,228 @@ func (s *Server) Serve(addr string) error {
 	mux.HandleFunc("GET /search.sh", s.HandleSearch)
 	mux.HandleFunc("GET /install.sh", s.HandleInstall)
 	mux.HandleFunc("GET /_catalog.json", s.HandleCatalog)
+	mux.HandleFunc("GET /_popular.json", s.HandlePopular)
+	mux.HandleFunc("GET /_recent.json", s.HandleRecent)
 	mux.HandleFunc("GET /_config.json", s.HandleConfig)
-	mux.HandleFunc("POST /_auth/unlock", s.HandleUnlock)
-	
-	// API endpoints (for UI)
-	mux.HandleFunc("GET /api/scripts", s.adminOnly(s.APIListScripts))
-	mux.HandleFunc("POST /api/scripts", s.adminOnly(s.APICreateScript))
-	mux.HandleFunc("GET /api/scripts/{id}", s.adminOnly(s.APIGetScript))
-	mux.HandleFunc("PUT /api/scripts/{id}", s.adminOnly(s.APIUpdateScript))
-	mux.HandleFunc("DELETE /api/scripts/{id}", s.adminOnly(s.APIDeleteScript))
-	mux.HandleFunc("GET /api/tree", s.adminOnly(s.APIGetTree))
-	mux.HandleFunc("GET /api/folders", s.adminOnly(s.APIListFolders))
-	mux.HandleFunc("POST /api/folders", s.adminOnly(s.APICreateFolder))
-	mux.HandleFunc("DELETE /api/folders/{id}", s.adminOnly(s.APIDeleteFolder))
-	mux.HandleFunc("GET /api/search", s.adminOnly(s.APISearch))
+	mux.HandleFunc("GET /_cloudinit", s.HandleCloudInit)
+	mux.HandleFunc("GET /_offline.tar.gz", longRunning(s.HandleOfflineBundle))
+	mux.HandleFunc("GET /_oembed", s.HandleOEmbed)
+	mux.HandleFunc("POST /_auth/unlock", rateLimited(s.rateLimits.unlock, s.limitBody(s.HandleUnlock)))
+	mux.HandleFunc("POST /_runs", s.limitBody(s.HandleRunReport))
+	mux.HandleFunc("POST /_sync/github", s.HandleGitHubSyncWebhook)
+	mux.HandleFunc("POST /_sync/replica", s.HandleReplicaWebhook)
+	mux.HandleFunc("GET /_share/{token}", rateLimited(s.rateLimits.scripts, s.accessLogged(s.HandleShare)))
+	mux.HandleFunc("POST /login", rateLimited(s.rateLimits.admin, s.HandleLogin))
+	mux.HandleFunc("POST /logout", s.HandleLogout)
+	mux.HandleFunc("GET /oidc/login", s.HandleOIDCLogin)
+	mux.HandleFunc("GET /oidc/callback", s.HandleOIDCCallback)
+	
+	// Read-only git repository of the public scripts
+	if s.gitHTTP != nil {
+		mux.HandleFunc("GET "+gitRepoPath+"/", longRunning(s.HandleGitHTTP))
+		mux.HandleFunc("POST "+gitRepoPath+"/git-upload-pack", longRunning(s.HandleGitHTTP))
+	}
+	
+	// WebDAV view of the script tree (admin only)
+	dav := rateLimited(s.rateLimits.admin, s.adminOnly(s.davHandler()))
+	for _, method := range davMethods {
+		mux.HandleFunc(method+" "+davPrefix+"/", dav)
+	}
+	
+	// gRPC admin API (adminpb/admin.proto)
+	mux.HandleFunc("POST /"+adminpb.Admin_ServiceDesc.ServiceName+"/", longRunning(rateLimited(s.rateLimits.admin, s.adminOnly(s.grpcHandler()))))
+	
+	// Admin API endpoints (also used by the UI) live under /api/v1 with
+	// JSON errors; the unversioned /api paths are deprecated aliases
+	api := func(pattern string, h http.HandlerFunc) { s.handleAPI(mux, pattern, h) }
+	for _, method := range []string{"GET", "POST", "PUT", "DELETE"} {
+		mux.HandleFunc(method+" "+apiPrefix+"/{path...}", jsonErrors(http.NotFound))
+	}
+	api("GET /graphql", s.APIGraphQL)
+	api("POST /graphql", s.APIGraphQL)
+	api("GET /scripts", s.APIListScripts)
+	api("POST /scripts", s.APICreateScript)
+	api("POST /scripts/from-template", s.APICreateFromTemplate)
+	api("GET /scripts/{id}", s.APIGetScript)
+	api("PUT /scripts/{id}", s.APIUpdateScript)
+	api("DELETE /scripts/{id}", s.APIDeleteScript)
+	api("GET /scripts/{id}/dependents", s.APIListDependents)
+	api("GET /scripts/{id}/access-log", s.APIScriptAccessLog)
+	api("GET /scripts/{id}/stats", s.APIScriptStats)
+	api("GET /scripts/{id}/stats/clients", s.APIScriptClientStats)
+	api("GET /scripts/{id}/stats/geo", s.APIScriptGeoStats)
+	api("GET /scripts/{id}/versions", s.APIListVersions)
+	api("GET /scripts/{id}/runs", s.APIScriptRuns)
+	api("POST /scripts/{id}/source/refresh", s.APIRefreshSource)
+	api("POST /scripts/{id}/disable", s.APIDisableScript)
+	api("POST /scripts/{id}/enable", s.APIEnableScript)
+	api("POST /scripts/{id}/shares", s.APICreateShareLink)
+	api("POST /scripts/{id}/tokens/revoke_all", s.APIRevokeScriptTokens)
+	api("POST /scripts/{id}/signed-url", s.APICreateSignedURL)
+	api("GET /scripts/{id}/canary", s.APIGetCanary)
+	api("POST /scripts/{id}/canary", s.APIStartCanary)
+	api("PUT /scripts/{id}/canary", s.APIUpdateCanary)
+	api("DELETE /scripts/{id}/canary", s.APIAbortCanary)
+	api("POST /scripts/{id}/canary/promote", s.APIPromoteCanary)
+	api("GET /scripts/{id}/variants", s.APIListVariants)
+	api("POST /scripts/{id}/variants", s.APICreateVariant)
+	api("GET /scripts/{id}/variants/stats", s.APIVariantStats)
+	api("PUT /scripts/{id}/variants/{vid}", s.APIUpdateVariant)
+	api("DELETE /scripts/{id}/variants/{vid}", s.APIDeleteVariant)
+	api("GET /shares", s.APIListShareLinks)
+	api("DELETE /shares/{token}", s.APIRevokeShareLink)
+	api("GET /tokens", s.APIListUnlockTokens)
+	api("DELETE /tokens/{token}", s.APIRevokeUnlockToken)
+	api("GET /tree", s.APIGetTree)
+	api("GET /folders", s.APIListFolders)
+	api("POST /folders", s.APICreateFolder)
+	api("DELETE /folders/{id}", s.APIDeleteFolder)
+	api("POST /folders/{id}/lock", s.APILockFolder)
+	api("POST /folders/{id}/unlock", s.APIUnlockFolder)
+	api("GET /search", s.APISearch)
+	api("POST /generators/github-release", s.APIGenerateGitHubInstaller)
+	api("GET /templates", s.APIListTemplates)
+	api("POST /templates", s.APICreateTemplate)
+	api("GET /templates/{id}", s.APIGetTemplate)
+	api("PUT /templates/{id}", s.APIUpdateTemplate)
+	api("DELETE /templates/{id}", s.APIDeleteTemplate)
+	api("POST /lint", s.APILint)
+	api("GET /scan-rules", s.APIListScanRules)
+	api("POST /scan-rules", s.APICreateScanRule)
+	api("DELETE /scan-rules/{id}", s.APIDeleteScanRule)
+	api("GET /audit/export", longRunning(s.APIExportAudit))
+	api("GET /access-log", s.APIListAccessLog)
+	api("GET /stats/scripts", s.APIListScriptStats)
+	api("GET /version", s.APIVersion)
+	api("GET /stats/summary", s.APIStatsSummary)
+	api("GET /stats/clients", s.APIClientStats)
+	api("GET /stats/geo", s.APIGeoStats)
+	api("GET /stats/digest", s.APIDigest)
+	api("GET /honeypots", s.APIListHoneypots)
+	api("POST /honeypots", s.APICreateHoneypot)
+	api("DELETE /honeypots/{id}", s.APIDeleteHoneypot)
+	api("GET /webhooks", s.APIListWebhooks)
+	api("POST /webhooks", s.APICreateWebhook)
+	api("DELETE /webhooks/{id}", s.APIDeleteWebhook)
+	api("GET /webhooks/{id}/deliveries", s.APIListWebhookDeliveries)
+	api("POST /webhooks/{id}/deliveries/{delivery}/redeliver", s.APIRedeliverWebhook)
+	api("POST /sync/github", s.APIGitHubSync)
+	api("POST /import/fs", s.APIImportFS)
+	api("GET /export.tar.gz", longRunning(s.APIExportArchive))
+	api("GET /export", longRunning(s.APIExportPortable))
+	api("POST /import", longRunning(s.APIImportPortable))
+	api("GET /backup", longRunning(s.APIBackup))
+	api("POST /restore", longRunning(s.APIRestore))
+	api("GET /backups", s.APIListBackups)
+	api("GET /maintenance", s.APIDatabaseStats)
+	api("GET /usage", s.APIUsage)
+	api("POST /maintenance/vacuum", longRunning(s.APIVacuum))
+	api("GET /replica", s.APIReplicaStatus)
+	api("POST /replica/sync", s.APIReplicaSync)
+	api("GET /notices", s.APIListNotices)
+	api("POST /notices", s.APICreateNotice)
+	api("DELETE /notices/{id}", s.APIDeleteNotice)
+	api("GET /session", s.APIGetSession)
+	api("GET /sessions", s.APIListSessions)
+	api("DELETE /sessions/{id}", s.APIRevokeSession)
+	api("GET /api-tokens", s.APIListAPITokens)
+	api("POST /api-tokens", s.APICreateAPIToken)
+	api("DELETE /api-tokens/{id}", s.APIRevokeAPIToken)
+	api("GET /admin-tokens", s.APIListAdminTokens)
+	api("POST /admin-tokens", s.APICreateAdminToken)
+	api("DELETE /admin-tokens/{id}", s.APIDeleteAdminToken)
+	api("POST /reload", s.APIReload)
 	
 	// Root and catch-all routes
 	mux.HandleFunc("GET /{$}", s.HandleRoot)
 	mux.HandleFunc("GET /{path...}", s.routeHandler)
+	mux.HandleFunc("PUT /{path...}", rateLimited(s.rateLimits.admin, s.adminOnly(s.HandleRawPut)))
+	mux.HandleFunc("DELETE /{path...}", rateLimited(s.rateLimits.admin, s.adminOnly(s.HandleRawDelete)))
 	
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if s.TLS.CertFile != "" && s.TLS.RedirectAddr != "" {
+		redirectLn, err := net.Listen("tcp", s.TLS.RedirectAddr)
+		if err != nil {
+			ln.Close()
+			return err
+		}
+		_, httpsPort, _ := net.SplitHostPort(ln.Addr().String())
+		slog.Info("redirecting plain HTTP to HTTPS", "addr", s.TLS.RedirectAddr)
+		go s.serveRedirect(ctx, redirectLn, httpsPort)
+	}
 	slog.Info("starting server", "addr", addr)
-	return http.ListenAndServe(addr, s.withLogging(mux))
+	return s.serveHTTP(ctx, ln, s.withRealIP(s.withLogging(s.withHSTS(s.withSecurityHeaders(withVersion(s.withVirtualHost(mux)))))))
+}
+
+// serveHTTP serves handler on ln until ctx is done. It then stops taking
+// connections and waits up to ShutdownTimeout for the requests in flight,
+// so a script being piped into sh isn't cut off halfway.
+func (s *Server) serveHTTP(ctx context.Context, ln net.Listener, handler http.Handler) error {
+	server := &http.Server{Handler: handler}
+	s.HTTP.apply(server)
+	// gRPC clients may also speak HTTP/2 without TLS
+	server.Protocols = new(http.Protocols)
+	server.Protocols.SetHTTP1(true)
+	server.Protocols.SetHTTP2(true)
+	server.Protocols.SetUnencryptedHTTP2(true)
+	// Watch streams would otherwise hold up the shutdown to the end
+	server.RegisterOnShutdown(s.watchers.close)
+	
+	errs := make(chan error, 1)
+	go func() {
+		if s.TLS.CertFile != "" {
+			server.TLSConfig = s.tlsConfig()
+			errs <- server.ServeTLS(ln, s.TLS.CertFile, s.TLS.KeyFile)
+			return
+		}
+		errs <- server.Serve(ln)
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	
+	slog.Info("shutting down, waiting for requests in flight", "timeout", s.ShutdownTimeout)
+	drain := context.Background()
+	if s.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		drain, cancel = context.WithTimeout(drain, s.ShutdownTimeout)
+		defer cancel()
+	}
+	if err := server.Shutdown(drain); err != nil {
+		slog.Warn("shutdown timed out, closing the remaining connections", "error", err)
+		server.Close()
+	}
+	return nil
 }
 
 func (s *Server) routeHandler(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
 	
+	// Decoy paths look missing but trip an alert
+	if s.honeypotHit(w, r) {
+		return
+	}
+	
+	// Scripts on a virtual host live under its folder
+	if vh := virtualHost(r.Context()); vh != nil {
+		r = vh.rewrite(r)
+		path = r.URL.Path
+	}
+	
+	// Usage badges of scripts
+	if isBadgePath(path) {
+		s.HandleBadge(w, r)
+		return
+	}
+	
 	// Handle .sh script requests
 	if strings.HasSuffix(path, ".sh") {
-		s.HandleScript(w, r)
+		rateLimited(s.rateLimits.scripts, s.accessLogged(s.HandleScript))(w, r)
 		return
 	}
 	
@@ -1006,28 +1914,134 @@ func (s *Server) routeHandler(w http.ResponseWriter, r *http.Request) {
 	http.NotFound(w, r)
 }
 
-func (s *Server) withLogging(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		slog.Info("request", "method", r.Method, "path", r.URL.Path, "duration", time.Since(start))
-	})
+// authRequired reports whether the admin API is protected at all
+func (s *Server) authRequired(ctx context.Context) bool {
+	if s.adminToken() != "" || s.OIDC != nil || s.TrustedHeader.Header != "" || s.clientCAs != nil {
+		return true
+	}
+	n, err := s.queries().CountAdminTokens(ctx)
+	return err != nil || n > 0
+}
+
+// hasAdminToken reports whether the request carries an admin token in a
+// header or as the Basic auth password and returns the actor it identifies
+func (s *Server) hasAdminToken(r *http.Request) (string, bool) {
+	if !s.authRequired(r.Context()) {
+		return "", true
+	}
+	token := r.Header.Get("X-Admin-Token")
+	if token == "" {
+		token = r.Header.Get("Authorization")
+		token = strings.TrimPrefix(token, "Bearer ")
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		// curl -u admin:$TOKEN; the user name is ignored
+		token = password
+	}
+	return s.adminTokenActor(r.Context(), token)
+}
+
+// isAdmin reports whether the request carries an admin token, an OIDC ID
+// token, a trusted proxy identity or a login session
+func (s *Server) isAdmin(r *http.Request) bool {
+	if _, ok := s.hasAdminToken(r); ok {
+		return true
+	}
+	if _, ok := clientCertActor(r); ok {
+		return true
+	}
+	if _, _, ok := s.bearerRole(r); ok {
+		return true
+	}
+	if _, _, ok := s.trustedHeaderRole(r); ok {
+		return true
+	}
+	_, ok := s.currentSession(r)
+	return ok
 }
 
+// adminOnly authenticates the request and records the actor for the audit log
 func (s *Server) adminOnly(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("X-Admin-Token")
-		if token == "" {
-			token = r.Header.Get("Authorization")
-			token = strings.TrimPrefix(token, "Bearer ")
+		cert, hasCert := clientCertActor(r)
+		if s.TLS.RequireClientCert && !hasCert {
+			http.Error(w, "Client certificate required", http.StatusForbidden)
+			return
+		}
+		
+		if who, ok := s.hasAdminToken(r); ok {
+			// Browsers replay cached Basic credentials on cross-site requests
+			if _, _, basic := r.BasicAuth(); basic && !safeMethod(r.Method) && !sameOrigin(r) {
+				http.Error(w, "Cross-origin request refused", http.StatusForbidden)
+				return
+			}
+			next(w, withActor(r, who))
+			return
+		}
+		
+		if hasCert {
+			next(w, withActor(r, cert))
+			return
+		}
+		
+		if tok, ok := s.apiToken(r); ok {
+			if err := s.checkTokenScope(r, tok); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			next(w, withActor(r, "api-token:"+tok.Name))
+			return
+		}
+		
+		if role, subject, ok := s.bearerRole(r); ok {
+			if !safeMethod(r.Method) && role != roleAdmin {
+				http.Error(w, "Read-only access", http.StatusForbidden)
+				return
+			}
+			next(w, withActor(r, subject))
+			return
+		}
+		
+		if role, identity, ok := s.trustedHeaderRole(r); ok {
+			if !safeMethod(r.Method) && !sameOrigin(r) {
+				http.Error(w, "Cross-origin request refused", http.StatusForbidden)
+				return
+			}
+			if !safeMethod(r.Method) && role != roleAdmin {
+				http.Error(w, "Read-only access", http.StatusForbidden)
+				return
+			}
+			next(w, withActor(r, identity))
+			return
 		}
 		
-		if s.AdminToken != "" && token != s.AdminToken {
+		// Cookie sessions are sent by the browser automatically, so state
+		// changes must also prove they came from our UI
+		sess, ok := s.currentSession(r)
+		if !ok {
+			if hasCredentials(r) {
+				s.authFailed(r, "admin", r.URL.Path)
+			}
+			if s.BasicAuthChallenge {
+				w.Header().Set("WWW-Authenticate", `Basic realm="sh-server", charset="UTF-8"`)
+			}
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
+		if !safeMethod(r.Method) && !validCSRF(r, sess) {
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		if !safeMethod(r.Method) && sess.Role != roleAdmin {
+			http.Error(w, "Read-only access", http.StatusForbidden)
+			return
+		}
 		
-		next(w, r)
+		who := "session"
+		if sess.Subject != nil {
+			who = *sess.Subject
+		}
+		next(w, withActor(r, who))
 	}
 }
 
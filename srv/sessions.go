@@ -0,0 +1,305 @@
+package srv
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+const (
+	sessionCookie = "shs_session"
+	sessionTTL    = 12 * time.Hour
+
+	// sessionTouchInterval limits how often last_seen_at is written
+	sessionTouchInterval = time.Minute
+
+	// Roles carried by sessions; viewers may only use safe methods
+	roleAdmin  = "admin"
+	roleViewer = "viewer"
+)
+
+// randomToken returns n random bytes as hex
+func randomToken(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// hashToken returns the hex SHA-256 under which a secret token is stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// isHTTPS reports whether the client reached us over TLS, directly or via
+// a proxy. X-Forwarded-Proto is only believed from TrustedProxies.
+func (s *Server) isHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	peer := net.ParseIP(peerIP(r))
+	return peer != nil && s.trustedProxy(peer) && r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// safeMethod reports whether the method cannot change state and so needs no CSRF token
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// currentSession returns the unexpired session named by the request's cookie
+func (s *Server) currentSession(r *http.Request) (dbgen.Session, bool) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || cookie.Value == "" {
+		return dbgen.Session{}, false
+	}
+
+	q := s.queries()
+	sess, err := q.GetSession(r.Context(), hashToken(cookie.Value))
+	if err != nil {
+		return dbgen.Session{}, false
+	}
+	now := time.Now()
+	if !now.Before(sess.ExpiresAt) {
+		return dbgen.Session{}, false
+	}
+	if now.Sub(sess.LastSeenAt) > sessionTouchInterval {
+		q.TouchSession(r.Context(), dbgen.TouchSessionParams{LastSeenAt: now, ID: sess.ID})
+	}
+	return sess, true
+}
+
+// validCSRF reports whether the request echoes the session's CSRF token
+func validCSRF(r *http.Request, sess dbgen.Session) bool {
+	token := r.Header.Get("X-CSRF-Token")
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(sess.CsrfToken)) == 1
+}
+
+// createSession stores a new session and sets its cookie on the response
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request, role string, subject *string) (dbgen.Session, error) {
+	now := time.Now()
+	cookieValue := randomToken(32)
+	sess := dbgen.Session{
+		ID:         hashToken(cookieValue),
+		CsrfToken:  randomToken(32),
+		Role:       role,
+		Subject:    subject,
+		IpAddress:  s.storedIP(r),
+		UserAgent:  strPtr(r.Header.Get("User-Agent")),
+		ExpiresAt:  now.Add(sessionTTL),
+		LastSeenAt: now,
+		CreatedAt:  now,
+	}
+
+	q := s.queries()
+	err := q.CreateSession(r.Context(), dbgen.CreateSessionParams{
+		ID:         sess.ID,
+		CsrfToken:  sess.CsrfToken,
+		Role:       sess.Role,
+		Subject:    sess.Subject,
+		IpAddress:  sess.IpAddress,
+		UserAgent:  sess.UserAgent,
+		ExpiresAt:  sess.ExpiresAt,
+		LastSeenAt: sess.LastSeenAt,
+		CreatedAt:  sess.CreatedAt,
+	})
+	if err != nil {
+		return dbgen.Session{}, err
+	}
+	q.DeleteExpiredSessions(r.Context(), now)
+
+	s.setSessionCookie(w, r, cookieValue, int(sessionTTL.Seconds()))
+	return sess, nil
+}
+
+func (s *Server) setSessionCookie(w http.ResponseWriter, r *http.Request, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   s.isHTTPS(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// SessionResponse describes a login session; the cookie value is never returned
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Role       string    `json:"role"`
+	Subject    string    `json:"subject"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	Current    bool      `json:"current"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func sessionToResponse(sess dbgen.Session, currentID string) SessionResponse {
+	resp := SessionResponse{
+		ID:         sess.ID,
+		Role:       sess.Role,
+		Current:    sess.ID == currentID,
+		ExpiresAt:  sess.ExpiresAt,
+		LastSeenAt: sess.LastSeenAt,
+		CreatedAt:  sess.CreatedAt,
+	}
+	if sess.Subject != nil {
+		resp.Subject = *sess.Subject
+	}
+	if sess.IpAddress != nil {
+		resp.IPAddress = *sess.IpAddress
+	}
+	if sess.UserAgent != nil {
+		resp.UserAgent = *sess.UserAgent
+	}
+	return resp
+}
+
+// HandleLogin exchanges an admin token for a session cookie and returns the
+// CSRF token the UI must send with mutating API calls
+func (s *Server) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+
+	q := s.queries()
+	now := time.Now()
+	who, ok := s.adminTokenActor(r.Context(), req.Token)
+	if !ok {
+		q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+			Action:     "LOGIN_FAILED",
+			EntityType: "session",
+			IpAddress:  s.storedIP(r),
+			UserAgent:  strPtr(r.Header.Get("User-Agent")),
+			RequestID:  requestID(r.Context()),
+			CreatedAt:  now,
+		})
+		s.authFailed(r, "login", r.URL.Path)
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	sess, err := s.createSession(w, r, roleAdmin, &who)
+	if err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "LOGIN",
+		EntityType: "session",
+		EntityID:   &sess.ID,
+		IpAddress:  s.storedIP(r),
+		UserAgent:  strPtr(r.Header.Get("User-Agent")),
+		Actor:      &who,
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"csrf_token": sess.CsrfToken,
+		"expires_at": sess.ExpiresAt,
+	})
+}
+
+// HandleLogout ends the current session and clears the cookie
+func (s *Server) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if sess, ok := s.currentSession(r); ok {
+		q := s.queries()
+		q.DeleteSession(r.Context(), sess.ID)
+		q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+			Action:     "LOGOUT",
+			EntityType: "session",
+			EntityID:   &sess.ID,
+			IpAddress:  s.storedIP(r),
+			Actor:      sess.Subject,
+			RequestID:  requestID(r.Context()),
+			CreatedAt:  time.Now(),
+		})
+	}
+	s.setSessionCookie(w, r, "", -1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// APIGetSession returns the CSRF token of the caller's session so the UI can
+// resume after a page reload
+func (s *Server) APIGetSession(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]interface{}{"authenticated": true, "role": roleAdmin}
+	if sess, ok := s.currentSession(r); ok {
+		resp["csrf_token"] = sess.CsrfToken
+		resp["expires_at"] = sess.ExpiresAt
+		resp["role"] = sess.Role
+		if sess.Subject != nil {
+			resp["subject"] = *sess.Subject
+		}
+	} else if role, identity, ok := s.trustedHeaderRole(r); ok {
+		resp["role"] = role
+		resp["subject"] = identity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIListSessions returns active sessions, most recently used first
+func (s *Server) APIListSessions(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	q.DeleteExpiredSessions(r.Context(), time.Now())
+	sessions, err := q.ListSessions(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	var currentID string
+	if sess, ok := s.currentSession(r); ok {
+		currentID = sess.ID
+	}
+	resp := make([]SessionResponse, len(sessions))
+	for i, sess := range sessions {
+		resp[i] = sessionToResponse(sess, currentID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIRevokeSession logs out a session by id
+func (s *Server) APIRevokeSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	if _, err := q.GetSession(r.Context(), id); err != nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err := q.DeleteSession(r.Context(), id); err != nil {
+		http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "SESSION_REVOKE",
+		EntityType: "session",
+		EntityID:   &id,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,272 @@
+package srv
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// ShareLinkRequest represents a request to mint a share link for a script.
+// At least one of MaxUses or ExpiresAt/Duration must be set.
+type ShareLinkRequest struct {
+	MaxUses   int64      `json:"max_uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Duration  string     `json:"duration,omitempty"`
+	Note      string     `json:"note"`
+}
+
+// ShareLinkResponse is the admin view of a share link
+type ShareLinkResponse struct {
+	Token      string     `json:"token"`
+	URL        string     `json:"url"`
+	ScriptID   string     `json:"script_id"`
+	ScriptPath string     `json:"script_path"`
+	MaxUses    *int64     `json:"max_uses"`
+	UseCount   int64      `json:"use_count"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Revoked    bool       `json:"revoked"`
+	Active     bool       `json:"active"`
+	Note       string     `json:"note"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// shareLinkActive reports whether the link can still be used
+func shareLinkActive(link dbgen.ShareLink, now time.Time) bool {
+	if link.Revoked != 0 {
+		return false
+	}
+	if link.MaxUses != nil && link.UseCount >= *link.MaxUses {
+		return false
+	}
+	return link.ExpiresAt == nil || now.Before(*link.ExpiresAt)
+}
+
+func (s *Server) shareLinkToResponse(r *http.Request, link dbgen.ShareLink, scriptPath string) ShareLinkResponse {
+	resp := ShareLinkResponse{
+		Token:      link.Token,
+		URL:        "https://" + s.hostname(r) + "/_share/" + link.Token,
+		ScriptID:   link.ScriptID,
+		ScriptPath: scriptPath,
+		MaxUses:    link.MaxUses,
+		UseCount:   link.UseCount,
+		ExpiresAt:  link.ExpiresAt,
+		Revoked:    link.Revoked != 0,
+		Active:     shareLinkActive(link, time.Now()),
+		LastUsedAt: link.LastUsedAt,
+		CreatedAt:  link.CreatedAt,
+	}
+	if link.Note != nil {
+		resp.Note = *link.Note
+	}
+	return resp
+}
+
+// shareTokenPrefix identifies a share link in the audit log, which viewers
+// can read, without giving away the token
+func shareTokenPrefix(token string) string {
+	return "link " + token[:min(len(token), 8)] + "..."
+}
+
+// HandleShare serves a script through a share link, consuming one use
+func (s *Server) HandleShare(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	q := s.queries()
+	link, err := q.GetShareLink(r.Context(), token)
+	if err != nil {
+		http.Error(w, "Share link not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	if !shareLinkActive(link, now) {
+		http.Error(w, "Share link is no longer valid", http.StatusGone)
+		return
+	}
+
+	script, err := q.GetScript(r.Context(), link.ScriptID)
+	if err != nil || script.Archived != 0 {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+	if s.geoBlocked(r, q, script) {
+		http.Error(w, "This script is not available in your region", http.StatusForbidden)
+		return
+	}
+	if script.Disabled != 0 {
+		s.serveDisabled(w, r, script)
+		return
+	}
+
+	// A client turned away by the download quota keeps its use
+	if !s.withinQuota(w, r, script) {
+		return
+	}
+
+	// Consume atomically so concurrent requests cannot exceed max_uses
+	n, err := q.ConsumeShareLink(r.Context(), dbgen.ConsumeShareLinkParams{
+		LastUsedAt: &now,
+		Token:      token,
+	})
+	if err != nil {
+		http.Error(w, "Failed to use share link", http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "Share link is no longer valid", http.StatusGone)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "SHARE_USED",
+		EntityType: "script",
+		EntityID:   &script.ID,
+		EntityPath: &script.Path,
+		Details:    strPtr(shareTokenPrefix(token)),
+		IpAddress:  s.storedIP(r),
+		UserAgent:  strPtr(r.Header.Get("User-Agent")),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	s.writeScriptBody(w, r, script, "no-store")
+}
+
+// APICreateShareLink mints a share link for a script
+func (s *Server) APICreateShareLink(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req ShareLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := req.ExpiresAt
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			http.Error(w, "Invalid duration: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		t := now.Add(d)
+		expiresAt = &t
+	}
+	if req.MaxUses < 0 {
+		http.Error(w, "max_uses must not be negative", http.StatusBadRequest)
+		return
+	}
+	if req.MaxUses == 0 && expiresAt == nil {
+		http.Error(w, "max_uses or expires_at/duration is required", http.StatusBadRequest)
+		return
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		http.Error(w, "Expiry must be in the future", http.StatusBadRequest)
+		return
+	}
+	var maxUses *int64
+	if req.MaxUses > 0 {
+		maxUses = &req.MaxUses
+	}
+
+	q := s.queries()
+	script, err := q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+
+	token := uuid.New().String()
+	err = q.CreateShareLink(r.Context(), dbgen.CreateShareLinkParams{
+		Token:     token,
+		ScriptID:  id,
+		MaxUses:   maxUses,
+		ExpiresAt: expiresAt,
+		Note:      &req.Note,
+		CreatedAt: now,
+	})
+	if err != nil {
+		http.Error(w, "Failed to create share link: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "SHARE_CREATE",
+		EntityType: "script",
+		EntityID:   &id,
+		EntityPath: &script.Path,
+		Details:    &req.Note,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	link, _ := q.GetShareLink(r.Context(), token)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s.shareLinkToResponse(r, link, script.Path))
+}
+
+// APIListShareLinks returns all share links, newest first
+func (s *Server) APIListShareLinks(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	rows, err := q.ListShareLinks(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list share links", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ShareLinkResponse, len(rows))
+	for i, row := range rows {
+		resp[i] = s.shareLinkToResponse(r, dbgen.ShareLink{
+			Token:      row.Token,
+			ScriptID:   row.ScriptID,
+			MaxUses:    row.MaxUses,
+			UseCount:   row.UseCount,
+			ExpiresAt:  row.ExpiresAt,
+			Revoked:    row.Revoked,
+			Note:       row.Note,
+			LastUsedAt: row.LastUsedAt,
+			CreatedAt:  row.CreatedAt,
+		}, row.ScriptPath)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIRevokeShareLink revokes a share link; the record is kept for tracking
+func (s *Server) APIRevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	q := s.queries()
+	link, err := q.GetShareLink(r.Context(), token)
+	if err != nil {
+		http.Error(w, "Share link not found", http.StatusNotFound)
+		return
+	}
+
+	if err := q.RevokeShareLink(r.Context(), token); err != nil {
+		http.Error(w, "Failed to revoke share link", http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "SHARE_REVOKE",
+		EntityType: "script",
+		EntityID:   &link.ScriptID,
+		Details:    strPtr(shareTokenPrefix(token)),
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,116 @@
+package srv
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// urlSignature signs a script path and expiry with the server signing key
+func (s *Server) urlSignature(path string, exp int64) string {
+	mac := hmac.New(sha256.New, []byte(s.SigningKey))
+	fmt.Fprintf(mac, "%s\n%d", path, exp)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// validSignedURL reports whether the request carries an unexpired signature
+// for path. Verification is stateless; rotating SIGNING_KEY invalidates every
+// signed URL.
+func (s *Server) validSignedURL(r *http.Request, path string) bool {
+	if s.SigningKey == "" {
+		return false
+	}
+	expStr, sig := r.URL.Query().Get("exp"), r.URL.Query().Get("sig")
+	if expStr == "" || sig == "" {
+		return false
+	}
+	exp, err := strconv.ParseInt(expStr, 10, 64)
+	if err != nil || time.Now().Unix() >= exp {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(s.urlSignature(path, exp)))
+}
+
+// SignedURLRequest represents a request to sign a script URL. One of
+// ExpiresAt or Duration is required.
+type SignedURLRequest struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Duration  string     `json:"duration,omitempty"`
+}
+
+// APICreateSignedURL returns a URL that serves a locked script without the
+// password prompt until it expires
+func (s *Server) APICreateSignedURL(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if s.SigningKey == "" {
+		http.Error(w, "Signed URLs need SIGNING_KEY to be configured", http.StatusNotImplemented)
+		return
+	}
+
+	var req SignedURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := req.ExpiresAt
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			http.Error(w, "Invalid duration: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		t := now.Add(d)
+		expiresAt = &t
+	}
+	if expiresAt == nil {
+		http.Error(w, "expires_at or duration is required", http.StatusBadRequest)
+		return
+	}
+	if !expiresAt.After(now) {
+		http.Error(w, "Expiry must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	q := s.queries()
+	script, err := q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+
+	exp := expiresAt.Unix()
+	query := url.Values{}
+	query.Set("exp", strconv.FormatInt(exp, 10))
+	query.Set("sig", s.urlSignature(script.Path, exp))
+	signed := virtualHost(r.Context()).scriptURL(s.Hostname, script.Path) + "?" + query.Encode()
+
+	details := "expires " + time.Unix(exp, 0).UTC().Format(time.RFC3339)
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "SIGNED_URL_CREATE",
+		EntityType: "script",
+		EntityID:   &script.ID,
+		EntityPath: &script.Path,
+		Details:    &details,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"url":        signed,
+		"expires_at": time.Unix(exp, 0).UTC(),
+	})
+}
@@ -0,0 +1,331 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHConfig serves the script tree over SFTP and a few exec commands, for
+// admins who can only reach the server over SSH
+type SSHConfig struct {
+	Addr               string // listen address, e.g. ":2222"; empty disables SSH
+	HostKeyFile        string // private host key, generated on first start if missing
+	AuthorizedKeysFile string // admin public keys in authorized_keys format, read on every login
+}
+
+// loadSSHHostKey reads the host key, generating an ed25519 key if the file
+// doesn't exist yet
+func loadSSHHostKey(file string) (ssh.Signer, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		block, err := ssh.MarshalPrivateKey(key, "sh-server")
+		if err != nil {
+			return nil, err
+		}
+		data = pem.EncodeToMemory(block)
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to save SSH host key: %w", err)
+		}
+		slog.Info("generated SSH host key", "file", file)
+	} else if err != nil {
+		return nil, err
+	}
+	return ssh.ParsePrivateKey(data)
+}
+
+// sshServerConfig sets up key-based authentication against the authorized
+// keys file. The actor is "ssh:" and the key's comment, or its fingerprint.
+func sshServerConfig(cfg SSHConfig) (*ssh.ServerConfig, error) {
+	hostKey, err := loadSSHHostKey(cfg.HostKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			rest, err := os.ReadFile(cfg.AuthorizedKeysFile)
+			if err != nil {
+				return nil, err
+			}
+			for len(rest) > 0 {
+				var authorized ssh.PublicKey
+				var comment string
+				authorized, comment, _, rest, err = ssh.ParseAuthorizedKey(rest)
+				if err != nil {
+					break
+				}
+				if bytes.Equal(authorized.Marshal(), key.Marshal()) {
+					if comment == "" {
+						comment = ssh.FingerprintSHA256(key)
+					}
+					return &ssh.Permissions{Extensions: map[string]string{"actor": "ssh:" + comment}}, nil
+				}
+			}
+			slog.Warn("SSH key refused", "remote", conn.RemoteAddr().String(), "user", conn.User(), "fingerprint", ssh.FingerprintSHA256(key))
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(hostKey)
+	return config, nil
+}
+
+// serveSSH accepts SSH connections until ln is closed
+func (s *Server) serveSSH(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleSSHConn(conn)
+	}
+}
+
+// handleSSHConn serves the sessions of one connection
+func (s *Server) handleSSHConn(nc net.Conn) {
+	conn, chans, reqs, err := ssh.NewServerConn(nc, s.sshConfig)
+	if err != nil {
+		slog.Debug("SSH handshake failed", "remote", nc.RemoteAddr().String(), "error", err)
+		return
+	}
+	defer conn.Close()
+	go ssh.DiscardRequests(reqs)
+	for ch := range chans {
+		if ch.ChannelType() != "session" {
+			ch.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+		channel, requests, err := ch.Accept()
+		if err != nil {
+			continue
+		}
+		go s.handleSSHSession(s.sshRequest(conn), channel, requests)
+	}
+}
+
+// sshRequest stands in for an HTTP request when an SSH session changes
+// scripts, so the API handlers see the actor and client address
+func (s *Server) sshRequest(conn *ssh.ServerConn) *http.Request {
+	r, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "/", nil)
+	r.RemoteAddr = conn.RemoteAddr().String()
+	r.Header.Set("User-Agent", string(conn.ClientVersion()))
+	return withOriginalRequest(withActor(r, conn.Permissions.Extensions["actor"]))
+}
+
+// handleSSHSession runs the sftp subsystem or one exec command
+func (s *Server) handleSSHSession(r *http.Request, channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	for req := range requests {
+		switch req.Type {
+		case "subsystem":
+			var payload struct{ Name string }
+			if ssh.Unmarshal(req.Payload, &payload) != nil || payload.Name != "sftp" {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			server := sftp.NewRequestServer(channel, sftpHandlers(r.Context(), treeFS{s: s}))
+			if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+				slog.Warn("SFTP session failed", "actor", derefStr(actor(r.Context())), "error", err)
+			}
+			server.Close()
+			return
+		case "exec":
+			var payload struct{ Command string }
+			if ssh.Unmarshal(req.Payload, &payload) != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			status := s.sshExec(r, payload.Command, channel, channel, channel.Stderr())
+			channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+			return
+		case "env", "pty-req":
+			req.Reply(true, nil)
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// sshExec runs an exec command and returns its exit status:
+//
+//	list [folder]  script paths, one per line
+//	cat <path>     a script's content
+//	put <path>     publish stdin as a script
+func (s *Server) sshExec(r *http.Request, command string, stdin io.Reader, stdout, stderr io.Writer) uint32 {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: list [folder] | cat <path> | put <path>")
+		return 2
+	}
+	ctx := r.Context()
+	switch {
+	case args[0] == "list" && len(args) <= 2:
+		prefix := "/"
+		if len(args) == 2 {
+			prefix = strings.TrimSuffix(path.Clean("/"+args[1]), "/") + "/"
+		}
+		scripts, err := s.queries().ListScripts(ctx)
+		if err != nil {
+			fmt.Fprintln(stderr, "Failed to list scripts")
+			return 1
+		}
+		for _, sc := range scripts {
+			if strings.HasPrefix(sc.Path, prefix) {
+				fmt.Fprintln(stdout, sc.Path)
+			}
+		}
+	case args[0] == "cat" && len(args) == 2:
+		sc, err := s.queries().GetScriptByPath(ctx, path.Clean("/"+args[1]))
+		if errors.Is(err, sql.ErrNoRows) {
+			fmt.Fprintln(stderr, "Script not found")
+			return 1
+		} else if err != nil {
+			fmt.Fprintln(stderr, "Failed to get script")
+			return 1
+		}
+		io.WriteString(stdout, sc.Content)
+	case args[0] == "put" && len(args) == 2:
+		name := path.Clean("/" + args[1])
+		if err := validatePath(name); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		body, err := io.ReadAll(io.LimitReader(stdin, s.Limits.MaxScriptSize+1))
+		if err != nil {
+			fmt.Fprintln(stderr, "Failed to read script")
+			return 1
+		}
+		if int64(len(body)) > s.Limits.MaxScriptSize {
+			fmt.Fprintln(stderr, "Script too large")
+			return 1
+		}
+		script, created, err := s.publishScript(r, name, string(body), nil)
+		var perr *PublishError
+		if errors.As(err, &perr) {
+			fmt.Fprintln(stderr, perr.Message)
+			return 1
+		} else if err != nil {
+			fmt.Fprintln(stderr, "Failed to save script")
+			return 1
+		}
+		verb := "Updated"
+		if created {
+			verb = "Created"
+		}
+		fmt.Fprintf(stdout, "%s %s\n", verb, script.Path)
+		for _, warn := range script.Warnings {
+			fmt.Fprintf(stdout, "warning: line %d: %s\n", warn.Line, warn.Message)
+		}
+	default:
+		fmt.Fprintln(stderr, "usage: list [folder] | cat <path> | put <path>")
+		return 2
+	}
+	return 0
+}
+
+// sftpHandlers serves the script tree over SFTP
+func sftpHandlers(ctx context.Context, tree treeFS) sftp.Handlers {
+	h := sftpTree{tree: tree, ctx: ctx}
+	return sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h}
+}
+
+// sftpTree adapts treeFS to the SFTP request server
+type sftpTree struct {
+	tree treeFS
+	ctx  context.Context
+}
+
+func (t sftpTree) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	f, err := t.tree.OpenFile(t.ctx, r.Filepath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	file := f.(*treeFile)
+	if file.info.dir {
+		return nil, fs.ErrInvalid
+	}
+	return file, nil
+}
+
+func (t sftpTree) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	flag := os.O_WRONLY | os.O_CREATE
+	if r.Pflags().Trunc {
+		flag |= os.O_TRUNC
+	}
+	f, err := t.tree.OpenFile(t.ctx, r.Filepath, flag, 0)
+	if err != nil {
+		return nil, err
+	}
+	return f.(*treeWriter), nil
+}
+
+func (t sftpTree) Filecmd(r *sftp.Request) error {
+	switch r.Method {
+	case "Setstat":
+		// Modes and times are not kept
+		return nil
+	case "Rename":
+		return t.tree.Rename(t.ctx, r.Filepath, r.Target)
+	case "Remove", "Rmdir":
+		return t.tree.RemoveAll(t.ctx, r.Filepath)
+	case "Mkdir":
+		return t.tree.Mkdir(t.ctx, r.Filepath, 0)
+	}
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+func (t sftpTree) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	switch r.Method {
+	case "List":
+		entries, exists, err := t.tree.children(t.ctx, r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fs.ErrNotExist
+		}
+		return sftpList(entries), nil
+	case "Stat":
+		info, err := t.tree.Stat(t.ctx, r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		return sftpList{info}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+// sftpList pages through directory entries
+type sftpList []fs.FileInfo
+
+func (l sftpList) ListAt(out []fs.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(out, l[offset:])
+	if offset+int64(n) >= int64(len(l)) {
+		return n, io.EOF
+	}
+	return n, nil
+}
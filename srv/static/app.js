@@ -2,7 +2,7 @@
 (function() {
     'use strict';
 
-    let adminToken = localStorage.getItem('adminToken') || '';
+    let csrfToken = '';
     let currentScript = null;
     let scripts = [];
     let folders = [];
@@ -19,7 +19,7 @@
             method,
             headers: {
                 'Content-Type': 'application/json',
-                'X-Admin-Token': adminToken
+                'X-CSRF-Token': csrfToken
             }
         };
         if (body) {
@@ -28,7 +28,14 @@
         const res = await fetch(path, opts);
         if (!res.ok) {
             const text = await res.text();
-            throw new Error(text || res.statusText);
+            let message = text || res.statusText;
+            try {
+                const { error } = JSON.parse(text);
+                message = [error.message, ...(error.fields || []).map(f => f.message)].join('\n');
+            } catch (e) {
+                // Not an API error, e.g. from /login
+            }
+            throw new Error(message);
         }
         if (res.status === 204) return null;
         return res.json();
@@ -53,24 +60,28 @@
         if (!serverConfig.auth_required) {
             $('#auth-modal').classList.remove('active');
             await loadData();
-        } else if (adminToken) {
-            // Check if we have a valid token
+        } else {
+            // Tokens were kept in localStorage before session login existed
+            localStorage.removeItem('adminToken');
+            $('#btn-logout').hidden = false;
+            $('#btn-sso').hidden = !serverConfig.oidc;
+            // Resume an existing session
             try {
-                await api('GET', '/api/scripts');
+                const session = await api('GET', '/api/v1/session');
+                csrfToken = session.csrf_token || '';
                 $('#auth-modal').classList.remove('active');
                 await loadData();
             } catch (e) {
-                adminToken = '';
-                localStorage.removeItem('adminToken');
+                // Not logged in; keep the auth modal open
             }
         }
 
         // Auth modal
         $('#btn-auth').addEventListener('click', async () => {
-            adminToken = $('#admin-token').value;
             try {
-                await api('GET', '/api/scripts');
-                localStorage.setItem('adminToken', adminToken);
+                const session = await api('POST', '/login', { token: $('#admin-token').value });
+                csrfToken = session.csrf_token;
+                $('#admin-token').value = '';
                 $('#auth-modal').classList.remove('active');
                 await loadData();
             } catch (e) {
@@ -78,6 +89,12 @@
             }
         });
 
+        $('#btn-logout').addEventListener('click', async () => {
+            await api('POST', '/logout');
+            csrfToken = '';
+            location.reload();
+        });
+
         $('#admin-token').addEventListener('keypress', (e) => {
             if (e.key === 'Enter') $('#btn-auth').click();
         });
@@ -119,7 +136,7 @@
                 return;
             }
             try {
-                await api('POST', '/api/folders', { path });
+                await api('POST', '/api/v1/folders', { path });
                 $('#folder-modal').classList.remove('active');
                 await loadData();
             } catch (e) {
@@ -137,9 +154,24 @@
         // Delete button
         $('#btn-delete').addEventListener('click', async () => {
             if (!currentScript || !currentScript.id) return;
-            if (!confirm('Delete this script?')) return;
+            let query = '';
+            if (currentScript.library) {
+                // Libraries may be sourced by other scripts; show them before deleting
+                try {
+                    const deps = await api('GET', `/api/v1/scripts/${currentScript.id}/dependents`);
+                    if (deps.length > 0) {
+                        const list = deps.map(d => '  ' + d.path).join('\n');
+                        if (!confirm(`This library is used by:\n${list}\n\nDelete anyway?`)) return;
+                        query = '?force=1';
+                    }
+                } catch (e) {
+                    alert('Failed to check dependents: ' + e.message);
+                    return;
+                }
+            }
+            if (!query && !confirm('Delete this script?')) return;
             try {
-                await api('DELETE', `/api/scripts/${currentScript.id}`);
+                await api('DELETE', `/api/v1/scripts/${currentScript.id}${query}`);
                 currentScript = null;
                 showWelcome();
                 await loadData();
@@ -148,6 +180,26 @@
             }
         });
 
+        // Kill switch: disable/enable without touching content or history
+        $('#btn-disable').addEventListener('click', async () => {
+            if (!currentScript || !currentScript.id) return;
+            try {
+                let result;
+                if (currentScript.disabled) {
+                    result = await api('POST', `/api/v1/scripts/${currentScript.id}/enable`);
+                } else {
+                    const reason = prompt('Reason shown to users running this script:', '');
+                    if (reason === null) return;
+                    result = await api('POST', `/api/v1/scripts/${currentScript.id}/disable`, { reason });
+                }
+                currentScript = result;
+                $('#btn-disable').textContent = result.disabled ? 'Enable' : 'Disable';
+                await loadData();
+            } catch (e) {
+                alert('Failed to update script: ' + e.message);
+            }
+        });
+
         // Search
         let searchTimeout;
         $('#search-input').addEventListener('input', (e) => {
@@ -176,6 +228,36 @@
             }
         });
 
+        // Context menu for folder locks
+        $('#ctx-lock-folder').addEventListener('click', async () => {
+            if (!contextMenuFolder) return;
+            hideContextMenu();
+            
+            const password = prompt(`Password for every script under "${contextMenuFolder.path}":`);
+            if (!password) return;
+            
+            try {
+                await api('POST', `/api/v1/folders/${contextMenuFolder.id}/lock`, { password });
+                await loadData();
+            } catch (e) {
+                alert('Failed to lock folder: ' + e.message);
+            }
+            contextMenuFolder = null;
+        });
+
+        $('#ctx-unlock-folder').addEventListener('click', async () => {
+            if (!contextMenuFolder) return;
+            hideContextMenu();
+            
+            try {
+                await api('POST', `/api/v1/folders/${contextMenuFolder.id}/unlock`);
+                await loadData();
+            } catch (e) {
+                alert('Failed to unlock folder: ' + e.message);
+            }
+            contextMenuFolder = null;
+        });
+
         // Context menu for folder deletion
         $('#ctx-delete-folder').addEventListener('click', async () => {
             if (!contextMenuFolder) return;
@@ -186,7 +268,7 @@
             }
             
             try {
-                await api('DELETE', `/api/folders/${contextMenuFolder.id}`);
+                await api('DELETE', `/api/v1/folders/${contextMenuFolder.id}`);
                 await loadData();
             } catch (e) {
                 alert('Failed to delete folder: ' + e.message);
@@ -204,9 +286,10 @@
 
     async function loadData() {
         try {
-            scripts = await api('GET', '/api/scripts');
-            folders = await api('GET', '/api/folders');
+            scripts = await api('GET', '/api/v1/scripts');
+            folders = await api('GET', '/api/v1/folders');
             renderTree();
+            loadDiscover();
         } catch (e) {
             console.error('Failed to load data:', e);
         }
@@ -226,6 +309,7 @@
                 if (!node.children[part]) {
                     node.children[part] = { 
                         id: i === parts.length - 1 ? f.id : null,
+                        locked: i === parts.length - 1 && f.locked,
                         name: part, 
                         path: '/' + parts.slice(0, i + 1).join('/'),
                         children: {}, 
@@ -295,7 +379,8 @@
         folderKeys.forEach(key => {
             const folder = node.children[key];
             const folderId = folder.id ? `data-folder-id="${folder.id}"` : '';
-            html += `<div class="tree-item folder" data-path="${folder.path}" ${folderId}>
+            const lockedClass = folder.locked ? ' locked' : '';
+            html += `<div class="tree-item folder${lockedClass}" data-path="${folder.path}" ${folderId}>
                 <span class="icon">📂</span>
                 <span class="name">${folder.name}</span>
             </div>`;
@@ -306,8 +391,14 @@
         if (node.scripts) {
             node.scripts.sort((a, b) => a.name.localeCompare(b.name)).forEach(s => {
                 const lockedClass = s.locked ? ' locked' : '';
-                html += `<div class="tree-item script${lockedClass}" data-id="${s.id}" data-path="${s.path}" draggable="true">
-                    <span class="icon">📄</span>
+                const deprecatedClass = s.deprecated ? ' deprecated' : '';
+                const disabledClass = s.disabled ? ' disabled' : '';
+                const expiredClass = s.expired || s.archived ? ' expired' : '';
+                const unlistedClass = s.unlisted ? ' unlisted' : '';
+                const privateClass = s.private ? ' private' : '';
+                const icon = s.library ? '📚' : '📄';
+                html += `<div class="tree-item script${lockedClass}${deprecatedClass}${disabledClass}${expiredClass}${unlistedClass}${privateClass}" data-id="${s.id}" data-path="${s.path}" draggable="true">
+                    <span class="icon">${icon}</span>
                     <span class="name">${s.name}</span>
                 </div>`;
             });
@@ -368,7 +459,7 @@
                 if (newPath === draggedScript.path) return;
                 
                 try {
-                    await api('PUT', `/api/scripts/${draggedScript.id}`, {
+                    await api('PUT', `/api/v1/scripts/${draggedScript.id}`, {
                         ...draggedScript,
                         path: newPath
                     });
@@ -384,6 +475,9 @@
                 const folderId = el.dataset.folderId;
                 if (folderId) {
                     contextMenuFolder = { id: folderId, path: el.dataset.path };
+                    const locked = el.classList.contains('locked');
+                    $('#ctx-lock-folder').textContent = locked ? '🔒 Change Folder Password' : '🔒 Lock Folder';
+                    $('#ctx-unlock-folder').style.display = locked ? '' : 'none';
                     showContextMenu(e.clientX, e.clientY);
                 }
             });
@@ -404,7 +498,7 @@
             if (newPath === draggedScript.path) return;
             
             try {
-                await api('PUT', `/api/scripts/${draggedScript.id}`, {
+                await api('PUT', `/api/v1/scripts/${draggedScript.id}`, {
                     ...draggedScript,
                     path: newPath
                 });
@@ -443,6 +537,50 @@
         });
     }
 
+    // Fill the welcome view with the popular and recently updated lists
+    async function loadDiscover() {
+        const lists = [
+            ['#popular-list', '/_popular.json', e => `${e.fetches} fetches`],
+            ['#recent-list', '/_recent.json', e => new Date(e.updated_at).toLocaleDateString()],
+        ];
+        for (const [sel, url, detail] of lists) {
+            const ul = $(sel);
+            ul.innerHTML = '';
+            let entries = [];
+            try {
+                const res = await fetch(url);
+                if (res.ok) entries = await res.json();
+            } catch (e) {
+                console.error('Failed to load ' + url, e);
+            }
+            if (entries.length === 0) {
+                const li = document.createElement('li');
+                li.className = 'empty';
+                li.textContent = 'Nothing yet';
+                ul.appendChild(li);
+                continue;
+            }
+            entries.forEach(e => {
+                const li = document.createElement('li');
+                const path = document.createElement('span');
+                path.className = 'path';
+                path.textContent = e.path;
+                const info = document.createElement('span');
+                info.className = 'detail';
+                info.textContent = detail(e);
+                li.append(path, info);
+                li.addEventListener('click', () => {
+                    const script = scripts.find(s => s.path === e.path);
+                    if (script) {
+                        currentScript = script;
+                        showEditor(script);
+                    }
+                });
+                ul.appendChild(li);
+            });
+        }
+    }
+
     function showWelcome() {
         $('#welcome-view').classList.add('active');
         $('#editor-view').classList.remove('active');
@@ -458,13 +596,40 @@
         $('#script-tags').value = script.tags || '';
         $('#script-requires').value = script.requires || '';
         $('#script-locked').checked = script.locked || false;
+        $('#script-unlisted').checked = script.unlisted || false;
+        $('#script-private').checked = script.private || false;
         $('#script-password').value = '';
+        $('#script-unlock-ttl').value = script.unlock_ttl || '';
+        $('#script-max-per-hour').value = script.max_downloads_per_hour || '';
+        $('#script-max-per-day').value = script.max_downloads_per_day || '';
         $('#script-danger').value = script.danger_level || 0;
+        $('#script-deprecated').checked = script.deprecated || false;
+        $('#script-replacement').value = script.replacement_path || '';
+        $('#script-allow-countries').value = script.allow_countries || '';
+        $('#script-deny-countries').value = script.deny_countries || '';
+        $('#script-sunset').value = script.sunset_at ? script.sunset_at.slice(0, 10) : '';
+        $('#script-available-from').value = toLocalInput(script.available_from);
+        $('#script-available-until').value = toLocalInput(script.available_until);
+        $('#script-expires').value = toLocalInput(script.expires_at);
+        $('#btn-disable').textContent = script.disabled ? 'Enable' : 'Disable';
+        $('#btn-disable').style.display = script.id ? '' : 'none';
         
         updateCurlCommand();
         updateScriptInfo();
     }
 
+    // Convert an RFC 3339 timestamp to a datetime-local input value
+    function toLocalInput(iso) {
+        if (!iso) return '';
+        const d = new Date(iso);
+        d.setMinutes(d.getMinutes() - d.getTimezoneOffset());
+        return d.toISOString().slice(0, 16);
+    }
+
+    function fromLocalInput(value) {
+        return value ? new Date(value).toISOString() : null;
+    }
+
     function updateCurlCommand() {
         const path = $('#script-path').value;
         if (path && path.endsWith('.sh')) {
@@ -503,8 +668,21 @@ curl -fsSL https://${hostname}/install.sh | sh`;
             tags: $('#script-tags').value,
             requires: $('#script-requires').value,
             locked: $('#script-locked').checked,
+            unlisted: $('#script-unlisted').checked,
+            private: $('#script-private').checked,
             password: $('#script-password').value,
-            danger_level: parseInt($('#script-danger').value) || 0
+            unlock_ttl: parseInt($('#script-unlock-ttl').value) || 0,
+            max_downloads_per_hour: parseInt($('#script-max-per-hour').value) || 0,
+            max_downloads_per_day: parseInt($('#script-max-per-day').value) || 0,
+            danger_level: parseInt($('#script-danger').value) || 0,
+            deprecated: $('#script-deprecated').checked,
+            replacement_path: $('#script-replacement').value,
+            allow_countries: $('#script-allow-countries').value,
+            deny_countries: $('#script-deny-countries').value,
+            sunset_at: $('#script-sunset').value ? new Date($('#script-sunset').value).toISOString() : null,
+            available_from: fromLocalInput($('#script-available-from').value),
+            available_until: fromLocalInput($('#script-available-until').value),
+            expires_at: fromLocalInput($('#script-expires').value)
         };
         
         if (!data.path || !data.path.startsWith('/') || !data.path.endsWith('.sh')) {
@@ -515,14 +693,19 @@ curl -fsSL https://${hostname}/install.sh | sh`;
         try {
             let result;
             if (currentScript && currentScript.id) {
-                result = await api('PUT', `/api/scripts/${currentScript.id}`, data);
+                result = await api('PUT', `/api/v1/scripts/${currentScript.id}`, data);
             } else {
-                result = await api('POST', '/api/scripts', data);
+                result = await api('POST', '/api/v1/scripts', data);
             }
             currentScript = result;
             updateScriptInfo();
             await loadData();
-            alert('Saved!');
+            if (result.warnings && result.warnings.length) {
+                const lines = result.warnings.map(w => w.line ? `line ${w.line}: ${w.message} (${w.match})` : w.message);
+                alert('Saved with warnings:\n\n' + lines.join('\n'));
+            } else {
+                alert('Saved!');
+            }
         } catch (e) {
             alert('Failed to save: ' + e.message);
         }
@@ -0,0 +1,196 @@
+package srv
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// statsDayLayout is the format of script_daily_stats.day
+const statsDayLayout = "2006-01-02"
+
+// Histogram window for the stats endpoints, in days
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+)
+
+// recordFetch counts a successful serve of a script
+func (s *Server) recordFetch(r *http.Request, scriptID string) {
+	ctx := r.Context()
+	now := time.Now()
+	day := now.UTC().Format(statsDayLayout)
+	q := s.queries()
+	if err := q.RecordScriptFetch(ctx, dbgen.RecordScriptFetchParams{ScriptID: scriptID, LastFetchedAt: &now}); err != nil {
+		slog.WarnContext(ctx, "failed to record fetch", "script", scriptID, "error", err)
+		return
+	}
+	q.RecordScriptDailyFetch(ctx, dbgen.RecordScriptDailyFetchParams{ScriptID: scriptID, Day: day})
+	q.RecordFetchConsumer(ctx, dbgen.RecordFetchConsumerParams{ScriptID: scriptID, Day: day, Consumer: s.consumerID(r, day)})
+	for kind, value := range s.fetchBreakdown(r) {
+		q.RecordFetchBreakdown(ctx, dbgen.RecordFetchBreakdownParams{ScriptID: scriptID, Day: day, Kind: kind, Value: value})
+	}
+}
+
+// statsDays reads the days query parameter
+func statsDays(r *http.Request) int {
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+	if err != nil || days <= 0 {
+		return defaultStatsDays
+	}
+	return min(days, maxStatsDays)
+}
+
+// statsSince returns the first day of a window of days ending today (UTC)
+func statsSince(now time.Time, days int) string {
+	return now.UTC().AddDate(0, 0, -(days - 1)).Format(statsDayLayout)
+}
+
+// DailyFetches is one bar of a fetch histogram. Consumers estimates how
+// many different clients the fetches came from.
+type DailyFetches struct {
+	Day       string `json:"day"`
+	Fetches   int64  `json:"fetches"`
+	Consumers int64  `json:"consumers"`
+}
+
+// ScriptStatsResponse is the fetch history of one script
+type ScriptStatsResponse struct {
+	ScriptID      string         `json:"script_id"`
+	Path          string         `json:"path"`
+	Fetches       int64          `json:"fetches"`
+	LastFetchedAt *time.Time     `json:"last_fetched_at"`
+	Daily         []DailyFetches `json:"daily"`
+}
+
+// dailyHistogram fills the days without fetches in with zeroes
+func dailyHistogram(rows []dbgen.ScriptDailyStat, consumers []dbgen.ListScriptDailyConsumersRow, now time.Time, days int) []DailyFetches {
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Day] += row.Fetches
+	}
+	unique := make(map[string]int64, len(consumers))
+	for _, row := range consumers {
+		unique[row.Day] = row.Consumers
+	}
+	out := make([]DailyFetches, days)
+	start := now.UTC().AddDate(0, 0, -(days - 1))
+	for i := range out {
+		day := start.AddDate(0, 0, i).Format(statsDayLayout)
+		out[i] = DailyFetches{Day: day, Fetches: counts[day], Consumers: unique[day]}
+	}
+	return out
+}
+
+// APIScriptStats returns a script's fetch count, last fetch and daily histogram
+func (s *Server) APIScriptStats(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	days := statsDays(r)
+	now := time.Now()
+
+	q := s.queries()
+	script, err := q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+
+	resp := ScriptStatsResponse{ScriptID: script.ID, Path: script.Path}
+	if st, err := q.GetScriptStats(r.Context(), id); err == nil {
+		resp.Fetches = st.Fetches
+		resp.LastFetchedAt = st.LastFetchedAt
+	}
+	since := statsSince(now, days)
+	rows, err := q.ListScriptDailyStats(r.Context(), dbgen.ListScriptDailyStatsParams{ScriptID: id, Day: since})
+	if err != nil {
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return
+	}
+	consumers, err := q.ListScriptDailyConsumers(r.Context(), dbgen.ListScriptDailyConsumersParams{ScriptID: id, Day: since})
+	if err != nil {
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return
+	}
+	resp.Daily = dailyHistogram(rows, consumers, now, days)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ScriptUsage summarizes how much one script is used. RecentConsumers adds
+// up the distinct clients of each day, since clients are not tracked across
+// days.
+type ScriptUsage struct {
+	ScriptID        string     `json:"script_id"`
+	Path            string     `json:"path"`
+	Fetches         int64      `json:"fetches"`
+	RecentFetches   int64      `json:"recent_fetches"`
+	RecentConsumers int64      `json:"recent_consumers"`
+	LastFetchedAt   *time.Time `json:"last_fetched_at"`
+}
+
+// APIListScriptStats returns usage of every script, most fetched first;
+// scripts nobody fetches come last with zero counts
+func (s *Server) APIListScriptStats(w http.ResponseWriter, r *http.Request) {
+	days := statsDays(r)
+
+	q := s.queries()
+	scripts, err := q.ListScriptMetadata(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list scripts", http.StatusInternalServerError)
+		return
+	}
+	totals, err := q.ListScriptStats(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return
+	}
+	since := statsSince(time.Now(), days)
+	recent, err := q.SumDailyFetchesByScript(r.Context(), since)
+	if err != nil {
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return
+	}
+	consumers, err := q.SumDailyConsumersByScript(r.Context(), since)
+	if err != nil {
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return
+	}
+
+	byID := make(map[string]*ScriptUsage, len(scripts))
+	usage := make([]ScriptUsage, len(scripts))
+	for i, sc := range scripts {
+		usage[i] = ScriptUsage{ScriptID: sc.ID, Path: sc.Path}
+		byID[sc.ID] = &usage[i]
+	}
+	for _, t := range totals {
+		if u, ok := byID[t.ScriptID]; ok {
+			u.Fetches = t.Fetches
+			u.LastFetchedAt = t.LastFetchedAt
+		}
+	}
+	for _, rc := range recent {
+		if u, ok := byID[rc.ScriptID]; ok {
+			u.RecentFetches = rc.Fetches
+		}
+	}
+	for _, c := range consumers {
+		if u, ok := byID[c.ScriptID]; ok {
+			u.RecentConsumers = c.Consumers
+		}
+	}
+	sort.SliceStable(usage, func(i, j int) bool {
+		if usage[i].RecentFetches != usage[j].RecentFetches {
+			return usage[i].RecentFetches > usage[j].RecentFetches
+		}
+		return usage[i].Fetches > usage[j].Fetches
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
@@ -0,0 +1,511 @@
+package srv
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/hunydev/sh-server/db"
+	"github.com/hunydev/sh-server/db/dbgen"
+	"github.com/hunydev/sh-server/storage"
+)
+
+// StorageConfig keeps script content in a storage backend instead of the
+// scripts table; metadata stays in the database
+type StorageConfig struct {
+	Backend     string // "sqlite", "fs" or "s3"; empty keeps content in the scripts table
+	Dir         string // directory of the fs backend
+	S3          storage.S3Config
+	Compression string // "zstd" compresses content; with no Backend it goes to "sqlite"
+}
+
+func openStorage(cfg StorageConfig, database dbgen.DBTX) (storage.Backend, error) {
+	switch cfg.Compression {
+	case "":
+	case "zstd":
+		if cfg.Backend == "" {
+			cfg.Backend = "sqlite"
+		}
+	default:
+		return nil, fmt.Errorf("unknown compression %q", cfg.Compression)
+	}
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case "sqlite":
+		return storage.NewSQLite(database), nil
+	case "fs":
+		if cfg.Dir == "" {
+			return nil, errors.New("the fs storage backend needs a directory")
+		}
+		return storage.NewFS(cfg.Dir)
+	case "s3":
+		return storage.NewS3(cfg.S3)
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+}
+
+// contentKey addresses content by its hash, so versions with the same
+// content share one object
+func contentKey(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	h := hex.EncodeToString(sum[:])
+	return "content/" + h[:2] + "/" + h
+}
+
+// queries is dbgen.Queries with the content of scripts and versions kept
+// in the storage backend, if there is one. Queries that read or write
+// content are wrapped; the rest are passed through.
+type queries struct {
+	*dbgen.Queries
+	store    storage.Backend
+	compress bool         // zstd-compress content put in store
+	cache    *scriptCache // of GetScriptByPath; nil if disabled
+	writes   *writeQueue  // of audit and analytics writes; nil if disabled
+
+	txDB      *sql.DB // where transactions begin
+	dialect   db.Dialect
+	committed func() // invalidates the caches a transaction's writes bypass
+}
+
+func (s *Server) queries() *queries {
+	return &queries{
+		Queries: s.prepared, store: s.store, compress: s.compress, cache: s.scriptCache, writes: s.writes,
+		txDB: s.DB, dialect: s.dialect, committed: s.invalidateCaches,
+	}
+}
+
+// inTx runs fn with queries whose database reads and writes are in one
+// transaction. Content is put in the store outside of it, since the
+// sqlite backend writes through the same connection.
+func (q *queries) inTx(ctx context.Context, fn func(tx *queries) error) error {
+	tx, err := q.txDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	txq := *q
+	txq.Queries, txq.cache = dbgen.New(db.Conn(tx, q.dialect)), nil
+	if err := fn(&txq); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	q.committed()
+	return nil
+}
+
+// put stores content and returns its key
+func (q *queries) put(ctx context.Context, content string) (*string, error) {
+	key, data := contentKey(content), []byte(content)
+	if q.compress {
+		key, data = compressedKey(key), compress(data)
+	}
+	if err := q.store.Put(ctx, key, data); err != nil {
+		return nil, fmt.Errorf("store content: %w", err)
+	}
+	return &key, nil
+}
+
+// load replaces content with the stored object ref points to, if any
+func (q *queries) load(ctx context.Context, ref *string, content *string) error {
+	if ref == nil {
+		return nil
+	}
+	if q.store == nil {
+		return errors.New("content is in a storage backend, but none is configured")
+	}
+	data, err := q.store.Get(ctx, *ref)
+	if err != nil {
+		return fmt.Errorf("load content %s: %w", *ref, err)
+	}
+	if isCompressedKey(*ref) {
+		if data, err = decompress(data); err != nil {
+			return fmt.Errorf("load content %s: %w", *ref, err)
+		}
+	}
+	*content = string(data)
+	return nil
+}
+
+func (q *queries) loadScripts(ctx context.Context, scripts []dbgen.Script) ([]dbgen.Script, error) {
+	for i := range scripts {
+		if err := q.load(ctx, scripts[i].ContentRef, &scripts[i].Content); err != nil {
+			return nil, err
+		}
+	}
+	return scripts, nil
+}
+
+func (q *queries) GetScript(ctx context.Context, id string) (dbgen.Script, error) {
+	script, err := q.Queries.GetScript(ctx, id)
+	if err != nil {
+		return script, err
+	}
+	return script, q.load(ctx, script.ContentRef, &script.Content)
+}
+
+func (q *queries) GetScriptByPath(ctx context.Context, path string) (dbgen.Script, error) {
+	var gen uint64
+	if q.cache != nil {
+		var script dbgen.Script
+		var ok bool
+		if script, ok, gen = q.cache.get(path); ok {
+			return script, nil
+		}
+	}
+	script, err := q.Queries.GetScriptByPath(ctx, path)
+	if err != nil {
+		return script, err
+	}
+	if err := q.load(ctx, script.ContentRef, &script.Content); err != nil {
+		return script, err
+	}
+	if q.cache != nil {
+		q.cache.put(gen, script)
+	}
+	return script, nil
+}
+
+func (q *queries) ListScripts(ctx context.Context) ([]dbgen.Script, error) {
+	scripts, err := q.Queries.ListScripts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return q.loadScripts(ctx, scripts)
+}
+
+func (q *queries) ListFavorites(ctx context.Context) ([]dbgen.Script, error) {
+	scripts, err := q.Queries.ListFavorites(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return q.loadScripts(ctx, scripts)
+}
+
+func (q *queries) ListRecentlyUpdated(ctx context.Context, limit int64) ([]dbgen.Script, error) {
+	scripts, err := q.Queries.ListRecentlyUpdated(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return q.loadScripts(ctx, scripts)
+}
+
+func (q *queries) ListScriptsByFolder(ctx context.Context, arg dbgen.ListScriptsByFolderParams) ([]dbgen.Script, error) {
+	scripts, err := q.Queries.ListScriptsByFolder(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	return q.loadScripts(ctx, scripts)
+}
+
+// ListScriptMetadata lists scripts with their content left empty, for
+// listings that don't need to read it, let alone load it from storage
+func (q *queries) ListScriptMetadata(ctx context.Context) ([]dbgen.Script, error) {
+	rows, err := q.Queries.ListScriptMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scripts := make([]dbgen.Script, len(rows))
+	for i, row := range rows {
+		scripts[i] = dbgen.Script(row)
+	}
+	return scripts, nil
+}
+
+func (q *queries) SearchScriptMetadata(ctx context.Context, arg dbgen.SearchScriptMetadataParams) ([]dbgen.Script, error) {
+	rows, err := q.Queries.SearchScriptMetadata(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	scripts := make([]dbgen.Script, len(rows))
+	for i, row := range rows {
+		scripts[i] = dbgen.Script(row)
+	}
+	return scripts, nil
+}
+
+// ListScriptsReferencing searches content, which SQL can't do once it is
+// in a storage backend
+func (q *queries) ListScriptsReferencing(ctx context.Context, arg dbgen.ListScriptsReferencingParams) ([]dbgen.Script, error) {
+	if q.store == nil {
+		return q.Queries.ListScriptsReferencing(ctx, arg)
+	}
+	scripts, err := q.ListScripts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var found []dbgen.Script
+	for _, sc := range scripts {
+		if path, _ := arg.Column1.(string); sc.ID != arg.ID && strings.Contains(sc.Content, path) {
+			found = append(found, sc)
+		}
+	}
+	return found, nil
+}
+
+// setContentSize records the size of the content a script was saved with,
+// which is not in the scripts table if it is stored
+func (q *queries) setContentSize(ctx context.Context, id, content string) error {
+	size := int64(len(content))
+	return q.SetScriptContentSize(ctx, dbgen.SetScriptContentSizeParams{ContentSize: &size, ID: id})
+}
+
+// CreateScript inserts the script and records its content in one
+// transaction, so a failure can't leave a script without content
+func (q *queries) CreateScript(ctx context.Context, arg dbgen.CreateScriptParams) error {
+	content := arg.Content
+	if q.store == nil {
+		return q.inTx(ctx, func(tx *queries) error {
+			if err := tx.Queries.CreateScript(ctx, arg); err != nil {
+				return err
+			}
+			return tx.setContentSize(ctx, arg.ID, content)
+		})
+	}
+	ref, err := q.put(ctx, arg.Content)
+	if err != nil {
+		return err
+	}
+	arg.Content = ""
+	err = q.inTx(ctx, func(tx *queries) error {
+		if err := tx.Queries.CreateScript(ctx, arg); err != nil {
+			return err
+		}
+		if err := tx.SetScriptContentRef(ctx, dbgen.SetScriptContentRefParams{ContentRef: ref, ID: arg.ID}); err != nil {
+			return err
+		}
+		return tx.setContentSize(ctx, arg.ID, content)
+	})
+	if err != nil {
+		q.deleteUnreferenced(ctx, []*string{ref})
+	}
+	return err
+}
+
+func (q *queries) UpdateScript(ctx context.Context, arg dbgen.UpdateScriptParams) error {
+	content := arg.Content
+	if q.store != nil {
+		arg.Content = ""
+	}
+	return q.replaceContent(ctx, arg.ID, content, func(tx *queries) error { return tx.Queries.UpdateScript(ctx, arg) })
+}
+
+func (q *queries) UpdateScriptContent(ctx context.Context, arg dbgen.UpdateScriptContentParams) error {
+	content := arg.Content
+	if q.store != nil {
+		arg.Content = ""
+	}
+	return q.replaceContent(ctx, arg.ID, content, func(tx *queries) error { return tx.Queries.UpdateScriptContent(ctx, arg) })
+}
+
+// replaceContent runs update, which writes a script's row with its content
+// left out if it is stored, and records the new content in one
+// transaction. The stored content the script had before is deleted once
+// nothing refers to it.
+func (q *queries) replaceContent(ctx context.Context, id, content string, update func(tx *queries) error) error {
+	if q.store == nil {
+		return q.inTx(ctx, func(tx *queries) error {
+			if err := update(tx); err != nil {
+				return err
+			}
+			return tx.setContentSize(ctx, id, content)
+		})
+	}
+	ref, err := q.put(ctx, content)
+	if err != nil {
+		return err
+	}
+	var old *string
+	err = q.inTx(ctx, func(tx *queries) error {
+		if script, err := tx.Queries.GetScript(ctx, id); err == nil {
+			old = script.ContentRef
+		}
+		if err := update(tx); err != nil {
+			return err
+		}
+		if err := tx.SetScriptContentRef(ctx, dbgen.SetScriptContentRefParams{ContentRef: ref, ID: id}); err != nil {
+			return err
+		}
+		return tx.setContentSize(ctx, id, content)
+	})
+	if err != nil {
+		return err
+	}
+	if old != nil && *old != *ref {
+		q.deleteUnreferenced(ctx, []*string{old})
+	}
+	return nil
+}
+
+// DeleteScript also deletes stored content no other script or version
+// refers to
+func (q *queries) DeleteScript(ctx context.Context, id string) error {
+	if q.store == nil {
+		return q.Queries.DeleteScript(ctx, id)
+	}
+	refs, err := q.ListVersionContentRefs(ctx, id)
+	if err != nil {
+		return err
+	}
+	if script, err := q.Queries.GetScript(ctx, id); err == nil && script.ContentRef != nil {
+		refs = append(refs, script.ContentRef)
+	}
+	if err := q.Queries.DeleteScript(ctx, id); err != nil {
+		return err
+	}
+	q.deleteUnreferenced(ctx, refs)
+	return nil
+}
+
+// deleteUnreferenced deletes the stored content of refs that no script or
+// version refers to anymore
+func (q *queries) deleteUnreferenced(ctx context.Context, refs []*string) {
+	for _, ref := range refs {
+		scripts, err := q.CountScriptContentRefs(ctx, ref)
+		if err != nil || scripts > 0 {
+			continue
+		}
+		versions, err := q.CountVersionContentRefs(ctx, ref)
+		if err != nil || versions > 0 {
+			continue
+		}
+		if err := q.store.Delete(ctx, *ref); err != nil {
+			slog.WarnContext(ctx, "storage: failed to delete content", "key", *ref, "error", err)
+		}
+	}
+}
+
+func (q *queries) CreateVersion(ctx context.Context, arg dbgen.CreateVersionParams) error {
+	if q.store == nil {
+		return q.Queries.CreateVersion(ctx, arg)
+	}
+	ref, err := q.put(ctx, arg.Content)
+	if err != nil {
+		return err
+	}
+	return q.CreateStoredVersion(ctx, dbgen.CreateStoredVersionParams{
+		ScriptID:   arg.ScriptID,
+		ContentRef: ref,
+		Version:    arg.Version,
+		Message:    arg.Message,
+		CreatedAt:  arg.CreatedAt,
+	})
+}
+
+func (q *queries) GetVersion(ctx context.Context, arg dbgen.GetVersionParams) (dbgen.ScriptVersion, error) {
+	v, err := q.Queries.GetVersion(ctx, arg)
+	if err != nil {
+		return v, err
+	}
+	return v, q.load(ctx, v.ContentRef, &v.Content)
+}
+
+func (q *queries) ListVersions(ctx context.Context, scriptID string) ([]dbgen.ScriptVersion, error) {
+	versions, err := q.Queries.ListVersions(ctx, scriptID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		if err := q.load(ctx, versions[i].ContentRef, &versions[i].Content); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+func (q *queries) ListAllVersions(ctx context.Context) ([]dbgen.ListAllVersionsRow, error) {
+	versions, err := q.Queries.ListAllVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		if err := q.load(ctx, versions[i].ContentRef, &versions[i].Content); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+// setUpStorage moves content still in the scripts and versions tables to
+// the storage backend, as when one is first configured. Without a backend
+// it refuses a database whose content is in one. Scripts saved before
+// content sizes were recorded are measured.
+func (s *Server) setUpStorage(ctx context.Context) error {
+	q := s.queries()
+	if s.store == nil {
+		n, err := q.CountStoredContent(ctx)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return errors.New("script content is in a storage backend; configure STORAGE or STORAGE_COMPRESSION to read it")
+		}
+		return measureContent(ctx, q)
+	}
+
+	scripts, err := q.Queries.ListScripts(ctx)
+	if err != nil {
+		return err
+	}
+	moved := 0
+	for _, sc := range scripts {
+		if sc.ContentRef != nil {
+			continue
+		}
+		ref, err := q.put(ctx, sc.Content)
+		if err != nil {
+			return err
+		}
+		if err := q.SetScriptContentRef(ctx, dbgen.SetScriptContentRefParams{ContentRef: ref, ID: sc.ID}); err != nil {
+			return err
+		}
+		moved++
+	}
+	versions, err := q.ListInlineVersions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		ref, err := q.put(ctx, v.Content)
+		if err != nil {
+			return err
+		}
+		if err := q.SetVersionContentRef(ctx, dbgen.SetVersionContentRefParams{ContentRef: ref, ID: v.ID}); err != nil {
+			return err
+		}
+	}
+	if moved+len(versions) > 0 {
+		slog.Info("storage: moved content to the storage backend", "scripts", moved, "versions", len(versions))
+	}
+	if s.compress {
+		if err := compressStoredContent(ctx, q); err != nil {
+			return err
+		}
+	}
+	return measureContent(ctx, q)
+}
+
+// measureContent records the content size of scripts that have none
+func measureContent(ctx context.Context, q *queries) error {
+	ids, err := q.ListUnsizedScriptIDs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		sc, err := q.GetScript(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := q.setContentSize(ctx, id, sc.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
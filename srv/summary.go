@@ -0,0 +1,113 @@
+package srv
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/hunydev/sh-server/db"
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// Sizes of the lists in the dashboard summary
+const (
+	summaryTopScripts = 5
+	summaryFailures   = 10
+)
+
+// SummaryTokens counts credentials that currently work
+type SummaryTokens struct {
+	Unlock      int64 `json:"unlock"`
+	Sessions    int64 `json:"sessions"`
+	APITokens   int64 `json:"api_tokens"`
+	AdminTokens int64 `json:"admin_tokens"`
+	ShareLinks  int64 `json:"share_links"`
+}
+
+// StatsSummary is everything the admin dashboard shows on its landing page
+type StatsSummary struct {
+	Scripts        int64             `json:"scripts"`
+	Folders        int64             `json:"folders"`
+	FetchesToday   int64             `json:"fetches_today"`
+	FetchesWeek    int64             `json:"fetches_week"`
+	TopScripts     []ScriptUsage     `json:"top_scripts"`
+	RecentFailures []dbgen.AuditLog  `json:"recent_failures"`
+	DBSizeBytes    int64             `json:"db_size_bytes"`
+	Tokens         SummaryTokens     `json:"tokens"`
+	ScriptCache    *ScriptCacheStats `json:"script_cache,omitempty"`
+}
+
+// APIStatsSummary returns the dashboard summary in one call
+func (s *Server) APIStatsSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	now := time.Now()
+	q := s.queries()
+
+	var sum StatsSummary
+	var err error
+	fail := func() {
+		http.Error(w, "Failed to build summary", http.StatusInternalServerError)
+	}
+
+	if sum.Scripts, err = q.CountScripts(ctx); err != nil {
+		fail()
+		return
+	}
+	if sum.Folders, err = q.CountFolders(ctx); err != nil {
+		fail()
+		return
+	}
+	if sum.FetchesToday, err = q.SumFetchesSince(ctx, statsSince(now, 1)); err != nil {
+		fail()
+		return
+	}
+	if sum.FetchesWeek, err = q.SumFetchesSince(ctx, statsSince(now, 7)); err != nil {
+		fail()
+		return
+	}
+	if sum.RecentFailures, err = q.ListAuditFailures(ctx, summaryFailures); err != nil {
+		fail()
+		return
+	}
+	if sum.DBSizeBytes, err = db.Size(ctx, s.DB, s.dialect); err != nil {
+		fail()
+		return
+	}
+
+	// Top scripts of the week
+	week, err := q.SumDailyFetchesByScript(ctx, statsSince(now, 7))
+	if err != nil {
+		fail()
+		return
+	}
+	sort.Slice(week, func(i, j int) bool { return week[i].Fetches > week[j].Fetches })
+	sum.TopScripts = []ScriptUsage{}
+	for _, row := range week {
+		if len(sum.TopScripts) == summaryTopScripts {
+			break
+		}
+		sc, err := q.GetScript(ctx, row.ScriptID)
+		if err != nil {
+			continue
+		}
+		usage := ScriptUsage{ScriptID: sc.ID, Path: sc.Path, RecentFetches: row.Fetches}
+		if st, err := q.GetScriptStats(ctx, sc.ID); err == nil {
+			usage.Fetches = st.Fetches
+			usage.LastFetchedAt = st.LastFetchedAt
+		}
+		sum.TopScripts = append(sum.TopScripts, usage)
+	}
+
+	sum.Tokens.Unlock, _ = q.CountActiveAuthTokens(ctx, now)
+	sum.Tokens.Sessions, _ = q.CountActiveSessions(ctx, now)
+	sum.Tokens.APITokens, _ = q.CountActiveAPITokens(ctx)
+	sum.Tokens.AdminTokens, _ = q.CountAdminTokens(ctx)
+	sum.Tokens.ShareLinks, _ = q.CountActiveShareLinks(ctx, &now)
+	if s.scriptCache != nil {
+		sum.ScriptCache = s.scriptCache.stats()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sum)
+}
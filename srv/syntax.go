@@ -0,0 +1,139 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syntaxCheckTimeout bounds a single "sh -n" run
+const syntaxCheckTimeout = 5 * time.Second
+
+// shebangShells maps recognized interpreters to the shell that parses them
+var shebangShells = map[string]string{
+	"sh":   "sh",
+	"ash":  "sh",
+	"dash": "dash",
+	"bash": "bash",
+	"ksh":  "ksh",
+	"zsh":  "zsh",
+}
+
+// SyntaxError is a problem found in a script's shebang or syntax
+type SyntaxError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"` // not every shell reports one
+	Message string `json:"message"`
+}
+
+func (e SyntaxError) String() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("line %d:%d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// shebangShell returns the shell named by the content's shebang, e.g. "bash"
+// for "#!/usr/bin/env bash"
+func shebangShell(content string) (string, error) {
+	first, _, _ := strings.Cut(content, "\n")
+	if !strings.HasPrefix(first, "#!") {
+		return "", fmt.Errorf("missing shebang (e.g. #!/bin/sh)")
+	}
+	fields := strings.Fields(strings.TrimPrefix(first, "#!"))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty shebang")
+	}
+	interp := path.Base(fields[0])
+	if interp == "env" {
+		interp = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				interp = path.Base(f)
+				break
+			}
+		}
+	}
+	if _, ok := shebangShells[interp]; !ok {
+		return "", fmt.Errorf("unrecognized shebang interpreter %q", interp)
+	}
+	return interp, nil
+}
+
+// shellErrorLine matches "sh: 4: Syntax error: ..." (dash) and
+// "bash: line 5: syntax error ..." (bash, ksh, zsh)
+var shellErrorLine = regexp.MustCompile(`^[^:]+: (?:line )?(\d+): (.+)$`)
+
+// checkSyntax validates the shebang and parses content with "<shell> -n".
+// Library scripts are sourced, so they need no shebang. When the shell is
+// not installed only the shebang is checked.
+func checkSyntax(ctx context.Context, content string, library bool) []SyntaxError {
+	shell, err := shebangShell(content)
+	if err != nil {
+		if !library {
+			return []SyntaxError{{Line: 1, Message: err.Error()}}
+		}
+		shell = "sh"
+	}
+	bin, err := exec.LookPath(shebangShells[shell])
+	if err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, syntaxCheckTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bin, "-n")
+	cmd.Stdin = strings.NewReader(content)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if cmd.Run() == nil {
+		return nil
+	}
+
+	var errs []SyntaxError
+	seen := make(map[int]bool)
+	for _, l := range strings.Split(stderr.String(), "\n") {
+		m := shellErrorLine.FindStringSubmatch(strings.TrimSpace(l))
+		if m == nil {
+			continue
+		}
+		// bash follows each error with the offending line; keep the first
+		line, _ := strconv.Atoi(m[1])
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		errs = append(errs, SyntaxError{Line: line, Message: m[2]})
+	}
+	if len(errs) == 0 && ctx.Err() == nil {
+		errs = append(errs, SyntaxError{Line: 1, Message: strings.TrimSpace(stderr.String())})
+	}
+	return errs
+}
+
+// checkScriptSyntax refuses a save whose content has no recognized shebang
+// or does not parse, when VALIDATE_SYNTAX is set. It reports whether the
+// save may continue.
+func (s *Server) checkScriptSyntax(w http.ResponseWriter, r *http.Request, scriptPath, content string) bool {
+	if !s.ValidateSyntax {
+		return true
+	}
+	errs := checkSyntax(r.Context(), content, isLibraryPath(scriptPath))
+	if len(errs) == 0 {
+		return true
+	}
+
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = e.String()
+	}
+	rejectContent(w, http.StatusUnprocessableEntity, "Invalid script", lines)
+	return false
+}
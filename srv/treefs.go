@@ -0,0 +1,241 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/webdav"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// treeFS presents folders and scripts as a file tree, for WebDAV and SFTP.
+// Writes go through the API handlers of the request stored in the
+// context, see publish.go.
+type treeFS struct {
+	s *Server
+}
+
+// treeInfo describes a script or folder
+type treeInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (fi treeInfo) Name() string       { return fi.name }
+func (fi treeInfo) Size() int64        { return fi.size }
+func (fi treeInfo) ModTime() time.Time { return fi.modTime }
+func (fi treeInfo) IsDir() bool        { return fi.dir }
+func (fi treeInfo) Sys() any           { return nil }
+func (fi treeInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func scriptInfo(sc dbgen.Script) treeInfo {
+	return treeInfo{name: path.Base(sc.Path), size: int64(len(sc.Content)), modTime: sc.UpdatedAt}
+}
+
+// children lists what is directly inside dir; folders also exist
+// implicitly through the paths of the scripts below them
+func (d treeFS) children(ctx context.Context, dir string) ([]fs.FileInfo, bool, error) {
+	q := d.s.queries()
+	scripts, err := q.ListScripts(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	folders, err := q.ListFolders(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	exists := dir == "/"
+	dirs := map[string]time.Time{}
+	var out []fs.FileInfo
+	addDir := func(p string, t time.Time) {
+		rest, ok := strings.CutPrefix(p, prefix)
+		if !ok || rest == "" {
+			return
+		}
+		exists = true
+		name, _, _ := strings.Cut(rest, "/")
+		if prev, ok := dirs[name]; !ok || t.After(prev) {
+			dirs[name] = t
+		}
+	}
+	for _, f := range folders {
+		if f.Path == dir {
+			exists = true
+		}
+		addDir(f.Path, f.CreatedAt)
+	}
+	for _, sc := range scripts {
+		if path.Dir(sc.Path) == path.Clean(dir) {
+			exists = true
+			out = append(out, scriptInfo(sc))
+			continue
+		}
+		addDir(path.Dir(sc.Path), sc.UpdatedAt)
+	}
+	for name, t := range dirs {
+		out = append(out, treeInfo{name: name, modTime: t, dir: true})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out, exists, nil
+}
+
+func (d treeFS) Stat(ctx context.Context, name string) (fs.FileInfo, error) {
+	if sc, err := d.s.queries().GetScriptByPath(ctx, name); err == nil {
+		return scriptInfo(sc), nil
+	}
+	if _, exists, err := d.children(ctx, name); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, fs.ErrNotExist
+	}
+	return treeInfo{name: path.Base(name), modTime: time.Now(), dir: true}, nil
+}
+
+func (d treeFS) OpenFile(ctx context.Context, name string, flag int, perm fs.FileMode) (webdav.File, error) {
+	sc, err := d.s.queries().GetScriptByPath(ctx, name)
+	found := err == nil
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+		if err := validatePath(name); err != nil {
+			return nil, fs.ErrPermission
+		}
+		if !found && flag&os.O_CREATE == 0 {
+			return nil, fs.ErrNotExist
+		}
+		f := &treeWriter{fs: d, ctx: ctx, name: name}
+		if found && flag&os.O_TRUNC == 0 {
+			f.buf.WriteString(sc.Content)
+		}
+		return f, nil
+	}
+	if found {
+		return &treeFile{Reader: bytes.NewReader([]byte(sc.Content)), info: scriptInfo(sc)}, nil
+	}
+	entries, exists, err := d.children(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fs.ErrNotExist
+	}
+	return &treeFile{Reader: bytes.NewReader(nil), info: treeInfo{name: path.Base(name), modTime: time.Now(), dir: true}, entries: entries}, nil
+}
+
+func (d treeFS) Mkdir(ctx context.Context, name string, perm fs.FileMode) error {
+	if _, err := d.Stat(ctx, name); err == nil {
+		return fs.ErrExist
+	}
+	_, err := callAPI(originalRequest(ctx), d.s.APICreateFolder, http.MethodPost, "", CreateFolderRequest{Path: path.Clean(name)})
+	return err
+}
+
+func (d treeFS) RemoveAll(ctx context.Context, name string) error {
+	r := originalRequest(ctx)
+	if _, err := d.s.queries().GetScriptByPath(ctx, name); err == nil {
+		return d.s.unpublishScript(r, name)
+	}
+	entries, exists, err := d.children(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fs.ErrNotExist
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			// Scripts are only deleted one at a time
+			return errors.New("folder is not empty")
+		}
+	}
+	folder, err := d.s.queries().GetFolderByPath(ctx, path.Clean(name))
+	if err != nil {
+		return err
+	}
+	_, err = callAPI(r, d.s.APIDeleteFolder, http.MethodDelete, folder.ID, nil)
+	return err
+}
+
+func (d treeFS) Rename(ctx context.Context, oldName, newName string) error {
+	if _, err := d.s.queries().GetScriptByPath(ctx, oldName); err != nil {
+		// Folders move along with their scripts; moving them alone is not supported
+		return fs.ErrPermission
+	}
+	return d.s.moveScript(originalRequest(ctx), oldName, newName)
+}
+
+// treeFile reads a script or lists a folder
+type treeFile struct {
+	*bytes.Reader
+	info    treeInfo
+	entries []fs.FileInfo
+}
+
+func (f *treeFile) Close() error                       { return nil }
+func (f *treeFile) Write([]byte) (int, error)          { return 0, fs.ErrPermission }
+func (f *treeFile) Stat() (fs.FileInfo, error)         { return f.info, nil }
+func (f *treeFile) Readdir(int) ([]fs.FileInfo, error) { return f.entries, nil }
+
+// treeWriter collects a script's new content and saves it on Close
+type treeWriter struct {
+	fs   treeFS
+	ctx  context.Context
+	name string
+	buf  bytes.Buffer
+}
+
+func (f *treeWriter) Write(p []byte) (int, error) {
+	if int64(f.buf.Len()+len(p)) > f.fs.s.Limits.MaxScriptSize {
+		return 0, errors.New("script too large")
+	}
+	return f.buf.Write(p)
+}
+
+// WriteAt places p at off, for SFTP clients that upload in parallel chunks
+func (f *treeWriter) WriteAt(p []byte, off int64) (int, error) {
+	end := off + int64(len(p))
+	if end > f.fs.s.Limits.MaxScriptSize {
+		return 0, errors.New("script too large")
+	}
+	if grow := int(end) - f.buf.Len(); grow > 0 {
+		f.buf.Write(make([]byte, grow))
+	}
+	return copy(f.buf.Bytes()[off:], p), nil
+}
+
+func (f *treeWriter) Read([]byte) (int, error) { return 0, io.EOF }
+
+func (f *treeWriter) Seek(offset int64, whence int) (int64, error) {
+	// Only used to find the size, after the content is written
+	if whence == io.SeekEnd {
+		return int64(f.buf.Len()) + offset, nil
+	}
+	return 0, nil
+}
+
+func (f *treeWriter) Readdir(int) ([]fs.FileInfo, error) { return nil, fs.ErrInvalid }
+
+func (f *treeWriter) Stat() (fs.FileInfo, error) {
+	return treeInfo{name: path.Base(f.name), size: int64(f.buf.Len()), modTime: time.Now()}, nil
+}
+
+func (f *treeWriter) Close() error {
+	_, _, err := f.fs.s.publishScript(originalRequest(f.ctx), f.name, f.buf.String(), nil)
+	return err
+}
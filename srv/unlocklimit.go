@@ -0,0 +1,189 @@
+package srv
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// UnlockLimitConfig configures brute-force protection on /_auth/unlock. After
+// MaxFailures wrong passwords from one IP, or ScriptMaxFailures against one
+// script from anywhere, attempts are refused for Lockout; every further
+// failure doubles the lockout. Zero disables the respective counter.
+type UnlockLimitConfig struct {
+	MaxFailures       int
+	ScriptMaxFailures int
+	Lockout           time.Duration
+}
+
+const (
+	// maxUnlockLockout caps the exponential backoff
+	maxUnlockLockout = time.Hour
+	// unlockFailureWindow is how long failures are remembered once any lockout is over
+	unlockFailureWindow = time.Hour
+)
+
+type unlockFailures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// unlockLimiter counts failed unlock attempts per IP and per script
+type unlockLimiter struct {
+	mu      sync.Mutex
+	cfg     UnlockLimitConfig
+	entries map[string]*unlockFailures
+}
+
+func newUnlockLimiter(cfg UnlockLimitConfig) *unlockLimiter {
+	l := &unlockLimiter{entries: make(map[string]*unlockFailures)}
+	l.setConfig(cfg)
+	return l
+}
+
+// setConfig changes the limits and reports whether they changed; failures
+// counted so far are kept
+func (l *unlockLimiter) setConfig(cfg UnlockLimitConfig) bool {
+	if cfg.Lockout <= 0 {
+		cfg.Lockout = time.Minute
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if cfg == l.cfg {
+		return false
+	}
+	l.cfg = cfg
+	return true
+}
+
+func (l *unlockLimiter) config() UnlockLimitConfig {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cfg
+}
+
+// stale reports whether an entry can be forgotten
+func (f *unlockFailures) stale(now time.Time) bool {
+	return !now.Before(f.lockedUntil) && now.Sub(f.last) > unlockFailureWindow
+}
+
+// blocked returns how long the key is still locked out
+func (l *unlockLimiter) blocked(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.entries[key]
+	if !ok || !now.Before(f.lockedUntil) {
+		return 0
+	}
+	return f.lockedUntil.Sub(now)
+}
+
+// fail records a failure for key and returns the lockout it triggers, if any
+func (l *unlockLimiter) fail(key string, limit int, now time.Time) (int, time.Duration) {
+	if limit <= 0 {
+		return 0, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, ok := l.entries[key]
+	if !ok || f.stale(now) {
+		f = &unlockFailures{}
+		l.entries[key] = f
+		l.prune(now)
+	}
+	f.count++
+	f.last = now
+	if f.count < limit {
+		return f.count, 0
+	}
+
+	lockout := maxUnlockLockout
+	if n := f.count - limit; n < 16 {
+		lockout = min(l.cfg.Lockout<<n, maxUnlockLockout)
+	}
+	f.lockedUntil = now.Add(lockout)
+	return f.count, lockout
+}
+
+// reset forgets the failures for key
+func (l *unlockLimiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, key)
+}
+
+// prune drops stale entries once the map grows; callers hold l.mu
+func (l *unlockLimiter) prune(now time.Time) {
+	if len(l.entries) < 1024 {
+		return
+	}
+	for key, f := range l.entries {
+		if f.stale(now) {
+			delete(l.entries, key)
+		}
+	}
+}
+
+func unlockIPKey(r *http.Request) string { return "ip:" + clientIP(r) }
+
+// unlockBlocked returns how long unlock attempts for this client and lock
+// are refused
+func (s *Server) unlockBlocked(r *http.Request, lockKey string) time.Duration {
+	if s.unlockLimit == nil {
+		return 0
+	}
+	now := time.Now()
+	return max(s.unlockLimit.blocked(unlockIPKey(r), now), s.unlockLimit.blocked(lockKey, now))
+}
+
+// recordUnlockFailure counts a wrong password and audits any lockout it starts
+func (s *Server) recordUnlockFailure(r *http.Request, q *queries, script dbgen.Script, lockKey string) {
+	if s.unlockLimit == nil {
+		return
+	}
+	now := time.Now()
+	cfg := s.unlockLimit.config()
+	ipCount, ipLockout := s.unlockLimit.fail(unlockIPKey(r), cfg.MaxFailures, now)
+	scriptCount, scriptLockout := s.unlockLimit.fail(lockKey, cfg.ScriptMaxFailures, now)
+
+	audit := func(details string) {
+		q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+			Action:     "UNLOCK_LOCKOUT",
+			EntityType: "script",
+			EntityID:   &script.ID,
+			EntityPath: &script.Path,
+			Details:    &details,
+			IpAddress:  s.storedIP(r),
+			UserAgent:  strPtr(r.Header.Get("User-Agent")),
+			Actor:      strPtr("system"),
+			RequestID:  requestID(r.Context()),
+			CreatedAt:  now,
+		})
+		s.emit(r.Context(), WebhookEvent{
+			Event:     EventUnlockLockout,
+			ScriptID:  script.ID,
+			Path:      script.Path,
+			IPAddress: derefStr(s.storedIP(r)),
+			Details:   details,
+			Time:      now,
+		})
+	}
+	if ipLockout > 0 {
+		audit(fmt.Sprintf("IP %s locked out for %s after %d failed attempts", s.anonymizeIP(clientIP(r)), ipLockout, ipCount))
+	}
+	if scriptLockout > 0 {
+		audit(fmt.Sprintf("%s locked out for %s after %d failed attempts", lockKey, scriptLockout, scriptCount))
+	}
+}
+
+// recordUnlockSuccess clears the client's failure count
+func (s *Server) recordUnlockSuccess(r *http.Request) {
+	if s.unlockLimit != nil {
+		s.unlockLimit.reset(unlockIPKey(r))
+	}
+}
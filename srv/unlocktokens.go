@@ -0,0 +1,219 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// Unlock token lifetimes used when the server is not configured otherwise
+const (
+	defaultUnlockTTL    = 5 * time.Minute
+	defaultUnlockMaxTTL = time.Hour
+)
+
+// unlockTTLs returns the configured default and maximum token lifetimes
+func (s *Server) unlockTTLs() (time.Duration, time.Duration) {
+	ttl, maxTTL := s.UnlockTTL, s.UnlockMaxTTL
+	if ttl <= 0 {
+		ttl = defaultUnlockTTL
+	}
+	if maxTTL <= 0 {
+		maxTTL = defaultUnlockMaxTTL
+	}
+	return ttl, max(ttl, maxTTL)
+}
+
+// unlockTTL returns how long a token for the script stays valid. The
+// client may ask for any lifetime up to the maximum; otherwise the
+// script's own setting or the server default applies.
+func (s *Server) unlockTTL(script dbgen.Script, requested int64) time.Duration {
+	ttl, maxTTL := s.unlockTTLs()
+	if script.UnlockTtl != nil && *script.UnlockTtl > 0 {
+		ttl = time.Duration(*script.UnlockTtl) * time.Second
+	}
+	if requested > 0 {
+		ttl = time.Duration(min(requested, int64(maxTTL/time.Second))) * time.Second
+	}
+	return min(ttl, maxTTL)
+}
+
+// validateUnlockTTL checks a per-script token lifetime in seconds
+func (s *Server) validateUnlockTTL(seconds int64) error {
+	_, maxTTL := s.unlockTTLs()
+	if seconds < 0 {
+		return fmt.Errorf("unlock_ttl must not be negative")
+	}
+	if seconds > int64(maxTTL/time.Second) {
+		return fmt.Errorf("unlock_ttl must be at most %d seconds", int64(maxTTL.Seconds()))
+	}
+	return nil
+}
+
+// unlockTTLParam converts a per-script lifetime for storage; zero means the default
+func unlockTTLParam(id string, seconds int64) dbgen.UpdateScriptUnlockTTLParams {
+	params := dbgen.UpdateScriptUnlockTTLParams{ID: id}
+	if seconds > 0 {
+		params.UnlockTtl = &seconds
+	}
+	return params
+}
+
+// unlockTokenClientMatches reports whether the request comes from the client
+// the token was issued to, as far as tokens are bound. A mismatch usually
+// means a leaked token URL and is written to the audit log.
+func (s *Server) unlockTokenClientMatches(r *http.Request, q *queries, tok dbgen.AuthToken, script dbgen.Script) bool {
+	var mismatches []string
+	// The client IP is anonymized as it was when the token was issued, so
+	// hashed IPs still match after the hash key rotates
+	if s.UnlockBindIP && (tok.IpAddress == nil || *tok.IpAddress != s.anonymizeIPAt(clientIP(r), tok.CreatedAt)) {
+		mismatches = append(mismatches, "IP")
+	}
+	if s.UnlockBindUA && (tok.UserAgent == nil || *tok.UserAgent != r.Header.Get("User-Agent")) {
+		mismatches = append(mismatches, "User-Agent")
+	}
+	if len(mismatches) == 0 {
+		return true
+	}
+
+	details := "token used from a different " + strings.Join(mismatches, " and ")
+	if tok.IpAddress != nil {
+		details += " (issued to " + *tok.IpAddress + ")"
+	}
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "UNLOCK_TOKEN_REJECTED",
+		EntityType: "script",
+		EntityID:   &script.ID,
+		EntityPath: &script.Path,
+		Details:    &details,
+		IpAddress:  s.storedIP(r),
+		UserAgent:  strPtr(r.Header.Get("User-Agent")),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+	return false
+}
+
+// UnlockTokenResponse describes an issued unlock token
+type UnlockTokenResponse struct {
+	Token      string    `json:"token"`
+	ScriptID   string    `json:"script_id"`
+	ScriptPath string    `json:"script_path"`
+	FolderPath string    `json:"folder_path,omitempty"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// APIListUnlockTokens returns the unexpired unlock tokens, newest first
+func (s *Server) APIListUnlockTokens(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	rows, err := q.ListActiveAuthTokens(r.Context(), time.Now())
+	if err != nil {
+		http.Error(w, "Failed to list tokens", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]UnlockTokenResponse, len(rows))
+	for i, row := range rows {
+		resp[i] = UnlockTokenResponse{
+			Token:      row.Token,
+			ScriptID:   row.ScriptID,
+			ScriptPath: row.ScriptPath,
+			ExpiresAt:  row.ExpiresAt,
+			CreatedAt:  row.CreatedAt,
+		}
+		if row.FolderPath != nil {
+			resp[i].FolderPath = *row.FolderPath
+		}
+		if row.IpAddress != nil {
+			resp[i].IPAddress = *row.IpAddress
+		}
+		if row.UserAgent != nil {
+			resp[i].UserAgent = *row.UserAgent
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIRevokeUnlockToken invalidates a single unlock token
+func (s *Server) APIRevokeUnlockToken(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	q := s.queries()
+	tok, err := q.GetAuthToken(r.Context(), token)
+	if err != nil {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+	if _, err := q.DeleteAuthToken(r.Context(), token); err != nil {
+		http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+		return
+	}
+
+	details := "token issued at " + tok.CreatedAt.Format(time.RFC3339)
+	if tok.IpAddress != nil {
+		details += " to " + *tok.IpAddress
+	}
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "TOKEN_REVOKE",
+		EntityType: "script",
+		EntityID:   &tok.ScriptID,
+		Details:    &details,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// APIRevokeScriptTokens invalidates every unlock token that opens the script,
+// including tokens for the locked folder it sits in
+func (s *Server) APIRevokeScriptTokens(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	script, err := q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+
+	revoked, err := q.DeleteTokensByScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to revoke tokens", http.StatusInternalServerError)
+		return
+	}
+	folders, _ := q.ListLockedFolders(r.Context())
+	if f := folderLock(folders, script.Path); f != nil {
+		n, err := q.DeleteTokensByFolder(r.Context(), &f.Path)
+		if err != nil {
+			http.Error(w, "Failed to revoke tokens", http.StatusInternalServerError)
+			return
+		}
+		revoked += n
+	}
+
+	details := fmt.Sprintf("%d tokens revoked", revoked)
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "TOKEN_REVOKE_ALL",
+		EntityType: "script",
+		EntityID:   &id,
+		EntityPath: &script.Path,
+		Details:    &details,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"revoked": revoked})
+}
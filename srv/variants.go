@@ -0,0 +1,399 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hunydev/sh-server/db"
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// defaultVariant names the script's own content in ?variant= and in stats
+const defaultVariant = "default"
+
+var variantNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// parseCIDRs parses a comma-separated list of CIDRs or bare IPs
+func parseCIDRs(list string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %q", item)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", item)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// ipInCIDRs reports whether ip falls in any range of the comma-separated list
+func ipInCIDRs(ip net.IP, list string) bool {
+	nets, _ := parseCIDRs(list)
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectVariant picks the variant to serve for a request, or ok=false for the
+// script's own content. Rules are tried in order: explicit ?variant=<name>,
+// then IP ranges, then percentage buckets (by hash of client IP).
+func selectVariant(r *http.Request, scriptID string, variants []dbgen.ScriptVariant) (dbgen.ScriptVariant, bool) {
+	if name := r.URL.Query().Get("variant"); name != "" {
+		for _, v := range variants {
+			if v.Name == name {
+				return v, true
+			}
+		}
+		return dbgen.ScriptVariant{}, false
+	}
+
+	ipStr := clientIP(r)
+	if ip := net.ParseIP(ipStr); ip != nil {
+		for _, v := range variants {
+			if v.MatchCidr != nil && *v.MatchCidr != "" && ipInCIDRs(ip, *v.MatchCidr) {
+				return v, true
+			}
+		}
+	}
+
+	bucket := rolloutBucket(scriptID + "|variant|" + ipStr)
+	cumulative := 0
+	for _, v := range variants {
+		if v.Percent <= 0 {
+			continue
+		}
+		cumulative += int(v.Percent)
+		if bucket < cumulative {
+			return v, true
+		}
+	}
+	return dbgen.ScriptVariant{}, false
+}
+
+// applyVariant swaps in a variant's content when one matches the request and
+// records the serve. It reports whether the script has variants at all.
+func (s *Server) applyVariant(w http.ResponseWriter, r *http.Request, q *queries, script dbgen.Script) (dbgen.Script, bool) {
+	variants, err := q.ListVariants(r.Context(), script.ID)
+	if err != nil || len(variants) == 0 {
+		return script, false
+	}
+
+	name := defaultVariant
+	if v, ok := selectVariant(r, script.ID, variants); ok {
+		script.Content = v.Content
+		name = v.Name
+	}
+
+	now := time.Now()
+	q.RecordVariantServe(r.Context(), dbgen.RecordVariantServeParams{
+		ScriptID:     script.ID,
+		Variant:      name,
+		LastServedAt: &now,
+	})
+	w.Header().Set("X-Script-Variant", name)
+	return script, name != defaultVariant
+}
+
+// VariantRequest represents a request to create or update a variant
+type VariantRequest struct {
+	Name      string `json:"name"`
+	Content   string `json:"content"`
+	MatchCIDR string `json:"match_cidr"`
+	Percent   int    `json:"percent"`
+	Priority  int    `json:"priority"`
+}
+
+// VariantResponse is a variant with its serve counters
+type VariantResponse struct {
+	ID           string     `json:"id"`
+	ScriptID     string     `json:"script_id"`
+	Name         string     `json:"name"`
+	Content      string     `json:"content"`
+	MatchCIDR    string     `json:"match_cidr"`
+	Percent      int64      `json:"percent"`
+	Priority     int64      `json:"priority"`
+	Serves       int64      `json:"serves"`
+	LastServedAt *time.Time `json:"last_served_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func variantToResponse(v dbgen.ScriptVariant, stats map[string]dbgen.VariantStat) VariantResponse {
+	resp := VariantResponse{
+		ID:        v.ID,
+		ScriptID:  v.ScriptID,
+		Name:      v.Name,
+		Content:   v.Content,
+		Percent:   v.Percent,
+		Priority:  v.Priority,
+		CreatedAt: v.CreatedAt,
+		UpdatedAt: v.UpdatedAt,
+	}
+	if v.MatchCidr != nil {
+		resp.MatchCIDR = *v.MatchCidr
+	}
+	if st, ok := stats[v.Name]; ok {
+		resp.Serves = st.Serves
+		resp.LastServedAt = st.LastServedAt
+	}
+	return resp
+}
+
+func variantStatsByName(ctx context.Context, q *queries, scriptID string) map[string]dbgen.VariantStat {
+	stats := map[string]dbgen.VariantStat{}
+	rows, _ := q.ListVariantStats(ctx, scriptID)
+	for _, st := range rows {
+		stats[st.Variant] = st
+	}
+	return stats
+}
+
+// validate checks the request against the script's other variants
+func (req *VariantRequest) validate(others []dbgen.ScriptVariant) error {
+	if !variantNamePattern.MatchString(req.Name) || req.Name == defaultVariant {
+		return errors.New("variant name must be alphanumeric (with - or _) and not 'default'")
+	}
+	if req.Content == "" {
+		return errors.New("content is required")
+	}
+	if _, err := parseCIDRs(req.MatchCIDR); err != nil {
+		return err
+	}
+	if req.Percent < 0 || req.Percent > 100 {
+		return errors.New("percent must be between 0 and 100")
+	}
+	total := req.Percent
+	for _, o := range others {
+		total += int(o.Percent)
+	}
+	if total > 100 {
+		return fmt.Errorf("variant percentages add up to %d%%, more than 100%%", total)
+	}
+	return nil
+}
+
+// APIListVariants returns the variants of a script with their serve counters
+func (s *Server) APIListVariants(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	if _, err := q.GetScript(r.Context(), id); err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+	variants, err := q.ListVariants(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to list variants", http.StatusInternalServerError)
+		return
+	}
+
+	stats := variantStatsByName(r.Context(), q, id)
+	resp := make([]VariantResponse, len(variants))
+	for i, v := range variants {
+		resp[i] = variantToResponse(v, stats)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIVariantStats returns serve counters for every variant including 'default'
+func (s *Server) APIVariantStats(w http.ResponseWriter, r *http.Request) {
+	q := s.queries()
+	stats, err := q.ListVariantStats(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Failed to get variant stats", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// APICreateVariant adds a variant to a script
+func (s *Server) APICreateVariant(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req VariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+
+	q := s.queries()
+	script, err := q.GetScript(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+	others, _ := q.ListVariants(r.Context(), id)
+	if err := req.validate(others); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.checkScriptSize(w, req.Content) {
+		return
+	}
+
+	now := time.Now()
+	variantID := uuid.New().String()
+	err = q.CreateVariant(r.Context(), dbgen.CreateVariantParams{
+		ID:        variantID,
+		ScriptID:  id,
+		Name:      req.Name,
+		Content:   req.Content,
+		MatchCidr: &req.MatchCIDR,
+		Percent:   int64(req.Percent),
+		Priority:  int64(req.Priority),
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			http.Error(w, "Variant with this name already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create variant: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "CREATE",
+		EntityType: "variant",
+		EntityID:   &variantID,
+		EntityPath: strPtr(script.Path + "#" + req.Name),
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	v, _ := q.GetVariant(r.Context(), variantID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(variantToResponse(v, nil))
+}
+
+// APIUpdateVariant updates a variant's content or selection rules
+func (s *Server) APIUpdateVariant(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	variantID := r.PathValue("vid")
+
+	var req VariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+
+	q := s.queries()
+	existing, err := q.GetVariant(r.Context(), variantID)
+	if err != nil || existing.ScriptID != id {
+		http.Error(w, "Variant not found", http.StatusNotFound)
+		return
+	}
+	variants, _ := q.ListVariants(r.Context(), id)
+	var others []dbgen.ScriptVariant
+	for _, v := range variants {
+		if v.ID != variantID {
+			others = append(others, v)
+		}
+	}
+	if err := req.validate(others); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.checkScriptSize(w, req.Content) {
+		return
+	}
+
+	now := time.Now()
+	err = q.UpdateVariant(r.Context(), dbgen.UpdateVariantParams{
+		Name:      req.Name,
+		Content:   req.Content,
+		MatchCidr: &req.MatchCIDR,
+		Percent:   int64(req.Percent),
+		Priority:  int64(req.Priority),
+		UpdatedAt: now,
+		ID:        variantID,
+	})
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			http.Error(w, "Variant with this name already exists", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to update variant: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "UPDATE",
+		EntityType: "variant",
+		EntityID:   &variantID,
+		EntityPath: &req.Name,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	v, _ := q.GetVariant(r.Context(), variantID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(variantToResponse(v, variantStatsByName(r.Context(), q, id)))
+}
+
+// APIDeleteVariant removes a variant; its counters are kept
+func (s *Server) APIDeleteVariant(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	variantID := r.PathValue("vid")
+
+	q := s.queries()
+	existing, err := q.GetVariant(r.Context(), variantID)
+	if err != nil || existing.ScriptID != id {
+		http.Error(w, "Variant not found", http.StatusNotFound)
+		return
+	}
+	if err := q.DeleteVariant(r.Context(), variantID); err != nil {
+		http.Error(w, "Failed to delete variant", http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "DELETE",
+		EntityType: "variant",
+		EntityID:   &variantID,
+		EntityPath: &existing.Name,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
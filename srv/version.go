@@ -0,0 +1,87 @@
+package srv
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/hunydev/sh-server/srv.Version=v1.2.0" ./cmd/srv
+//
+// Commit and BuildDate default to what the go command recorded from git.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// VersionInfo tells which build of the server is running
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// BuildVersion returns the version information of this binary
+var BuildVersion = sync.OnceValue(func() VersionInfo {
+	v := VersionInfo{Version: Version, Commit: Commit, BuildDate: BuildDate, GoVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v
+	}
+	var dirty bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if v.Commit == "" {
+				v.Commit = setting.Value
+			}
+		case "vcs.time":
+			if v.BuildDate == "" {
+				v.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			dirty = setting.Value == "true"
+		}
+	}
+	if dirty && Commit == "" && v.Commit != "" {
+		v.Commit += "-dirty"
+	}
+	return v
+})
+
+// String is the version and short commit, as sent in X-Server-Version
+func (v VersionInfo) String() string {
+	if v.Commit == "" {
+		return v.Version
+	}
+	commit, dirty := strings.CutSuffix(v.Commit, "-dirty")
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if dirty {
+		commit += "-dirty"
+	}
+	return v.Version + " (" + commit + ")"
+}
+
+// withVersion names the build in a header of every response
+func withVersion(next http.Handler) http.Handler {
+	version := BuildVersion().String()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Server-Version", version)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIVersion returns the version, commit and build date of the server
+func (s *Server) APIVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BuildVersion())
+}
@@ -0,0 +1,53 @@
+package srv
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxVersionMessage caps stored version messages
+const maxVersionMessage = 1000
+
+// versionMessage prepares a version message for storage; nil when empty
+func versionMessage(msg string) *string {
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return nil
+	}
+	if len(msg) > maxVersionMessage {
+		msg = msg[:maxVersionMessage]
+	}
+	return &msg
+}
+
+// VersionResponse is one entry of a script's version history
+type VersionResponse struct {
+	Version   int64     `json:"version"`
+	Message   string    `json:"message,omitempty"`
+	Serves    int64     `json:"serves"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIListVersions returns a script's versions, newest first, without content
+func (s *Server) APIListVersions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	q := s.queries()
+	if _, err := q.GetScript(r.Context(), id); err != nil {
+		http.Error(w, "Script not found", http.StatusNotFound)
+		return
+	}
+	rows, err := q.ListVersionHistory(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to list versions", http.StatusInternalServerError)
+		return
+	}
+
+	out := make([]VersionResponse, len(rows))
+	for i, v := range rows {
+		out[i] = VersionResponse{Version: v.Version, Message: derefStr(v.Message), Serves: v.Serves, CreatedAt: v.CreatedAt}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
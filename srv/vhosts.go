@@ -0,0 +1,142 @@
+package srv
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// VirtualHost serves the scripts under Prefix at the root of Host, so one
+// server can pose as several script repositories
+type VirtualHost struct {
+	Host   string // e.g. team1.sh.example
+	Prefix string // folder the host is scoped to, e.g. /team1
+}
+
+type virtualHostKey struct{}
+
+// parseVirtualHosts parses comma-separated host=/folder pairs
+func parseVirtualHosts(list string) ([]VirtualHost, error) {
+	var hosts []VirtualHost
+	seen := map[string]bool{}
+	for _, pair := range strings.Split(list, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		host, prefix, ok := strings.Cut(pair, "=")
+		host, prefix = strings.ToLower(strings.TrimSpace(host)), strings.TrimRight(strings.TrimSpace(prefix), "/")
+		if !ok || host == "" {
+			return nil, fmt.Errorf("%q is not in host=/folder form", pair)
+		}
+		if !strings.HasPrefix(prefix, "/") || strings.HasSuffix(prefix, ".sh") || strings.Contains(prefix, "..") {
+			return nil, fmt.Errorf("%s: %q is not a folder", host, prefix)
+		}
+		if seen[host] {
+			return nil, fmt.Errorf("%s is listed twice", host)
+		}
+		seen[host] = true
+		hosts = append(hosts, VirtualHost{Host: host, Prefix: prefix})
+	}
+	return hosts, nil
+}
+
+// virtualHost returns the virtual host a request came in on, or nil for
+// the main hostname
+func virtualHost(ctx context.Context) *VirtualHost {
+	vh, _ := ctx.Value(virtualHostKey{}).(*VirtualHost)
+	return vh
+}
+
+// hostname is the host scripts are fetched from in generated commands:
+// the virtual host the request came in on, or the server's
+func (s *Server) hostname(r *http.Request) string {
+	if vh := virtualHost(r.Context()); vh != nil {
+		return vh.Host
+	}
+	return s.Hostname
+}
+
+// contains reports whether a script path is in the host's namespace
+func (vh *VirtualHost) contains(path string) bool {
+	return strings.HasPrefix(path, vh.Prefix+"/")
+}
+
+// strip turns a script path into the one it has on the host. Paths outside
+// the namespace, or on the main hostname (nil), are left whole.
+func (vh *VirtualHost) strip(path string) string {
+	if vh == nil || !vh.contains(path) {
+		return path
+	}
+	return strings.TrimPrefix(path, vh.Prefix)
+}
+
+// locate returns the host and path a script is fetched at: on the virtual
+// host if the script is in its namespace, otherwise on hostname
+func (vh *VirtualHost) locate(hostname, path string) (string, string) {
+	if vh == nil || !vh.contains(path) {
+		return hostname, path
+	}
+	return vh.Host, vh.strip(path)
+}
+
+// scriptURL is the absolute URL a script is fetched at, as with locate
+func (vh *VirtualHost) scriptURL(hostname, path string) string {
+	host, path := vh.locate(hostname, path)
+	return "https://" + host + path
+}
+
+// virtualHostOf returns the virtual host whose namespace holds a script, for
+// links made outside of a request, or nil if none does
+func (s *Server) virtualHostOf(path string) *VirtualHost {
+	var found *VirtualHost
+	for i := range s.VirtualHosts {
+		if vh := &s.VirtualHosts[i]; vh.contains(path) && (found == nil || len(vh.Prefix) > len(found.Prefix)) {
+			found = vh
+		}
+	}
+	return found
+}
+
+// scoped keeps the scripts in the host's namespace; on the main hostname
+// (nil) that is all of them
+func (vh *VirtualHost) scoped(scripts []dbgen.Script) []dbgen.Script {
+	if vh == nil {
+		return scripts
+	}
+	return slices.DeleteFunc(slices.Clone(scripts), func(sc dbgen.Script) bool { return !vh.contains(sc.Path) })
+}
+
+// rewrite maps a script path requested on the host to its full path
+func (vh *VirtualHost) rewrite(r *http.Request) *http.Request {
+	u := *r.URL
+	u.Path, u.RawPath = vh.Prefix+r.URL.Path, ""
+	r2 := r.Clone(r.Context())
+	r2.URL = &u
+	return r2
+}
+
+// withVirtualHost marks requests that came in on a virtual host
+func (s *Server) withVirtualHost(next http.Handler) http.Handler {
+	if len(s.VirtualHosts) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, bare := strings.ToLower(r.Host), ""
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			bare = h
+		}
+		for i := range s.VirtualHosts {
+			if vh := s.VirtualHosts[i].Host; vh == host || vh == bare {
+				r = r.WithContext(context.WithValue(r.Context(), virtualHostKey{}, &s.VirtualHosts[i]))
+				break
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
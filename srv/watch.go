@@ -0,0 +1,62 @@
+package srv
+
+import "sync"
+
+// watchBuffer is how many events a watcher may fall behind before it
+// misses some
+const watchBuffer = 64
+
+// watchers hands every emitted event to in-process subscribers, such as
+// gRPC Watch streams
+type watchers struct {
+	mu     sync.Mutex
+	subs   map[chan WebhookEvent]struct{}
+	closed bool
+}
+
+// subscribe returns a channel of events and a function to stop receiving
+// them
+func (w *watchers) subscribe() (<-chan WebhookEvent, func()) {
+	ch := make(chan WebhookEvent, watchBuffer)
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if w.subs == nil {
+		w.subs = map[chan WebhookEvent]struct{}{}
+	}
+	w.subs[ch] = struct{}{}
+	w.mu.Unlock()
+	return ch, func() {
+		w.mu.Lock()
+		delete(w.subs, ch)
+		w.mu.Unlock()
+	}
+}
+
+// close ends every subscription, and the ones made later right away, as
+// the server shuts down
+func (w *watchers) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	for ch := range w.subs {
+		close(ch)
+	}
+	clear(w.subs)
+}
+
+// publish passes ev to every subscriber; a subscriber that isn't keeping
+// up misses it rather than holding up the change that caused it
+func (w *watchers) publish(ev WebhookEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for ch := range w.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package srv
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"golang.org/x/net/webdav"
+)
+
+// davPrefix is where the script tree is mounted over WebDAV
+const davPrefix = "/_dav"
+
+// davMethods are the methods routed to the WebDAV handler
+var davMethods = []string{"OPTIONS", "GET", "HEAD", "PUT", "DELETE", "PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"}
+
+// davHandler serves the script tree under davPrefix. Uploads are saved
+// directly so that refusals (syntax errors, pre-save hooks, secrets) reach
+// the client with their reason, which the WebDAV library would reduce to a
+// bare status.
+func (s *Server) davHandler() http.HandlerFunc {
+	dav := &webdav.Handler{
+		Prefix:     davPrefix,
+		FileSystem: treeFS{s: s},
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				slog.WarnContext(r.Context(), "webdav request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+			}
+		},
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		r = withOriginalRequest(r)
+		if r.Method != http.MethodPut {
+			dav.ServeHTTP(w, r)
+			return
+		}
+		name := path.Clean("/" + strings.TrimPrefix(r.URL.Path, davPrefix))
+		body, err := io.ReadAll(io.LimitReader(r.Body, s.Limits.MaxScriptSize+1))
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if int64(len(body)) > s.Limits.MaxScriptSize {
+			http.Error(w, "Script too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err := validatePath(name); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		_, created, err := s.publishScript(r, name, string(body), nil)
+		var perr *PublishError
+		switch {
+		case errors.As(err, &perr):
+			http.Error(w, perr.Message, perr.Status)
+		case err != nil:
+			http.Error(w, "Failed to save script", http.StatusInternalServerError)
+		case created:
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// webhookTimeout bounds a single webhook delivery
+const webhookTimeout = 10 * time.Second
+
+// Webhook retries: failed deliveries are tried up to webhookMaxAttempts
+// times, backing off from webhookRetryBase; due retries are looked for every
+// webhookRetryInterval
+const (
+	webhookMaxAttempts   = 6
+	webhookRetryBase     = 30 * time.Second
+	webhookRetryInterval = 15 * time.Second
+)
+
+// webhookResponseSnippet is how much of a response body is kept per attempt
+const webhookResponseSnippet = 512
+
+// Number of attempts returned by the delivery log
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+// Events webhooks can subscribe to
+const (
+	EventScriptCreated = "script.created"
+	EventScriptUpdated = "script.updated"
+	EventScriptDeleted = "script.deleted"
+	EventUnlockFailed  = "unlock.failed"
+	EventUnlockLockout = "unlock.lockout"
+	EventHoneypotHit   = "honeypot.hit"
+
+	// A script marked dangerous or matching a risky pattern was saved
+	EventScriptDangerous = "script.dangerous"
+)
+
+// webhookEvents lists every event name a webhook may subscribe to
+var webhookEvents = []string{EventScriptCreated, EventScriptUpdated, EventScriptDeleted, EventUnlockFailed, EventUnlockLockout, EventHoneypotHit, EventScriptDangerous}
+
+// dangerLevelDangerous is the danger_level the UI calls "Dangerous"
+const dangerLevelDangerous = 2
+
+// WebhookEvent is the JSON payload of a webhook delivery
+type WebhookEvent struct {
+	Event     string       `json:"event"`
+	ScriptID  string       `json:"script_id,omitempty"`
+	Path      string       `json:"path"`
+	Actor     string       `json:"actor,omitempty"`
+	Version   int64        `json:"version,omitempty"`
+	Diff      *DiffSummary `json:"diff,omitempty"` // script.updated only
+	IPAddress string       `json:"ip_address,omitempty"`
+	Details   string       `json:"details,omitempty"`
+	Time      time.Time    `json:"time"`
+}
+
+// scriptEvent builds the event for a change to a script, made by the
+// request's actor
+func (s *Server) scriptEvent(ctx context.Context, q *queries, event string, script dbgen.Script) WebhookEvent {
+	ev := WebhookEvent{
+		Event:    event,
+		ScriptID: script.ID,
+		Path:     script.Path,
+		Actor:    derefStr(actor(ctx)),
+		Time:     time.Now(),
+	}
+	if event != EventScriptDeleted {
+		ev.Version, _ = q.GetCurrentVersion(ctx, script.ID)
+	}
+	if event == EventScriptUpdated && ev.Version > 1 {
+		if prev, err := q.GetVersion(ctx, dbgen.GetVersionParams{ScriptID: script.ID, Version: ev.Version - 1}); err == nil {
+			diff := diffStat(prev.Content, script.Content)
+			ev.Diff = &diff
+		}
+	}
+	return ev
+}
+
+// emitIfDangerous sends script.dangerous after a save when the script is
+// marked dangerous or its content has scan warnings
+func (s *Server) emitIfDangerous(ctx context.Context, q *queries, script dbgen.Script, warnings []ScanWarning) {
+	marked := script.DangerLevel != nil && *script.DangerLevel >= dangerLevelDangerous
+	if !marked && len(warnings) == 0 {
+		return
+	}
+	ev := s.scriptEvent(ctx, q, EventScriptDangerous, script)
+	var reasons []string
+	if marked {
+		reasons = append(reasons, "marked dangerous")
+	}
+	for _, w := range warnings {
+		reasons = append(reasons, fmt.Sprintf("%s on line %d", w.Rule, w.Line))
+	}
+	ev.Details = strings.Join(reasons, ", ")
+	s.emit(ctx, ev)
+}
+
+// subscribed reports whether a webhook wants an event
+func subscribed(hook dbgen.Webhook, event string) bool {
+	events := splitScope(&hook.Events)
+	return slices.Contains(events, "*") || slices.Contains(events, event)
+}
+
+// emit delivers an event to watchers and to every webhook subscribed to
+// it, in the background so the request that caused it is not held up
+func (s *Server) emit(ctx context.Context, ev WebhookEvent) {
+	s.watchers.publish(ev)
+	hooks, err := s.queries().ListWebhooks(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to list webhooks", "error", err)
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	for _, hook := range hooks {
+		if subscribed(hook, ev.Event) {
+			go s.attemptWebhook(ctx, hook, uuid.New().String(), ev.Event, s.webhookBody(hook.Kind, ev), 1, true)
+		}
+	}
+}
+
+// signWebhook returns the X-SH-Signature value for a payload
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait before the retry following a failed attempt:
+// 30s, 2m, 8m, 32m, ...
+func webhookBackoff(attempt int64) time.Duration {
+	return webhookRetryBase << (2 * (attempt - 1))
+}
+
+// attemptWebhook posts a payload to a webhook once and logs the attempt in
+// webhook_deliveries. With retry, a failed attempt is scheduled to be tried
+// again with exponential backoff until webhookMaxAttempts, after which the
+// delivery is marked dead.
+func (s *Server) attemptWebhook(ctx context.Context, hook dbgen.Webhook, deliveryID, event string, body []byte, attempt int64, retry bool) dbgen.WebhookDelivery {
+	start := time.Now()
+	row := dbgen.CreateWebhookDeliveryParams{
+		DeliveryID: deliveryID,
+		WebhookID:  hook.ID,
+		Event:      event,
+		Payload:    string(body),
+		Attempt:    attempt,
+		CreatedAt:  start,
+	}
+
+	status, response, err := postWebhook(ctx, hook, deliveryID, event, body)
+	row.DurationMs = time.Since(start).Milliseconds()
+	if status != 0 {
+		row.StatusCode = &status
+		row.Response = &response
+	}
+	switch {
+	case err != nil:
+		row.Error = strPtr(err.Error())
+	case status >= 300:
+		row.Error = strPtr(http.StatusText(int(status)))
+	}
+	if row.Error != nil {
+		slog.WarnContext(ctx, "webhook delivery failed", "webhook", hook.ID, "event", event, "attempt", attempt, "error", *row.Error)
+		if retry && attempt < webhookMaxAttempts {
+			next := time.Now().Add(webhookBackoff(attempt))
+			row.NextAttemptAt = &next
+		} else if retry {
+			row.Dead = 1
+		}
+	}
+
+	delivery, err := s.queries().CreateWebhookDelivery(ctx, row)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to log webhook delivery", "webhook", hook.ID, "error", err)
+	}
+	return delivery
+}
+
+// postWebhook sends one signed request and returns the response status and
+// the start of its body
+func postWebhook(ctx context.Context, hook dbgen.Webhook, deliveryID, event string, body []byte) (int64, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	if hook.Kind == webhookNtfy {
+		setNtfyHeaders(req, hook, event)
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-SH-Event", event)
+		req.Header.Set("X-SH-Delivery", deliveryID)
+		req.Header.Set("X-SH-Signature", signWebhook(hook.Secret, body))
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseSnippet))
+	return int64(resp.StatusCode), string(snippet), nil
+}
+
+// retryWebhooks makes the attempts whose retry is due
+func (s *Server) retryWebhooks(ctx context.Context, now time.Time) {
+	q := s.queries()
+	due, err := q.ListDueWebhookRetries(ctx, &now)
+	if err != nil {
+		slog.Error("failed to list webhook retries", "error", err)
+		return
+	}
+	for _, d := range due {
+		if n, err := q.ClaimWebhookRetry(ctx, d.ID); err != nil || n == 0 {
+			continue
+		}
+		hook, err := q.GetWebhook(ctx, d.WebhookID)
+		if err != nil {
+			continue
+		}
+		s.attemptWebhook(ctx, hook, d.DeliveryID, d.Event, []byte(d.Payload), d.Attempt+1, true)
+	}
+}
+
+// runWebhookRetryJob retries failed deliveries every webhookRetryInterval
+func (s *Server) runWebhookRetryJob() {
+	ticker := time.NewTicker(webhookRetryInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.retryWebhooks(context.Background(), time.Now())
+	}
+}
+
+// WebhookRequest represents a request to register a webhook
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Kind   string   `json:"kind"`   // json (default), slack, discord or ntfy
+	Secret string   `json:"secret"` // generated when empty; the access token for ntfy
+	Events []string `json:"events"` // all events when empty
+}
+
+// WebhookResponse describes a webhook; the secret is only returned when the
+// webhook is created
+type WebhookResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Kind      string    `json:"kind"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func webhookToResponse(hook dbgen.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.Url,
+		Kind:      hook.Kind,
+		Events:    splitScope(&hook.Events),
+		CreatedAt: hook.CreatedAt,
+	}
+}
+
+// APIListWebhooks returns all webhooks without their secrets
+func (s *Server) APIListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := s.queries().ListWebhooks(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]WebhookResponse, len(hooks))
+	for i, hook := range hooks {
+		resp[i] = webhookToResponse(hook)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APICreateWebhook registers a webhook
+func (s *Server) APICreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, err)
+		return
+	}
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "URL must be an http or https URL", http.StatusBadRequest)
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = webhookJSON
+	}
+	if !slices.Contains(webhookKinds, req.Kind) {
+		http.Error(w, "Kind must be one of "+strings.Join(webhookKinds, ", "), http.StatusBadRequest)
+		return
+	}
+	events := "*"
+	if req.Kind == webhookNtfy {
+		events = strings.Join(ntfyDefaultEvents, ",")
+	}
+	if len(req.Events) > 0 {
+		for _, ev := range req.Events {
+			if ev != "*" && !slices.Contains(webhookEvents, ev) {
+				http.Error(w, "Unknown event "+ev+", expected one of "+strings.Join(webhookEvents, ", "), http.StatusBadRequest)
+				return
+			}
+		}
+		events = strings.Join(req.Events, ",")
+	}
+	if req.Secret == "" && req.Kind != webhookNtfy {
+		req.Secret = randomToken(32)
+	}
+
+	id := uuid.New().String()
+	now := time.Now()
+	q := s.queries()
+	if err := q.CreateWebhook(r.Context(), dbgen.CreateWebhookParams{
+		ID:        id,
+		Url:       req.URL,
+		Secret:    req.Secret,
+		Events:    events,
+		Kind:      req.Kind,
+		CreatedAt: now,
+	}); err != nil {
+		http.Error(w, "Failed to create webhook: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "CREATE",
+		EntityType: "webhook",
+		EntityID:   &id,
+		Details:    &req.URL,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  now,
+	})
+
+	hook, _ := q.GetWebhook(r.Context(), id)
+	resp := webhookToResponse(hook)
+	resp.Secret = hook.Secret
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// APIDeleteWebhook removes a webhook
+func (s *Server) APIDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	q := s.queries()
+	hook, err := q.GetWebhook(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	if err := q.DeleteWebhook(r.Context(), id); err != nil {
+		http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+
+	q.CreateAuditLog(r.Context(), dbgen.CreateAuditLogParams{
+		Action:     "DELETE",
+		EntityType: "webhook",
+		EntityID:   &id,
+		Details:    &hook.Url,
+		Actor:      actor(r.Context()),
+		RequestID:  requestID(r.Context()),
+		CreatedAt:  time.Now(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// APIListWebhookDeliveries returns the ?limit= most recent delivery attempts
+// of a webhook
+func (s *Server) APIListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	limit = min(limit, maxDeliveryLimit)
+
+	q := s.queries()
+	if _, err := q.GetWebhook(r.Context(), id); err != nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	deliveries, err := q.ListWebhookDeliveries(r.Context(), dbgen.ListWebhookDeliveriesParams{WebhookID: id, Limit: int64(limit)})
+	if err != nil {
+		http.Error(w, "Failed to list deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// APIRedeliverWebhook sends the payload of a logged attempt again, right
+// away and without automatic retries, and returns the new attempt
+func (s *Server) APIRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	attemptID, err := strconv.ParseInt(r.PathValue("delivery"), 10, 64)
+	if err != nil {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+
+	q := s.queries()
+	hook, err := q.GetWebhook(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	d, err := q.GetWebhookDelivery(r.Context(), dbgen.GetWebhookDeliveryParams{ID: attemptID, WebhookID: id})
+	if err != nil {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+	last, err := q.GetLastWebhookAttempt(r.Context(), d.DeliveryID)
+	if err != nil {
+		last = d.Attempt
+	}
+
+	delivery := s.attemptWebhook(r.Context(), hook, d.DeliveryID, d.Event, []byte(d.Payload), last+1, false)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
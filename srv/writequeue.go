@@ -0,0 +1,209 @@
+package srv
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/hunydev/sh-server/db"
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// WriteQueueConfig moves audit log, access log and fetch statistics writes
+// off the request path. They are written in the background, many to a
+// transaction.
+type WriteQueueConfig struct {
+	Size          int           // writes waiting to be written; 0 writes them during the request
+	BatchSize     int           // most writes in one transaction
+	FlushInterval time.Duration // longest a write waits for more to batch with
+}
+
+// queuedWrite is a write to run later with q
+type queuedWrite func(ctx context.Context, q *dbgen.Queries) error
+
+type queuedItem struct {
+	write   queuedWrite
+	flushed chan struct{} // instead of a write: closed once everything before it is written
+}
+
+// writeQueue writes in the background what the request path hands it.
+// When it is full, or closed, writes run right away instead, so nothing is
+// lost under load or during shutdown.
+type writeQueue struct {
+	cfg     WriteQueueConfig
+	db      dbgen.DBTX // to write one at a time
+	begin   func(ctx context.Context) (*sql.Tx, error)
+	dialect db.Dialect
+
+	mu     sync.RWMutex
+	closed bool
+	items  chan queuedItem
+	done   chan struct{}
+}
+
+func newWriteQueue(s *Server, cfg WriteQueueConfig) *writeQueue {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1
+	}
+	w := &writeQueue{
+		cfg:     cfg,
+		db:      s.conn,
+		begin:   func(ctx context.Context) (*sql.Tx, error) { return s.DB.BeginTx(ctx, nil) },
+		dialect: s.dialect,
+		items:   make(chan queuedItem, cfg.Size),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// enqueue hands write to the background writer
+func (w *writeQueue) enqueue(write queuedWrite) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.closed {
+		select {
+		case w.items <- queuedItem{write: write}:
+			return
+		default:
+		}
+	}
+	w.writeOne(write)
+}
+
+// flush waits until everything queued so far is written
+func (w *writeQueue) flush() {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	w.items <- queuedItem{flushed: flushed}
+	w.mu.RUnlock()
+	<-flushed
+}
+
+// close writes what is queued and stops the background writer
+func (w *writeQueue) close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.items)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+func (w *writeQueue) run() {
+	defer close(w.done)
+	batch := make([]queuedWrite, 0, w.cfg.BatchSize)
+	for item := range w.items {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		batch = append(batch[:0], item.write)
+		var flushed chan struct{}
+		timer := time.NewTimer(w.cfg.FlushInterval)
+	fill:
+		for len(batch) < w.cfg.BatchSize {
+			select {
+			case item, ok := <-w.items:
+				if !ok {
+					break fill
+				}
+				if item.flushed != nil {
+					flushed = item.flushed
+					break fill
+				}
+				batch = append(batch, item.write)
+			case <-timer.C:
+				break fill
+			}
+		}
+		timer.Stop()
+		w.writeBatch(batch)
+		if flushed != nil {
+			close(flushed)
+		}
+	}
+}
+
+// writeBatch writes a batch in one transaction. If any write fails, the
+// batch is rolled back and written one at a time, so one bad write costs
+// only itself.
+func (w *writeQueue) writeBatch(batch []queuedWrite) {
+	if len(batch) == 1 {
+		w.writeOne(batch[0])
+		return
+	}
+	ctx := context.Background()
+	err := func() error {
+		tx, err := w.begin(ctx)
+		if err != nil {
+			return err
+		}
+		q := dbgen.New(db.Conn(tx, w.dialect))
+		for _, write := range batch {
+			if err := write(ctx, q); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		return tx.Commit()
+	}()
+	if err == nil {
+		return
+	}
+	slog.Warn("write queue: batch failed, writing one at a time", "writes", len(batch), "error", err)
+	for _, write := range batch {
+		w.writeOne(write)
+	}
+}
+
+func (w *writeQueue) writeOne(write queuedWrite) {
+	if err := write(context.Background(), dbgen.New(w.db)); err != nil {
+		slog.Warn("write queue: write failed", "error", err)
+	}
+}
+
+// enqueue runs write in the background if the write queue is enabled, and
+// right away otherwise
+func (q *queries) enqueue(ctx context.Context, write queuedWrite) error {
+	if q.writes == nil {
+		return write(ctx, q.Queries)
+	}
+	q.writes.enqueue(write)
+	return nil
+}
+
+func (q *queries) CreateAuditLog(ctx context.Context, arg dbgen.CreateAuditLogParams) error {
+	return q.enqueue(ctx, func(ctx context.Context, q *dbgen.Queries) error { return q.CreateAuditLog(ctx, arg) })
+}
+
+func (q *queries) CreateAccessLog(ctx context.Context, arg dbgen.CreateAccessLogParams) error {
+	return q.enqueue(ctx, func(ctx context.Context, q *dbgen.Queries) error { return q.CreateAccessLog(ctx, arg) })
+}
+
+func (q *queries) RecordScriptFetch(ctx context.Context, arg dbgen.RecordScriptFetchParams) error {
+	return q.enqueue(ctx, func(ctx context.Context, q *dbgen.Queries) error { return q.RecordScriptFetch(ctx, arg) })
+}
+
+func (q *queries) RecordScriptDailyFetch(ctx context.Context, arg dbgen.RecordScriptDailyFetchParams) error {
+	return q.enqueue(ctx, func(ctx context.Context, q *dbgen.Queries) error { return q.RecordScriptDailyFetch(ctx, arg) })
+}
+
+func (q *queries) RecordFetchConsumer(ctx context.Context, arg dbgen.RecordFetchConsumerParams) error {
+	return q.enqueue(ctx, func(ctx context.Context, q *dbgen.Queries) error { return q.RecordFetchConsumer(ctx, arg) })
+}
+
+func (q *queries) RecordFetchBreakdown(ctx context.Context, arg dbgen.RecordFetchBreakdownParams) error {
+	return q.enqueue(ctx, func(ctx context.Context, q *dbgen.Queries) error { return q.RecordFetchBreakdown(ctx, arg) })
+}
+
+func (q *queries) RecordVariantServe(ctx context.Context, arg dbgen.RecordVariantServeParams) error {
+	return q.enqueue(ctx, func(ctx context.Context, q *dbgen.Queries) error { return q.RecordVariantServe(ctx, arg) })
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/hunydev/sh-server/db/dbgen"
+)
+
+// SQLite stores blobs in the blobs table of the server's database, apart
+// from the scripts table that metadata queries scan. On PostgreSQL the
+// table is in PostgreSQL.
+type SQLite struct {
+	q *dbgen.Queries
+}
+
+func NewSQLite(db dbgen.DBTX) *SQLite {
+	return &SQLite{q: dbgen.New(db)}
+}
+
+func (s *SQLite) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.q.GetBlob(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *SQLite) Put(ctx context.Context, key string, data []byte) error {
+	return s.q.PutBlob(ctx, dbgen.PutBlobParams{Key: key, Data: data})
+}
+
+func (s *SQLite) Delete(ctx context.Context, key string) error {
+	return s.q.DeleteBlob(ctx, key)
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Get for a key that isn't stored
+var ErrNotFound = errors.New("storage: object not found")
+
+// Backend stores blobs by key. Keys are slash-separated paths of
+// lowercase letters and digits.
+type Backend interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte) error
+	Delete(ctx context.Context, key string) error // deleting a missing key is not an error
+}
+
+// Object is a stored blob as listed by a Lister
+type Object struct {
+	Key      string
+	Size     int64
+	Modified time.Time
+}
+
+// Lister is a Backend that can list the keys under a prefix
+type Lister interface {
+	List(ctx context.Context, prefix string) ([]Object, error)
+}